@@ -0,0 +1,39 @@
+package extract
+
+import (
+	"reflect"
+)
+
+// As converts val, such as the result of an evaluation, to a Go value
+// of type T. If val is already a T, it is returned directly.
+// Otherwise, it is converted in the same way as by [Unmarshal], such
+// as from an int64 to an int or from a *Map to a struct. If val is an
+// error, such as one returned from a failed evaluation, that error is
+// returned. If val can't be converted, a [TypeError] is returned.
+func As[T any](val any) (T, error) {
+	if v, ok := val.(T); ok {
+		return v, nil
+	}
+
+	var v T
+	if err, ok := val.(error); ok {
+		return v, err
+	}
+
+	err := unmarshal(val, reflect.ValueOf(&v).Elem())
+	if err != nil {
+		return v, NewTypeError(val, reflect.TypeFor[T]())
+	}
+	return v, nil
+}
+
+// CallAs calls fn with args using [Env.Call] and converts the result
+// to a T with [As].
+func CallAs[T any](env *Env, fn any, args ...any) (T, error) {
+	r, err := env.Call(fn, args...)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return As[T](r)
+}
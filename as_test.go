@@ -0,0 +1,52 @@
+package extract_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"deedles.dev/extract"
+)
+
+func TestAs(t *testing.T) {
+	n, err := extract.As[int](int64(3))
+	if err != nil || n != 3 {
+		t.Fatal(n, err)
+	}
+
+	s, err := extract.As[[]string](extract.ListOf("a", "b"))
+	if err != nil || len(s) != 2 || s[1] != "b" {
+		t.Fatal(s, err)
+	}
+
+	_, err = extract.As[string](int64(3))
+	var terr *extract.TypeError
+	if !errors.As(err, &terr) || terr.Val != int64(3) {
+		t.Fatalf("%#v", err)
+	}
+
+	_, err = extract.As[int](extract.ErrPatternMatch)
+	if err != extract.ErrPatternMatch {
+		t.Fatalf("%#v", err)
+	}
+}
+
+func TestCallAs(t *testing.T) {
+	env := extract.New(context.Background())
+
+	str, err := extract.CallAs[string](env, "String.to_upper", "test")
+	if err != nil || str != "TEST" {
+		t.Fatal(str, err)
+	}
+
+	n, err := extract.CallAs[int](env, "add", 1, 2)
+	if err != nil || n != 3 {
+		t.Fatal(n, err)
+	}
+
+	_, err = extract.CallAs[int](env, "Missing.func")
+	var merr *extract.UndefinedModuleError
+	if !errors.As(err, &merr) {
+		t.Fatalf("%#v", err)
+	}
+}
@@ -0,0 +1,215 @@
+package extract
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"deedles.dev/extract/scanner"
+)
+
+// AssertionError is returned by the assertion functions of the
+// Testing module when an assertion fails. Pos is the location of the
+// failed assertion in the source code, if it is known.
+type AssertionError struct {
+	Message string
+	Pos     scanner.Position
+}
+
+func (err *AssertionError) Error() string {
+	if err.Pos == (scanner.Position{}) {
+		return fmt.Sprintf("assertion failed: %v", err.Message)
+	}
+	return fmt.Sprintf("assertion failed (%v): %v", err.Pos, err.Message)
+}
+
+func (err *AssertionError) ErrorCode() Atom {
+	return codeAssertion
+}
+
+// testCase is a test defined by Testing.deftest.
+type testCase struct {
+	name string
+	body *List
+	env  *Env
+	pos  scanner.Position
+}
+
+// testSuite holds the tests that have been defined in an Env.
+type testSuite struct {
+	m     sync.Mutex
+	tests []testCase
+}
+
+func (s *testSuite) add(tc testCase) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.tests = append(s.tests, tc)
+}
+
+func (s *testSuite) all() []testCase {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.tests[:len(s.tests):len(s.tests)]
+}
+
+// TestResult is the result of running a test defined with
+// Testing.deftest. Err is nil if the test passed. Pos is the location
+// of the failed assertion if it is known, or of the test otherwise.
+type TestResult struct {
+	Name    string
+	Pos     scanner.Position
+	Err     error
+	Elapsed time.Duration
+}
+
+// RunTests runs the tests that have been defined in env whose names
+// contain filter, in the order that they were defined, and returns
+// their results. Each test runs in a copy of the Env that it was
+// defined in, so the tests are not isolated from each other if they
+// modify shared state, such as by sending messages to a process.
+func RunTests(env *Env, filter string) []TestResult {
+	var results []TestResult
+	for _, tc := range env.tests.all() {
+		if !strings.Contains(tc.name, filter) {
+			continue
+		}
+
+		start := time.Now()
+		_, r := Run(tc.env.inherit(env), tc.body.All())
+		result := TestResult{Name: tc.name, Pos: tc.pos, Elapsed: time.Since(start)}
+		if err, ok := r.(error); ok {
+			result.Err = err
+			var aerr *AssertionError
+			if errors.As(err, &aerr) && aerr.Pos != (scanner.Position{}) {
+				result.Pos = aerr.Pos
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// runTests runs every test whose name contains filter, writing a
+// report of the failures and a summary to env's stdout. It returns a
+// map with the number of tests that passed and failed and a list of
+// the failures in the form [name message].
+func runTests(env *Env, filter string) *Map {
+	out := env.Stdout()
+
+	var passed, failed int64
+	var failures []any
+	for _, result := range RunTests(env, filter) {
+		if result.Err == nil {
+			passed++
+			continue
+		}
+
+		failed++
+		fmt.Fprintf(out, "FAIL %v (%v)\n%v\n", result.Name, result.Pos, indent(result.Err.Error()))
+		failures = append(failures, ListOf(result.Name, result.Err.Error()))
+	}
+
+	fmt.Fprintf(out, "%v passed, %v failed\n", passed, failed)
+	return MapOf(
+		MakeAtom("passed"), passed,
+		MakeAtom("failed"), failed,
+		MakeAtom("failures"), ListOf(failures...),
+	)
+}
+
+// assertion returns a function for the Testing module that evaluates
+// its arguments and passes them to check, which returns a message
+// describing the failure if the assertion fails. An optional extra
+// argument replaces the message.
+func assertion(n int, check func(vals []any) (string, bool)) EvalFunc {
+	return func(env *Env, args *List) (*Env, any) {
+		if args.Len() != n && args.Len() != n+1 {
+			return env, &ArgumentNumError{Num: args.Len(), Expected: -1}
+		}
+
+		vals, err := evalArgs(env, args)
+		if err != nil {
+			return env, err
+		}
+
+		msg, ok := check(vals[:n])
+		if ok {
+			return env, true
+		}
+		if len(vals) > n {
+			msg, ok = vals[n].(string)
+			if !ok {
+				return env, NewTypeError(vals[n], reflect.TypeFor[string]())
+			}
+		}
+		return env, &AssertionError{Message: msg, Pos: env.pos}
+	}
+}
+
+func stdTesting() *Module {
+	m := Module{name: MakeAtom("Testing")}
+	m.decls = map[Ident]any{
+		MakeIdent("deftest"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() < 1 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: -1}
+			}
+
+			_, name := Eval(env, args.Head(), nil)
+			if err, ok := name.(error); ok {
+				return env, err
+			}
+			str, ok := name.(string)
+			if !ok {
+				return env, NewTypeError(name, reflect.TypeFor[string]())
+			}
+
+			env.tests.add(testCase{name: str, body: args.Tail(), env: env, pos: env.pos})
+			return env, okAtom
+		}),
+		MakeIdent("assert"): assertion(1, func(vals []any) (string, bool) {
+			return fmt.Sprintf("expected true, got %v", Inspect(vals[0])), vals[0] == true
+		}),
+		MakeIdent("refute"): assertion(1, func(vals []any) (string, bool) {
+			return fmt.Sprintf("expected false, got %v", Inspect(vals[0])), vals[0] == false
+		}),
+		MakeIdent("assert_equal"): assertion(2, func(vals []any) (string, bool) {
+			return fmt.Sprintf("expected %v, got %v", Inspect(vals[1]), Inspect(vals[0])), Equal(vals[0], vals[1])
+		}),
+		MakeIdent("assert_raises"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 1 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 1}
+			}
+
+			vals, err := evalArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			r, err := callFunc(env, vals[0])
+			if err == nil {
+				return env, &AssertionError{
+					Message: fmt.Sprintf("expected an error, got %v", Inspect(r)),
+					Pos:     env.pos,
+				}
+			}
+			return env, err.Error()
+		}),
+		MakeIdent("run"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			strs, err := evalStrings(env, args, 0, 1)
+			if err != nil {
+				return env, err
+			}
+
+			var filter string
+			if len(strs) > 0 {
+				filter = strs[0]
+			}
+			return env, runTests(env, filter)
+		}),
+	}
+
+	return &m
+}
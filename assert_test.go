@@ -0,0 +1,63 @@
+package extract_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"deedles.dev/extract"
+	"deedles.dev/extract/parser"
+)
+
+func TestTestingModule(t *testing.T) {
+	const src = `
+	(let two 2)
+	(Testing.deftest "passes"
+		(Testing.assert (eq two 2))
+		(Testing.refute (eq two 3))
+		(Testing.assert_equal (add 1 1) two)
+		(Testing.assert_raises (func (f) (add 1 :a))))
+	(Testing.deftest "fails"
+		(Testing.assert_equal (add 1 2) two))
+	(Testing.deftest "custom message"
+		(Testing.assert false "custom"))
+	(Testing.deftest "errors"
+		(Testing.assert_raises (func (f) 1)))
+	(list (Testing.run) (Testing.run "pass"))
+	`
+	s, err := parser.ParseString("test.ext", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	env := extract.New(context.Background(), extract.WithStdout(&out))
+	_, result := extract.Run(env, s.All())
+	if err, ok := result.(error); ok {
+		t.Fatal(err)
+	}
+
+	all := result.(*extract.List).Head().(*extract.Map)
+	if v, _ := all.Get(extract.MakeAtom("passed")); v != int64(1) {
+		t.Fatal(all)
+	}
+	if v, _ := all.Get(extract.MakeAtom("failed")); v != int64(3) {
+		t.Fatal(all)
+	}
+	filtered := result.(*extract.List).Tail().Head().(*extract.Map)
+	if v, _ := filtered.Get(extract.MakeAtom("failed")); v != int64(0) {
+		t.Fatal(filtered)
+	}
+
+	for _, ex := range []string{
+		"FAIL fails (test.ext:9:3)\n\tassertion failed (test.ext:9:3): expected 2, got 3\n",
+		"FAIL custom message (test.ext:11:3)\n\tassertion failed (test.ext:11:3): custom\n",
+		"FAIL errors (test.ext:13:3)\n\tassertion failed (test.ext:13:3): expected an error, got 1\n",
+		"1 passed, 3 failed\n",
+		"1 passed, 0 failed\n",
+	} {
+		if !strings.Contains(out.String(), ex) {
+			t.Fatalf("%q not in\n%v", ex, out.String())
+		}
+	}
+}
@@ -0,0 +1,372 @@
+// Package astfile encodes parsed Extract scripts in a compact binary
+// format that can be decoded much faster than the source code can be
+// parsed. This allows a host that runs the same scripts repeatedly to
+// skip parsing them, either by caching the encoded scripts with a
+// [Cache] or by shipping them instead of the source code.
+//
+// An encoded script starts with a header that identifies the format
+// and its version, followed by the script's expressions. Identifiers,
+// atoms, strings, and filenames are each only stored once. Positions
+// are preserved so that errors from a decoded script point to the
+// original source code.
+package astfile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"deedles.dev/extract"
+	"deedles.dev/extract/scanner"
+)
+
+// Ext is the conventional file extension of encoded scripts.
+const Ext = ".extc"
+
+// header is written at the start of every encoded script. Its last
+// byte is the version of the format, which must be incremented if the
+// format changes.
+var header = []byte("\x00EXTC\x01")
+
+var (
+	// ErrFormat is returned when decoding data that is not an encoded
+	// script, or that was encoded with a different version of the
+	// format.
+	ErrFormat = errors.New("not an encoded script")
+
+	// ErrCorrupt is returned when decoding an encoded script that is
+	// truncated or otherwise invalid.
+	ErrCorrupt = errors.New("corrupt encoded script")
+)
+
+// UnsupportedTypeError is returned when encoding a script containing
+// a value that the parser does not produce.
+type UnsupportedTypeError struct {
+	Val any
+}
+
+func (err *UnsupportedTypeError) Error() string {
+	return fmt.Sprintf("unsupported value %v (%T) in script", err.Val, err.Val)
+}
+
+// Tags identify the type of each encoded expression.
+const (
+	tagNil byte = iota
+	tagInt
+	tagFloat
+	tagRune
+	tagString
+	tagBinary
+	tagAtom
+	tagIdent
+	tagCall
+	tagListExpr
+	tagList
+	tagRef
+	tagPinnedIdent
+	tagPinnedExpr
+	tagDefault
+	tagRest
+)
+
+// IsEncoded returns true if data starts with the header of an encoded
+// script.
+func IsEncoded(data []byte) bool {
+	return bytes.HasPrefix(data, header)
+}
+
+// Encode writes script, as returned by the parser, to w.
+func Encode(w io.Writer, script *extract.List) error {
+	e := encoder{buf: bytes.Clone(header), strs: make(map[string]uint64)}
+	if err := e.list(script); err != nil {
+		return err
+	}
+	_, err := w.Write(e.buf)
+	return err
+}
+
+// Decode reads a script that was written by [Encode] from r.
+func Decode(r io.Reader) (*extract.List, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeBytes(data)
+}
+
+// DecodeBytes decodes a script that was written by [Encode] from
+// data.
+func DecodeBytes(data []byte) (script *extract.List, err error) {
+	if !IsEncoded(data) {
+		return nil, ErrFormat
+	}
+
+	d := decoder{data: data[len(header):]}
+	defer func() {
+		if r := recover(); r != nil {
+			if r != errCorrupt {
+				panic(r)
+			}
+			script, err = nil, ErrCorrupt
+		}
+	}()
+	script = d.list()
+	if len(d.data) != 0 {
+		return nil, ErrCorrupt
+	}
+	return script, nil
+}
+
+type encoder struct {
+	buf  []byte
+	strs map[string]uint64
+}
+
+func (e *encoder) uint(v uint64) {
+	e.buf = binary.AppendUvarint(e.buf, v)
+}
+
+// str writes str, or a reference to it if it has already been
+// written.
+func (e *encoder) str(str string) {
+	if i, ok := e.strs[str]; ok {
+		e.uint(i + 1)
+		return
+	}
+	e.strs[str] = uint64(len(e.strs))
+	e.uint(0)
+	e.bytes([]byte(str))
+}
+
+func (e *encoder) bytes(b []byte) {
+	e.uint(uint64(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+func (e *encoder) pos(pos scanner.Position) {
+	e.str(pos.Filename)
+	e.uint(uint64(pos.Line))
+	e.uint(uint64(pos.Col))
+	e.uint(uint64(pos.Offset))
+}
+
+func (e *encoder) list(list *extract.List) error {
+	e.uint(uint64(list.Len()))
+	for expr := range list.All() {
+		if err := e.expr(expr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *encoder) expr(expr any) error {
+	switch expr := expr.(type) {
+	case nil:
+		e.buf = append(e.buf, tagNil)
+	case int64:
+		e.buf = append(e.buf, tagInt)
+		e.buf = binary.AppendVarint(e.buf, expr)
+	case float64:
+		e.buf = append(e.buf, tagFloat)
+		e.buf = binary.LittleEndian.AppendUint64(e.buf, math.Float64bits(expr))
+	case extract.Rune:
+		e.buf = append(e.buf, tagRune)
+		e.uint(uint64(expr))
+	case string:
+		e.buf = append(e.buf, tagString)
+		e.str(expr)
+	case *extract.Binary:
+		e.buf = append(e.buf, tagBinary)
+		e.bytes(expr.Bytes())
+	case extract.Atom:
+		e.buf = append(e.buf, tagAtom)
+		e.str(expr.String())
+	case extract.Ident:
+		e.buf = append(e.buf, tagIdent)
+		e.str(expr.String())
+	case extract.Call:
+		e.buf = append(e.buf, tagCall)
+		e.pos(expr.Pos)
+		return e.list(expr.List)
+	case extract.ListExpr:
+		e.buf = append(e.buf, tagListExpr)
+		e.pos(expr.Pos)
+		return e.list(expr.List)
+	case *extract.List:
+		e.buf = append(e.buf, tagList)
+		return e.list(expr)
+	case extract.Ref:
+		e.buf = append(e.buf, tagRef)
+		e.str(expr.Name.String())
+		return e.expr(expr.In)
+	case extract.Pinned:
+		if expr.Expr != nil {
+			e.buf = append(e.buf, tagPinnedExpr)
+			return e.expr(expr.Expr)
+		}
+		e.buf = append(e.buf, tagPinnedIdent)
+		e.str(expr.Ident.String())
+	case extract.Default:
+		e.buf = append(e.buf, tagDefault)
+		if err := e.expr(expr.Pattern); err != nil {
+			return err
+		}
+		return e.expr(expr.Value)
+	case extract.Rest:
+		e.buf = append(e.buf, tagRest)
+		return e.expr(expr.Pattern)
+	default:
+		return &UnsupportedTypeError{Val: expr}
+	}
+	return nil
+}
+
+// maxDepth is the maximum nesting depth of expressions that the
+// decoder accepts. It is far deeper than the parser allows by default
+// and only exists to stop invalid data from exhausting the stack.
+const maxDepth = 100000
+
+// errCorrupt is panicked by the decoder when the data is invalid and
+// is recovered by DecodeBytes.
+var errCorrupt = errors.New("corrupt")
+
+type decoder struct {
+	data  []byte
+	strs  []string
+	depth int
+}
+
+func (d *decoder) byte() byte {
+	if len(d.data) == 0 {
+		panic(errCorrupt)
+	}
+	b := d.data[0]
+	d.data = d.data[1:]
+	return b
+}
+
+func (d *decoder) uint() uint64 {
+	v, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		panic(errCorrupt)
+	}
+	d.data = d.data[n:]
+	return v
+}
+
+func (d *decoder) int() int {
+	v := d.uint()
+	if v > math.MaxInt32 {
+		panic(errCorrupt)
+	}
+	return int(v)
+}
+
+func (d *decoder) bytes() []byte {
+	n := d.uint()
+	if n > uint64(len(d.data)) {
+		panic(errCorrupt)
+	}
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b
+}
+
+func (d *decoder) str() string {
+	i := d.uint()
+	if i == 0 {
+		str := string(d.bytes())
+		d.strs = append(d.strs, str)
+		return str
+	}
+	if i > uint64(len(d.strs)) {
+		panic(errCorrupt)
+	}
+	return d.strs[i-1]
+}
+
+func (d *decoder) pos() scanner.Position {
+	return scanner.Position{
+		Filename: d.str(),
+		Line:     d.int(),
+		Col:      d.int(),
+		Offset:   d.int(),
+	}
+}
+
+func (d *decoder) list() *extract.List {
+	n := d.uint()
+	if n > uint64(len(d.data)) {
+		// Every expression takes at least one byte.
+		panic(errCorrupt)
+	}
+
+	exprs := make([]any, n)
+	for i := range exprs {
+		exprs[i] = d.expr()
+	}
+	return extract.ListOf(exprs...)
+}
+
+func (d *decoder) expr() any {
+	d.depth++
+	defer func() { d.depth-- }()
+	if d.depth > maxDepth {
+		panic(errCorrupt)
+	}
+
+	switch d.byte() {
+	case tagNil:
+		return nil
+	case tagInt:
+		v, n := binary.Varint(d.data)
+		if n <= 0 {
+			panic(errCorrupt)
+		}
+		d.data = d.data[n:]
+		return v
+	case tagFloat:
+		if len(d.data) < 8 {
+			panic(errCorrupt)
+		}
+		v := math.Float64frombits(binary.LittleEndian.Uint64(d.data))
+		d.data = d.data[8:]
+		return v
+	case tagRune:
+		return extract.Rune(d.uint())
+	case tagString:
+		return d.str()
+	case tagBinary:
+		return extract.BinaryOf(d.bytes())
+	case tagAtom:
+		return extract.MakeAtom(d.str())
+	case tagIdent:
+		return extract.MakeIdent(d.str())
+	case tagCall:
+		pos := d.pos()
+		return extract.Call{List: d.list(), Pos: pos}
+	case tagListExpr:
+		pos := d.pos()
+		return extract.ListExpr{List: d.list(), Pos: pos}
+	case tagList:
+		return d.list()
+	case tagRef:
+		name := extract.MakeIdent(d.str())
+		return extract.Ref{In: d.expr(), Name: name}
+	case tagPinnedIdent:
+		return extract.Pinned{Ident: extract.MakeIdent(d.str())}
+	case tagPinnedExpr:
+		return extract.Pinned{Expr: d.expr()}
+	case tagDefault:
+		pattern := d.expr()
+		return extract.Default{Pattern: pattern, Value: d.expr()}
+	case tagRest:
+		return extract.Rest{Pattern: d.expr()}
+	default:
+		panic(errCorrupt)
+	}
+}
@@ -0,0 +1,99 @@
+package astfile_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"deedles.dev/extract/astfile"
+	"deedles.dev/extract/parser"
+)
+
+const src = `(defmodule Example
+	(def (greet name \\ "world" &rest) (String.concat "hello, " name))
+	(def (f [a b] \a \(Example.g)) (list 'x' -3 2.5 :atom b"\x00\xff" (1 + 2 * 3))))
+(let x (Example.greet))
+x`
+
+func TestRoundTrip(t *testing.T) {
+	script, err := parser.ParseString("test.ext", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := astfile.Encode(&buf, script); err != nil {
+		t.Fatal(err)
+	}
+	if !astfile.IsEncoded(buf.Bytes()) {
+		t.Fatal("missing header")
+	}
+
+	decoded, err := astfile.Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, script) {
+		t.Fatalf("%v\n%v", decoded, script)
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	script, err := parser.ParseString("test.ext", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := astfile.Encode(&buf, script); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	if _, err := astfile.DecodeBytes([]byte(src)); !errors.Is(err, astfile.ErrFormat) {
+		t.Fatal(err)
+	}
+	for n := len(data) - 1; n > 6; n-- {
+		if _, err := astfile.DecodeBytes(data[:n]); !errors.Is(err, astfile.ErrCorrupt) {
+			t.Fatalf("truncated to %v: %v", n, err)
+		}
+	}
+	if _, err := astfile.DecodeBytes(append(data, 0)); !errors.Is(err, astfile.ErrCorrupt) {
+		t.Fatal(err)
+	}
+}
+
+func TestCache(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.ext")
+	if err := os.WriteFile(path, []byte(src), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	cache := astfile.Cache{Dir: filepath.Join(dir, "cache")}
+	script, err := cache.ParseFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := filepath.Glob(filepath.Join(cache.Dir, "*"+astfile.Ext))
+	if err != nil || len(entries) != 1 {
+		t.Fatal(entries, err)
+	}
+
+	cached, err := cache.ParseFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cached, script) {
+		t.Fatalf("%v\n%v", cached, script)
+	}
+
+	if err := os.WriteFile(path, []byte(`(`), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.ParseFile(path); err == nil {
+		t.Fatal("expected a parse error after the file changed")
+	}
+}
@@ -0,0 +1,94 @@
+package astfile
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"deedles.dev/extract"
+	"deedles.dev/extract/parser"
+)
+
+// Cache stores encoded scripts in a directory, keyed by their source
+// code, so that a script is only parsed the first time that it is
+// loaded. The directory is created if it doesn't exist. A Cache can be
+// shared by multiple processes.
+type Cache struct {
+	Dir string
+}
+
+// ParseFile parses the script at path, or decodes it from the cache if
+// it has been parsed before. Changes to the file are detected by
+// comparing its contents, so the file is still read, but not parsed.
+func (c Cache) ParseFile(path string) (*extract.List, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return c.ParseBytes(path, src)
+}
+
+// ParseBytes parses src, using filename for positions, or decodes it
+// from the cache if it has been parsed before.
+func (c Cache) ParseBytes(filename string, src []byte) (*extract.List, error) {
+	path := c.path(filename, src)
+	if data, err := os.ReadFile(path); err == nil {
+		// A corrupt entry is ignored and replaced.
+		if script, err := DecodeBytes(data); err == nil {
+			return script, nil
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	script, err := parser.ParseBytes(filename, src)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.store(path, script); err != nil {
+		return nil, err
+	}
+	return script, nil
+}
+
+// path returns the path of the cache entry for the script with the
+// given filename and source code. The filename is part of the key
+// because it is recorded in the positions of the encoded script.
+func (c Cache) path(filename string, src []byte) string {
+	h := sha256.New()
+	h.Write(header)
+	h.Write([]byte(filename))
+	h.Write([]byte{0})
+	h.Write(src)
+	return filepath.Join(c.Dir, hex.EncodeToString(h.Sum(nil))+Ext)
+}
+
+// store writes script to path. It writes to a temporary file first so
+// that other processes never see a partially written entry.
+func (c Cache) store(path string, script *extract.List) error {
+	var buf bytes.Buffer
+	if err := Encode(&buf, script); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(c.Dir, 0777); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(c.Dir, "*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,61 @@
+package extract
+
+import "reflect"
+
+// atomExists returns true if str is the name of an atom that the
+// runtime uses, such as :ok, or of a module or a function declared in
+// one in env. Other atoms aren't tracked so that they can be garbage
+// collected when they are no longer used.
+func atomExists(env *Env, str string) bool {
+	if _, ok := builtinAtoms[str]; ok {
+		return true
+	}
+	if env.GetModule(MakeAtom(str)) != nil {
+		return true
+	}
+
+	ident := MakeIdent(str)
+	var found bool
+	env.modules.Range(func(_ Atom, m *Module) bool {
+		_, found = m.Lookup(ident)
+		return !found
+	})
+	return found
+}
+
+func stdAtom() *Module {
+	m := Module{name: MakeAtom("Atom")}
+	m.decls = map[Ident]any{
+		MakeIdent("to_string"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 1 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 1}
+			}
+
+			_, head := Eval(env, args.Head(), nil)
+			if err, ok := head.(error); ok {
+				return env, err
+			}
+			atom, ok := head.(Atom)
+			if !ok {
+				return env, NewTypeError(head, reflect.TypeFor[Atom]())
+			}
+			return env, atom.String()
+		}),
+		MakeIdent("from_string"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			strs, err := evalStrings(env, args, 1, 1)
+			if err != nil {
+				return env, err
+			}
+			return env, MakeAtom(strs[0])
+		}),
+		MakeIdent("exists?"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			strs, err := evalStrings(env, args, 1, 1)
+			if err != nil {
+				return env, err
+			}
+			return env, atomExists(env, strs[0])
+		}),
+	}
+
+	return &m
+}
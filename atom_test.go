@@ -0,0 +1,34 @@
+package extract_test
+
+import (
+	"testing"
+
+	"deedles.dev/extract"
+)
+
+func TestAtom(t *testing.T) {
+	const src = `
+	(defmodule Test)
+	(list
+		(Atom.to_string :hello)
+		(Atom.to_string :"with space")
+		(Atom.from_string "computed")
+		(Atom.exists? "ok")
+		(Atom.exists? "Test")
+		(Atom.exists? "to_string")
+		(Atom.exists? "never_made_by_TestAtom")
+		(eq (Atom.from_string "literal_in_source") :literal_in_source)
+	)
+	`
+	result := runScript(t, src, true)
+	checkList(t, result,
+		"hello",
+		"with space",
+		extract.MakeAtom("computed"),
+		true,
+		true,
+		true,
+		false,
+		true,
+	)
+}
@@ -0,0 +1,208 @@
+package extract
+
+import (
+	"cmp"
+	"reflect"
+	"runtime/metrics"
+	"slices"
+	"time"
+)
+
+// defaultBenchTime is how long Bench.run runs a function for if it
+// isn't told how many iterations to run or for how long.
+const defaultBenchTime = time.Second
+
+// benchOptions configures a benchmark. If iterations is positive, the
+// function is run exactly that many times. Otherwise, the number of
+// iterations is increased until a round takes at least time.
+type benchOptions struct {
+	iterations int64
+	time       time.Duration
+}
+
+// benchOptionsFrom reads benchmark options from a map with the
+// optional keys :iterations and :time, the latter in milliseconds.
+func benchOptionsFrom(v any) (opts benchOptions, err error) {
+	opts.time = defaultBenchTime
+	if v == nil {
+		return opts, nil
+	}
+
+	m, ok := v.(*Map)
+	if !ok {
+		return opts, NewTypeError(v, reflect.TypeFor[*Map]())
+	}
+	if n, ok := m.Get(MakeAtom("iterations")); ok {
+		opts.iterations, ok = n.(int64)
+		if !ok {
+			return opts, NewTypeError(n, reflect.TypeFor[int64]())
+		}
+	}
+	if ms, ok := m.Get(MakeAtom("time")); ok {
+		opts.time, err = durationMS(ms)
+		if err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
+
+// benchResult is the result of benchmarking a function.
+type benchResult struct {
+	iterations         int64
+	elapsed            time.Duration
+	allocs, allocBytes uint64
+}
+
+func (r benchResult) nsPerOp() float64 {
+	return float64(r.elapsed.Nanoseconds()) / float64(r.iterations)
+}
+
+func (r benchResult) toMap() *Map {
+	n := float64(r.iterations)
+	return MapOf(
+		MakeAtom("iterations"), r.iterations,
+		MakeAtom("ns_per_op"), r.nsPerOp(),
+		MakeAtom("allocs_per_op"), float64(r.allocs)/n,
+		MakeAtom("bytes_per_op"), float64(r.allocBytes)/n,
+	)
+}
+
+var benchMetrics = []string{
+	"/gc/heap/allocs:objects",
+	"/gc/heap/allocs:bytes",
+}
+
+// readAllocs returns the total number of heap allocations made by the
+// program so far and their total size in bytes.
+func readAllocs() (objects, bytes uint64) {
+	samples := make([]metrics.Sample, len(benchMetrics))
+	for i, name := range benchMetrics {
+		samples[i].Name = name
+	}
+	metrics.Read(samples)
+	return samples[0].Value.Uint64(), samples[1].Value.Uint64()
+}
+
+// benchRound calls fn with no arguments n times.
+func benchRound(env *Env, fn any, n int64) (r benchResult, err error) {
+	objects, bytes := readAllocs()
+	start := time.Now()
+	for range n {
+		if _, err := callFunc(env, fn); err != nil {
+			return r, err
+		}
+	}
+	r.elapsed = time.Since(start)
+	endObjects, endBytes := readAllocs()
+
+	r.iterations = n
+	r.allocs, r.allocBytes = endObjects-objects, endBytes-bytes
+	return r, nil
+}
+
+// bench benchmarks fn as described by opts. Allocations are counted
+// for the whole program, so allocations made concurrently by other
+// processes are included in the results.
+func bench(env *Env, fn any, opts benchOptions) (benchResult, error) {
+	if opts.iterations > 0 {
+		return benchRound(env, fn, opts.iterations)
+	}
+
+	n := int64(1)
+	for {
+		if err := env.step(); err != nil {
+			return benchResult{}, err
+		}
+
+		r, err := benchRound(env, fn, n)
+		if err != nil || r.elapsed >= opts.time || n >= 1e9 {
+			return r, err
+		}
+
+		// Aim to finish the next round in the target time, but don't
+		// grow too quickly in case the first few rounds were slow.
+		next := n * 100
+		if r.elapsed > 0 {
+			next = int64(float64(opts.time) / float64(r.elapsed) * float64(n) * 1.2)
+		}
+		n = min(max(next, n+1), n*100, 1e9)
+	}
+}
+
+// evalBenchArgs evaluates a value followed by an optional map of
+// benchmark options.
+func evalBenchArgs(env *Env, args *List) (any, benchOptions, error) {
+	if args.Len() != 1 && args.Len() != 2 {
+		return nil, benchOptions{}, &ArgumentNumError{Num: args.Len(), Expected: -1}
+	}
+
+	vals, err := evalArgs(env, args)
+	if err != nil {
+		return nil, benchOptions{}, err
+	}
+
+	var optv any
+	if len(vals) == 2 {
+		optv = vals[1]
+	}
+	opts, err := benchOptionsFrom(optv)
+	return vals[0], opts, err
+}
+
+func stdBench() *Module {
+	m := Module{name: MakeAtom("Bench")}
+	m.decls = map[Ident]any{
+		MakeIdent("run"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			fn, opts, err := evalBenchArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			r, err := bench(env, fn, opts)
+			if err != nil {
+				return env, err
+			}
+			return env, r.toMap()
+		}),
+		MakeIdent("compare"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			fns, opts, err := evalBenchArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			pairs, err := collectEnum(fns)
+			if err != nil {
+				return env, err
+			}
+
+			type named struct {
+				name any
+				r    benchResult
+			}
+			results := make([]named, 0, len(pairs))
+			for _, pair := range pairs {
+				p, ok := pair.(*List)
+				if !ok || p.Len() != 2 {
+					return env, NewTypeError(pair, reflect.TypeFor[*List]())
+				}
+
+				r, err := bench(env, p.Tail().Head(), opts)
+				if err != nil {
+					return env, err
+				}
+				results = append(results, named{name: p.Head(), r: r})
+			}
+			slices.SortStableFunc(results, func(r1, r2 named) int { return cmp.Compare(r1.r.nsPerOp(), r2.r.nsPerOp()) })
+
+			list := make([]any, 0, len(results))
+			for _, r := range results {
+				m := r.r.toMap().Put(MakeAtom("name"), r.name)
+				m = m.Put(MakeAtom("relative"), r.r.nsPerOp()/max(results[0].r.nsPerOp(), 1e-9))
+				list = append(list, m)
+			}
+			return env, ListOf(list...)
+		}),
+	}
+
+	return &m
+}
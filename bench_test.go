@@ -0,0 +1,42 @@
+package extract_test
+
+import (
+	"errors"
+	"testing"
+
+	"deedles.dev/extract"
+)
+
+func TestBench(t *testing.T) {
+	const src = `
+	(let r (Bench.run (func (f) (List.reverse [1 2 3])) (Map.new :iterations 50)))
+	(let c (Bench.compare
+		[[:slow (func (f) (Enum.sum (Range.new 1 200)))] [:fast (func (f) 1)]]
+		(Map.new :time 5)))
+	(list
+		(Map.get r :iterations)
+		(eq (Map.get r :ns_per_op) 0.0)
+		(Enum.map c (func (f m) (Map.get m :name)))
+		(Map.get (List.nth c 0) :relative)
+	)
+	`
+	result := runScript(t, src, true)
+	checkList(t, result,
+		int64(50),
+		false,
+		extract.ListOf(extract.MakeAtom("fast"), extract.MakeAtom("slow")),
+		1.0,
+	)
+}
+
+func TestBenchError(t *testing.T) {
+	result := runScript(t, `(Bench.run (func (f) (add 1 :a)))`, false)
+	var terr *extract.TypeError
+	if err, _ := result.(error); !errors.As(err, &terr) {
+		t.Fatalf("%#v", result)
+	}
+	result = runScript(t, `(Bench.run (func (f) 1) (Map.new :time "1s"))`, false)
+	if err, _ := result.(error); !errors.As(err, new(*extract.TypeError)) {
+		t.Fatalf("%#v", result)
+	}
+}
@@ -0,0 +1,355 @@
+package extract
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"hash/maphash"
+	"iter"
+	"reflect"
+	"strings"
+)
+
+// ErrByteRange is returned when an integer that is not in the range
+// [0, 255] is used as a byte.
+var ErrByteRange = errors.New("byte out of range")
+
+// Binary is an immutable sequence of bytes. Binaries are created from
+// literals of the form b"data\x00", and, unlike strings, are not
+// expected to contain valid UTF-8. A nil *Binary is a valid, empty
+// binary.
+type Binary struct {
+	data []byte
+}
+
+// BinaryOf returns a binary containing a copy of data.
+func BinaryOf(data []byte) *Binary {
+	return &Binary{data: bytes.Clone(data)}
+}
+
+// Bytes returns a copy of the contents of the binary.
+func (b *Binary) Bytes() []byte {
+	return bytes.Clone(b.bytes())
+}
+
+func (b *Binary) bytes() []byte {
+	if b == nil {
+		return nil
+	}
+	return b.data
+}
+
+// Len returns the number of bytes in the binary.
+func (b *Binary) Len() int {
+	return len(b.bytes())
+}
+
+// At returns the byte at index i. It panics if i is out of range.
+func (b *Binary) At(i int) byte {
+	return b.data[i]
+}
+
+// Enumerate yields the bytes of the binary as integers.
+func (b *Binary) Enumerate() iter.Seq2[any, error] {
+	return func(yield func(any, error) bool) {
+		for _, c := range b.bytes() {
+			if !yield(int64(c), nil) {
+				return
+			}
+		}
+	}
+}
+
+// Equal returns true if other is a *Binary containing the same bytes
+// as b.
+func (b *Binary) Equal(other any) bool {
+	o, ok := other.(*Binary)
+	return ok && bytes.Equal(b.bytes(), o.bytes())
+}
+
+// Hash returns a hash of the contents of the binary that is
+// consistent with [Binary.Equal].
+func (b *Binary) Hash() uint64 {
+	var h maphash.Hash
+	h.SetSeed(hashSeed)
+	h.WriteString("binary")
+	h.Write(b.bytes())
+	return h.Sum64()
+}
+
+// Compare orders binaries byte by byte, with a binary that is a
+// prefix of another sorting first.
+func (b *Binary) Compare(other any) int {
+	return bytes.Compare(b.bytes(), other.(*Binary).bytes())
+}
+
+// Inspect returns a binary literal representing b, such as b"a\x00".
+func (b *Binary) Inspect() string {
+	const digits = "0123456789abcdef"
+
+	var sb strings.Builder
+	sb.WriteString(`b"`)
+	for _, c := range b.bytes() {
+		switch {
+		case c == '"', c == '\\':
+			sb.WriteByte('\\')
+			sb.WriteByte(c)
+		case c == '\n':
+			sb.WriteString(`\n`)
+		case c == '\t':
+			sb.WriteString(`\t`)
+		case c >= 0x20 && c < 0x7f:
+			sb.WriteByte(c)
+		default:
+			sb.WriteString(`\x`)
+			sb.WriteByte(digits[c>>4])
+			sb.WriteByte(digits[c&0xf])
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+func (b *Binary) String() string {
+	return Inspect(b)
+}
+
+// toBytes returns the contents of v, which must be either a string or
+// a *Binary.
+func toBytes(v any) ([]byte, error) {
+	switch v := v.(type) {
+	case string:
+		return []byte(v), nil
+	case *Binary:
+		return v.bytes(), nil
+	case error:
+		return nil, v
+	default:
+		return nil, NewTypeError(v, reflect.TypeFor[string](), reflect.TypeFor[*Binary]())
+	}
+}
+
+// evalBytes evaluates a single argument that is either a string or a
+// *Binary and returns its contents.
+func evalBytes(env *Env, args *List) ([]byte, error) {
+	if args.Len() != 1 {
+		return nil, &ArgumentNumError{Num: args.Len(), Expected: 1}
+	}
+
+	_, head := Eval(env, args.Head(), nil)
+	return toBytes(head)
+}
+
+var restAtom = MakeAtom("rest")
+
+// matchSizes splits data into consecutive pieces with the given
+// sizes. If the last size is :rest, the last piece contains whatever
+// is left over. Otherwise, the sizes must add up to exactly the length
+// of data. It returns false if data can't be split as described.
+func matchSizes(data []byte, sizes *List) (*List, bool, error) {
+	parts := make([]any, 0, sizes.Len())
+	for size := range sizes.All() {
+		if size == restAtom && len(parts) == sizes.Len()-1 {
+			parts = append(parts, &Binary{data: data})
+			return ListOf(parts...), true, nil
+		}
+
+		n, ok := size.(int64)
+		if !ok {
+			return nil, false, NewTypeError(size, reflect.TypeFor[int64]())
+		}
+		if n < 0 || n > int64(len(data)) {
+			return nil, false, nil
+		}
+		parts = append(parts, &Binary{data: data[:n]})
+		data = data[n:]
+	}
+	return ListOf(parts...), len(data) == 0, nil
+}
+
+func stdBinary() *Module {
+	m := Module{name: MakeAtom("Binary")}
+	m.decls = map[Ident]any{
+		MakeIdent("new"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			list, err := evalList(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			data := make([]byte, 0, list.Len())
+			for v := range list.All() {
+				c, ok := v.(int64)
+				if !ok {
+					return env, NewTypeError(v, reflect.TypeFor[int64]())
+				}
+				if c < 0 || c > 255 {
+					return env, ErrByteRange
+				}
+				data = append(data, byte(c))
+			}
+			return env, &Binary{data: data}
+		}),
+		MakeIdent("from_string"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			strs, err := evalStrings(env, args, 1, 1)
+			if err != nil {
+				return env, err
+			}
+			return env, &Binary{data: []byte(strs[0])}
+		}),
+		MakeIdent("to_string"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 1 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 1}
+			}
+
+			b, _, err := evalBinaryArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			return env, string(b.bytes())
+		}),
+		MakeIdent("from_hex"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			strs, err := evalStrings(env, args, 1, 1)
+			if err != nil {
+				return env, err
+			}
+
+			data, err := hex.DecodeString(strs[0])
+			if err != nil {
+				return env, errorResult(MakeAtom("invalid"))
+			}
+			return env, okResult(&Binary{data: data})
+		}),
+		MakeIdent("to_hex"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 1 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 1}
+			}
+
+			b, _, err := evalBinaryArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			return env, hex.EncodeToString(b.bytes())
+		}),
+		MakeIdent("size"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 1 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 1}
+			}
+
+			b, _, err := evalBinaryArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			return env, int64(b.Len())
+		}),
+		MakeIdent("at"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 2 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 2}
+			}
+
+			b, vals, err := evalBinaryArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			i, ok := vals[0].(int64)
+			if !ok {
+				return env, NewTypeError(vals[0], reflect.TypeFor[int64]())
+			}
+
+			n, ok := resolveIndex(i, b.Len())
+			if !ok {
+				return env, &IndexError{Index: i, Len: b.Len()}
+			}
+			return env, int64(b.At(n))
+		}),
+		MakeIdent("slice"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 3 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 3}
+			}
+
+			b, vals, err := evalBinaryArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			for _, v := range vals {
+				if _, ok := v.(int64); !ok {
+					return env, NewTypeError(v, reflect.TypeFor[int64]())
+				}
+			}
+
+			lo, hi := sliceBounds(vals[0].(int64), vals[1].(int64), b.Len())
+			return env, &Binary{data: b.bytes()[lo:hi]}
+		}),
+		MakeIdent("concat"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			vals, err := evalArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			var data []byte
+			for _, v := range vals {
+				b, ok := v.(*Binary)
+				if !ok {
+					return env, NewTypeError(v, reflect.TypeFor[*Binary]())
+				}
+				data = append(data, b.bytes()...)
+			}
+			return env, &Binary{data: data}
+		}),
+		MakeIdent("to_list"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 1 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 1}
+			}
+
+			b, _, err := evalBinaryArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			var list *List
+			data := b.bytes()
+			for i := len(data) - 1; i >= 0; i-- {
+				list = list.Push(int64(data[i]))
+			}
+			return env, list
+		}),
+		MakeIdent("match"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 2 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 2}
+			}
+
+			b, vals, err := evalBinaryArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			sizes, ok := vals[0].(*List)
+			if !ok {
+				return env, NewTypeError(vals[0], reflect.TypeFor[*List]())
+			}
+
+			parts, ok, err := matchSizes(b.bytes(), sizes)
+			if err != nil {
+				return env, err
+			}
+			if !ok {
+				return env, errorResult(MakeAtom("size_mismatch"))
+			}
+			return env, okResult(parts)
+		}),
+	}
+
+	return &m
+}
+
+// evalBinaryArgs evaluates args, the first of which must be a
+// *Binary. It returns the binary and the rest of the evaluated
+// arguments.
+func evalBinaryArgs(env *Env, args *List) (*Binary, []any, error) {
+	vals, err := evalArgs(env, args)
+	if err != nil {
+		return nil, nil, err
+	}
+	b, ok := vals[0].(*Binary)
+	if !ok {
+		return nil, nil, NewTypeError(vals[0], reflect.TypeFor[*Binary]())
+	}
+	return b, vals[1:], nil
+}
@@ -0,0 +1,77 @@
+package extract_test
+
+import (
+	"errors"
+	"testing"
+
+	"deedles.dev/extract"
+)
+
+func TestBinary(t *testing.T) {
+	const src = `
+	(let b (Binary.concat b"\x01\x02" (Binary.new [3 255]) (Binary.from_string "hi")))
+	(list
+		b
+		(Binary.size b)
+		(Binary.at b -3)
+		(Binary.slice b 1 2)
+		(Binary.to_hex b)
+		(Binary.from_hex "01ff")
+		(Binary.from_hex "zz")
+		(Binary.to_string (Binary.slice b 4 10))
+		(Binary.to_list b"\x00a")
+		(Enum.sum b"\x01\x02")
+		(inspect b"\"\\\n\x7f")
+	)
+	`
+	result := runScript(t, src, true)
+	checkList(t, result,
+		extract.BinaryOf([]byte{1, 2, 3, 255, 'h', 'i'}),
+		int64(6),
+		int64(255),
+		extract.BinaryOf([]byte{2, 3}),
+		"010203ff6869",
+		extract.TupleOf(extract.MakeAtom("ok"), extract.BinaryOf([]byte{1, 255})),
+		extract.TupleOf(extract.MakeAtom("error"), extract.MakeAtom("invalid")),
+		"hi",
+		extract.ListOf(int64(0), int64('a')),
+		int64(3),
+		`b"\"\\\n\x7f"`,
+	)
+}
+
+func TestBinaryMatch(t *testing.T) {
+	const src = `
+	(let (:ok (header len body)) (Binary.match b"\x01\x02\x00\x03abc" [2 2 :rest]))
+	(list
+		header
+		(Binary.to_list len)
+		(Binary.to_string body)
+		(Binary.match b"abc" [1 1])
+		(Binary.match b"abc" [1 4 :rest])
+		(let (b"\x00" z) [b"\x00" :zero])
+		z
+	)
+	`
+	result := runScript(t, src, true)
+	checkList(t, result,
+		extract.BinaryOf([]byte{1, 2}),
+		extract.ListOf(int64(0), int64(3)),
+		"abc",
+		extract.TupleOf(extract.MakeAtom("error"), extract.MakeAtom("size_mismatch")),
+		extract.TupleOf(extract.MakeAtom("error"), extract.MakeAtom("size_mismatch")),
+		extract.ListOf(extract.BinaryOf([]byte{0}), extract.MakeAtom("zero")),
+		extract.MakeAtom("zero"),
+	)
+}
+
+func TestBinaryErrors(t *testing.T) {
+	result := runScript(t, `(Binary.new [256])`, false)
+	if err, _ := result.(error); !errors.Is(err, extract.ErrByteRange) {
+		t.Fatalf("%#v", result)
+	}
+	result = runScript(t, `(Binary.at b"a" 1)`, false)
+	if err, _ := result.(error); !errors.As(err, new(*extract.IndexError)) {
+		t.Fatalf("%#v", result)
+	}
+}
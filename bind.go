@@ -0,0 +1,76 @@
+package extract
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// Bind returns a module named name that exposes v to Extract code.
+// Each exported method of v becomes a function in the module, wrapped
+// as described by [Module.RegisterFunc]. If v is a struct or a pointer
+// to one, each of its exported fields also becomes a function that
+// takes no arguments and returns the current value of the field. If a
+// method and a field have the same name, the method takes precedence.
+//
+// Go names are converted to the conventional Extract style, so a
+// method named UserID becomes user_id.
+func Bind(name Atom, v any) *Module {
+	m := Module{name: name, decls: make(map[Ident]any)}
+
+	rv := reflect.ValueOf(v)
+	sv := reflect.Indirect(rv)
+	if sv.Kind() == reflect.Struct {
+		st := sv.Type()
+		for i := range st.NumField() {
+			field := st.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			m.decls[MakeIdent(snakeCase(field.Name))] = fieldGetter(sv, i)
+		}
+	}
+
+	rt := rv.Type()
+	for i := range rt.NumMethod() {
+		method := rt.Method(i)
+		if !method.IsExported() {
+			continue
+		}
+		m.RegisterFunc(snakeCase(method.Name), rv.Method(i).Interface())
+	}
+
+	return &m
+}
+
+func fieldGetter(sv reflect.Value, i int) EvalFunc {
+	return func(env *Env, args *List) (*Env, any) {
+		if args.Len() != 0 {
+			return env, &ArgumentNumError{Num: args.Len(), Expected: 0}
+		}
+		r, err := fromGo(sv.Field(i))
+		if err != nil {
+			return env, err
+		}
+		return env, r
+	}
+}
+
+// snakeCase converts a Go identifier, such as HTTPServer, to
+// snake_case, such as http_server.
+func snakeCase(name string) string {
+	runes := []rune(name)
+
+	var sb strings.Builder
+	for i, c := range runes {
+		if unicode.IsUpper(c) && i > 0 {
+			prevLower := !unicode.IsUpper(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || nextLower {
+				sb.WriteByte('_')
+			}
+		}
+		sb.WriteRune(unicode.ToLower(c))
+	}
+	return sb.String()
+}
@@ -0,0 +1,53 @@
+package extract_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"deedles.dev/extract"
+	"deedles.dev/extract/parser"
+)
+
+type account struct {
+	UserID  int
+	Name    string
+	balance int
+}
+
+func (a *account) Deposit(n int) int {
+	a.balance += n
+	return a.balance
+}
+
+func (a *account) Balance() int {
+	return a.balance
+}
+
+func (a *account) HTTPPath() string {
+	return fmt.Sprintf("/users/%v", a.UserID)
+}
+
+func TestBind(t *testing.T) {
+	acct := &account{UserID: 3, Name: "test"}
+	m := extract.Bind(extract.MakeAtom("Account"), acct)
+
+	script, err := parser.ParseString(t.Name(), `
+	(Account.deposit 10)
+	(Account.deposit 5)
+	(list (Account.user_id) (Account.name) (Account.balance) (Account.http_path))
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env := extract.New(context.Background(), extract.WithModules(m))
+	_, result := extract.Run(env, script.All())
+	ex := extract.ListOf(int64(3), "test", int64(15), "/users/3")
+	if !extract.Equal(result, ex) {
+		t.Fatal(extract.Inspect(result))
+	}
+	if acct.balance != 15 {
+		t.Fatal(acct.balance)
+	}
+}
@@ -0,0 +1,64 @@
+package extract
+
+import "math"
+
+func stdBitwise() *Module {
+	m := Module{name: MakeAtom("Bitwise")}
+	m.decls = map[Ident]any{
+		MakeIdent("band"): bitwiseFold(func(a, b int64) int64 { return a & b }),
+		MakeIdent("bor"):  bitwiseFold(func(a, b int64) int64 { return a | b }),
+		MakeIdent("bxor"): bitwiseFold(func(a, b int64) int64 { return a ^ b }),
+		MakeIdent("bnot"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			ints, err := evalInts(env, args, 1, 1)
+			if err != nil {
+				return env, err
+			}
+			return env, ^ints[0]
+		}),
+		MakeIdent("bsl"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			ints, err := evalInts(env, args, 2, 2)
+			if err != nil {
+				return env, err
+			}
+			return env, shift(ints[0], ints[1])
+		}),
+		MakeIdent("bsr"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			ints, err := evalInts(env, args, 2, 2)
+			if err != nil {
+				return env, err
+			}
+			if ints[1] == math.MinInt64 {
+				return env, shift(ints[0], math.MaxInt64)
+			}
+			return env, shift(ints[0], -ints[1])
+		}),
+	}
+
+	return &m
+}
+
+// bitwiseFold returns a function for the Bitwise module that combines
+// two or more integers with op.
+func bitwiseFold(op func(a, b int64) int64) EvalFunc {
+	return func(env *Env, args *List) (*Env, any) {
+		ints, err := evalInts(env, args, 2, math.MaxInt)
+		if err != nil {
+			return env, err
+		}
+
+		r := ints[0]
+		for _, n := range ints[1:] {
+			r = op(r, n)
+		}
+		return env, r
+	}
+}
+
+// shift shifts n left by s bits. If s is negative, n is instead
+// arithmetically shifted right by -s bits.
+func shift(n, s int64) int64 {
+	if s >= 0 {
+		return n << min(s, 64)
+	}
+	return n >> min(-s, 64)
+}
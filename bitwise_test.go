@@ -0,0 +1,33 @@
+package extract_test
+
+import "testing"
+
+func TestBitwise(t *testing.T) {
+	const src = `
+	(list
+		(Bitwise.band 12 10)
+		(Bitwise.band 15 7 3)
+		(Bitwise.bor 12 10)
+		(Bitwise.bxor 12 10)
+		(Bitwise.bnot 0)
+		(Bitwise.bsl 1 4)
+		(Bitwise.bsl 16 -2)
+		(Bitwise.bsr -16 2)
+		(Bitwise.bsr 1 -3)
+		(Bitwise.bsl 1 100)
+	)
+	`
+	result := runScript(t, src, true)
+	checkList(t, result,
+		int64(8),
+		int64(3),
+		int64(14),
+		int64(6),
+		int64(-1),
+		int64(16),
+		int64(4),
+		int64(-4),
+		int64(8),
+		int64(0),
+	)
+}
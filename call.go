@@ -0,0 +1,52 @@
+package extract
+
+import (
+	"strings"
+)
+
+// Call calls fn with args from Go, converting args to Extract values
+// with [Marshal]. The function can either be a callable value, such as
+// a *Func returned by earlier evaluation, or a string naming a
+// function, in which case it may either be the name of a function
+// that is bound in env, such as "add", or a reference to a function
+// in a module, such as "String.to_upper". If the call results in an
+// error value, it is returned as the error.
+func (env *Env) Call(fn any, args ...any) (any, error) {
+	if name, ok := fn.(string); ok {
+		v, err := env.resolve(name)
+		if err != nil {
+			return nil, err
+		}
+		fn = v
+	}
+
+	vals := make([]any, len(args))
+	for i, arg := range args {
+		vals[i] = Marshal(arg)
+	}
+	return callFunc(env, fn, vals...)
+}
+
+// resolve finds the value referred to by name, which is either a
+// bound identifier or a reference to a declaration in a module.
+func (env *Env) resolve(name string) (any, error) {
+	i := strings.LastIndexByte(name, '.')
+	if i < 0 {
+		v, ok := env.Lookup(MakeIdent(name))
+		if !ok {
+			return nil, &NameError{Ident: MakeIdent(name)}
+		}
+		return v, nil
+	}
+
+	mod, ident := MakeAtom(name[:i]), MakeIdent(name[i+1:])
+	m := env.GetModule(mod)
+	if m == nil {
+		return nil, &UndefinedModuleError{Name: mod}
+	}
+	v, ok := m.Lookup(ident)
+	if !ok {
+		return nil, &NameError{Ident: ident}
+	}
+	return v, nil
+}
@@ -0,0 +1,52 @@
+package extract_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"deedles.dev/extract"
+	"deedles.dev/extract/parser"
+)
+
+func TestEnvCall(t *testing.T) {
+	script, err := parser.ParseString(t.Name(), `
+	(defmodule Greeter
+		(def (greet name) (String.format "Hello, %v." name))
+		(def (first [a &_]) a)
+	)
+	(func (double n) (n * 2))
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env := extract.New(context.Background())
+	env, double := extract.Run(env, script.All())
+
+	r, err := env.Call("Greeter.greet", "World")
+	if err != nil || r != "Hello, World." {
+		t.Fatal(r, err)
+	}
+
+	r, err = env.Call("Greeter.first", []int{3, 2, 1})
+	if err != nil || r != int64(3) {
+		t.Fatal(r, err)
+	}
+
+	r, err = env.Call(double, 4)
+	if err != nil || r != int64(8) {
+		t.Fatal(r, err)
+	}
+
+	_, err = env.Call("Greeter.first", []int{})
+	if !errors.Is(err, extract.ErrPatternMatch) {
+		t.Fatalf("%#v", err)
+	}
+
+	_, err = env.Call("Greeter.missing")
+	var nerr *extract.NameError
+	if !errors.As(err, &nerr) || nerr.Ident != extract.MakeIdent("missing") {
+		t.Fatalf("%#v", err)
+	}
+}
@@ -0,0 +1,243 @@
+// Package callback adapts Extract functions to the signatures of
+// common Go callbacks so that scripts can supply behavior to Go code,
+// such as a comparison for sorting or an HTTP handler.
+//
+// Each adapter takes an [extract.Env] and a function, which can be
+// anything accepted by [extract.Env.Call], such as a *extract.Func
+// returned by a script or the name of a function in a module. The
+// function is called in the Env with the callback's arguments
+// converted with [extract.Marshal], and its result is converted back
+// with [extract.Unmarshal].
+//
+// Callbacks whose signatures can return an error do so if the call or
+// the conversion of its result fails. Callbacks whose signatures
+// can't report errors to an [Errors] instead and return the zero
+// value of their result type.
+package callback
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"deedles.dev/extract"
+)
+
+// Errors records the first error from callbacks that have no way to
+// return one themselves. A nil *Errors discards errors. An Errors can
+// be shared by callbacks that are called concurrently.
+type Errors struct {
+	m   sync.Mutex
+	err error
+}
+
+// Err returns the first error that was reported, if any.
+func (e *Errors) Err() error {
+	e.m.Lock()
+	defer e.m.Unlock()
+	return e.err
+}
+
+func (e *Errors) report(err error) {
+	if e == nil {
+		return
+	}
+
+	e.m.Lock()
+	defer e.m.Unlock()
+	if e.err == nil {
+		e.err = err
+	}
+}
+
+// call calls fn in env with args and converts the result to an R.
+func call[R any](env *extract.Env, fn any, args ...any) (R, error) {
+	var out R
+	r, err := env.Call(fn, args...)
+	if err != nil {
+		return out, err
+	}
+	if err := extract.Unmarshal(r, &out); err != nil {
+		return out, err
+	}
+	return out, nil
+}
+
+// Func returns a function that calls fn with one argument.
+func Func[T, R any](env *extract.Env, fn any) func(T) (R, error) {
+	return func(v T) (R, error) {
+		return call[R](env, fn, v)
+	}
+}
+
+// Func2 returns a function that calls fn with two arguments.
+func Func2[T1, T2, R any](env *extract.Env, fn any) func(T1, T2) (R, error) {
+	return func(v1 T1, v2 T2) (R, error) {
+		return call[R](env, fn, v1, v2)
+	}
+}
+
+// Unchecked is like [Func], but the returned function reports errors
+// to errs instead of returning them, for use where a func(T) R is
+// required.
+func Unchecked[T, R any](env *extract.Env, fn any, errs *Errors) func(T) R {
+	return func(v T) R {
+		r, err := call[R](env, fn, v)
+		if err != nil {
+			errs.report(err)
+		}
+		return r
+	}
+}
+
+// Less returns a less function, such as for [sort.Slice] with an
+// index into the slice, that calls fn with two values. fn may return
+// either a boolean or an integer that is negative if the first value
+// is less than the second, as for [Compare]. If the call
+// fails, the error is reported to errs and the returned function
+// returns false.
+func Less[T any](env *extract.Env, fn any, errs *Errors) func(a, b T) bool {
+	return func(a, b T) bool {
+		r, err := env.Call(fn, a, b)
+		if err != nil {
+			errs.report(err)
+			return false
+		}
+
+		switch r := r.(type) {
+		case bool:
+			return r
+		case int64:
+			return r < 0
+		default:
+			errs.report(extract.NewTypeError(r, reflect.TypeFor[bool](), reflect.TypeFor[int64]()))
+			return false
+		}
+	}
+}
+
+// Compare returns a comparison function, such as for
+// [slices.SortFunc], that calls fn with two values. fn must return an
+// integer that is negative if the first value is less than the second,
+// positive if it is greater, and zero if they are equal. If the call
+// fails, the error is reported to errs and the returned function
+// returns 0.
+func Compare[T any](env *extract.Env, fn any, errs *Errors) func(a, b T) int {
+	return func(a, b T) int {
+		r, err := call[int64](env, fn, a, b)
+		if err != nil {
+			errs.report(err)
+			return 0
+		}
+		return cmp.Compare(r, 0)
+	}
+}
+
+// Request is the representation of an HTTP request that is passed to
+// the function called by a [Handler]. Headers with several values
+// have them joined with commas, as do query parameters.
+type Request struct {
+	Method     string            `extract:"method"`
+	Path       string            `extract:"path"`
+	Query      map[string]string `extract:"query"`
+	Headers    map[string]string `extract:"headers"`
+	Body       string            `extract:"body"`
+	RemoteAddr string            `extract:"remote_addr"`
+}
+
+// Response is the representation of an HTTP response that is returned
+// by the function called by a [Handler]. A zero Status is sent as 200.
+type Response struct {
+	Status  int               `extract:"status"`
+	Headers map[string]string `extract:"headers"`
+	Body    []byte            `extract:"body"`
+}
+
+// MaxBodySize is the size of the largest request body that a
+// [Handler] reads. Larger requests are rejected.
+const MaxBodySize = 10 << 20
+
+// Handler returns an HTTP handler that calls fn with a [Request]
+// converted to a map, such as
+//
+//	(Map.get request :path)
+//
+// fn may return either a string, which is sent as the body of the
+// response, or a map that can be converted to a [Response], such as
+//
+//	(Map.from_list [[:status 404] [:body "not found"]])
+//
+// The call is made with the request's context, so it is canceled if
+// the client goes away. If the call fails, the error is reported to
+// errs and a 500 Internal Server Error is sent without any details.
+func Handler(env *extract.Env, fn any, errs *Errors) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		body, err := io.ReadAll(http.MaxBytesReader(w, req.Body, MaxBodySize))
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+
+		r := Request{
+			Method:     req.Method,
+			Path:       req.URL.Path,
+			Query:      joinValues(req.URL.Query()),
+			Headers:    joinValues(req.Header),
+			Body:       string(body),
+			RemoteAddr: req.RemoteAddr,
+		}
+		resp, err := handle(env.WithContext(req.Context()), fn, r)
+		if err != nil {
+			errs.report(err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		for k, v := range resp.Headers {
+			w.Header().Set(k, v)
+		}
+		if resp.Status == 0 {
+			resp.Status = http.StatusOK
+		}
+		w.WriteHeader(resp.Status)
+		w.Write(resp.Body)
+	}
+}
+
+func handle(env *extract.Env, fn any, r Request) (resp Response, err error) {
+	v, err := env.Call(fn, r)
+	if err != nil {
+		return resp, err
+	}
+
+	switch v := v.(type) {
+	case string:
+		resp.Body = []byte(v)
+	case *extract.Map:
+		err = extract.Unmarshal(v, &resp)
+	default:
+		err = extract.NewTypeError(v, reflect.TypeFor[string](), reflect.TypeFor[*extract.Map]())
+	}
+	if err == nil && resp.Status != 0 && (resp.Status < 100 || resp.Status > 999) {
+		err = fmt.Errorf("invalid HTTP status %v", resp.Status)
+	}
+	return resp, err
+}
+
+func joinValues(values map[string][]string) map[string]string {
+	m := make(map[string]string, len(values))
+	for k, v := range values {
+		m[k] = strings.Join(v, ",")
+	}
+	return m
+}
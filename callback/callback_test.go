@@ -0,0 +1,155 @@
+package callback_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	"deedles.dev/extract"
+	"deedles.dev/extract/callback"
+	"deedles.dev/extract/parser"
+)
+
+func setup(t *testing.T, src string) *extract.Env {
+	script, err := parser.ParseString(t.Name(), src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env, r := extract.Run(extract.New(context.Background()), script.All())
+	if err, ok := r.(error); ok {
+		t.Fatal(err)
+	}
+	return env
+}
+
+func TestFunc(t *testing.T) {
+	env := setup(t, `
+	(defmodule Test
+		(def (double n) (n * 2))
+		(def (greet greeting name) (String.format "%v, %v." greeting name))
+		(def (lengths strs) (Enum.map strs String.length)))
+	`)
+
+	double := callback.Func[int, int](env, "Test.double")
+	if r, err := double(21); err != nil || r != 42 {
+		t.Fatal(r, err)
+	}
+
+	greet := callback.Func2[string, string, string](env, "Test.greet")
+	if r, err := greet("Hello", "World"); err != nil || r != "Hello, World." {
+		t.Fatal(r, err)
+	}
+
+	lengths := callback.Func[[]string, []int](env, "Test.lengths")
+	if r, err := lengths([]string{"a", "abc"}); err != nil || !slices.Equal(r, []int{1, 3}) {
+		t.Fatal(r, err)
+	}
+
+	badResult := callback.Func[int, string](env, "Test.double")
+	var terr *extract.TypeError
+	if _, err := badResult(1); !errors.As(err, &terr) {
+		t.Fatalf("%#v", err)
+	}
+
+	var errs callback.Errors
+	unchecked := callback.Unchecked[string, int](env, "Test.double", &errs)
+	if r := unchecked("x"); r != 0 || errs.Err() == nil {
+		t.Fatal(r, errs.Err())
+	}
+	if r := callback.Unchecked[int, int](env, "Test.double", nil)(2); r != 4 {
+		t.Fatal(r)
+	}
+}
+
+func TestSort(t *testing.T) {
+	env := setup(t, `
+	(defmodule Test
+		(def (by_length a b) ((String.length a) - (String.length b)))
+		(def (shorter a b) (eq (String.length a) (Integer.min (String.length a) (String.length b)))))
+	`)
+
+	var errs callback.Errors
+	words := []string{"three", "a", "to"}
+	slices.SortFunc(words, callback.Compare[string](env, "Test.by_length", &errs))
+	if !slices.Equal(words, []string{"a", "to", "three"}) || errs.Err() != nil {
+		t.Fatal(words, errs.Err())
+	}
+
+	words = []string{"three", "a", "to"}
+	less := callback.Less[string](env, "Test.by_length", &errs)
+	sort.Slice(words, func(i, j int) bool { return less(words[i], words[j]) })
+	if !slices.Equal(words, []string{"a", "to", "three"}) || errs.Err() != nil {
+		t.Fatal(words, errs.Err())
+	}
+
+	less = callback.Less[string](env, "Test.shorter", &errs)
+	if !less("a", "bb") || less("bb", "a") || errs.Err() != nil {
+		t.Fatal(errs.Err())
+	}
+
+	cmp := callback.Compare[int](env, "Test.by_length", &errs)
+	if r := cmp(1, 2); r != 0 || errs.Err() == nil {
+		t.Fatal(r, errs.Err())
+	}
+}
+
+func TestHandler(t *testing.T) {
+	env := setup(t, `
+	(defmodule Test
+		(def (handle req) (route (Map.get req :path) req))
+		(def (route "/hello" req)
+			(String.format "Hello, %v." (Map.get (Map.get req :query) "name")))
+		(def (route "/echo" req)
+			(Map.from_list [
+				[:status 201]
+				[:headers (Map.from_list [["Content-Type" "text/plain"]])]
+				[:body (Map.get req :body)]]))
+		(def (route "/fail" _) (add 1 :a)))
+	`)
+
+	var errs callback.Errors
+	srv := httptest.NewServer(callback.Handler(env, "Test.handle", &errs))
+	defer srv.Close()
+
+	get := func(method, path, body string) (int, string, http.Header) {
+		req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, string(data), resp.Header
+	}
+
+	if code, body, _ := get("GET", "/hello?name=World", ""); code != 200 || body != "Hello, World." {
+		t.Fatal(code, body)
+	}
+	code, body, header := get("POST", "/echo", "ping")
+	if code != 201 || body != "ping" || header.Get("Content-Type") != "text/plain" {
+		t.Fatal(code, body, header)
+	}
+	if errs.Err() != nil {
+		t.Fatal(errs.Err())
+	}
+
+	if code, body, _ := get("GET", "/fail", ""); code != 500 || strings.Contains(body, "type") {
+		t.Fatal(code, body)
+	}
+	var terr *extract.TypeError
+	if !errors.As(errs.Err(), &terr) {
+		t.Fatalf("%#v", errs.Err())
+	}
+}
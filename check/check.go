@@ -0,0 +1,693 @@
+// Package check implements static analysis of Extract scripts. It
+// finds likely mistakes in a parsed script without running it.
+package check
+
+import (
+	"cmp"
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+
+	"deedles.dev/extract"
+	"deedles.dev/extract/scanner"
+)
+
+// Diagnostic is a problem found in a script.
+type Diagnostic struct {
+	Pos     scanner.Position
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%v: %v", d.Pos, d.Message)
+}
+
+// Check analyzes script, which should be the result of parsing an
+// entire file, and returns the problems that it finds sorted by
+// position. The modules and top-level bindings of env, such as the
+// kernel functions and the standard library, are assumed to be
+// available when the script is run. Nothing is evaluated in env.
+//
+// Check reports
+//
+//   - identifiers that aren't bound by either the script or env,
+//   - references to modules and module functions that don't exist,
+//     although references to unknown modules are allowed in scripts
+//     that use import, as the imported files may declare them,
+//   - calls to functions declared in the script with a number of
+//     arguments that none of their variants accept,
+//   - variants of functions, loops, and receives that can never be
+//     chosen because an earlier variant matches everything that they
+//     do, and
+//   - let bindings that are never used, unless their names begin with
+//     an underscore.
+//
+// Positions are only recorded for lists, so problems are reported at
+// the position of the innermost list that contains them.
+func Check(env *extract.Env, script *extract.List) []Diagnostic {
+	c := checker{
+		env:     env,
+		modules: make(map[extract.Atom]*module),
+	}
+	c.collectModules(script.All())
+	for _, m := range c.modules {
+		for name, variants := range m.funcs {
+			c.unreachable(fmt.Sprintf("%v.%v", m.name, name), variants)
+		}
+	}
+
+	c.seq(nil, script)
+	for _, b := range c.lets {
+		if !b.used && !strings.HasPrefix(b.name.String(), "_") {
+			c.reportAt(b.pos, "declared and not used: %v", b.name)
+		}
+	}
+
+	slices.SortStableFunc(c.diags, func(d1, d2 Diagnostic) int {
+		return cmp.Or(
+			strings.Compare(d1.Pos.Filename, d2.Pos.Filename),
+			cmp.Compare(d1.Pos.Line, d2.Pos.Line),
+			cmp.Compare(d1.Pos.Col, d2.Pos.Col),
+		)
+	})
+	return c.diags
+}
+
+// arity is the range of the number of arguments that a variant of a
+// function accepts. Max is -1 if there is no upper limit.
+type arity struct {
+	min, max int
+}
+
+func (a arity) accepts(n int) bool {
+	return n >= a.min && (a.max < 0 || n <= a.max)
+}
+
+func (a arity) String() string {
+	switch {
+	case a.max < 0:
+		return fmt.Sprintf("at least %v", a.min)
+	case a.min == a.max:
+		return fmt.Sprint(a.min)
+	default:
+		return fmt.Sprintf("%v to %v", a.min, a.max)
+	}
+}
+
+// variant is a variant of a function declared in the script.
+type variant struct {
+	params []any
+	arity  arity
+	pos    scanner.Position
+}
+
+func newVariant(params *extract.List, pos scanner.Position) variant {
+	v := variant{params: slices.Collect(params.All()), pos: pos}
+	for _, param := range v.params {
+		switch param.(type) {
+		case extract.Rest:
+			v.arity.max = -1
+		case extract.Default:
+			v.arity.max++
+		default:
+			v.arity.min++
+			v.arity.max++
+		}
+	}
+	return v
+}
+
+// hasDefaults returns true if any of the variant's parameters have
+// default values.
+func (v variant) hasDefaults() bool {
+	return slices.ContainsFunc(v.params, func(param any) bool {
+		_, ok := param.(extract.Default)
+		return ok
+	})
+}
+
+// module is a module declared in the script.
+type module struct {
+	name  extract.Atom
+	funcs map[extract.Ident][]variant
+}
+
+// binding is a name bound by the script, either with let or by a
+// pattern.
+type binding struct {
+	name     extract.Ident
+	pos      scanner.Position
+	variants []variant
+	used     bool
+}
+
+// scope is a persistent list of the bindings that are visible at a
+// point in the script. A scope with a module marks the start of the
+// module's body, where the functions declared in the module become
+// visible.
+type scope struct {
+	next   *scope
+	b      *binding
+	module *module
+}
+
+func (sc *scope) bind(b *binding) *scope {
+	return &scope{next: sc, b: b}
+}
+
+// lookup finds what ident refers to in the scope. It returns either
+// the binding or the module that declares it, or neither if ident is
+// not bound by the script.
+func (sc *scope) lookup(ident extract.Ident) (*binding, *module) {
+	for ; sc != nil; sc = sc.next {
+		if sc.b != nil && sc.b.name == ident {
+			return sc.b, nil
+		}
+		if sc.module != nil {
+			if _, ok := sc.module.funcs[ident]; ok {
+				return nil, sc.module
+			}
+		}
+	}
+	return nil, nil
+}
+
+type checker struct {
+	env     *extract.Env
+	modules map[extract.Atom]*module
+	lets    []*binding
+	diags   []Diagnostic
+
+	// imports is true if the script uses import.
+	imports bool
+
+	// pos is the position of the innermost list being checked.
+	pos scanner.Position
+}
+
+func (c *checker) report(format string, args ...any) {
+	c.reportAt(c.pos, format, args...)
+}
+
+func (c *checker) reportAt(pos scanner.Position, format string, args ...any) {
+	c.diags = append(c.diags, Diagnostic{Pos: pos, Message: fmt.Sprintf(format, args...)})
+}
+
+// at sets the position of the innermost list to pos and returns a
+// function that restores the previous one.
+func (c *checker) at(pos scanner.Position) func() {
+	prev := c.pos
+	c.pos = pos
+	return func() { c.pos = prev }
+}
+
+// collectModules finds every module declared in exprs, along with the
+// functions declared in them, so that functions can be referenced
+// before they are declared, as they can be at runtime.
+func (c *checker) collectModules(exprs iter.Seq[any]) {
+	for expr := range exprs {
+		call, ok := expr.(extract.Call)
+		if !ok || call.Len() == 0 {
+			continue
+		}
+		if call.Head() == importIdent {
+			c.imports = true
+		}
+		name, ok := call.Tail().Head().(extract.Atom)
+		if call.Head() != defmoduleIdent || !ok {
+			c.collectModules(call.All())
+			continue
+		}
+
+		m := c.modules[name]
+		if m == nil {
+			m = &module{name: name, funcs: make(map[extract.Ident][]variant)}
+			c.modules[name] = m
+		}
+		for expr := range call.Tail().Tail().All() {
+			def, ok := expr.(extract.Call)
+			if !ok || def.Head() != defIdent {
+				continue
+			}
+			head, ok := def.Tail().Head().(extract.Call)
+			if !ok || head.Len() == 0 {
+				continue
+			}
+			if fname, ok := head.Head().(extract.Ident); ok {
+				m.funcs[fname] = append(m.funcs[fname], newVariant(head.Tail(), def.Pos))
+			}
+		}
+		c.collectModules(call.Tail().Tail().All())
+	}
+}
+
+// unreachable reports the variants of the function called name that
+// are matched entirely by an earlier variant.
+func (c *checker) unreachable(name string, variants []variant) {
+	for j, v := range variants {
+		for _, prev := range variants[:j] {
+			if subsumes(prev, v) {
+				c.reportAt(v.pos, "unreachable variant of %v: the variant at %v matches everything that it does", name, prev.pos)
+				break
+			}
+		}
+	}
+}
+
+// seq checks a sequence of expressions that are run one after another,
+// such as the body of a function, in which bindings made by each
+// expression are visible to the ones after it. It returns the scope
+// after the last expression.
+func (c *checker) seq(sc *scope, exprs *extract.List) *scope {
+	for expr := range exprs.All() {
+		sc = c.expr(sc, expr)
+	}
+	return sc
+}
+
+// exprs checks expressions that are evaluated in order, such as the
+// arguments of a call. As with [extract.EvalAll], bindings made by each
+// expression are visible to the ones after it, but not once they have
+// all been evaluated.
+func (c *checker) exprs(sc *scope, exprs iter.Seq[any]) {
+	for expr := range exprs {
+		sc = c.expr(sc, expr)
+	}
+}
+
+// expr checks a single expression and returns the scope after it.
+func (c *checker) expr(sc *scope, expr any) *scope {
+	switch expr := expr.(type) {
+	case extract.Ident:
+		c.callee(sc, expr)
+	case extract.Ref:
+		c.callee(sc, expr)
+	case extract.Call:
+		return c.call(sc, expr)
+	case extract.ListExpr:
+		defer c.at(expr.Pos)()
+		c.exprs(sc, expr.All())
+	}
+	return sc
+}
+
+func (c *checker) call(sc *scope, call extract.Call) *scope {
+	if call.Len() == 0 {
+		return sc
+	}
+	defer c.at(call.Pos)()
+
+	switch head := call.Head().(type) {
+	case extract.Ident:
+		if form, ok := forms[head]; ok && c.global(sc, head) {
+			return form(c, sc, call)
+		}
+	case extract.Ref:
+		if in, ok := head.In.(extract.Atom); ok && c.modules[in] == nil {
+			if form, ok := refForms[extract.Ref{In: in, Name: head.Name}]; ok {
+				return form(c, sc, call)
+			}
+		}
+	}
+
+	name, variants := c.callee(sc, call.Head())
+	c.exprs(sc, call.Tail().All())
+	c.checkArity(name, variants, call.Len()-1)
+	return sc
+}
+
+// checkArity reports a call with n arguments to the function called
+// name with the given variants if none of them accept n arguments.
+func (c *checker) checkArity(name string, variants []variant, n int) {
+	if len(variants) == 0 {
+		return
+	}
+
+	var want []string
+	for _, v := range variants {
+		if v.arity.accepts(n) {
+			return
+		}
+		want = append(want, v.arity.String())
+	}
+	slices.Sort(want)
+	c.report("wrong number of arguments in call to %v: got %v, want %v", name, n, strings.Join(slices.Compact(want), " or "))
+}
+
+// global returns true if ident refers to a binding provided by the Env
+// rather than to one made by the script.
+func (c *checker) global(sc *scope, ident extract.Ident) bool {
+	if b, m := sc.lookup(ident); b != nil || m != nil {
+		return false
+	}
+	_, ok := c.env.Lookup(ident)
+	return ok
+}
+
+// callee checks expr, which may be called, and returns its name and
+// variants if it is known to be a function declared by the script.
+func (c *checker) callee(sc *scope, expr any) (string, []variant) {
+	switch expr := expr.(type) {
+	case extract.Ident:
+		b, m := sc.lookup(expr)
+		switch {
+		case b != nil:
+			b.used = true
+			return expr.String(), b.variants
+		case m != nil:
+			return expr.String(), m.funcs[expr]
+		}
+		if _, ok := c.env.Lookup(expr); !ok {
+			c.report("undefined: %v", expr)
+		}
+		return "", nil
+
+	case extract.Ref:
+		name, ok := expr.In.(extract.Atom)
+		if !ok {
+			c.expr(sc, expr.In)
+			return "", nil
+		}
+
+		if m := c.modules[name]; m != nil {
+			variants, ok := m.funcs[expr.Name]
+			if !ok {
+				c.report("undefined: %v", expr)
+			}
+			return expr.String(), variants
+		}
+		m := c.env.GetModule(name)
+		if m == nil {
+			if !c.imports {
+				c.report("undefined module %v", name)
+			}
+			return "", nil
+		}
+		if _, ok := m.Lookup(expr.Name); !ok {
+			c.report("undefined: %v", expr)
+		}
+		return "", nil
+
+	default:
+		c.expr(sc, expr)
+		return "", nil
+	}
+}
+
+// pattern checks a pattern and returns the scope with the identifiers
+// that it binds added.
+func (c *checker) pattern(sc *scope, pattern any) *scope {
+	switch pattern := pattern.(type) {
+	case extract.Ident:
+		return sc.bind(&binding{name: pattern, pos: c.pos})
+	case extract.Pinned:
+		if pattern.Expr != nil {
+			c.expr(sc, pattern.Expr)
+			return sc
+		}
+		c.callee(sc, pattern.Ident)
+		return sc
+	case extract.Default:
+		c.expr(sc, pattern.Value)
+		return c.pattern(sc, pattern.Pattern)
+	case extract.Rest:
+		return c.pattern(sc, pattern.Pattern)
+	case extract.Call:
+		defer c.at(pattern.Pos)()
+		return c.patterns(sc, pattern.List)
+	case extract.ListExpr:
+		defer c.at(pattern.Pos)()
+		return c.patterns(sc, pattern.List)
+	default:
+		return sc
+	}
+}
+
+func (c *checker) patterns(sc *scope, patterns *extract.List) *scope {
+	for pattern := range patterns.All() {
+		sc = c.pattern(sc, pattern)
+	}
+	return sc
+}
+
+// clauses checks the clauses of a multi-clause func or loop, binding
+// self in each of them, and returns their variants.
+func (c *checker) clauses(sc *scope, self *binding, clauses *extract.List) []variant {
+	var variants []variant
+	for clause := range clauses.All() {
+		clause, ok := clause.(extract.Call)
+		if !ok || clause.Len() < 2 {
+			continue
+		}
+		params, ok := clause.Head().(extract.Call)
+		if !ok {
+			continue
+		}
+		variants = append(variants, newVariant(params.List, clause.Pos))
+	}
+	self.variants = variants
+
+	sc = sc.bind(self)
+	for clause := range clauses.All() {
+		clause, ok := clause.(extract.Call)
+		if !ok || clause.Len() < 2 {
+			continue
+		}
+		done := c.at(clause.Pos)
+		c.seq(c.pattern(sc, clause.Head()), clause.Tail())
+		done()
+	}
+	return variants
+}
+
+var (
+	defmoduleIdent = extract.MakeIdent("defmodule")
+	importIdent    = extract.MakeIdent("import")
+	defIdent       = extract.MakeIdent("def")
+	funcIdent      = extract.MakeIdent("func")
+	recurIdent     = extract.MakeIdent("recur")
+	afterIdent     = extract.MakeIdent("after")
+)
+
+// forms are the checks for kernel functions that don't evaluate their
+// arguments normally. They are only used if the kernel function hasn't
+// been shadowed by the script.
+var forms map[extract.Ident]func(c *checker, sc *scope, call extract.Call) *scope
+
+// refForms are the checks for module functions that don't evaluate
+// their arguments normally.
+var refForms map[extract.Ref]func(c *checker, sc *scope, call extract.Call) *scope
+
+func init() {
+	forms = map[extract.Ident]func(c *checker, sc *scope, call extract.Call) *scope{
+		defmoduleIdent:               (*checker).defmodule,
+		defIdent:                     (*checker).def,
+		funcIdent:                    (*checker).fn,
+		extract.MakeIdent("let"):     (*checker).let,
+		extract.MakeIdent("match"):   (*checker).match,
+		extract.MakeIdent("loop"):    (*checker).loop,
+		extract.MakeIdent("receive"): (*checker).receive,
+	}
+
+	refForms = map[extract.Ref]func(c *checker, sc *scope, call extract.Call) *scope{
+		{In: extract.MakeAtom("Testing"), Name: extract.MakeIdent("deftest")}: (*checker).deftest,
+	}
+}
+
+func (c *checker) defmodule(sc *scope, call extract.Call) *scope {
+	name, ok := call.Tail().Head().(extract.Atom)
+	if !ok {
+		c.exprs(sc, call.Tail().All())
+		return sc
+	}
+	c.seq(&scope{next: sc, module: c.modules[name]}, call.Tail().Tail())
+	return sc
+}
+
+func (c *checker) def(sc *scope, call extract.Call) *scope {
+	head, ok := call.Tail().Head().(extract.Call)
+	if !ok || head.Len() == 0 {
+		return sc
+	}
+	c.seq(c.patterns(sc, head.Tail()), call.Tail().Tail())
+	return sc
+}
+
+func (c *checker) fn(sc *scope, call extract.Call) *scope {
+	c.funcVariants(sc, call)
+	return sc
+}
+
+// funcVariants checks a call to func and returns the variants of the
+// function that it creates.
+func (c *checker) funcVariants(sc *scope, call extract.Call) []variant {
+	args := call.Tail()
+	if name, ok := args.Head().(extract.Ident); ok {
+		variants := c.clauses(sc, &binding{name: name, used: true}, args.Tail())
+		c.unreachable(name.String(), variants)
+		return variants
+	}
+
+	head, ok := args.Head().(extract.Call)
+	if !ok || head.Len() == 0 {
+		return nil
+	}
+	name, _ := head.Head().(extract.Ident)
+	variants := []variant{newVariant(head.Tail(), call.Pos)}
+	self := &binding{name: name, variants: variants, used: true}
+	c.seq(c.patterns(sc.bind(self), head.Tail()), args.Tail())
+	return variants
+}
+
+func (c *checker) let(sc *scope, call extract.Call) *scope {
+	name, ok := call.Tail().Head().(extract.Ident)
+	if !ok {
+		return c.match(sc, call)
+	}
+
+	// As at runtime, bindings made while evaluating the value are not
+	// visible after the let.
+	b := binding{name: name, pos: call.Pos}
+	vsc := sc
+	for expr := range call.Tail().Tail().All() {
+		b.variants = nil
+		if fn, ok := expr.(extract.Call); ok && fn.Len() > 0 && fn.Head() == funcIdent && c.global(vsc, funcIdent) {
+			done := c.at(fn.Pos)
+			b.variants = c.funcVariants(vsc, fn)
+			done()
+			continue
+		}
+		vsc = c.expr(vsc, expr)
+	}
+	c.lets = append(c.lets, &b)
+	return sc.bind(&b)
+}
+
+func (c *checker) match(sc *scope, call extract.Call) *scope {
+	c.seq(sc, call.Tail().Tail())
+	return c.pattern(sc, call.Tail().Head())
+}
+
+func (c *checker) loop(sc *scope, call extract.Call) *scope {
+	init, ok := call.Tail().Head().(extract.Call)
+	if !ok {
+		c.exprs(sc, call.Tail().All())
+		return sc
+	}
+
+	c.exprs(sc, init.All())
+	variants := c.clauses(sc, &binding{name: recurIdent, used: true}, call.Tail().Tail())
+	c.unreachable("loop", variants)
+	c.checkArity("loop", variants, init.Len())
+	return sc
+}
+
+func (c *checker) receive(sc *scope, call extract.Call) *scope {
+	var variants []variant
+	for clause := range call.Tail().All() {
+		clause, ok := clause.(extract.Call)
+		if !ok || clause.Len() < 2 {
+			continue
+		}
+		done := c.at(clause.Pos)
+		if clause.Head() == afterIdent {
+			c.expr(sc, clause.Tail().Head())
+			c.seq(sc, clause.Tail().Tail())
+			done()
+			continue
+		}
+
+		variants = append(variants, variant{params: []any{clause.Head()}, arity: arity{1, 1}, pos: clause.Pos})
+		c.seq(c.pattern(sc, clause.Head()), clause.Tail())
+		done()
+	}
+	c.unreachable("receive", variants)
+	return sc
+}
+
+// deftest checks a test, the body of which is run later in the scope
+// that the test was defined in.
+func (c *checker) deftest(sc *scope, call extract.Call) *scope {
+	c.callee(sc, call.Head())
+	if call.Len() > 1 {
+		c.expr(sc, call.Tail().Head())
+		c.seq(sc, call.Tail().Tail())
+	}
+	return sc
+}
+
+// subsumes returns true if p matches every list of arguments that q
+// does. It is conservative, only returning true if it is certain.
+func subsumes(p, q variant) bool {
+	if p.hasDefaults() || q.hasDefaults() {
+		return false
+	}
+	return subsumesList(p.params, q.params)
+}
+
+// subsumesList returns true if the list pattern with the elements ps
+// matches every list that the one with the elements qs does.
+func subsumesList(ps, qs []any) bool {
+	prest, ps := splitRest(ps)
+	qrest, qs := splitRest(qs)
+
+	if prest == nil {
+		if qrest != nil || len(ps) != len(qs) {
+			return false
+		}
+	} else {
+		if _, ok := prest.(extract.Ident); !ok || len(qs) < len(ps) {
+			return false
+		}
+	}
+
+	for i, p := range ps {
+		if !subsumesPattern(p, qs[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// splitRest separates the pattern of a trailing rest parameter from
+// the rest of a list pattern.
+func splitRest(patterns []any) (any, []any) {
+	if len(patterns) == 0 {
+		return nil, patterns
+	}
+	if rest, ok := patterns[len(patterns)-1].(extract.Rest); ok {
+		return rest.Pattern, patterns[:len(patterns)-1]
+	}
+	return nil, patterns
+}
+
+// subsumesPattern returns true if the pattern p matches every value
+// that the pattern q does.
+func subsumesPattern(p, q any) bool {
+	switch p := p.(type) {
+	case extract.Ident:
+		return true
+	case extract.Call:
+		return subsumesListPattern(p.List, q)
+	case extract.ListExpr:
+		return subsumesListPattern(p.List, q)
+	case extract.Atom, int64, float64, string, extract.Rune:
+		return p == q
+	case *extract.Binary:
+		return extract.Equal(p, q)
+	default:
+		return false
+	}
+}
+
+func subsumesListPattern(p *extract.List, q any) bool {
+	switch q := q.(type) {
+	case extract.Call:
+		return subsumesList(slices.Collect(p.All()), slices.Collect(q.All()))
+	case extract.ListExpr:
+		return subsumesList(slices.Collect(p.All()), slices.Collect(q.All()))
+	default:
+		return false
+	}
+}
@@ -0,0 +1,143 @@
+package check_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"deedles.dev/extract"
+	"deedles.dev/extract/check"
+	"deedles.dev/extract/parser"
+)
+
+func runCheck(t *testing.T, src string) []string {
+	script, err := parser.ParseString("test.ext", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var diags []string
+	for _, d := range check.Check(extract.New(context.Background()), script) {
+		diags = append(diags, d.String())
+	}
+	return diags
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name  string
+		src   string
+		diags []string
+	}{
+		{
+			name: "Valid",
+			src: `
+(defmodule Test
+	(def (fact 0) 1)
+	(def (fact n) (mul n (fact (n - 1))))
+	(def (sum list) (total list))
+	(def (total [] \\ 0) 0))
+(let x 2)
+(let f (func (f a b \\ 1) (add a b)))
+(list (f x) (f x 2) (Test.fact 3) (String.to_upper "a"))
+(let [a b] [1 2])
+(list (let c 3) (add a b c))
+(loop (3 0)
+	((0 acc) acc)
+	((n acc) (recur (n - 1) (acc + n))))
+(Testing.deftest "test" (let y 1) (Testing.assert_equal y 1))
+(let _ignored 1)
+`,
+		},
+		{
+			name: "Undefined",
+			src: `(missing 1)
+(Test.missing)
+(Missing.func)
+(String.missing "a")
+(recur 1)
+(defmodule Test (def (f) :ok))
+(let shadowed (func (shadowed) :ok))
+(shadowed)
+(list shadowed)`,
+			diags: []string{
+				"test.ext:1:1: undefined: missing",
+				"test.ext:2:1: undefined: Test.missing",
+				"test.ext:3:1: undefined module Missing",
+				"test.ext:4:1: undefined: String.missing",
+				"test.ext:5:1: undefined: recur",
+			},
+		},
+		{
+			name: "Import",
+			src: `(import "lib/shapes")
+(Shapes.area 1 2)
+(String.missing "a")`,
+			diags: []string{
+				"test.ext:3:1: undefined: String.missing",
+			},
+		},
+		{
+			name: "Arity",
+			src: `(defmodule Test
+	(def (inc v) (add v 1))
+	(def (inc v n) (add v n))
+	(def (f v) (inc)))
+(Test.inc 1 2 3)
+(let g (func (g a &rest) a))
+(g)
+(g 1 2 3)
+(loop (1) ((n acc) n))`,
+			diags: []string{
+				"test.ext:4:13: wrong number of arguments in call to inc: got 0, want 1 or 2",
+				"test.ext:5:1: wrong number of arguments in call to Test.inc: got 3, want 1 or 2",
+				"test.ext:7:1: wrong number of arguments in call to g: got 0, want at least 1",
+				"test.ext:9:1: wrong number of arguments in call to loop: got 1, want 2",
+			},
+		},
+		{
+			name: "Unreachable",
+			src: `(defmodule Test
+	(def (f n) n)
+	(def (f 0) 0)
+	(def (g [a &rest]) a)
+	(def (g [1 2]) 2)
+	(def (h :a) 1)
+	(def (h :b) 2))
+(func f ((x) x) ((:x) :x))
+(let me 1)
+(receive
+	((:ping \me) me)
+	((:ping from) from)
+	(msg msg)
+	((:pong) :pong))`,
+			diags: []string{
+				"test.ext:3:2: unreachable variant of Test.f: the variant at test.ext:2:2 matches everything that it does",
+				"test.ext:5:2: unreachable variant of Test.g: the variant at test.ext:4:2 matches everything that it does",
+				"test.ext:8:17: unreachable variant of f: the variant at test.ext:8:9 matches everything that it does",
+				"test.ext:14:2: unreachable variant of receive: the variant at test.ext:13:2 matches everything that it does",
+			},
+		},
+		{
+			name: "Unused",
+			src: `(let x 1)
+(let y 2)
+(let x (add x y))
+(let z (let w 1))`,
+			diags: []string{
+				"test.ext:3:1: declared and not used: x",
+				"test.ext:4:1: declared and not used: z",
+				"test.ext:4:8: declared and not used: w",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			diags := runCheck(t, test.src)
+			if !slices.Equal(diags, test.diags) {
+				t.Fatalf("%q", diags)
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+//go:build js && wasm
+
+// Command extract-wasm makes Extract available to JavaScript as the
+// global object extract. It must be built with GOOS=js and
+// GOARCH=wasm. See package deedles.dev/extract/wasm for how to load it
+// and what the object provides.
+package main
+
+import "deedles.dev/extract/wasm"
+
+func main() {
+	wasm.Register("extract")
+	select {}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"deedles.dev/extract"
+	"deedles.dev/extract/check"
+)
+
+func (c *cli) check(ctx context.Context, args []string) int {
+	fset := c.flags("check")
+	if err := fset.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	env := extract.New(ctx)
+	code := exitOK
+	checkScript := func(path string) {
+		script, err := c.parseScript(path)
+		if err != nil {
+			fmt.Fprintf(c.stderr, "extract: %v\n", err)
+			code = exitError
+			return
+		}
+		for _, d := range check.Check(env, script) {
+			fmt.Fprintln(c.stderr, d)
+			code = exitError
+		}
+	}
+
+	if fset.NArg() == 0 {
+		checkScript("-")
+		return code
+	}
+	for _, root := range fset.Args() {
+		err := eachScript(root, checkScript)
+		if err != nil {
+			fmt.Fprintf(c.stderr, "extract: %v\n", err)
+			code = exitError
+		}
+	}
+	return code
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"deedles.dev/extract/astfile"
+	"deedles.dev/extract/parser"
+)
+
+func (c *cli) compile(ctx context.Context, args []string) int {
+	fset := c.flags("compile")
+	out := fset.String("o", "", "write the encoded script to `file` instead of next to the source with an "+astfile.Ext+" extension")
+	if err := fset.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fset.NArg() != 1 {
+		fset.Usage()
+		return exitUsage
+	}
+
+	path := fset.Arg(0)
+	script, err := parser.ParseFile(path)
+	if err != nil {
+		fmt.Fprintf(c.stderr, "extract: %v\n", err)
+		return exitError
+	}
+
+	var buf bytes.Buffer
+	if err := astfile.Encode(&buf, script); err != nil {
+		fmt.Fprintf(c.stderr, "extract: %v\n", err)
+		return exitError
+	}
+
+	dst := *out
+	if dst == "" {
+		dst = strings.TrimSuffix(path, filepath.Ext(path)) + astfile.Ext
+	}
+	if err := os.WriteFile(dst, buf.Bytes(), 0666); err != nil {
+		fmt.Fprintf(c.stderr, "extract: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
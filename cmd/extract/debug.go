@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"deedles.dev/extract"
+	"deedles.dev/extract/debugger"
+)
+
+func (c *cli) debug(ctx context.Context, args []string) int {
+	fset := c.flags("debug")
+	var breaks []string
+	fset.Func("b", "set a breakpoint at `loc`, such as 12, main.ext:12, or Example.run (may be repeated)", func(loc string) error {
+		breaks = append(breaks, loc)
+		return nil
+	})
+	if err := fset.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fset.NArg() != 1 {
+		fset.Usage()
+		return exitUsage
+	}
+
+	src, err := c.readScript(fset.Arg(0))
+	if err != nil {
+		fmt.Fprintf(c.stderr, "extract: %v\n", err)
+		return exitError
+	}
+	script, err := src.parse()
+	if err != nil {
+		c.printError(err, src)
+		return exitError
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	d := debugger.New(c.lineReader(), c.stdout)
+	d.Quit = cancel
+	for _, loc := range breaks {
+		if _, err := d.Break(loc); err != nil {
+			fmt.Fprintf(c.stderr, "extract: %v\n", err)
+			return exitUsage
+		}
+	}
+	if len(breaks) == 0 {
+		d.Step()
+	}
+
+	env := extract.New(ctx,
+		extract.WithStdout(c.stdout),
+		extract.WithStderr(c.stderr),
+		extract.WithHooks(d.Hooks()),
+	)
+	_, r := extract.Run(env, script.All())
+	if err, ok := r.(error); ok {
+		c.printError(err, src)
+		return exitError
+	}
+	fmt.Fprintln(c.stdout, extract.Inspect(r))
+	return exitOK
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around changes in
+// a diff.
+const diffContext = 3
+
+// diffLine is a line of a diff. Kind is ' ' for a line that is in both
+// versions, '-' for a removed line, and '+' for an added one.
+type diffLine struct {
+	kind byte
+	text string
+}
+
+// writeDiff writes a unified diff between old and new, the original and
+// formatted contents of the file at path, to w.
+func writeDiff(w io.Writer, path string, old, new []byte) {
+	lines := diffLines(splitLines(string(old)), splitLines(string(new)))
+
+	// oldPos and newPos are the line numbers in each version before
+	// each line of the diff.
+	oldPos := make([]int, len(lines)+1)
+	newPos := make([]int, len(lines)+1)
+	for i, line := range lines {
+		oldPos[i+1], newPos[i+1] = oldPos[i], newPos[i]
+		if line.kind != '+' {
+			oldPos[i+1]++
+		}
+		if line.kind != '-' {
+			newPos[i+1]++
+		}
+	}
+
+	fmt.Fprintf(w, "--- %v.orig\n+++ %v\n", path, path)
+	for i := 0; i < len(lines); {
+		if lines[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		start, end := max(i-diffContext, 0), i
+		for end < len(lines) {
+			if lines[end].kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(lines) && lines[run].kind == ' ' {
+				run++
+			}
+			if run == len(lines) || run-end > 2*diffContext {
+				end = min(end+diffContext, len(lines))
+				break
+			}
+			end = run
+		}
+
+		fmt.Fprintf(w, "@@ -%v +%v @@\n",
+			hunkRange(oldPos[start], oldPos[end]),
+			hunkRange(newPos[start], newPos[end]),
+		)
+		for _, line := range lines[start:end] {
+			fmt.Fprintf(w, "%c%v\n", line.kind, line.text)
+		}
+		i = end
+	}
+}
+
+// hunkRange formats the range of lines [start, end), numbered from
+// zero, for a hunk header.
+func hunkRange(start, end int) string {
+	if start == end {
+		return fmt.Sprintf("%v,0", start)
+	}
+	return fmt.Sprintf("%v,%v", start+1, end-start)
+}
+
+// diffLines finds a shortest edit script that turns a into b using
+// their longest common subsequence.
+func diffLines(a, b []string) []diffLine {
+	// lcs[i][j] is the length of the longest common subsequence of
+	// a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+				continue
+			}
+			lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+		}
+	}
+
+	lines := make([]diffLine, 0, max(len(a), len(b)))
+	var i, j int
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, diffLine{kind: ' ', text: a[i]})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, diffLine{kind: '-', text: a[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{kind: '+', text: b[j]})
+			j++
+		}
+	}
+	return lines
+}
+
+// splitLines splits str into lines without their line breaks.
+func splitLines(str string) []string {
+	if str == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(str, "\n"), "\n")
+}
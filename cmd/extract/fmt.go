@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"deedles.dev/extract"
+	"deedles.dev/extract/format"
+)
+
+// fmtOptions are the flags of the fmt command.
+type fmtOptions struct {
+	list, diff bool
+}
+
+func (c *cli) fmt(ctx context.Context, args []string) int {
+	fset := c.flags("fmt")
+	var opts fmtOptions
+	fset.BoolVar(&opts.list, "l", false, "list files whose formatting differs instead of rewriting them")
+	fset.BoolVar(&opts.diff, "d", false, "print diffs of files whose formatting differs instead of rewriting them")
+	if err := fset.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if fset.NArg() == 0 {
+		return c.fmtStdin(opts)
+	}
+
+	code := exitOK
+	for _, root := range fset.Args() {
+		err := eachScript(root, func(path string) {
+			changed, err := c.fmtFile(path, opts)
+			if err != nil {
+				fmt.Fprintf(c.stderr, "extract: %v: %v\n", path, err)
+				code = exitError
+				return
+			}
+			if changed && (opts.list || opts.diff) {
+				code = exitError
+			}
+		})
+		if err != nil {
+			fmt.Fprintf(c.stderr, "extract: %v\n", err)
+			code = exitError
+		}
+	}
+	return code
+}
+
+// eachScript calls fn with the path of each script in root. If root is
+// a file, it is passed to fn regardless of its extension. If it is a
+// directory, every file in it, recursively, with the extension of an
+// Extract script is.
+func eachScript(root string, fn func(path string)) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || (path != root && filepath.Ext(path) != extract.SourceExt) {
+			return nil
+		}
+		fn(path)
+		return nil
+	})
+}
+
+// fmtStdin formats stdin, writing the result to stdout.
+func (c *cli) fmtStdin(opts fmtOptions) int {
+	src, err := io.ReadAll(c.stdin)
+	if err != nil {
+		fmt.Fprintf(c.stderr, "extract: %v\n", err)
+		return exitError
+	}
+	res, err := format.Source(src)
+	if err != nil {
+		fmt.Fprintf(c.stderr, "extract: <stdin>: %v\n", err)
+		return exitError
+	}
+
+	changed := !bytes.Equal(src, res)
+	switch {
+	case opts.list || opts.diff:
+		if !changed {
+			return exitOK
+		}
+		if opts.list {
+			fmt.Fprintln(c.stdout, "<stdin>")
+		}
+		if opts.diff {
+			writeDiff(c.stdout, "<stdin>", src, res)
+		}
+		return exitError
+	default:
+		c.stdout.Write(res)
+		return exitOK
+	}
+}
+
+// fmtFile formats the file at path, either rewriting it or reporting
+// the differences, depending on opts. It returns true if the file was
+// not already formatted.
+func (c *cli) fmtFile(path string, opts fmtOptions) (bool, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	res, err := format.Source(src)
+	if err != nil {
+		return false, err
+	}
+	if bytes.Equal(src, res) {
+		return false, nil
+	}
+
+	if opts.list {
+		fmt.Fprintln(c.stdout, path)
+	}
+	if opts.diff {
+		writeDiff(c.stdout, path, src, res)
+	}
+	if opts.list || opts.diff {
+		return true, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return true, err
+	}
+	return true, os.WriteFile(path, res, info.Mode().Perm())
+}
@@ -0,0 +1,110 @@
+// Command extract runs and works with Extract scripts.
+//
+// Usage:
+//
+//	extract <command> [arguments]
+//
+// Run extract help for a list of commands.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+)
+
+// Exit codes.
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+// cli holds the standard streams of the command so that they can be
+// replaced in tests.
+type cli struct {
+	stdin          io.Reader
+	stdout, stderr io.Writer
+}
+
+// command is a subcommand of the extract command.
+type command struct {
+	name  string
+	args  string
+	short string
+	run   func(c *cli, ctx context.Context, args []string) int
+}
+
+// commands is initialized in init to avoid an initialization cycle
+// with the help command.
+var commands []command
+
+func init() {
+	commands = []command{
+		{"run", "[flags] [file | -]", "run a script", (*cli).run},
+		{"repl", "[flags]", "start an interactive session", (*cli).repl},
+		{"debug", "[flags] file", "run a script in the debugger", (*cli).debug},
+		{"fmt", "[flags] [path ...]", "format scripts", (*cli).fmt},
+		{"check", "[path ...]", "report likely mistakes in scripts", (*cli).check},
+		{"test", "[flags] [path ...]", "run the tests in *_test.ext files", (*cli).test},
+		{"compile", "[flags] file", "encode a script so that it can be run without parsing", (*cli).compile},
+		{"help", "", "show this help", (*cli).help},
+	}
+}
+
+func (c *cli) main(ctx context.Context, args []string) int {
+	if len(args) == 0 {
+		c.help(ctx, nil)
+		return exitUsage
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(c, ctx, args[1:])
+		}
+	}
+
+	fmt.Fprintf(c.stderr, "extract: unknown command %q\n", args[0])
+	fmt.Fprintln(c.stderr, "Run 'extract help' for usage.")
+	return exitUsage
+}
+
+func (c *cli) help(ctx context.Context, args []string) int {
+	fmt.Fprintln(c.stderr, "Usage: extract <command> [arguments]")
+	fmt.Fprintln(c.stderr)
+	fmt.Fprintln(c.stderr, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(c.stderr, "\t%-8v%v\n", cmd.name, cmd.short)
+	}
+	return exitOK
+}
+
+// flags returns a FlagSet for the named command that writes its usage
+// to c's stderr.
+func (c *cli) flags(name string) *flag.FlagSet {
+	fset := flag.NewFlagSet(name, flag.ContinueOnError)
+	fset.SetOutput(c.stderr)
+	fset.Usage = func() {
+		for _, cmd := range commands {
+			if cmd.name == name {
+				fmt.Fprintf(c.stderr, "Usage: extract %v %v\n", cmd.name, cmd.args)
+				break
+			}
+		}
+		fset.PrintDefaults()
+	}
+	return fset
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	c := cli{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+	code := c.main(ctx, os.Args[1:])
+	stop()
+	os.Exit(code)
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"deedles.dev/extract/debugger"
+)
+
+// runCLI runs the command with args and stdin, returning the exit code
+// and what was written to stdout and stderr.
+func runCLI(t *testing.T, stdin string, args ...string) (code int, stdout, stderr string) {
+	var out, errOut strings.Builder
+	c := cli{stdin: strings.NewReader(stdin), stdout: &out, stderr: &errOut}
+	code = c.main(context.Background(), args)
+	return code, out.String(), errOut.String()
+}
+
+func TestRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.ext")
+	err := os.WriteFile(path, []byte(`(IO.println "hello") (add 1 2)`), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, stdout, _ := runCLI(t, "", "run", path)
+	if code != exitOK || stdout != "hello\n3\n" {
+		t.Fatalf("%v %q", code, stdout)
+	}
+
+	code, stdout, _ = runCLI(t, `(String.to_upper "stdin")`, "run", "-")
+	if code != exitOK || stdout != "\"STDIN\"\n" {
+		t.Fatalf("%v %q", code, stdout)
+	}
+
+	code, stdout, _ = runCLI(t, `:quiet`, "run", "-q")
+	if code != exitOK || stdout != "" {
+		t.Fatalf("%v %q", code, stdout)
+	}
+}
+
+func TestRunBytecode(t *testing.T) {
+	script := `(defmodule M (def (f 0) 1) (def (f n) (mul n (f (sub n 1))))) (M.f 5)`
+	code, stdout, stderr := runCLI(t, script, "run", "-bytecode")
+	if code != exitOK || stdout != "120\n" {
+		t.Fatalf("%v %q %q", code, stdout, stderr)
+	}
+}
+
+func TestRunTrace(t *testing.T) {
+	code, _, stderr := runCLI(t, `(add 1 (mul 2 3))`, "run", "--trace")
+	const ex = "(add 1 (mul 2 3)) (1:1)\n  (mul 2 3) (1:8)\n  => 6\n=> 7\n"
+	if code != exitOK || stderr != ex {
+		t.Fatalf("%v %q", code, stderr)
+	}
+}
+
+func TestRunImport(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "lib.ext"), []byte(`(defmodule Lib (def (f) :lib))`), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, stdout, stderr := runCLI(t, `(import "lib") (Lib.f)`, "run", "-I", dir)
+	if code != exitOK || stdout != ":lib\n" {
+		t.Fatalf("%v %q %q", code, stdout, stderr)
+	}
+}
+
+func TestCompile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.ext")
+	err := os.WriteFile(path, []byte(`(IO.println "compiled") (add 1 2)`), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, _, stderr := runCLI(t, "", "compile", path)
+	if code != exitOK {
+		t.Fatalf("%v %q", code, stderr)
+	}
+	compiled := filepath.Join(dir, "test.extc")
+	if err := os.WriteFile(path, []byte(`(`), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	code, stdout, stderr := runCLI(t, "", "run", compiled)
+	if code != exitOK || stdout != "compiled\n3\n" {
+		t.Fatalf("%v %q %q", code, stdout, stderr)
+	}
+
+	code, _, stderr = runCLI(t, "", "compile", "-o", filepath.Join(dir, "out.extc"), path)
+	if code != exitError || !strings.Contains(stderr, "unexpected") {
+		t.Fatalf("%v %q", code, stderr)
+	}
+}
+
+func TestRunProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.pprof")
+	script := `(defmodule M (def (f x) (mul x 2))) (M.f (M.f 1))`
+	code, stdout, stderr := runCLI(t, script, "run", "-profile", path, "-profiletable")
+	if code != exitOK || stdout != "4\n" || !strings.Contains(stderr, "M.f/1") {
+		t.Fatalf("%v %q %q", code, stdout, stderr)
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+		t.Fatal(info, err)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	code, _, stderr := runCLI(t, `(add 1 :a)`, "run")
+	if code != exitError || !strings.Contains(stderr, "incorrect type") {
+		t.Fatalf("%v %q", code, stderr)
+	}
+
+	code, _, stderr = runCLI(t, `(add 1`, "run")
+	if code != exitError || !strings.Contains(stderr, "incomplete input") {
+		t.Fatalf("%v %q", code, stderr)
+	}
+
+	code, _, stderr = runCLI(t, "(add 1\n  (sub 2 3)))", "run")
+	if code != exitError || !strings.HasSuffix(stderr, "   2 |   (sub 2 3)))\n     |             ^\n") {
+		t.Fatalf("%v %q", code, stderr)
+	}
+
+	const src = "(defmodule Test\n  (def (f x) (add x :a)))\n(Test.f 1)"
+	code, _, stderr = runCLI(t, src, "run")
+	if code != exitError || !strings.HasSuffix(stderr, "\tat f (3:1)\n   2 |   (def (f x) (add x :a)))\n     |              ^^^^^^^^^^\n") {
+		t.Fatalf("%v %q", code, stderr)
+	}
+
+	code, _, _ = runCLI(t, `(File.read "x")`, "run", "-nofs")
+	if code != exitError {
+		t.Fatal(code)
+	}
+
+	code, _, _ = runCLI(t, "", "unknown")
+	if code != exitUsage {
+		t.Fatal(code)
+	}
+}
+
+func TestREPL(t *testing.T) {
+	code, stdout, _ := runCLI(t, "(let x 3)\n(add x\n1)\n", "repl")
+	if code != exitOK || stdout != "> 3\n> ... 4\n> " {
+		t.Fatalf("%v %q", code, stdout)
+	}
+}
+
+func TestFmt(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.ext")
+	const src = "(add  1\n2) # sum\n"
+	const ex = "(add 1 2) # sum\n"
+	for name, data := range map[string]string{"test.ext": src, "other.txt": src} {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0666)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	code, stdout, _ := runCLI(t, "", "fmt", "-l", dir)
+	if code != exitError || stdout != path+"\n" {
+		t.Fatalf("%v %q", code, stdout)
+	}
+
+	code, stdout, _ = runCLI(t, "", "fmt", "-d", path)
+	if code != exitError || !strings.Contains(stdout, "-(add  1\n-2) # sum\n+(add 1 2) # sum\n") {
+		t.Fatalf("%v %q", code, stdout)
+	}
+
+	code, _, _ = runCLI(t, "", "fmt", dir)
+	if code != exitOK {
+		t.Fatal(code)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != ex {
+		t.Fatalf("%q", data)
+	}
+	data, err = os.ReadFile(filepath.Join(dir, "other.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != src {
+		t.Fatalf("%q", data)
+	}
+
+	code, stdout, _ = runCLI(t, "", "fmt", "-l", dir)
+	if code != exitOK || stdout != "" {
+		t.Fatalf("%v %q", code, stdout)
+	}
+
+	code, stdout, _ = runCLI(t, src, "fmt")
+	if code != exitOK || stdout != ex {
+		t.Fatalf("%v %q", code, stdout)
+	}
+
+	code, _, stderr := runCLI(t, "(add 1", "fmt")
+	if code != exitError || !strings.Contains(stderr, "incomplete input") {
+		t.Fatalf("%v %q", code, stderr)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.ext")
+	err := os.WriteFile(path, []byte("(let x 1)\n(add x 2)\n(missing)\n"), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, _, stderr := runCLI(t, "", "check", dir)
+	if ex := path + ":3:1: undefined: missing\n"; code != exitError || stderr != ex {
+		t.Fatalf("%v %q", code, stderr)
+	}
+
+	code, _, stderr = runCLI(t, "(let x 1) (add x 2)", "check")
+	if code != exitOK || stderr != "" {
+		t.Fatalf("%v %q", code, stderr)
+	}
+}
+
+func TestDebug(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.ext")
+	err := os.WriteFile(path, []byte("(let x 2)\n(add x 1)\n"), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, stdout, _ := runCLI(t, "locals\nc\n", "debug", "-b", "2", path)
+	ex := "breakpoint 1 at " + path + ":2:1: (add x 1)\n" + debugger.Prompt + "x = 2\n" + debugger.Prompt + "3\n"
+	if code != exitOK || stdout != ex {
+		t.Fatalf("%v %q", code, stdout)
+	}
+
+	code, _, _ = runCLI(t, "", "debug", "-b", "file:x", path)
+	if code != exitUsage {
+		t.Fatal(code)
+	}
+}
+
+func TestTest(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"math_test.ext": `
+(Testing.deftest "add" (Testing.assert_equal (add 1 2) 3))
+(Testing.deftest "sub" (Testing.assert_equal (sub 3 1) 2))`,
+		"sub/fail_test.ext": `
+(IO.println "output")
+(Testing.deftest "passes" (Testing.assert true))
+(Testing.deftest "fails" (Testing.assert_equal 1 2))`,
+		"helper.ext": `(Testing.deftest "ignored" (Testing.assert false))`,
+	}
+	for name, src := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	code, stdout, stderr := runCLI(t, "", "test", dir)
+	if code != exitError {
+		t.Fatalf("%v %q %q", code, stdout, stderr)
+	}
+	for _, ex := range []string{
+		"ok\t" + filepath.Join(dir, "math_test.ext") + "\t2 passed",
+		"--- FAIL: fails (" + filepath.Join(dir, "sub", "fail_test.ext") + ":4:26)\n\tassertion failed",
+		"output\n",
+		"FAIL\t" + filepath.Join(dir, "sub", "fail_test.ext") + "\t1 passed, 1 failed",
+	} {
+		if !strings.Contains(stdout, ex) {
+			t.Fatalf("missing %q in %q", ex, stdout)
+		}
+	}
+	if strings.Contains(stdout, "ignored") || strings.Index(stdout, "math_test") > strings.Index(stdout, "fail_test") {
+		t.Fatal(stdout)
+	}
+
+	code, stdout, _ = runCLI(t, "", "test", "-v", "-run", "add", dir)
+	if code != exitOK || !strings.Contains(stdout, "--- PASS: add") || strings.Contains(stdout, "sub\n") {
+		t.Fatalf("%v %q", code, stdout)
+	}
+}
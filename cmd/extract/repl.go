@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"deedles.dev/extract"
+	"deedles.dev/extract/debugger"
+	"deedles.dev/extract/repl"
+)
+
+func (c *cli) repl(ctx context.Context, args []string) int {
+	fset := c.flags("repl")
+	history := fset.String("history", defaultHistoryFile(), "file to keep the history of entered lines in, if interactive")
+	if err := fset.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fset.NArg() != 0 {
+		fset.Usage()
+		return exitUsage
+	}
+
+	lines := c.lineReader()
+	d := debugger.New(lines, c.stdout)
+	env := extract.New(ctx,
+		extract.WithStdout(c.stdout),
+		extract.WithStderr(c.stderr),
+		extract.WithHooks(d.Hooks()),
+	)
+	r := repl.New(env, lines)
+	r.SetDebugger(d)
+	r.SetColor(c.color())
+
+	if t, ok := lines.(*repl.Terminal); ok {
+		t.Complete = r.Complete
+		if *history != "" {
+			loadHistory(t, *history)
+			defer saveHistory(t, *history)
+		}
+	}
+	return c.runREPL(r)
+}
+
+// lineReader returns a reader of lines from stdin that supports line
+// editing if stdin is a terminal.
+func (c *cli) lineReader() repl.LineReader {
+	if in, ok := c.stdin.(*os.File); ok && repl.IsTerminal(in) {
+		return repl.NewTerminal(in, c.stdout)
+	}
+	return repl.NewReader(c.stdin, c.stdout)
+}
+
+func (c *cli) runREPL(r *repl.REPL) int {
+	if err := r.Run(); err != nil {
+		fmt.Fprintf(c.stderr, "extract: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+// defaultHistoryFile returns the path of the REPL's history file in
+// the user's home directory, or an empty string if there isn't one.
+func defaultHistoryFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".extract_history")
+}
+
+// loadHistory loads the history file at path into t. A missing history
+// file is not an error, as it is created when the REPL exits.
+func loadHistory(t *repl.Terminal, path string) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	t.LoadHistory(file)
+}
+
+func saveHistory(t *repl.Terminal, path string) {
+	file, err := os.Create(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	t.SaveHistory(file)
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"deedles.dev/extract"
+	"deedles.dev/extract/astfile"
+	"deedles.dev/extract/errfmt"
+	"deedles.dev/extract/parser"
+	"golang.org/x/term"
+)
+
+// source is the source code of a script, kept so that errors can be
+// shown along with the code that they refer to.
+type source struct {
+	filename string
+	data     []byte
+}
+
+// readScript reads the script at path, or stdin if path is "-".
+func (c *cli) readScript(path string) (source, error) {
+	var src []byte
+	var err error
+	if path == "-" {
+		path = ""
+		src, err = io.ReadAll(c.stdin)
+	} else {
+		src, err = os.ReadFile(path)
+	}
+	return source{filename: path, data: src}, err
+}
+
+// parse parses the script. Scripts that were encoded by the compile
+// command are decoded instead.
+func (src source) parse() (*extract.List, error) {
+	if astfile.IsEncoded(src.data) {
+		return astfile.DecodeBytes(src.data)
+	}
+	return parser.ParseBytes(src.filename, src.data)
+}
+
+// parseScript reads and parses the script at path. See [cli.readScript]
+// and [source.parse].
+func (c *cli) parseScript(path string) (*extract.List, error) {
+	src, err := c.readScript(path)
+	if err != nil {
+		return nil, err
+	}
+	return src.parse()
+}
+
+// printError prints err to stderr along with the line of src that it
+// refers to, if any. See package errfmt.
+func (c *cli) printError(err error, src source) {
+	io.WriteString(c.stderr, "extract: ")
+	errfmt.Fprint(c.stderr, err, src.filename, src.data, errfmt.WithColor(c.color()))
+}
+
+// color returns true if errors should be highlighted, which they are
+// if stderr is a terminal, unless the NO_COLOR environment variable is
+// set.
+func (c *cli) color() bool {
+	f, ok := c.stderr.(*os.File)
+	return ok && os.Getenv("NO_COLOR") == "" && term.IsTerminal(int(f.Fd()))
+}
+
+func (c *cli) run(ctx context.Context, args []string) int {
+	fset := c.flags("run")
+	quiet := fset.Bool("q", false, "don't print the result of the script")
+	steps := fset.Int64("steps", 0, "limit the number of evaluation steps (0 for no limit)")
+	noFS := fset.Bool("nofs", false, "disable filesystem access")
+	noNet := fset.Bool("nonet", false, "disable network access")
+	var loadPath []string
+	fset.Func("I", "search `dir` for imported files (may be repeated)", func(dir string) error {
+		loadPath = append(loadPath, dir)
+		return nil
+	})
+	bytecode := fset.Bool("bytecode", false, "compile the script to bytecode and run it with the VM")
+	trace := fset.Bool("trace", false, "log every call and its result to stderr")
+	profile := fset.String("profile", "", "write a pprof profile of function calls to `file`")
+	profileTable := fset.Bool("profiletable", false, "print a summary of function calls to stderr")
+	if err := fset.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	path := "-"
+	switch fset.NArg() {
+	case 0:
+	case 1:
+		path = fset.Arg(0)
+	default:
+		fset.Usage()
+		return exitUsage
+	}
+
+	src, err := c.readScript(path)
+	if err != nil {
+		fmt.Fprintf(c.stderr, "extract: %v\n", err)
+		return exitError
+	}
+	script, err := src.parse()
+	if err != nil {
+		c.printError(err, src)
+		return exitError
+	}
+
+	opts := []extract.Option{
+		extract.WithStdin(c.stdin),
+		extract.WithStdout(c.stdout),
+		extract.WithStderr(c.stderr),
+	}
+	if *steps > 0 {
+		opts = append(opts, extract.WithStepLimit(*steps))
+	}
+	if *noFS {
+		opts = append(opts, extract.WithoutFileSystem())
+	}
+	if *noNet {
+		opts = append(opts, extract.WithoutNetwork())
+	}
+	if len(loadPath) > 0 {
+		opts = append(opts, extract.WithLoadPath(loadPath...))
+	}
+	if *bytecode {
+		opts = append(opts, extract.WithBytecode())
+	}
+	if *trace {
+		opts = append(opts, extract.WithTrace(c.stderr))
+	}
+
+	var prof *extract.Profile
+	if *profile != "" || *profileTable {
+		prof = extract.NewProfile()
+		opts = append(opts, extract.WithProfile(prof))
+	}
+
+	env := extract.New(ctx, opts...)
+	var r any
+	if *bytecode {
+		_, r = extract.Eval(env, extract.Compile(script), nil)
+	} else {
+		_, r = extract.Run(env, script.All())
+	}
+	if prof != nil {
+		if err := c.writeProfile(prof, *profile, *profileTable); err != nil {
+			fmt.Fprintf(c.stderr, "extract: %v\n", err)
+			return exitError
+		}
+	}
+	if err, ok := r.(error); ok {
+		c.printError(err, src)
+		return exitError
+	}
+
+	if !*quiet {
+		fmt.Fprintln(c.stdout, extract.Inspect(r))
+	}
+	return exitOK
+}
+
+// writeProfile writes prof to the file at path in pprof's format, if
+// path is not empty, and prints it to stderr as a table if table is
+// true.
+func (c *cli) writeProfile(prof *extract.Profile, path string, table bool) error {
+	if table {
+		if err := prof.WriteTable(c.stderr); err != nil {
+			return err
+		}
+	}
+	if path == "" {
+		return nil
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := prof.WritePprof(file); err != nil {
+		return err
+	}
+	return file.Close()
+}
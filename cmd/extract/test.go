@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"deedles.dev/extract"
+)
+
+// testSuffix is the suffix of the names of files that contain tests.
+const testSuffix = "_test" + extract.SourceExt
+
+// testOptions are the flags of the test command.
+type testOptions struct {
+	filter  string
+	verbose bool
+}
+
+func (c *cli) test(ctx context.Context, args []string) int {
+	fset := c.flags("test")
+	var opts testOptions
+	fset.StringVar(&opts.filter, "run", "", "only run tests whose names contain `str`")
+	fset.BoolVar(&opts.verbose, "v", false, "list every test and print the output of files that pass")
+	parallel := fset.Int("p", runtime.GOMAXPROCS(0), "run up to `n` test files at once")
+	if err := fset.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	roots := fset.Args()
+	if len(roots) == 0 {
+		roots = []string{"."}
+	}
+
+	code := exitOK
+	var paths []string
+	for _, root := range roots {
+		err := eachTestFile(root, func(path string) { paths = append(paths, path) })
+		if err != nil {
+			fmt.Fprintf(c.stderr, "extract: %v\n", err)
+			code = exitError
+		}
+	}
+	if len(paths) == 0 {
+		fmt.Fprintln(c.stderr, "extract: no test files")
+		return code
+	}
+
+	// Each file runs in its own Env, so files can run in parallel, but
+	// the tests in a file share state and run one at a time. Reports
+	// are written in the order of the files.
+	reports := make([]bytes.Buffer, len(paths))
+	passed := make([]bool, len(paths))
+	done := make([]chan struct{}, len(paths))
+	sem := make(chan struct{}, max(*parallel, 1))
+	for i, path := range paths {
+		done[i] = make(chan struct{})
+		go func() {
+			defer close(done[i])
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			passed[i] = c.testFile(ctx, &reports[i], path, opts)
+		}()
+	}
+
+	for i := range paths {
+		<-done[i]
+		c.stdout.Write(reports[i].Bytes())
+		if !passed[i] {
+			code = exitError
+		}
+	}
+	return code
+}
+
+// eachTestFile calls fn with the path of each test file in root. If
+// root is a file, it is passed to fn regardless of its name.
+func eachTestFile(root string, fn func(path string)) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || (path != root && !strings.HasSuffix(path, testSuffix)) {
+			return nil
+		}
+		fn(path)
+		return nil
+	})
+}
+
+// testFile runs the script at path and then the tests that it defines,
+// writing a report to w. It returns true if all of the tests passed.
+func (c *cli) testFile(ctx context.Context, w io.Writer, path string, opts testOptions) bool {
+	start := time.Now()
+	script, err := c.parseScript(path)
+	if err != nil {
+		fmt.Fprintf(w, "FAIL\t%v\n\t%v\n", path, err)
+		return false
+	}
+
+	var out syncBuffer
+	env := extract.New(ctx, extract.WithStdout(&out), extract.WithStderr(&out))
+	_, r := extract.Run(env, script.All())
+	if err, ok := r.(error); ok {
+		w.Write(out.Bytes())
+		fmt.Fprintf(w, "FAIL\t%v\n%v\n", path, indent(fmt.Sprintf("%+v", err)))
+		return false
+	}
+
+	var failed int
+	results := extract.RunTests(env, opts.filter)
+	for _, result := range results {
+		switch {
+		case result.Err != nil:
+			failed++
+			fmt.Fprintf(w, "--- FAIL: %v (%v)\n%v\n", result.Name, result.Pos, indent(result.Err.Error()))
+		case opts.verbose:
+			fmt.Fprintf(w, "--- PASS: %v (%.3fs)\n", result.Name, result.Elapsed.Seconds())
+		}
+	}
+	if failed > 0 || opts.verbose {
+		w.Write(out.Bytes())
+	}
+
+	elapsed := time.Since(start).Seconds()
+	if failed > 0 {
+		fmt.Fprintf(w, "FAIL\t%v\t%v passed, %v failed\t%.3fs\n", path, len(results)-failed, failed, elapsed)
+		return false
+	}
+	fmt.Fprintf(w, "ok\t%v\t%v passed\t%.3fs\n", path, len(results), elapsed)
+	return true
+}
+
+// indent indents every line of str with a tab.
+func indent(str string) string {
+	return "\t" + strings.ReplaceAll(str, "\n", "\n\t")
+}
+
+// syncBuffer is a bytes.Buffer that can be written to concurrently,
+// such as by processes spawned by a test.
+type syncBuffer struct {
+	m   sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(data []byte) (int, error) {
+	b.m.Lock()
+	defer b.m.Unlock()
+	return b.buf.Write(data)
+}
+
+func (b *syncBuffer) Bytes() []byte {
+	b.m.Lock()
+	defer b.m.Unlock()
+	return bytes.Clone(b.buf.Bytes())
+}
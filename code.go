@@ -0,0 +1,170 @@
+package extract
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"sync/atomic"
+
+	"deedles.dev/xsync"
+)
+
+// ErrNoParser is returned by the Code module if no parser has been
+// registered with [RegisterParser].
+var ErrNoParser = errors.New("no parser registered; import deedles.dev/extract/parser")
+
+// ParseFunc parses Extract source code into a list of top-level
+// expressions. filename is used for positions in errors.
+type ParseFunc func(filename string, src []byte) (*List, error)
+
+var parseFunc atomic.Pointer[ParseFunc]
+
+// RegisterParser sets the function that is used to parse source code
+// at runtime, such as by the Code module. This package can't depend on
+// the parser directly, as the parser depends on it, so the parser
+// package registers itself when it is imported.
+func RegisterParser(parse ParseFunc) {
+	parseFunc.Store(&parse)
+}
+
+// parseSource parses src with the registered parser.
+func parseSource(filename string, src []byte) (*List, error) {
+	parse := parseFunc.Load()
+	if parse == nil {
+		return nil, ErrNoParser
+	}
+	return (*parse)(filename, src)
+}
+
+// EvalString parses src with the registered parser and runs it in a
+// fresh Env created with the given options, returning the result of
+// the last top-level expression. If parsing fails or the script
+// evaluates to an error, that error is returned instead. It returns
+// [ErrNoParser] if no parser has been registered.
+func EvalString(ctx context.Context, src string, opts ...Option) (any, error) {
+	code, err := parseSource("<string>", []byte(src))
+	if err != nil {
+		return nil, err
+	}
+
+	env := New(ctx, opts...)
+	_, r := Run(env, code.All())
+	if err, ok := r.(error); ok {
+		return nil, err
+	}
+	return r, nil
+}
+
+var sandboxAtom = MakeAtom("sandbox")
+
+func init() {
+	// The Code module is added here rather than in the declaration of
+	// std because sandboxing refers to std, which would otherwise be
+	// an initialization cycle.
+	std[MakeAtom("Code")] = stdCode()
+}
+
+// sandbox returns a new Env with none of the modules or bindings
+// created by code run in env, but with the same standard library
+// modules and the same restrictions and configuration, such as step
+// limits, disabled filesystem access, and IO streams.
+func (env Env) sandbox() *Env {
+	modules := new(xsync.Map[Atom, *Module])
+	for name, m := range std {
+		if cur := env.GetModule(name); cur == m {
+			modules.Store(name, m)
+		}
+	}
+
+	env.modules = modules
+	env.loader = env.loader.fresh()
+	env.currentModule = nil
+	env.locals = kernel
+	env.moduleSeq = 0
+	return &env
+}
+
+// codeEval runs code in env, or in a sandbox derived from it, and
+// returns a list containing the result followed by a map of the
+// bindings that the code made.
+func codeEval(env *Env, code *List, sandbox bool) any {
+	if sandbox {
+		env = env.sandbox()
+	}
+
+	base := env.locals.Len()
+	renv, r := Run(env, code.All())
+	if err, ok := r.(error); ok {
+		return err
+	}
+
+	var bindings *Map
+	for b := range renv.locals.All() {
+		if b.seq <= base {
+			continue
+		}
+		bindings = bindings.Put(MakeAtom(b.ident.String()), b.val)
+	}
+	return ListOf(r, bindings)
+}
+
+// evalCodeArgs evaluates a string argument followed by an optional
+// :sandbox flag.
+func evalCodeArgs(env *Env, args *List) (string, bool, error) {
+	if args.Len() != 1 && args.Len() != 2 {
+		return "", false, &ArgumentNumError{Num: args.Len(), Expected: -1}
+	}
+
+	vals, err := evalArgs(env, args)
+	if err != nil {
+		return "", false, err
+	}
+	str, ok := vals[0].(string)
+	if !ok {
+		return "", false, NewTypeError(vals[0], reflect.TypeFor[string]())
+	}
+	if len(vals) == 2 && vals[1] != sandboxAtom {
+		return "", false, NewTypeError(vals[1], reflect.TypeFor[Atom]())
+	}
+	return str, len(vals) == 2, nil
+}
+
+func stdCode() *Module {
+	m := Module{name: MakeAtom("Code")}
+	m.decls = map[Ident]any{
+		MakeIdent("eval_string"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			src, sandbox, err := evalCodeArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			code, err := parseSource("<string>", []byte(src))
+			if err != nil {
+				return env, err
+			}
+			return env, codeEval(env, code, sandbox)
+		}),
+		MakeIdent("eval_file"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			path, sandbox, err := evalCodeArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			if err := env.checkFS(); err != nil {
+				return env, err
+			}
+
+			src, err := os.ReadFile(path)
+			if err != nil {
+				return env, err
+			}
+			code, err := parseSource(path, src)
+			if err != nil {
+				return env, err
+			}
+			return env, codeEval(env, code, sandbox)
+		}),
+	}
+
+	return &m
+}
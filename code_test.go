@@ -0,0 +1,98 @@
+package extract_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"deedles.dev/extract"
+)
+
+func TestEvalString(t *testing.T) {
+	r, err := extract.EvalString(context.Background(), `(let x 2) (x * 3)`)
+	if err != nil || r != int64(6) {
+		t.Fatal(r, err)
+	}
+
+	_, err = extract.EvalString(context.Background(), `(match (a 2) (list 1 3))`)
+	if !errors.Is(err, extract.ErrPatternMatch) {
+		t.Fatalf("%#v", err)
+	}
+
+	_, err = extract.EvalString(context.Background(), `(String.to_upper "a")`, extract.WithoutStd())
+	var merr *extract.UndefinedModuleError
+	if !errors.As(err, &merr) {
+		t.Fatalf("%#v", err)
+	}
+
+	_, err = extract.EvalString(context.Background(), `(add 1`)
+	if err == nil {
+		t.Fatal("expected parse error")
+	}
+}
+
+func TestCodeEvalString(t *testing.T) {
+	const src = `
+	(let x 2)
+	(let (r b) (Code.eval_string "(let y (add x 3)) (let z 1) (add y z)"))
+	(list r (Map.get b :y) (Map.get b :z) (Map.has_key? b :x) (Map.has_key? b :r))
+	`
+	result := runScript(t, src, true)
+	checkList(t, result, int64(6), int64(5), int64(1), false, false)
+}
+
+func TestCodeEvalStringSandbox(t *testing.T) {
+	const src = `
+	(let x 2)
+	(defmodule Test (def (f) 1))
+	(list
+		(Code.eval_string "(String.to_upper \"a\")" :sandbox)
+		(Code.eval_string "x" :sandbox)
+		(Code.eval_string "(Test.f)" :sandbox)
+	)
+	`
+	result := runScript(t, src, true).(*extract.List)
+	checkList(t, result.Head(), "A", (*extract.Map)(nil))
+	for v := range result.Tail().All() {
+		if _, ok := v.(error); !ok {
+			t.Fatalf("%#v", v)
+		}
+	}
+}
+
+func TestCodeEvalStringErrors(t *testing.T) {
+	result := runScript(t, `(Code.eval_string "(add 1")`, false)
+	if _, ok := result.(error); !ok {
+		t.Fatalf("%#v", result)
+	}
+	result = runScript(t, `(Code.eval_string "(add 1 :a)")`, false)
+	if err, _ := result.(error); !errors.As(err, new(*extract.TypeError)) {
+		t.Fatalf("%#v", result)
+	}
+	result = runScript(t, `(Code.eval_string "1" :other)`, false)
+	if err, _ := result.(error); !errors.As(err, new(*extract.TypeError)) {
+		t.Fatalf("%#v", result)
+	}
+}
+
+func TestCodeEvalFile(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "test.ex"), []byte(`(let v [1 2]) (List.length v)`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env := extract.New(context.Background()).Let(extract.MakeIdent("dir"), dir)
+	result, err := env.Call("Code.eval_file", filepath.Join(dir, "test.ex"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkList(t, result, int64(2), extract.MapOf(extract.MakeAtom("v"), extract.ListOf(int64(1), int64(2))))
+
+	_, result = runInDir(t, `(Code.eval_file "test.ex")`, extract.WithoutFileSystem())
+	if err, _ := result.(error); !errors.Is(err, extract.ErrFileSystemDisabled) {
+		t.Fatalf("%#v", result)
+	}
+}
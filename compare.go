@@ -0,0 +1,180 @@
+package extract
+
+import (
+	"cmp"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// Comparer is implemented by types that want to define their own
+// ordering relative to other values of the same type. See [Compare].
+type Comparer interface {
+	// Compare returns a negative number if the value is less than
+	// other, a positive number if it is greater, and 0 if they are
+	// equal. other is always of the same type as the value.
+	Compare(other any) int
+}
+
+// Value classes, in the order that they sort in.
+const (
+	classNumber = iota
+	classRune
+	classBool
+	classAtom
+	classString
+	classList
+	classMap
+	classOther
+)
+
+func classOf(v any) int {
+	switch v.(type) {
+	case int64, float64:
+		return classNumber
+	case Rune:
+		return classRune
+	case bool:
+		return classBool
+	case Atom:
+		return classAtom
+	case string:
+		return classString
+	case *List:
+		return classList
+	case *Map:
+		return classMap
+	default:
+		return classOther
+	}
+}
+
+// Compare defines a total ordering over all values. It returns a
+// negative number if v1 sorts before v2, a positive number if it
+// sorts after, and 0 if they are equal.
+//
+// Values of different kinds are ordered as
+//
+//	numbers < runes < bools < atoms < strings < lists < maps < others
+//
+// Numbers are compared by exact value regardless of whether they are
+// integers or floats, except that an integer sorts before a float
+// that is numerically equal to it, as the two are not [Equal]. Atoms
+// and strings are compared by their text, and lists are compared
+// element by element, with a list that is a prefix of another sorting
+// first. Smaller maps sort before larger ones, and maps of the same
+// size are compared pair by pair in the order of their keys.
+//
+// Other values are ordered first by the name of their Go type. Values
+// of the same type are then compared with their Compare method if
+// they implement [Comparer], or by their [Inspect] representation if
+// not.
+func Compare(v1, v2 any) int {
+	c1, c2 := classOf(v1), classOf(v2)
+	if c1 != c2 {
+		return cmp.Compare(c1, c2)
+	}
+
+	switch v1 := v1.(type) {
+	case int64, float64:
+		return compareNumbers(v1, v2)
+	case Rune:
+		return cmp.Compare(v1, v2.(Rune))
+	case bool:
+		return compareBools(v1, v2.(bool))
+	case Atom:
+		return strings.Compare(v1.String(), v2.(Atom).String())
+	case string:
+		return strings.Compare(v1, v2.(string))
+	case *List:
+		return compareLists(v1, v2.(*List))
+	case *Map:
+		return compareMaps(v1, v2.(*Map))
+	default:
+		return compareOther(v1, v2)
+	}
+}
+
+func compareNumbers(v1, v2 any) int {
+	switch v1 := v1.(type) {
+	case int64:
+		switch v2 := v2.(type) {
+		case int64:
+			return cmp.Compare(v1, v2)
+		case float64:
+			return cmp.Or(compareIntFloat(v1, v2), -1)
+		}
+	case float64:
+		switch v2 := v2.(type) {
+		case int64:
+			return cmp.Or(-compareIntFloat(v2, v1), 1)
+		case float64:
+			return cmp.Compare(v1, v2)
+		}
+	}
+	panic("unreachable")
+}
+
+// compareIntFloat compares i and f exactly. Converting i to a float64
+// would round integers too large to be represented exactly, making
+// them compare equal to floats that they aren't equal to. A NaN sorts
+// before every integer, as it does before every other float.
+func compareIntFloat(i int64, f float64) int {
+	switch {
+	case math.IsNaN(f):
+		return 1
+	case f >= 1<<63:
+		return -1
+	case f < -1<<63:
+		return 1
+	}
+
+	t := math.Trunc(f)
+	return cmp.Or(cmp.Compare(i, int64(t)), cmp.Compare(0, f-t))
+}
+
+func compareBools(v1, v2 bool) int {
+	switch {
+	case v1 == v2:
+		return 0
+	case v2:
+		return -1
+	default:
+		return 1
+	}
+}
+
+func compareLists(l1, l2 *List) int {
+	for l1.Len() > 0 && l2.Len() > 0 {
+		if l1 == l2 {
+			return 0
+		}
+		if c := Compare(l1.Head(), l2.Head()); c != 0 {
+			return c
+		}
+		l1, l2 = l1.Tail(), l2.Tail()
+	}
+	return cmp.Compare(l1.Len(), l2.Len())
+}
+
+func compareOther(v1, v2 any) int {
+	t1, t2 := reflect.TypeOf(v1), reflect.TypeOf(v2)
+	if t1 != t2 {
+		return strings.Compare(typeName(t1), typeName(t2))
+	}
+
+	if c, ok := v1.(Comparer); ok {
+		return c.Compare(v2)
+	}
+	if Equal(v1, v2) {
+		return 0
+	}
+	return strings.Compare(Inspect(v1), Inspect(v2))
+}
+
+func typeName(t reflect.Type) string {
+	if t == nil {
+		return ""
+	}
+	return t.String()
+}
@@ -0,0 +1,62 @@
+package extract_test
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"testing"
+
+	"deedles.dev/extract"
+)
+
+func TestCompare(t *testing.T) {
+	sorted := []any{
+		math.Inf(-1),
+		int64(math.MinInt64),
+		float64(math.MinInt64),
+		int64(-1),
+		int64(1),
+		1.0,
+		1.5,
+		int64(2),
+		int64(1 << 53),
+		float64(1 << 53),
+		int64(1<<53 + 1),
+		int64(math.MaxInt64),
+		float64(math.MaxInt64),
+		math.Inf(1),
+		extract.Rune('a'),
+		false,
+		true,
+		extract.MakeAtom("a"),
+		extract.MakeAtom("b"),
+		"",
+		"a",
+		extract.ListOf(),
+		extract.ListOf(int64(1)),
+		extract.ListOf(int64(1), int64(2)),
+		extract.ListOf(int64(2)),
+	}
+
+	for i, v1 := range sorted {
+		for j, v2 := range sorted {
+			if c := extract.Compare(v1, v2); c != cmp.Compare(i, j) {
+				t.Fatalf("Compare(%v, %v) = %v", extract.Inspect(v1), extract.Inspect(v2), c)
+			}
+		}
+	}
+
+	shuffled := slices.Clone(sorted)
+	slices.Reverse(shuffled)
+	shuffled[0], shuffled[5] = shuffled[5], shuffled[0]
+	slices.SortFunc(shuffled, extract.Compare)
+	for i := range sorted {
+		if !extract.Equal(shuffled[i], sorted[i]) {
+			t.Fatalf("%v: %v", i, extract.Inspect(shuffled[i]))
+		}
+	}
+
+	if c := extract.Compare(extract.ListOf(int64(1), "a"), extract.ListOf(int64(1), "a")); c != 0 {
+		t.Fatal(c)
+	}
+}
@@ -0,0 +1,15 @@
+package extract
+
+import "iter"
+
+// Runtime is the old name of [Env].
+//
+// Deprecated: Use [Env] instead.
+type Runtime = Env
+
+// EvalAllWithRuntime is the old name of [EvalAllWithEnv].
+//
+// Deprecated: Use [EvalAllWithEnv] instead.
+func EvalAllWithRuntime[T any](env *Env, seq iter.Seq[T]) iter.Seq2[*Env, any] {
+	return EvalAllWithEnv(env, seq)
+}
@@ -0,0 +1,265 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"reflect"
+)
+
+// errTooLarge is returned when decompressed data would exceed the
+// limit set by [WithMaxDecompressedSize].
+var errTooLarge = errors.New("decompressed data is too large")
+
+// decompressLimit returns the maximum number of bytes that may be
+// decompressed from a single input.
+func (env *Env) decompressLimit() int64 {
+	if env.maxDecompressed <= 0 {
+		return math.MaxInt64
+	}
+	return env.maxDecompressed
+}
+
+// readAllLimited is like [io.ReadAll], but fails with errTooLarge if r
+// has more than limit bytes.
+func readAllLimited(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit))
+	if err != nil || int64(len(data)) < limit {
+		return data, err
+	}
+
+	switch _, err := io.ReadFull(r, make([]byte, 1)); err {
+	case nil:
+		return nil, errTooLarge
+	case io.EOF:
+		return data, nil
+	default:
+		return nil, err
+	}
+}
+
+// compressErrorReason converts an error from decompression into a
+// reason for a tagged error result. Corrupt or truncated input is
+// reported as :invalid, and input that decompresses to too much data
+// as :too_large.
+func compressErrorReason(err error) any {
+	switch {
+	case errors.Is(err, errTooLarge):
+		return MakeAtom("too_large")
+	case errors.Is(err, gzip.ErrHeader), errors.Is(err, gzip.ErrChecksum),
+		errors.Is(err, zlib.ErrHeader), errors.Is(err, zlib.ErrChecksum), errors.Is(err, zlib.ErrDictionary),
+		errors.Is(err, zip.ErrFormat), errors.Is(err, zip.ErrAlgorithm), errors.Is(err, zip.ErrChecksum),
+		errors.Is(err, io.ErrUnexpectedEOF):
+		return MakeAtom("invalid")
+	default:
+		return fsErrorReason(err)
+	}
+}
+
+// compressModule returns a module named name with compress and
+// decompress functions that use the given writer and reader.
+func compressModule(name string, newWriter func(io.Writer) io.WriteCloser, newReader func(io.Reader) (io.ReadCloser, error)) *Module {
+	m := Module{name: MakeAtom(name)}
+	m.decls = map[Ident]any{
+		MakeIdent("compress"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			data, err := evalBytes(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			var buf bytes.Buffer
+			w := newWriter(&buf)
+			_, err = w.Write(data)
+			if err := errors.Join(err, w.Close()); err != nil {
+				return env, err
+			}
+			return env, &Binary{data: buf.Bytes()}
+		}),
+		MakeIdent("decompress"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			data, err := evalBytes(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			r, err := newReader(bytes.NewReader(data))
+			if err != nil {
+				return env, errorResult(compressErrorReason(err))
+			}
+			out, err := readAllLimited(r, env.decompressLimit())
+			if err := errors.Join(err, r.Close()); err != nil {
+				return env, errorResult(compressErrorReason(err))
+			}
+			return env, okResult(&Binary{data: out})
+		}),
+	}
+
+	return &m
+}
+
+func stdGzip() *Module {
+	return compressModule(
+		"Gzip",
+		func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+		func(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) },
+	)
+}
+
+func stdZlib() *Module {
+	return compressModule(
+		"Zlib",
+		func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) },
+		zlib.NewReader,
+	)
+}
+
+// readZip reads every file in the archive into a map from file names
+// to binaries. Directories are skipped. It fails with errTooLarge if
+// the files contain more than limit bytes in total.
+func readZip(r *zip.Reader, limit int64) (*Map, error) {
+	var files *Map
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		data, err := readAllLimited(rc, limit)
+		if err := errors.Join(err, rc.Close()); err != nil {
+			return nil, err
+		}
+		limit -= int64(len(data))
+		files = files.Put(f.Name, &Binary{data: data})
+	}
+	return files, nil
+}
+
+// writeZip writes an archive containing entries to w. entries must be
+// enumerable, yielding [name data] pairs, such as a *Map from names to
+// contents.
+func writeZip(w io.Writer, entries any) error {
+	pairs, err := collectEnum(entries)
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+	for _, pair := range pairs {
+		p, ok := pair.(*List)
+		if !ok || p.Len() != 2 {
+			return NewTypeError(pair, reflect.TypeFor[*List]())
+		}
+		name, ok := p.Head().(string)
+		if !ok {
+			return NewTypeError(p.Head(), reflect.TypeFor[string]())
+		}
+		data, err := toBytes(p.Tail().Head())
+		if err != nil {
+			return err
+		}
+
+		fw, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func stdZip() *Module {
+	m := Module{name: MakeAtom("Zip")}
+	m.decls = map[Ident]any{
+		MakeIdent("read"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 1 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 1}
+			}
+
+			_, src := Eval(env, args.Head(), nil)
+			switch src := src.(type) {
+			case *Binary:
+				r, err := zip.NewReader(bytes.NewReader(src.bytes()), int64(src.Len()))
+				if err != nil {
+					return env, errorResult(compressErrorReason(err))
+				}
+				files, err := readZip(r, env.decompressLimit())
+				if err != nil {
+					return env, errorResult(compressErrorReason(err))
+				}
+				return env, okResult(files)
+
+			case string:
+				if err := env.checkFS(); err != nil {
+					return env, err
+				}
+				r, err := zip.OpenReader(src)
+				if err != nil {
+					return env, errorResult(compressErrorReason(err))
+				}
+				defer r.Close()
+				files, err := readZip(&r.Reader, env.decompressLimit())
+				if err != nil {
+					return env, errorResult(compressErrorReason(err))
+				}
+				return env, okResult(files)
+
+			case error:
+				return env, src
+
+			default:
+				return env, NewTypeError(src, reflect.TypeFor[*Binary](), reflect.TypeFor[string]())
+			}
+		}),
+		MakeIdent("write"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			switch args.Len() {
+			case 1:
+				_, entries := Eval(env, args.Head(), nil)
+				if err, ok := entries.(error); ok {
+					return env, err
+				}
+
+				var buf bytes.Buffer
+				if err := writeZip(&buf, entries); err != nil {
+					return env, err
+				}
+				return env, &Binary{data: buf.Bytes()}
+
+			case 2:
+				if err := env.checkFS(); err != nil {
+					return env, err
+				}
+				vals, err := evalArgs(env, args)
+				if err != nil {
+					return env, err
+				}
+				path, ok := vals[0].(string)
+				if !ok {
+					return env, NewTypeError(vals[0], reflect.TypeFor[string]())
+				}
+
+				var buf bytes.Buffer
+				if err := writeZip(&buf, vals[1]); err != nil {
+					return env, err
+				}
+				if err := os.WriteFile(path, buf.Bytes(), 0666); err != nil {
+					return env, errorResult(fsErrorReason(err))
+				}
+				return env, okAtom
+
+			default:
+				return env, &ArgumentNumError{Num: args.Len(), Expected: -1}
+			}
+		}),
+	}
+
+	return &m
+}
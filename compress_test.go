@@ -0,0 +1,91 @@
+package extract_test
+
+import (
+	"errors"
+	"testing"
+
+	"deedles.dev/extract"
+)
+
+func TestGzip(t *testing.T) {
+	const src = `
+	(let (:ok data) (Gzip.decompress (Gzip.compress "hello, world")))
+	(let (:ok zdata) (Zlib.decompress (Zlib.compress b"\x00\x01")))
+	(list
+		(Binary.to_string data)
+		zdata
+		(Gzip.decompress b"not gzip")
+		(Zlib.decompress "")
+	)
+	`
+	invalid := extract.TupleOf(extract.MakeAtom("error"), extract.MakeAtom("invalid"))
+	result := runScript(t, src, true)
+	checkList(t, result,
+		"hello, world",
+		extract.BinaryOf([]byte{0, 1}),
+		invalid,
+		invalid,
+	)
+}
+
+func TestZip(t *testing.T) {
+	const src = `
+	(let archive (Zip.write (Map.new "a.txt" "one" "dir/b.bin" b"\xff")))
+	(let path (Path.join dir "test.zip"))
+	(list
+		(Zip.read archive)
+		(Zip.write path [["c.txt" "three"]])
+		(Zip.read path)
+		(Zip.read b"junk")
+		(Zip.read (Path.join dir "missing.zip"))
+	)
+	`
+	_, result := runInDir(t, src)
+	if err, ok := result.(error); ok {
+		t.Fatal(err)
+	}
+	ok := extract.MakeAtom("ok")
+	checkList(t, result,
+		extract.TupleOf(ok, extract.MapOf(
+			"a.txt", extract.BinaryOf([]byte("one")),
+			"dir/b.bin", extract.BinaryOf([]byte{0xff}),
+		)),
+		ok,
+		extract.TupleOf(ok, extract.MapOf("c.txt", extract.BinaryOf([]byte("three")))),
+		extract.TupleOf(extract.MakeAtom("error"), extract.MakeAtom("invalid")),
+		extract.TupleOf(extract.MakeAtom("error"), extract.MakeAtom("enoent")),
+	)
+
+	_, result = runInDir(t, `(Zip.read "test.zip")`, extract.WithoutFileSystem())
+	if err, _ := result.(error); !errors.Is(err, extract.ErrFileSystemDisabled) {
+		t.Fatalf("%#v", result)
+	}
+}
+
+func TestDecompressLimit(t *testing.T) {
+	const src = `
+	(list
+		(Gzip.decompress (Gzip.compress "0123456789"))
+		(Zlib.decompress (Zlib.compress "0123456789a"))
+		(Zip.read (Zip.write (Map.new "a.txt" "012345" "b.txt" "6789")))
+		(Zip.read (Zip.write (Map.new "a.txt" "012345" "b.txt" "6789a")))
+	)
+	`
+	_, result := runInDir(t, src, extract.WithMaxDecompressedSize(10))
+	if err, ok := result.(error); ok {
+		t.Fatal(err)
+	}
+	tooLarge := extract.TupleOf(extract.MakeAtom("error"), extract.MakeAtom("too_large"))
+	checkList(t, result,
+		extract.TupleOf(extract.MakeAtom("ok"), extract.BinaryOf([]byte("0123456789"))),
+		tooLarge,
+		extract.TupleOf(extract.MakeAtom("ok"), extract.MapOf(
+			"a.txt", extract.BinaryOf([]byte("012345")),
+			"b.txt", extract.BinaryOf([]byte("6789")),
+		)),
+		tooLarge,
+	)
+
+	_, result = runInDir(t, `(Zlib.decompress (Zlib.compress "0123456789a"))`, extract.WithMaxDecompressedSize(0))
+	checkTuple(t, result, extract.MakeAtom("ok"), extract.BinaryOf([]byte("0123456789a")))
+}
@@ -0,0 +1,95 @@
+package extract
+
+import (
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// fromConfig converts a value produced by decoding a configuration
+// document into a generic Go value into an Extract value. Tables and
+// mappings become *Maps with the keys converted the same way as
+// values, so string keys stay strings, and dates and times become
+// strings in RFC 3339 format.
+func fromConfig(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		var m *Map
+		for k, v := range v {
+			m = m.Put(k, fromConfig(v))
+		}
+		return m
+	case map[any]any:
+		var m *Map
+		for k, v := range v {
+			m = m.Put(fromConfig(k), fromConfig(v))
+		}
+		return m
+	case []map[string]any:
+		vals := make([]any, 0, len(v))
+		for _, v := range v {
+			vals = append(vals, fromConfig(v))
+		}
+		return ListOf(vals...)
+	case []any:
+		vals := make([]any, 0, len(v))
+		for _, v := range v {
+			vals = append(vals, fromConfig(v))
+		}
+		return ListOf(vals...)
+	case time.Time:
+		return formatConfigTime(v)
+	default:
+		return Marshal(v)
+	}
+}
+
+// formatConfigTime formats t as a string. The TOML decoder represents
+// dates and times without an offset by using special locations, and
+// those are formatted without an offset to preserve the original
+// value.
+func formatConfigTime(t time.Time) string {
+	switch t.Location().String() {
+	case "datetime-local":
+		return t.Format("2006-01-02T15:04:05.999999999")
+	case "date-local":
+		return t.Format(time.DateOnly)
+	case "time-local":
+		return t.Format("15:04:05.999999999")
+	default:
+		return t.Format(time.RFC3339Nano)
+	}
+}
+
+func stdConfig() *Module {
+	m := Module{name: MakeAtom("Config")}
+	m.decls = map[Ident]any{
+		MakeIdent("parse_toml"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			strs, err := evalStrings(env, args, 1, 1)
+			if err != nil {
+				return env, err
+			}
+
+			var doc map[string]any
+			if _, err := toml.Decode(strs[0], &doc); err != nil {
+				return env, errorResult(err.Error())
+			}
+			return env, okResult(fromConfig(doc))
+		}),
+		MakeIdent("parse_yaml"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			strs, err := evalStrings(env, args, 1, 1)
+			if err != nil {
+				return env, err
+			}
+
+			var doc any
+			if err := yaml.Unmarshal([]byte(strs[0]), &doc); err != nil {
+				return env, errorResult(err.Error())
+			}
+			return env, okResult(fromConfig(doc))
+		}),
+	}
+
+	return &m
+}
@@ -0,0 +1,76 @@
+package extract_test
+
+import (
+	"testing"
+
+	"deedles.dev/extract"
+)
+
+func TestConfigTOML(t *testing.T) {
+	const src = `
+	(Config.parse_toml """
+		title = "test"
+		ports = [80, 443]
+		ratio = 0.5
+		created = 1979-05-27T07:32:00Z
+		day = 1979-05-27
+
+		[server]
+		enabled = true
+
+		[[users]]
+		name = "a"
+		[[users]]
+		name = "b"
+		""")
+	`
+	result := runScript(t, src, true)
+	checkTuple(t, result, extract.MakeAtom("ok"), extract.MapOf(
+		"title", "test",
+		"ports", extract.ListOf(int64(80), int64(443)),
+		"ratio", 0.5,
+		"created", "1979-05-27T07:32:00Z",
+		"day", "1979-05-27",
+		"server", extract.MapOf("enabled", true),
+		"users", extract.ListOf(extract.MapOf("name", "a"), extract.MapOf("name", "b")),
+	))
+}
+
+func TestConfigYAML(t *testing.T) {
+	const src = `
+	(list
+		(Config.parse_yaml """
+			name: test
+			count: 3
+			tags: [a, b]
+			nested:
+			  empty: null
+			  1: one
+			""")
+		(Config.parse_yaml "")
+	)
+	`
+	result := runScript(t, src, true)
+	ok := extract.MakeAtom("ok")
+	checkList(t, result,
+		extract.TupleOf(ok, extract.MapOf(
+			"name", "test",
+			"count", int64(3),
+			"tags", extract.ListOf("a", "b"),
+			"nested", extract.MapOf("empty", nil, int64(1), "one"),
+		)),
+		extract.TupleOf(ok, nil),
+	)
+}
+
+func TestConfigErrors(t *testing.T) {
+	for _, src := range []string{
+		`(Config.parse_toml "key = ")`,
+		`(Config.parse_yaml "a: [")`,
+	} {
+		result := runScript(t, src, true).(*extract.Tuple)
+		if result.At(0) != extract.MakeAtom("error") {
+			t.Fatal(result)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package extract
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+	"time"
+)
+
+// WithContextValues exposes values from the Env's context to the
+// Context module. keys maps the names that scripts use to the keys
+// that the values are stored under in the context, such as
+//
+//	extract.WithContextValues(map[string]any{"request_id": requestIDKey{}})
+//
+// allows a script to get the value with
+//
+//	(Context.value :request_id)
+//
+// Values are looked up in the context of the Env at the time of the
+// call, such as one set with [Env.WithContext], and are converted with
+// [Marshal]. Values whose keys aren't exposed can't be accessed by
+// scripts. The option may be given more than once, in which case the
+// names are combined.
+func WithContextValues(keys map[string]any) Option {
+	return func(env *Env) {
+		m := maps.Clone(env.ctxKeys)
+		if m == nil {
+			m = make(map[string]any, len(keys))
+		}
+		maps.Copy(m, keys)
+		env.ctxKeys = m
+	}
+}
+
+// stdContext returns the Context module. Evaluation stops with a
+// [CancelledError] soon after the Env's context is done, so scripts
+// will usually check remaining to decide whether there is time to
+// start something rather than waiting for done? to become true.
+func stdContext() *Module {
+	m := Module{name: MakeAtom("Context")}
+	m.decls = map[Ident]any{
+		MakeIdent("done?"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 0 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 0}
+			}
+			return env, env.Context().Err() != nil
+		}),
+		MakeIdent("remaining"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 0 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 0}
+			}
+
+			deadline, ok := env.Context().Deadline()
+			if !ok {
+				return env, nil
+			}
+			return env, max(time.Until(deadline).Milliseconds(), 0)
+		}),
+		MakeIdent("value"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 1 && args.Len() != 2 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: -1}
+			}
+
+			vals, err := evalArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			var def any
+			if len(vals) == 2 {
+				def = vals[1]
+			}
+
+			var name string
+			switch v := vals[0].(type) {
+			case Atom:
+				name = v.String()
+			case string:
+				name = v
+			default:
+				return env, NewTypeError(vals[0], reflect.TypeFor[Atom](), reflect.TypeFor[string]())
+			}
+
+			key, ok := env.ctxKeys[name]
+			if !ok {
+				return env, def
+			}
+			v := env.Context().Value(key)
+			if v == nil {
+				return env, def
+			}
+			return env, Marshal(v)
+		}),
+		MakeIdent("keys"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 0 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 0}
+			}
+
+			var list *List
+			names := slices.Sorted(maps.Keys(env.ctxKeys))
+			for i := len(names) - 1; i >= 0; i-- {
+				list = list.Push(MakeAtom(names[i]))
+			}
+			return env, list
+		}),
+	}
+
+	return &m
+}
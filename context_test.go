@@ -0,0 +1,69 @@
+package extract_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"deedles.dev/extract"
+	"deedles.dev/extract/parser"
+)
+
+type requestIDKey struct{}
+
+type userKey struct{}
+
+type ctxUser struct {
+	Name  string
+	Admin bool
+}
+
+func TestContext(t *testing.T) {
+	s, err := parser.ParseString(t.Name(), `
+	[
+		(Context.value :request_id)
+		(Context.value "user")
+		(Context.value :secret)
+		(Context.value :missing :none)
+		(Context.keys)
+		(Context.done?)
+		(Context.remaining)
+	]
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "abc123")
+	ctx = context.WithValue(ctx, userKey{}, ctxUser{Name: "bob", Admin: true})
+	ctx = context.WithValue(ctx, "secret", "hidden")
+	env := extract.New(ctx,
+		extract.WithContextValues(map[string]any{"request_id": requestIDKey{}}),
+		extract.WithContextValues(map[string]any{"user": userKey{}, "missing": struct{}{}}),
+	)
+	_, result := extract.Run(env, s.All())
+	checkList(t, result,
+		"abc123",
+		extract.MapOf(extract.MakeAtom("name"), "bob", extract.MakeAtom("admin"), true),
+		nil,
+		extract.MakeAtom("none"),
+		extract.ListOf(extract.MakeAtom("missing"), extract.MakeAtom("request_id"), extract.MakeAtom("user")),
+		false,
+		nil,
+	)
+}
+
+func TestContextDeadline(t *testing.T) {
+	s, err := parser.ParseString(t.Name(), `[(Context.remaining) (Context.done?)]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	_, result := extract.Run(extract.New(ctx), s.All())
+	list := result.(*extract.List)
+	if r, _ := list.Head().(int64); r <= 50*1000 || r > 60*1000 || list.Tail().Head() != false {
+		t.Fatal(extract.Inspect(result))
+	}
+}
@@ -0,0 +1,182 @@
+package extract
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// ErrIntRange is returned when a Go integer is too large to be
+// converted to an Extract integer, such as a uint64 greater than
+// [math.MaxInt64].
+var ErrIntRange = errors.New("integer out of range")
+
+var (
+	typeAny     = reflect.TypeFor[any]()
+	typeError   = reflect.TypeFor[error]()
+	typeEnv     = reflect.TypeFor[*Env]()
+	typeContext = reflect.TypeFor[context.Context]()
+	typeList    = reflect.TypeFor[*List]()
+)
+
+// toGo converts the Extract value v to a Go value of type t.
+func toGo(v any, t reflect.Type) (reflect.Value, error) {
+	if v == nil {
+		return reflect.Zero(t), nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Type().AssignableTo(t) {
+		out := reflect.New(t).Elem()
+		out.Set(rv)
+		return out, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		switch v := v.(type) {
+		case int64:
+			n = v
+		case Rune:
+			n = int64(v)
+		default:
+			return reflect.Value{}, NewTypeError(v, t)
+		}
+		out := reflect.New(t).Elem()
+		if out.OverflowInt(n) {
+			return reflect.Value{}, NewTypeError(v, t)
+		}
+		out.SetInt(n)
+		return out, nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, ok := v.(int64)
+		out := reflect.New(t).Elem()
+		if !ok || n < 0 || out.OverflowUint(uint64(n)) {
+			return reflect.Value{}, NewTypeError(v, t)
+		}
+		out.SetUint(uint64(n))
+		return out, nil
+
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		switch v := v.(type) {
+		case float64:
+			f = v
+		case int64:
+			f = float64(v)
+		default:
+			return reflect.Value{}, NewTypeError(v, t)
+		}
+		out := reflect.New(t).Elem()
+		if t.Kind() == reflect.Float32 && !math.IsInf(f, 0) && out.OverflowFloat(f) {
+			return reflect.Value{}, NewTypeError(v, t)
+		}
+		out.SetFloat(f)
+		return out, nil
+
+	case reflect.String:
+		var s string
+		switch v := v.(type) {
+		case string:
+			s = v
+		case Atom:
+			s = v.String()
+		default:
+			return reflect.Value{}, NewTypeError(v, t)
+		}
+		out := reflect.New(t).Elem()
+		out.SetString(s)
+		return out, nil
+
+	case reflect.Bool:
+		b, ok := v.(bool)
+		if !ok {
+			return reflect.Value{}, NewTypeError(v, t)
+		}
+		out := reflect.New(t).Elem()
+		out.SetBool(b)
+		return out, nil
+
+	case reflect.Slice:
+		if s, ok := v.(string); ok && t.Elem().Kind() == reflect.Uint8 {
+			return reflect.ValueOf([]byte(s)).Convert(t), nil
+		}
+		if b, ok := v.(*Binary); ok && t.Elem().Kind() == reflect.Uint8 {
+			return reflect.ValueOf(b.Bytes()).Convert(t), nil
+		}
+
+		list, ok := v.(*List)
+		if !ok {
+			return reflect.Value{}, NewTypeError(v, t, typeList)
+		}
+		out := reflect.MakeSlice(t, 0, list.Len())
+		for elem := range list.All() {
+			e, err := toGo(elem, t.Elem())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			out = reflect.Append(out, e)
+		}
+		return out, nil
+
+	default:
+		return reflect.Value{}, NewTypeError(v, t)
+	}
+}
+
+// fromGo converts the Go value v to an Extract value. Integers and
+// floats of all sizes become int64 and float64 respectively, slices
+// and arrays other than byte slices become *Lists, and byte slices
+// become strings. Other values are returned as-is. Unsigned integers
+// that are too large to be an int64 can't be converted, so they
+// result in an error wrapping [ErrIntRange].
+func fromGo(v reflect.Value) (any, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n := v.Uint()
+		if n > math.MaxInt64 {
+			return nil, fmt.Errorf("%v: %w", n, ErrIntRange)
+		}
+		return int64(n), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return v.Bool(), nil
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return string(v.Bytes()), nil
+		}
+
+		var list *List
+		for i := v.Len() - 1; i >= 0; i-- {
+			elem, err := fromGo(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			list = list.Push(elem)
+		}
+		return list, nil
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return fromGo(v.Elem())
+
+	default:
+		return v.Interface(), nil
+	}
+}
@@ -0,0 +1,491 @@
+// Package debugger implements an interactive debugger for Extract
+// code. A Debugger observes evaluation through [extract.Hooks] and
+// pauses it at breakpoints, which can be set either at a line of a
+// source file or at the entry of a function. While paused, commands
+// read from a LineReader can inspect the bindings and calls in
+// progress, evaluate expressions, and step through the code.
+package debugger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"deedles.dev/extract"
+	"deedles.dev/extract/parser"
+	"deedles.dev/extract/scanner"
+)
+
+// Prompt is shown when the debugger is waiting for a command.
+const Prompt = "(debug) "
+
+// maxShown is the number of characters of an expression that are
+// shown when the debugger pauses before it is truncated.
+const maxShown = 60
+
+// LineReader is a source of commands for a Debugger. It has the same
+// method as a repl.LineReader, so the REPL's readers can be used.
+type LineReader interface {
+	ReadLine(prompt string) (string, error)
+}
+
+// ErrInvalidLocation is returned when a breakpoint location can't be
+// parsed.
+var ErrInvalidLocation = errors.New("invalid breakpoint location")
+
+// Breakpoint is a location at which a Debugger pauses. Loc is either
+// a line, optionally preceded by a file name and a colon, such as
+// main.ext:12, or the name of a function, optionally qualified with
+// the module that it is declared in, such as Example.run.
+type Breakpoint struct {
+	ID  int
+	Loc string
+
+	file   string
+	line   int
+	module extract.Atom
+	fn     extract.Ident
+}
+
+// parseBreakpoint parses a breakpoint location.
+func parseBreakpoint(loc string) (Breakpoint, error) {
+	bp := Breakpoint{Loc: loc}
+
+	file, line := "", loc
+	if i := strings.LastIndexByte(loc, ':'); i >= 0 {
+		file, line = loc[:i], loc[i+1:]
+	}
+	if n, err := strconv.Atoi(line); err == nil {
+		if n <= 0 {
+			return bp, fmt.Errorf("%w: %q", ErrInvalidLocation, loc)
+		}
+		bp.file, bp.line = file, n
+		return bp, nil
+	}
+	if file != "" {
+		return bp, fmt.Errorf("%w: %q", ErrInvalidLocation, loc)
+	}
+
+	module, fn, ok := strings.Cut(loc, ".")
+	if !ok {
+		module, fn = "", loc
+	}
+	if fn == "" || strings.ContainsAny(fn, " ()[]") {
+		return bp, fmt.Errorf("%w: %q", ErrInvalidLocation, loc)
+	}
+	if module != "" {
+		bp.module = extract.MakeAtom(module)
+	}
+	bp.fn = extract.MakeIdent(fn)
+	return bp, nil
+}
+
+// atPos returns true if the breakpoint is a line breakpoint that
+// includes pos.
+func (bp *Breakpoint) atPos(pos scanner.Position) bool {
+	if bp.line == 0 || pos.Line != bp.line {
+		return false
+	}
+	return bp.file == "" || pos.Filename == bp.file || strings.HasSuffix(pos.Filename, "/"+bp.file)
+}
+
+// atFunc returns true if the breakpoint is a function breakpoint for
+// f. A breakpoint qualified with a module only matches the function
+// that is declared in that module.
+func (bp *Breakpoint) atFunc(env *extract.Env, f *extract.Func) bool {
+	if bp.fn == (extract.Ident{}) || f.Name() != bp.fn {
+		return false
+	}
+	if bp.module == (extract.Atom{}) {
+		return true
+	}
+	m := env.GetModule(bp.module)
+	if m == nil {
+		return false
+	}
+	decl, _ := m.Lookup(bp.fn)
+	return decl == f
+}
+
+type mode int
+
+const (
+	modeContinue mode = iota
+	modeStep
+	modeNext
+	modeDetached
+)
+
+// Debugger pauses evaluation at breakpoints and reads commands while
+// paused. Evaluation in every process of an Env that is being debugged
+// stops while the Debugger is paused.
+type Debugger struct {
+	// Quit, if it is not nil, is called when the quit command is
+	// entered, such as to cancel the context of the Env being
+	// debugged. Either way, the Debugger stops pausing.
+	Quit func()
+
+	lines LineReader
+	out   io.Writer
+
+	// suspended is set while an expression entered with the print
+	// command is being evaluated so that it isn't debugged itself.
+	suspended atomic.Bool
+
+	// idle is set while there are no breakpoints or calls being
+	// counted and the Debugger isn't stepping, so that the hooks can
+	// return without taking m. It is updated by update.
+	idle atomic.Bool
+
+	m      sync.Mutex
+	breaks []Breakpoint
+	nextID int
+	mode   mode
+
+	// depth is the number of calls being evaluated, stopDepth is the
+	// depth at which the next command pauses, and holdDepth is the
+	// depth of the call that was last paused at, inside of which line
+	// breakpoints on holdLine are ignored.
+	depth, stopDepth, holdDepth int
+	holdLine                    int
+}
+
+// New returns a Debugger that reads commands from lines and writes its
+// output to out. It won't pause until a breakpoint is set or Step is
+// called.
+func New(lines LineReader, out io.Writer) *Debugger {
+	d := Debugger{lines: lines, out: out, nextID: 1}
+	d.idle.Store(true)
+	return &d
+}
+
+// update updates d.idle. It must be called with d.m held after
+// anything that it depends on changes.
+func (d *Debugger) update() {
+	idle := d.mode == modeDetached || (d.mode == modeContinue && len(d.breaks) == 0)
+	d.idle.Store(idle && d.depth == 0)
+}
+
+// Hooks returns the hooks that must be installed in an Env with
+// [extract.WithHooks] for the Debugger to debug it. While there are no
+// breakpoints and the Debugger isn't stepping, they return immediately,
+// so they can be installed before debugging is needed.
+func (d *Debugger) Hooks() extract.Hooks {
+	return extract.Hooks{
+		Before: d.before,
+		After:  d.after,
+		Enter:  d.enter,
+	}
+}
+
+// Break adds a breakpoint at loc, the format of which is described by
+// [Breakpoint].
+func (d *Debugger) Break(loc string) (Breakpoint, error) {
+	bp, err := parseBreakpoint(loc)
+	if err != nil {
+		return bp, err
+	}
+
+	d.m.Lock()
+	defer d.m.Unlock()
+	return d.addBreak(bp), nil
+}
+
+// addBreak assigns bp an ID and adds it. It must be called with d.m
+// held.
+func (d *Debugger) addBreak(bp Breakpoint) Breakpoint {
+	bp.ID = d.nextID
+	d.nextID++
+	d.breaks = append(d.breaks, bp)
+	if d.mode == modeDetached {
+		d.mode = modeContinue
+	}
+	d.update()
+	return bp
+}
+
+// Delete removes the breakpoint with the given ID. It returns false if
+// there is no such breakpoint.
+func (d *Debugger) Delete(id int) bool {
+	d.m.Lock()
+	defer d.m.Unlock()
+	return d.deleteBreak(id)
+}
+
+// deleteBreak must be called with d.m held.
+func (d *Debugger) deleteBreak(id int) bool {
+	i := slices.IndexFunc(d.breaks, func(bp Breakpoint) bool { return bp.ID == id })
+	if i < 0 {
+		return false
+	}
+	d.breaks = slices.Delete(d.breaks, i, i+1)
+	d.update()
+	return true
+}
+
+// Breakpoints returns the breakpoints that are set, in the order that
+// they were added.
+func (d *Debugger) Breakpoints() []Breakpoint {
+	d.m.Lock()
+	defer d.m.Unlock()
+	return slices.Clone(d.breaks)
+}
+
+// Step causes the Debugger to pause before the next call is evaluated.
+func (d *Debugger) Step() {
+	d.m.Lock()
+	defer d.m.Unlock()
+	d.mode = modeStep
+	d.update()
+}
+
+func (d *Debugger) before(env *extract.Env, expr any, args *extract.List) {
+	call, ok := expr.(extract.Call)
+	if !ok || call.Len() == 0 || d.idle.Load() || d.suspended.Load() {
+		return
+	}
+
+	d.m.Lock()
+	defer d.m.Unlock()
+	defer d.update()
+
+	d.depth++
+	switch d.mode {
+	case modeDetached:
+		return
+	case modeStep:
+		d.pause(env, call.Pos, "", call, d.depth)
+		return
+	case modeNext:
+		if d.depth <= d.stopDepth {
+			d.pause(env, call.Pos, "", call, d.depth)
+			return
+		}
+	}
+
+	held := d.holdDepth > 0 && d.depth > d.holdDepth && call.Pos.Line == d.holdLine
+	for _, bp := range d.breaks {
+		if !held && bp.atPos(call.Pos) {
+			d.pause(env, call.Pos, fmt.Sprintf("breakpoint %v", bp.ID), call, d.depth)
+			return
+		}
+	}
+}
+
+func (d *Debugger) after(env *extract.Env, expr any, args *extract.List, result any) {
+	call, ok := expr.(extract.Call)
+	if !ok || call.Len() == 0 || d.idle.Load() || d.suspended.Load() {
+		return
+	}
+
+	d.m.Lock()
+	defer d.m.Unlock()
+	defer d.update()
+
+	if d.depth > 0 {
+		// A call that started while d was idle wasn't counted.
+		d.depth--
+	}
+	if d.depth < d.holdDepth {
+		d.holdDepth = 0
+	}
+}
+
+func (d *Debugger) enter(env *extract.Env, f *extract.Func, args *extract.List) {
+	if d.idle.Load() || d.suspended.Load() {
+		return
+	}
+
+	d.m.Lock()
+	defer d.m.Unlock()
+	defer d.update()
+
+	if d.mode == modeDetached {
+		return
+	}
+	for _, bp := range d.breaks {
+		if bp.atFunc(env, f) {
+			var pos scanner.Position
+			if trace := env.Trace(); len(trace) > 0 {
+				pos = trace[0].Pos
+			}
+			call := extract.Call{List: args.Push(f.Name()), Pos: pos}
+			d.pause(env, pos, fmt.Sprintf("breakpoint %v", bp.ID), call, d.depth+1)
+			return
+		}
+	}
+}
+
+// pause reads and runs commands until one of them resumes evaluation.
+// Depth is the depth of the calls that the next command steps over.
+// It must be called with d.m held.
+func (d *Debugger) pause(env *extract.Env, pos scanner.Position, reason string, call extract.Call, depth int) {
+	d.holdDepth, d.holdLine = d.depth, pos.Line
+
+	if reason != "" {
+		reason += " "
+	}
+	fmt.Fprintf(d.out, "%vat %v: %v\n", reason, pos, shorten(extract.Inspect(call)))
+
+	for {
+		line, err := d.lines.ReadLine(Prompt)
+		if err != nil {
+			// Without any more commands, there is no way to resume after
+			// pausing again.
+			d.mode = modeDetached
+			return
+		}
+
+		name, arg, _ := strings.Cut(strings.TrimSpace(line), " ")
+		cmd, ok := commands[name]
+		if !ok {
+			fmt.Fprintf(d.out, "unknown command %q, enter help for a list of commands\n", name)
+			continue
+		}
+		if cmd.run(d, env, strings.TrimSpace(arg), depth) {
+			return
+		}
+	}
+}
+
+// shorten truncates str to at most maxShown characters.
+func shorten(str string) string {
+	if len(str) <= maxShown {
+		return str
+	}
+	return str[:maxShown-3] + "..."
+}
+
+// command is a command that can be entered while the debugger is
+// paused. Run returns true if evaluation should resume.
+type command struct {
+	names []string
+	args  string
+	help  string
+	run   func(d *Debugger, env *extract.Env, arg string, depth int) bool
+}
+
+// commands maps every name of a command to it. It is initialized in
+// init to avoid an initialization cycle with the help command.
+var commands map[string]*command
+
+var commandList []*command
+
+func init() {
+	commandList = []*command{
+		{[]string{"continue", "c"}, "", "resume until the next breakpoint", (*Debugger).cmdContinue},
+		{[]string{"step", "s"}, "", "pause at the next call, including inside of functions", (*Debugger).cmdStep},
+		{[]string{"next", "n"}, "", "pause at the next call that isn't inside of this one", (*Debugger).cmdNext},
+		{[]string{"break", "b"}, "<loc>", "set a breakpoint at a line, [file:]line, or a function, [Module.]name", (*Debugger).cmdBreak},
+		{[]string{"delete", "d"}, "<id>", "delete a breakpoint", (*Debugger).cmdDelete},
+		{[]string{"breakpoints", "bl"}, "", "list the breakpoints", (*Debugger).cmdBreakpoints},
+		{[]string{"locals", "l"}, "", "show the local bindings", (*Debugger).cmdLocals},
+		{[]string{"where", "bt"}, "", "show the calls in progress", (*Debugger).cmdWhere},
+		{[]string{"print", "p"}, "<expr>", "evaluate an expression and show the result", (*Debugger).cmdPrint},
+		{[]string{"quit", "q"}, "", "stop debugging", (*Debugger).cmdQuit},
+		{[]string{"help", "h"}, "", "show this help", (*Debugger).cmdHelp},
+	}
+
+	commands = make(map[string]*command)
+	for _, cmd := range commandList {
+		for _, name := range cmd.names {
+			commands[name] = cmd
+		}
+	}
+}
+
+func (d *Debugger) cmdContinue(env *extract.Env, arg string, depth int) bool {
+	d.mode = modeContinue
+	return true
+}
+
+func (d *Debugger) cmdStep(env *extract.Env, arg string, depth int) bool {
+	d.mode = modeStep
+	return true
+}
+
+func (d *Debugger) cmdNext(env *extract.Env, arg string, depth int) bool {
+	d.mode, d.stopDepth = modeNext, depth
+	return true
+}
+
+func (d *Debugger) cmdBreak(env *extract.Env, arg string, depth int) bool {
+	bp, err := parseBreakpoint(arg)
+	if err != nil {
+		fmt.Fprintf(d.out, "error: %v\n", err)
+		return false
+	}
+	bp = d.addBreak(bp)
+	fmt.Fprintf(d.out, "breakpoint %v at %v\n", bp.ID, bp.Loc)
+	return false
+}
+
+func (d *Debugger) cmdDelete(env *extract.Env, arg string, depth int) bool {
+	id, err := strconv.Atoi(arg)
+	if err != nil || !d.deleteBreak(id) {
+		fmt.Fprintf(d.out, "error: no breakpoint %q\n", arg)
+	}
+	return false
+}
+
+func (d *Debugger) cmdBreakpoints(env *extract.Env, arg string, depth int) bool {
+	for _, bp := range d.breaks {
+		fmt.Fprintf(d.out, "%v\t%v\n", bp.ID, bp.Loc)
+	}
+	return false
+}
+
+func (d *Debugger) cmdLocals(env *extract.Env, arg string, depth int) bool {
+	for ident, val := range env.Locals() {
+		fmt.Fprintf(d.out, "%v = %v\n", ident, shorten(extract.Inspect(val)))
+	}
+	return false
+}
+
+func (d *Debugger) cmdWhere(env *extract.Env, arg string, depth int) bool {
+	fmt.Fprintln(d.out, env.Trace())
+	return false
+}
+
+func (d *Debugger) cmdPrint(env *extract.Env, arg string, depth int) bool {
+	list, err := parser.ParseString("<debug>", arg)
+	if err != nil {
+		fmt.Fprintf(d.out, "error: %v\n", err)
+		return false
+	}
+
+	d.suspended.Store(true)
+	_, r := extract.Run(env, list.All())
+	d.suspended.Store(false)
+	if err, ok := r.(error); ok {
+		fmt.Fprintf(d.out, "error: %v\n", err)
+		return false
+	}
+	fmt.Fprintln(d.out, extract.Inspect(r))
+	return false
+}
+
+func (d *Debugger) cmdQuit(env *extract.Env, arg string, depth int) bool {
+	d.mode = modeDetached
+	d.breaks = nil
+	if d.Quit != nil {
+		d.Quit()
+	}
+	return true
+}
+
+func (d *Debugger) cmdHelp(env *extract.Env, arg string, depth int) bool {
+	for _, cmd := range commandList {
+		usage := strings.Join(cmd.names, ", ")
+		if cmd.args != "" {
+			usage += " " + cmd.args
+		}
+		fmt.Fprintf(d.out, "  %-18v%v\n", usage, cmd.help)
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+package debugger_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"deedles.dev/extract"
+	"deedles.dev/extract/debugger"
+	"deedles.dev/extract/parser"
+)
+
+// lines is a debugger.LineReader that returns a fixed list of
+// commands.
+type lines []string
+
+func (l *lines) ReadLine(prompt string) (string, error) {
+	if len(*l) == 0 {
+		return "", io.EOF
+	}
+	line := (*l)[0]
+	*l = (*l)[1:]
+	return line, nil
+}
+
+func runDebugger(t *testing.T, src string, setup func(d *debugger.Debugger), cmds ...string) (string, any) {
+	script, err := parser.ParseString("test.ext", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	l := lines(cmds)
+	d := debugger.New(&l, &out)
+	setup(d)
+
+	env := extract.New(context.Background(), extract.WithHooks(d.Hooks()))
+	_, r := extract.Run(env, script.All())
+	return out.String(), r
+}
+
+const src = `(defmodule Test
+	(def (double v)
+		(let r (mul v 2))
+		(add r 0)))
+(let x 3)
+(add (Test.double x) 1)
+(list x)`
+
+func TestDebuggerBreakLine(t *testing.T) {
+	out, r := runDebugger(t, src, func(d *debugger.Debugger) {
+		if _, err := d.Break("test.ext:6"); err != nil {
+			t.Fatal(err)
+		}
+	}, "locals", "print (mul x 10)", "c")
+	if !extract.Equal(r, extract.ListOf(int64(3))) {
+		t.Fatalf("%#v", r)
+	}
+
+	const ex = "breakpoint 1 at test.ext:6:1: (add (Test.double x) 1)\nx = 3\n30\n"
+	if out != ex {
+		t.Fatalf("%q", out)
+	}
+}
+
+func TestDebuggerBreakFunc(t *testing.T) {
+	out, _ := runDebugger(t, src, func(d *debugger.Debugger) {
+		if _, err := d.Break("Test.double"); err != nil {
+			t.Fatal(err)
+		}
+	}, "locals", "where", "n", "n", "locals", "c")
+
+	const ex = "breakpoint 1 at test.ext:6:6: (double 3)\n" +
+		"v = 3\n" +
+		"at double (test.ext:6:6)\n" +
+		"at test.ext:3:3: (let r (mul v 2))\n" +
+		"at test.ext:4:3: (add r 0)\n" +
+		"r = 6\nv = 3\n"
+	if out != ex {
+		t.Fatalf("%q", out)
+	}
+}
+
+func TestDebuggerStep(t *testing.T) {
+	out, r := runDebugger(t, `(add 1 (mul 2 3))`, (*debugger.Debugger).Step, "s", "s", "q")
+	if r != int64(7) {
+		t.Fatalf("%#v", r)
+	}
+
+	const ex = "at test.ext:1:1: (add 1 (mul 2 3))\nat test.ext:1:8: (mul 2 3)\n"
+	if out != ex {
+		t.Fatalf("%q", out)
+	}
+}
+
+func TestDebuggerBreakpoints(t *testing.T) {
+	d := debugger.New(new(lines), io.Discard)
+	for _, loc := range []string{"12", "main.ext:3", "run", "Example.run"} {
+		if _, err := d.Break(loc); err != nil {
+			t.Fatal(loc, err)
+		}
+	}
+	for _, loc := range []string{"main.ext:x", "0", "Example.", "(add)"} {
+		if _, err := d.Break(loc); !errors.Is(err, debugger.ErrInvalidLocation) {
+			t.Fatal(loc, err)
+		}
+	}
+
+	if !d.Delete(2) || d.Delete(2) {
+		t.Fatal("delete")
+	}
+	bps := d.Breakpoints()
+	if len(bps) != 3 || bps[0].ID != 1 || bps[1].Loc != "run" {
+		t.Fatalf("%+v", bps)
+	}
+}
+
+func TestDebuggerIdle(t *testing.T) {
+	script, err := parser.ParseString("test.ext", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	call, err := parser.ParseString("call.ext", `(add (Test.double 3) 1)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	l := lines{"delete 1", "c", "n", "n", "locals", "c"}
+	d := debugger.New(&l, &out)
+	if _, err := d.Break("test.ext:3"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first run pauses inside of a call and then continues without
+	// any breakpoints, and the second has nothing to pause at, which
+	// shouldn't confuse the depth that next steps over in the third.
+	env := extract.New(context.Background(), extract.WithHooks(d.Hooks()))
+	env, _ = extract.Run(env, script.All())
+	extract.Run(env, call.All())
+	if _, err := d.Break("Test.double"); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	if _, r := extract.Run(env, call.All()); r != int64(7) {
+		t.Fatalf("%#v", r)
+	}
+
+	const ex = "breakpoint 2 at call.ext:1:6: (double 3)\n" +
+		"at test.ext:3:3: (let r (mul v 2))\n" +
+		"at test.ext:4:3: (add r 0)\n" +
+		"r = 6\nv = 3\n"
+	if out.String() != ex {
+		t.Fatalf("%q", out.String())
+	}
+}
@@ -0,0 +1,87 @@
+package extract
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+func stdDir() *Module {
+	m := Module{name: MakeAtom("Dir")}
+	m.decls = map[Ident]any{
+		MakeIdent("list"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			path, err := evalPath(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			entries, err := os.ReadDir(path)
+			if err != nil {
+				return env, errorResult(fsErrorReason(err))
+			}
+			names := make([]any, 0, len(entries))
+			for _, entry := range entries {
+				names = append(names, entry.Name())
+			}
+			return env, okResult(ListOf(names...))
+		}),
+		MakeIdent("make"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			path, err := evalPath(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			err = os.MkdirAll(path, 0777)
+			if err != nil {
+				return env, errorResult(fsErrorReason(err))
+			}
+			return env, okAtom
+		}),
+		MakeIdent("remove"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			path, err := evalPath(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			err = os.Remove(path)
+			if err != nil {
+				return env, errorResult(fsErrorReason(err))
+			}
+			return env, okAtom
+		}),
+		MakeIdent("glob"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			pattern, err := evalPath(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			matches, err := filepath.Glob(pattern)
+			if err != nil {
+				return env, err
+			}
+			return env, CollectList(slices.Values(matches))
+		}),
+		MakeIdent("walk"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			root, err := evalPath(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			return env, &Stream{seq: func(yield func(any, error) bool) {
+				filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+					if err != nil {
+						yield(nil, err)
+						return filepath.SkipAll
+					}
+					if !yield(path, nil) {
+						return filepath.SkipAll
+					}
+					return nil
+				})
+			}}
+		}),
+	}
+
+	return &m
+}
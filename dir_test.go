@@ -0,0 +1,51 @@
+package extract_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"deedles.dev/extract"
+)
+
+func TestDir(t *testing.T) {
+	const src = `
+	(let sub (Path.join dir "a" "b"))
+	(list
+		(Dir.make sub)
+		(File.write (Path.join sub "one.txt") "1")
+		(File.write (Path.join dir "a" "two.txt") "2")
+		(Dir.list (Path.join dir "a"))
+		(List.map (Dir.glob (Path.join dir "a" "*.txt")) (func (base p) (Path.basename p)))
+		(List.map (Enum.to_list (Dir.walk dir)) (func (rel p) (String.replace p dir "")))
+		(Dir.remove sub)
+		(File.write (Path.join sub "one.txt") "1")
+		(Dir.list (Path.join dir "missing"))
+	)
+	`
+	_, result := runInDir(t, src)
+	if err, ok := result.(error); ok {
+		t.Fatal(err)
+	}
+
+	ok, errAtom := extract.MakeAtom("ok"), extract.MakeAtom("error")
+	sep := string(filepath.Separator)
+	checkList(t, result,
+		ok,
+		ok,
+		ok,
+		extract.TupleOf(ok, extract.ListOf("b", "two.txt")),
+		extract.ListOf("two.txt"),
+		extract.ListOf("", sep+"a", sep+filepath.Join("a", "b"), sep+filepath.Join("a", "b", "one.txt"), sep+filepath.Join("a", "two.txt")),
+		extract.TupleOf(errAtom, extract.MakeAtom("enotempty")),
+		ok,
+		extract.TupleOf(errAtom, extract.MakeAtom("enoent")),
+	)
+}
+
+func TestDirWithoutFileSystem(t *testing.T) {
+	_, result := runInDir(t, `(Dir.walk dir)`, extract.WithoutFileSystem())
+	if err, _ := result.(error); !errors.Is(err, extract.ErrFileSystemDisabled) {
+		t.Fatalf("%#v", result)
+	}
+}
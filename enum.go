@@ -0,0 +1,406 @@
+package extract
+
+import (
+	"iter"
+	"reflect"
+	"slices"
+)
+
+// Enumerable is implemented by values that can be enumerated by the
+// Enum module. If producing an element fails, such as because a
+// function that a lazy sequence calls returns an error, the error is
+// yielded and enumeration should stop.
+type Enumerable interface {
+	Enumerate() iter.Seq2[any, error]
+}
+
+// Enumerate yields the elements of the list.
+func (list *List) Enumerate() iter.Seq2[any, error] {
+	return func(yield func(any, error) bool) {
+		for v := range list.All() {
+			if !yield(v, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Enumerate yields the pairs in the map as two element lists of the
+// form [key value].
+func (m *Map) Enumerate() iter.Seq2[any, error] {
+	return func(yield func(any, error) bool) {
+		for k, v := range m.All() {
+			if !yield(ListOf(k, v), nil) {
+				return
+			}
+		}
+	}
+}
+
+func stdEnum() *Module {
+	m := Module{name: MakeAtom("Enum")}
+	m.decls = map[Ident]any{
+		MakeIdent("to_list"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 1 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 1}
+			}
+
+			vals, err := evalArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			if list, ok := vals[0].(*List); ok {
+				return env, list
+			}
+
+			s, err := collectEnum(vals[0])
+			if err != nil {
+				return env, err
+			}
+			return env, ListOf(s...)
+		}),
+		MakeIdent("map"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			e, fn, err := evalEnumFunc(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			var s []any
+			for v, err := range e.Enumerate() {
+				if err != nil {
+					return env, err
+				}
+				r, err := callFunc(env, fn, v)
+				if err != nil {
+					return env, err
+				}
+				s = append(s, r)
+			}
+			return env, ListOf(s...)
+		}),
+		MakeIdent("filter"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			e, fn, err := evalEnumFunc(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			var s []any
+			for v, err := range e.Enumerate() {
+				if err != nil {
+					return env, err
+				}
+				ok, err := callPredicate(env, fn, v)
+				if err != nil {
+					return env, err
+				}
+				if ok {
+					s = append(s, v)
+				}
+			}
+			return env, ListOf(s...)
+		}),
+		MakeIdent("reduce"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 3 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 3}
+			}
+
+			vals, err := evalArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			e, err := enumerable(vals[0])
+			if err != nil {
+				return env, err
+			}
+
+			acc, fn := vals[1], vals[2]
+			for v, err := range e.Enumerate() {
+				if err != nil {
+					return env, err
+				}
+				acc, err = callFunc(env, fn, v, acc)
+				if err != nil {
+					return env, err
+				}
+			}
+			return env, acc
+		}),
+		MakeIdent("each"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			e, fn, err := evalEnumFunc(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			for v, err := range e.Enumerate() {
+				if err != nil {
+					return env, err
+				}
+				_, err = callFunc(env, fn, v)
+				if err != nil {
+					return env, err
+				}
+			}
+			return env, okAtom
+		}),
+		MakeIdent("count"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 1 && args.Len() != 2 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: -1}
+			}
+
+			vals, err := evalArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			e, err := enumerable(vals[0])
+			if err != nil {
+				return env, err
+			}
+
+			var n int64
+			for v, err := range e.Enumerate() {
+				if err != nil {
+					return env, err
+				}
+				if len(vals) == 2 {
+					ok, err := callPredicate(env, vals[1], v)
+					if err != nil {
+						return env, err
+					}
+					if !ok {
+						continue
+					}
+				}
+				n++
+			}
+			return env, n
+		}),
+		MakeIdent("sum"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			e, err := evalEnum(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			var total int64
+			var totalf float64
+			var isFloat bool
+			for v, err := range e.Enumerate() {
+				if err != nil {
+					return env, err
+				}
+				switch v := v.(type) {
+				case int64:
+					total += v
+				case float64:
+					totalf += v
+					isFloat = true
+				default:
+					return env, NewTypeError(v, reflect.TypeFor[int64](), reflect.TypeFor[float64]())
+				}
+			}
+
+			if isFloat {
+				return env, float64(total) + totalf
+			}
+			return env, total
+		}),
+		MakeIdent("sort_by"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			e, fn, err := evalEnumFunc(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			type keyed struct{ key, val any }
+			var s []keyed
+			for v, err := range e.Enumerate() {
+				if err != nil {
+					return env, err
+				}
+				key, err := callFunc(env, fn, v)
+				if err != nil {
+					return env, err
+				}
+				s = append(s, keyed{key: key, val: v})
+			}
+
+			slices.SortStableFunc(s, func(k1, k2 keyed) int { return Compare(k1.key, k2.key) })
+			r := make([]any, 0, len(s))
+			for _, k := range s {
+				r = append(r, k.val)
+			}
+			return env, ListOf(r...)
+		}),
+		MakeIdent("group_by"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			e, fn, err := evalEnumFunc(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			// Groups are built in reverse so that each element can be
+			// pushed onto its group's list.
+			var groups *Map
+			for v, err := range e.Enumerate() {
+				if err != nil {
+					return env, err
+				}
+				key, err := callFunc(env, fn, v)
+				if err != nil {
+					return env, err
+				}
+				group, _ := groups.Get(key)
+				list, _ := group.(*List)
+				groups = groups.Put(key, list.Push(v))
+			}
+
+			var r *Map
+			for key, group := range groups.All() {
+				r = r.Put(key, PushAll(nil, group.(*List).All()))
+			}
+			return env, r
+		}),
+		MakeIdent("any?"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			e, fn, err := evalEnumFunc(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			for v, err := range e.Enumerate() {
+				if err != nil {
+					return env, err
+				}
+				ok, err := callPredicate(env, fn, v)
+				if err != nil {
+					return env, err
+				}
+				if ok {
+					return env, true
+				}
+			}
+			return env, false
+		}),
+		MakeIdent("all?"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			e, fn, err := evalEnumFunc(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			for v, err := range e.Enumerate() {
+				if err != nil {
+					return env, err
+				}
+				ok, err := callPredicate(env, fn, v)
+				if err != nil {
+					return env, err
+				}
+				if !ok {
+					return env, false
+				}
+			}
+			return env, true
+		}),
+		MakeIdent("find"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			e, fn, err := evalEnumFunc(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			for v, err := range e.Enumerate() {
+				if err != nil {
+					return env, err
+				}
+				ok, err := callPredicate(env, fn, v)
+				if err != nil {
+					return env, err
+				}
+				if ok {
+					return env, v
+				}
+			}
+			return env, nil
+		}),
+		MakeIdent("member?"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 2 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 2}
+			}
+
+			vals, err := evalArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			e, err := enumerable(vals[0])
+			if err != nil {
+				return env, err
+			}
+
+			for v, err := range e.Enumerate() {
+				if err != nil {
+					return env, err
+				}
+				if Equal(v, vals[1]) {
+					return env, true
+				}
+			}
+			return env, false
+		}),
+	}
+
+	return &m
+}
+
+// enumerable returns v as an [Enumerable] or a [TypeError] if it is
+// not one.
+func enumerable(v any) (Enumerable, error) {
+	e, ok := v.(Enumerable)
+	if !ok {
+		return nil, NewTypeError(v, reflect.TypeFor[Enumerable]())
+	}
+	return e, nil
+}
+
+// collectEnum collects the elements of v, which must be an
+// [Enumerable], into a slice.
+func collectEnum(v any) ([]any, error) {
+	e, err := enumerable(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var s []any
+	for v, err := range e.Enumerate() {
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, v)
+	}
+	return s, nil
+}
+
+// evalEnum evaluates a single [Enumerable] argument.
+func evalEnum(env *Env, args *List) (Enumerable, error) {
+	if args.Len() != 1 {
+		return nil, &ArgumentNumError{Num: args.Len(), Expected: 1}
+	}
+
+	_, head := Eval(env, args.Head(), nil)
+	if err, ok := head.(error); ok {
+		return nil, err
+	}
+	return enumerable(head)
+}
+
+// evalEnumFunc evaluates an [Enumerable] argument followed by a
+// function.
+func evalEnumFunc(env *Env, args *List) (e Enumerable, fn any, err error) {
+	if args.Len() != 2 {
+		return nil, nil, &ArgumentNumError{Num: args.Len(), Expected: 2}
+	}
+
+	vals, err := evalArgs(env, args)
+	if err != nil {
+		return nil, nil, err
+	}
+	e, err = enumerable(vals[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	return e, vals[1], nil
+}
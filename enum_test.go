@@ -0,0 +1,79 @@
+package extract_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"deedles.dev/extract"
+	"deedles.dev/extract/parser"
+)
+
+func TestEnum(t *testing.T) {
+	const src = `
+	(let l [3 1 2])
+	(let parity (func parity ((n) (n % 2))))
+	(list
+		(Enum.to_list m)
+		(Enum.map l (func (double n) (n * 2)))
+		(Enum.filter l (func (odd? n) (eq (parity n) 1)))
+		(Enum.reduce l 0 add)
+		(Enum.count m)
+		(Enum.count l (func (odd? n) (eq (parity n) 1)))
+		(Enum.sum [1 2.5])
+		(Enum.sort_by m (func (value [_ v]) v))
+		(Enum.group_by l parity)
+		(Enum.any? l (func (big? n) (eq n 3)))
+		(Enum.all? l (func (big? n) (eq n 3)))
+		(Enum.find l (func (even? n) (eq (parity n) 0)))
+		(Enum.member? m [:a 2])
+		(Enum.each l (func (f _) :ignored))
+	)
+	`
+	s, err := parser.ParseString(t.Name(), src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, b := extract.MakeAtom("a"), extract.MakeAtom("b")
+	env := extract.New(context.Background())
+	env = env.Let(extract.MakeIdent("m"), extract.MapOf(a, int64(2), b, int64(1)))
+	_, result := extract.Run(env, s.All())
+	if err, ok := result.(error); ok {
+		t.Fatal(err)
+	}
+
+	ex := []any{
+		extract.ListOf(extract.ListOf(a, int64(2)), extract.ListOf(b, int64(1))),
+		extract.ListOf(int64(6), int64(2), int64(4)),
+		extract.ListOf(int64(3), int64(1)),
+		int64(6),
+		int64(2),
+		int64(2),
+		3.5,
+		extract.ListOf(extract.ListOf(b, int64(1)), extract.ListOf(a, int64(2))),
+		extract.MapOf(int64(0), extract.ListOf(int64(2)), int64(1), extract.ListOf(int64(3), int64(1))),
+		true,
+		false,
+		int64(2),
+		true,
+		extract.MakeAtom("ok"),
+	}
+	r := slices.Collect(result.(*extract.List).All())
+	if len(r) != len(ex) {
+		t.Fatalf("%v", r)
+	}
+	for i := range r {
+		if !extract.Equal(r[i], ex[i]) {
+			t.Errorf("%v: %v != %v", i, r[i], ex[i])
+		}
+	}
+}
+
+func TestEnumNotEnumerable(t *testing.T) {
+	result := runScript(t, `(Enum.sum 3)`, false)
+	if err, _ := result.(error); !errors.As(err, new(*extract.TypeError)) {
+		t.Fatalf("%#v", result)
+	}
+}
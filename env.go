@@ -1,55 +1,406 @@
 package extract
 
 import (
+	"bufio"
 	"context"
+	"io"
 	"iter"
+	"log/slog"
+	"maps"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 
+	"deedles.dev/extract/scanner"
 	"deedles.dev/xsync"
 )
 
-var moduleIdent = MakeIdent("$module")
-
 // Env is the language's state. It tracks global data that is
 // necessary throughout an Extract program, such as declared modules.
-// A runtime is necessary to properly evaluate Extract code. To do so,
-// use the context returned by a runtime's [Context] method.
+// An Env is necessary to properly evaluate Extract code. Functions
+// that need a context while evaluating should use the one returned by
+// the Env's [Context] method.
 type Env struct {
 	ctx           context.Context
 	modules       *xsync.Map[Atom, *Module]
 	currentModule *Module
-	locals        *localList
+	locals        *scope
+	self          Process
+	steps         *steps
+
+	// depth is the number of function calls that are currently in
+	// progress, and maxDepth is the limit on it. See [WithMaxDepth].
+	depth, maxDepth int
+
+	// stack is the stack of function calls that are in progress, and
+	// pos is the position of the call that is currently being
+	// evaluated. pos is only set for calls to functions that might
+	// need it, such as builtins that report it, as setting it requires
+	// copying the Env. See [Call.Eval].
+	stack *stack
+	pos   scanner.Position
+
+	hooks *Hooks
+	io    *streams
+
+	// tracer logs calls, and traceDepth is the number of calls that
+	// are being evaluated. See [WithTrace].
+	tracer     *tracer
+	traceDepth int
+
+	// profile records function calls, and profileFrame is the call
+	// that is currently being evaluated. See [WithProfile].
+	profile      *Profile
+	profileFrame *profileFrame
+
+	// noFS disables access to the filesystem. See
+	// [WithoutFileSystem].
+	noFS bool
+
+	// noNet disables access to the network. See [WithoutNetwork].
+	noNet bool
+
+	// maxDecompressed is the limit on the size of decompressed data.
+	// See [WithMaxDecompressedSize].
+	maxDecompressed int64
+
+	// bytecode runs functions with the bytecode VM. See
+	// [WithBytecode].
+	bytecode bool
+
+	environ *environ
+	tests   *testSuite
+
+	// ctxKeys maps the names of context values that are exposed to
+	// the Context module to their keys. See [WithContextValues].
+	ctxKeys map[string]any
+
+	// loader finds and caches the files run by import, and importing
+	// is the chain of imports currently being run.
+	loader    *loader
+	importing *importChain
+
+	rand *lockedRand
+	log  *slog.Logger
+
+	// moduleSeq is the number of locals that were bound when the
+	// current module was entered. Declarations in the module shadow
+	// locals that were bound before that point.
+	moduleSeq int
+}
+
+// Option is an option that can be passed to [New].
+type Option func(*Env)
+
+// WithModules makes the given modules available in the Env, replacing
+// any other modules with the same names, including those from the
+// standard library.
+func WithModules(modules ...*Module) Option {
+	return func(env *Env) {
+		for _, m := range modules {
+			env.modules.Store(m.name, m)
+		}
+	}
+}
+
+// WithoutStd removes the standard library modules from the Env. This,
+// combined with [WithModules], allows an embedder to control exactly
+// which modules untrusted code has access to. Modules added by
+// WithModules are not removed, even if they have the same name as a
+// standard library module.
+func WithoutStd() Option {
+	return func(env *Env) {
+		for name, m := range std {
+			env.modules.CompareAndDelete(name, m)
+		}
+	}
+}
+
+// DenyModule removes the modules with the given names from the Env,
+// whether they are from the standard library or not.
+func DenyModule(names ...Atom) Option {
+	return func(env *Env) {
+		for _, name := range names {
+			env.modules.Delete(name)
+		}
+	}
+}
+
+// WithoutFileSystem disables the standard library functions that
+// access the host's filesystem, such as those in the File module. They
+// fail with [ErrFileSystemDisabled] instead.
+func WithoutFileSystem() Option {
+	return func(env *Env) {
+		env.noFS = true
+	}
+}
+
+// WithoutNetwork disables the standard library functions that access
+// the network, such as those in the Socket module. They fail with
+// [ErrNetworkDisabled] instead.
+func WithoutNetwork() Option {
+	return func(env *Env) {
+		env.noNet = true
+	}
+}
+
+// WithoutHostEnvironment hides the environment variables of the host
+// process from the Env module. Variables loaded from .env files are
+// still available.
+func WithoutHostEnvironment() Option {
+	return func(env *Env) {
+		env.environ.noHost = true
+	}
+}
+
+// WithLogHandler sets the handler that the Logger module sends
+// records to. By default, records are sent to the handler of
+// [slog.Default].
+func WithLogHandler(h slog.Handler) Option {
+	return func(env *Env) {
+		env.log = slog.New(h)
+	}
+}
+
+// WithRandSeed seeds the source of random numbers used by the Random
+// and UUID modules so that scripts produce the same results every time
+// that they are run, such as for tests. Without it, the source is
+// seeded randomly.
+func WithRandSeed(seed uint64) Option {
+	return func(env *Env) {
+		env.rand = &lockedRand{r: rand.New(rand.NewPCG(seed, seed))}
+	}
+}
+
+// WithStepLimit limits the number of evaluation steps that can be
+// performed in the Env to limit. Every evaluation of a value, such as
+// by [Eval], counts as a step. Once the limit is reached, all further
+// evaluations fail with a [LimitExceededError]. The limit is shared
+// by everything run from the Env, including processes and tasks.
+func WithStepLimit(limit int64) Option {
+	return func(env *Env) {
+		env.steps = &steps{limit: limit}
+	}
+}
+
+// Hooks are callbacks that are called around every evaluation in an
+// Env, allowing the evaluation to be observed, such as by a debugger
+// or a coverage tool. Either callback may be nil. The callbacks are
+// called from whatever goroutine the evaluation happens on, so they
+// must be safe to call concurrently if the evaluated code uses
+// processes or tasks.
+type Hooks struct {
+	// Before is called before expr is evaluated with args. As with
+	// [Eval], args is nil if expr is not being called.
+	Before func(env *Env, expr any, args *List)
+
+	// After is called after expr is evaluated with args with the Env
+	// and result that the evaluation produced.
+	After func(env *Env, expr any, args *List, result any)
+
+	// Enter is called when a function declared in Extract code is
+	// called, once its evaluated arguments, args, have matched one of
+	// its variants. Env is the Env that the body of the variant runs
+	// in, with the parameters bound.
+	Enter func(env *Env, f *Func, args *List)
+}
+
+func (h *Hooks) before(env *Env, expr any, args *List) {
+	if h.Before != nil {
+		h.Before(env, expr, args)
+	}
+}
+
+func (h *Hooks) after(env *Env, expr any, args *List, result any) {
+	if h.After != nil {
+		h.After(env, expr, args, result)
+	}
+}
+
+func (h *Hooks) enter(env *Env, f *Func, args *List) {
+	if h != nil && h.Enter != nil {
+		h.Enter(env, f, args)
+	}
+}
+
+// WithHooks sets the hooks that are called around every evaluation in
+// the Env.
+func WithHooks(hooks Hooks) Option {
+	return func(env *Env) {
+		env.hooks = &hooks
+	}
+}
+
+// WithTrace logs every call that is evaluated in the Env to w, along
+// with its result, indented by how deeply the call is nested. Calls to
+// functions declared in Extract code also log their evaluated
+// arguments and which variant of the function they matched, which
+// helps to find out why a call didn't match the variant that was
+// expected. Calls made by concurrent processes may be interleaved.
+func WithTrace(w io.Writer) Option {
+	return func(env *Env) {
+		env.tracer = &tracer{w: w}
+	}
+}
+
+// streams are the standard IO streams of an Env. See [WithStdout],
+// [WithStderr], and [WithStdin].
+type streams struct {
+	stdout, stderr io.Writer
+	stdin          *bufio.Reader
+
+	// stdinMu serializes reads of stdin, which is shared by every
+	// process of an Env and, by default, by every Env.
+	stdinMu *sync.Mutex
+}
+
+func (s *streams) clone() *streams {
+	c := *s
+	return &c
+}
+
+// WithStdout sets the writer that the Env uses as standard output.
+// Defaults to [os.Stdout].
+func WithStdout(w io.Writer) Option {
+	return func(env *Env) {
+		env.io = env.io.clone()
+		env.io.stdout = w
+	}
+}
+
+// WithStderr sets the writer that the Env uses as standard error.
+// Defaults to [os.Stderr].
+func WithStderr(w io.Writer) Option {
+	return func(env *Env) {
+		env.io = env.io.clone()
+		env.io.stderr = w
+	}
+}
+
+// WithStdin sets the reader that the Env uses as standard input.
+// Defaults to [os.Stdin]. Input is buffered, so r should not be read
+// from by anything else while the Env is in use.
+func WithStdin(r io.Reader) Option {
+	return func(env *Env) {
+		env.io = env.io.clone()
+		env.io.stdin = bufio.NewReader(r)
+		env.io.stdinMu = new(sync.Mutex)
+	}
 }
 
-// New returns a runtime that has been initialized with the standard
-// global state.
-func New(ctx context.Context) *Env {
+// DefaultMaxDepth is the default maximum depth of nested function
+// calls. See [WithMaxDepth].
+const DefaultMaxDepth = 10000
+
+// WithMaxDepth sets the maximum depth of nested function calls in the
+// Env. A call that would exceed it fails with a [StackOverflowError]
+// instead of possibly exhausting the stack of the host program. A
+// depth of 0 or less means that there is no limit.
+func WithMaxDepth(depth int) Option {
+	return func(env *Env) {
+		env.maxDepth = depth
+	}
+}
+
+// DefaultMaxDecompressedSize is the default maximum size in bytes of
+// the data decompressed from a single input. See
+// [WithMaxDecompressedSize].
+const DefaultMaxDecompressedSize = 64 << 20
+
+// WithMaxDecompressedSize sets the maximum size in bytes of the data
+// that the Gzip, Zlib, and Zip modules decompress from a single input,
+// including all of the files in a zip archive, so that a small input
+// can't exhaust the memory of the host program. Decompressing more
+// fails with an {:error, :too_large} result. A size of 0 or less means
+// that there is no limit.
+func WithMaxDecompressedSize(size int64) Option {
+	return func(env *Env) {
+		env.maxDecompressed = size
+	}
+}
+
+var defaultStreams = streams{
+	stdout: os.Stdout,
+	stderr: os.Stderr,
+	stdin:  bufio.NewReader(os.Stdin),
+
+	stdinMu: new(sync.Mutex),
+}
+
+// New returns an Env that has been initialized with the standard
+// global state, modified by opts in the order that they are given.
+func New(ctx context.Context, opts ...Option) *Env {
 	r := Env{
 		ctx:     ctx,
 		modules: new(xsync.Map[Atom, *Module]),
 		locals:  kernel,
+		self:    newRootProcess(),
+		io:      &defaultStreams,
+		environ: new(environ),
+		tests:   new(testSuite),
+		loader:  new(loader),
+
+		maxDepth:        DefaultMaxDepth,
+		maxDecompressed: DefaultMaxDecompressedSize,
 	}
 	for name, m := range std {
 		r.modules.Store(name, m)
 	}
+	for _, opt := range opts {
+		opt(&r)
+	}
 	return &r
 }
 
 // All returns an iterator that yields all bound identifiers in the
-// order that they are looked up in.
+// order that they are looked up in. Identifiers that are shadowed by
+// other bindings are not yielded.
 func (env *Env) All() iter.Seq2[Ident, any] {
 	return func(yield func(Ident, any) bool) {
-		for ident, val := range env.locals.All() {
-			switch ident {
-			case moduleIdent:
-				for ident, val := range env.currentModule.decls {
+		module := env.currentModule != nil
+		for b := range env.locals.Since(0) {
+			if module && b.seq <= env.moduleSeq {
+				module = false
+				for ident, val := range env.currentModule.All() {
 					if !yield(ident, val) {
 						return
 					}
 				}
-			default:
-				if !yield(ident, val) {
-					return
-				}
+			}
+			if env.shadowed(b) {
+				continue
+			}
+			if !yield(b.ident, b.val) {
+				return
+			}
+		}
+	}
+}
+
+// shadowed returns true if b is shadowed by a declaration in the
+// current module.
+func (env *Env) shadowed(b binding) bool {
+	if env.currentModule == nil || b.seq > env.moduleSeq {
+		return false
+	}
+	_, ok := env.currentModule.Lookup(b.ident)
+	return ok
+}
+
+// Locals returns an iterator that yields the bindings that have been
+// made on top of the kernel, such as by let or by matching a pattern,
+// from most to least recent. Shadowed bindings are not yielded.
+func (env *Env) Locals() iter.Seq2[Ident, any] {
+	return func(yield func(Ident, any) bool) {
+		for b := range env.locals.Since(kernel.Len()) {
+			if env.shadowed(b) {
+				continue
+			}
+			if !yield(b.ident, b.val) {
+				return
 			}
 		}
 	}
@@ -64,6 +415,29 @@ func (env Env) Context() context.Context {
 	return env.ctx
 }
 
+// Self returns the process that env belongs to.
+func (env Env) Self() Process {
+	return env.self
+}
+
+// Stdout returns the Env's standard output. See [WithStdout].
+func (env Env) Stdout() io.Writer {
+	return env.io.stdout
+}
+
+// Stderr returns the Env's standard error. See [WithStderr].
+func (env Env) Stderr() io.Writer {
+	return env.io.stderr
+}
+
+// Stdin returns the Env's standard input. See [WithStdin]. The buffer
+// is shared with Extract code that reads from the Env, so reads
+// should only be done through the returned reader, and not while
+// Extract code might be reading from it concurrently.
+func (env Env) Stdin() *bufio.Reader {
+	return env.io.stdin
+}
+
 // Let returns a copy of env in which ident is bound to val.
 func (env Env) Let(ident Ident, val any) *Env {
 	env.locals = env.locals.Push(ident, val)
@@ -74,10 +448,12 @@ func (env Env) Let(ident Ident, val any) *Env {
 // environment. If ident is not bound to anything, it will return
 // false as the second return value.
 func (env Env) Lookup(ident Ident) (any, bool) {
-	for id, val := range env.All() {
-		if id == ident {
-			return val, true
-		}
+	b, ok := env.locals.Get(ident)
+	if ok && !env.shadowed(b) {
+		return b.val, true
+	}
+	if env.currentModule != nil {
+		return env.currentModule.Lookup(ident)
 	}
 	return nil, false
 }
@@ -93,6 +469,26 @@ func (env *Env) AddModule(name Atom) *Module {
 	return &m
 }
 
+// Modules returns an iterator over the modules declared in the Env,
+// sorted by name.
+func (env *Env) Modules() iter.Seq[*Module] {
+	return func(yield func(*Module) bool) {
+		var modules []*Module
+		env.modules.Range(func(_ Atom, m *Module) bool {
+			modules = append(modules, m)
+			return true
+		})
+		slices.SortFunc(modules, func(m1, m2 *Module) int {
+			return strings.Compare(m1.name.String(), m2.name.String())
+		})
+		for _, m := range modules {
+			if !yield(m) {
+				return
+			}
+		}
+	}
+}
+
 // GetModule finds a declared module with the given name. If no such
 // module has been declared, it returns nil.
 func (env *Env) GetModule(name Atom) *Module {
@@ -100,9 +496,53 @@ func (env *Env) GetModule(name Atom) *Module {
 	return v
 }
 
+// inherit returns a copy of env with the state that is tied to the
+// current execution, rather than to lexical scope, copied from
+// caller. This is used to run the body of a function in the scope
+// that it was defined in while still, for example, using the context
+// and process of the code that called it.
+func (env Env) inherit(caller *Env) *Env {
+	env.ctx = caller.ctx
+	env.self = caller.self
+	env.steps = caller.steps
+	env.depth, env.maxDepth = caller.depth, caller.maxDepth
+	env.stack, env.pos = caller.stack, caller.pos
+	env.hooks = caller.hooks
+	env.tracer, env.traceDepth = caller.tracer, caller.traceDepth
+	env.profile, env.profileFrame = caller.profile, caller.profileFrame
+	env.importing = caller.importing
+	return &env
+}
+
+// Trace returns a trace of the function calls that are in progress.
+func (env *Env) Trace() Trace {
+	return env.stack.Trace()
+}
+
+func (env Env) at(pos scanner.Position) *Env {
+	env.pos = pos
+	return &env
+}
+
+// step counts an evaluation step, returning an error if doing so
+// exceeds the Env's step limit or if the Env's context has been
+// canceled.
+func (env *Env) step() error {
+	select {
+	case <-env.ctx.Done():
+		return &CancelledError{Cause: context.Cause(env.ctx)}
+	default:
+	}
+
+	if env.steps == nil {
+		return nil
+	}
+	return env.steps.step()
+}
+
 func (env Env) withCurrentModule(m *Module) *Env {
 	env.currentModule = m
-	env.locals = env.locals.Push(moduleIdent, nil)
+	env.moduleSeq = env.locals.Len()
 	return &env
 }
 
@@ -111,8 +551,25 @@ func (env Env) withCurrentModule(m *Module) *Env {
 // identified by an atom and are global to a [Env] once they are
 // declared.
 type Module struct {
-	name  Atom
+	name Atom
+
+	// mu guards the rest of the fields, as processes spawned from an
+	// Env share its modules and can use one while it is still being
+	// declared.
+	mu    sync.RWMutex
 	decls map[Ident]any
+
+	doc     string
+	docs    map[Ident]string
+	pending string
+}
+
+// NewModule returns a new module with the given name containing
+// decls. The module can be made available to Extract code with
+// [WithModules]. The decls map must not be modified after being
+// passed to NewModule.
+func NewModule(name Atom, decls map[Ident]any) *Module {
+	return &Module{name: name, decls: decls}
 }
 
 // Name returns the name of the module.
@@ -125,31 +582,72 @@ func (m *Module) Name() Atom {
 // declared in the module, it returns false as the second return
 // value.
 func (m *Module) Lookup(ident Ident) (any, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	v, ok := m.decls[ident]
 	return v, ok
 }
 
-type localList struct {
-	ident Ident
-	val   any
-	next  *localList
+// All returns an iterator over the declarations in the module, sorted
+// by identifier.
+func (m *Module) All() iter.Seq2[Ident, any] {
+	return func(yield func(Ident, any) bool) {
+		m.mu.RLock()
+		decls := maps.Clone(m.decls)
+		m.mu.RUnlock()
+
+		idents := slices.SortedFunc(maps.Keys(decls), func(i1, i2 Ident) int {
+			return strings.Compare(i1.String(), i2.String())
+		})
+		for _, ident := range idents {
+			if !yield(ident, decls[ident]) {
+				return
+			}
+		}
+	}
+}
+
+// Doc returns the documentation attached to the module with
+// moduledoc, if any.
+func (m *Module) Doc() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.doc
 }
 
-func (ll *localList) Push(ident Ident, val any) *localList {
-	return &localList{
-		ident: ident,
-		val:   val,
-		next:  ll,
+// FuncDoc returns the documentation attached with doc to the
+// declaration with the given identifier. If the declaration has no
+// documentation, it returns false as the second return value.
+func (m *Module) FuncDoc(ident Ident) (string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	doc, ok := m.docs[ident]
+	return doc, ok
+}
+
+// setDoc attaches the pending documentation, if there is any, to the
+// declaration with the given identifier. It must be called with m.mu
+// held.
+func (m *Module) setDoc(ident Ident) {
+	if m.pending == "" {
+		return
+	}
+	if m.docs == nil {
+		m.docs = make(map[Ident]string)
 	}
+	m.docs[ident] = m.pending
+	m.pending = ""
 }
 
-func (ll *localList) All() iter.Seq2[Ident, any] {
-	return func(yield func(Ident, any) bool) {
-		for ll != nil {
-			if !yield(ll.ident, ll.val) {
-				return
-			}
-			ll = ll.next
-		}
+// steps counts evaluation steps. See [WithStepLimit].
+type steps struct {
+	limit int64
+	used  atomic.Int64
+}
+
+func (s *steps) step() error {
+	if s.used.Add(1) > s.limit {
+		return &LimitExceededError{Limit: s.limit}
 	}
+	return nil
 }
@@ -0,0 +1,296 @@
+package extract_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"deedles.dev/extract"
+	"deedles.dev/extract/parser"
+)
+
+func TestEnvLookup(t *testing.T) {
+	env := extract.New(context.Background())
+	for i := range 10000 {
+		env = env.Let(extract.MakeIdent("v"+strconv.Itoa(i%5000)), i)
+	}
+	shadowed := env.Let(extract.MakeIdent("v10"), "shadowed")
+
+	for i := range 5000 {
+		v, ok := env.Lookup(extract.MakeIdent("v" + strconv.Itoa(i)))
+		if !ok || v != i+5000 {
+			t.Fatalf("v%v: %v", i, v)
+		}
+	}
+	if v, _ := env.Lookup(extract.MakeIdent("v10")); v != 5010 {
+		t.Fatal(v)
+	}
+	if v, _ := shadowed.Lookup(extract.MakeIdent("v10")); v != "shadowed" {
+		t.Fatal(v)
+	}
+	if _, ok := env.Lookup(extract.MakeIdent("v5000")); ok {
+		t.Fatal("v5000 should not be bound")
+	}
+
+	seen := make(map[extract.Ident]struct{})
+	for ident := range shadowed.All() {
+		if _, ok := seen[ident]; ok {
+			t.Fatalf("%v yielded twice", ident)
+		}
+		seen[ident] = struct{}{}
+	}
+}
+
+func TestSandbox(t *testing.T) {
+	custom := extract.NewModule(extract.MakeAtom("Custom"), map[extract.Ident]any{
+		extract.MakeIdent("answer"): int64(42),
+	})
+
+	tests := []struct {
+		name    string
+		opts    []extract.Option
+		allowed []string
+		denied  []string
+	}{
+		{"Default", nil, []string{"String", "Task"}, []string{"Custom"}},
+		{"WithoutStd", []extract.Option{extract.WithoutStd(), extract.WithModules(custom)}, []string{"Custom"}, []string{"String", "Task"}},
+		{"WithModulesFirst", []extract.Option{extract.WithModules(custom), extract.WithoutStd()}, []string{"Custom"}, []string{"String"}},
+		{"DenyModule", []extract.Option{extract.DenyModule(extract.MakeAtom("Task"))}, []string{"String"}, []string{"Task"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			env := extract.New(context.Background(), test.opts...)
+			for _, name := range test.allowed {
+				if env.GetModule(extract.MakeAtom(name)) == nil {
+					t.Fatalf("%v should be allowed", name)
+				}
+			}
+			for _, name := range test.denied {
+				if env.GetModule(extract.MakeAtom(name)) != nil {
+					t.Fatalf("%v should be denied", name)
+				}
+			}
+		})
+	}
+}
+
+func TestStepLimit(t *testing.T) {
+	script, err := parser.ParseString(t.Name(), `
+	(defmodule Spin
+		(def (forever n) (forever (n + 1)))
+	)
+	(Spin.forever 0)
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env := extract.New(context.Background(), extract.WithStepLimit(10000))
+	_, result := extract.Run(env, script.All())
+	var limit *extract.LimitExceededError
+	if !errors.As(result.(error), &limit) || limit.Limit != 10000 {
+		t.Fatalf("%#v", result)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	script, err := parser.ParseString(t.Name(), `
+	(loop (0)
+		((n) (recur (n + 1)))
+	)
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	env := extract.New(ctx)
+	_, result := extract.Run(env, script.All())
+	var cerr *extract.CancelledError
+	if !errors.As(result.(error), &cerr) || !errors.Is(cerr, context.DeadlineExceeded) {
+		t.Fatalf("%#v", result)
+	}
+}
+
+func TestMaxDepth(t *testing.T) {
+	script, err := parser.ParseString(t.Name(), `
+	(defmodule Deep
+		(def (down n) (1 + (down (n + 1))))
+	)
+	(Deep.down 0)
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env := extract.New(context.Background(), extract.WithMaxDepth(100))
+	_, result := extract.Run(env, script.All())
+	var overflow *extract.StackOverflowError
+	if !errors.As(result.(error), &overflow) || overflow.Depth != 100 {
+		t.Fatalf("%#v", result)
+	}
+
+	env = extract.New(context.Background())
+	_, result = extract.Run(env, script.All())
+	if !errors.As(result.(error), &overflow) || overflow.Depth != extract.DefaultMaxDepth {
+		t.Fatalf("%#v", result)
+	}
+}
+
+func TestHooks(t *testing.T) {
+	script, err := parser.ParseString(t.Name(), `(add 1 (mul 2 3))`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var depth int
+	var calls []string
+	env := extract.New(context.Background(), extract.WithHooks(extract.Hooks{
+		Before: func(env *extract.Env, expr any, args *extract.List) {
+			depth++
+		},
+		After: func(env *extract.Env, expr any, args *extract.List, result any) {
+			depth--
+			if ident, ok := expr.(extract.Ident); ok && args != nil {
+				calls = append(calls, fmt.Sprintf("%v = %v", ident, result))
+			}
+		},
+	}))
+	_, result := extract.Run(env, script.All())
+	if result != int64(7) {
+		t.Fatalf("%#v", result)
+	}
+
+	if depth != 0 {
+		t.Fatal(depth)
+	}
+	if !slices.Equal(calls, []string{"mul = 6", "add = 7"}) {
+		t.Fatal(calls)
+	}
+}
+
+func TestHooksEnter(t *testing.T) {
+	script, err := parser.ParseString(t.Name(), `
+	(let y 1)
+	(let f (func (f x) (add x y)))
+	(f 2)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var entered []string
+	env := extract.New(context.Background(), extract.WithHooks(extract.Hooks{
+		Enter: func(env *extract.Env, f *extract.Func, args *extract.List) {
+			var locals []string
+			for ident, val := range env.Locals() {
+				locals = append(locals, fmt.Sprintf("%v=%v", ident, extract.Inspect(val)))
+			}
+			entered = append(entered, fmt.Sprintf("%v %v %v", f.Name(), args, locals))
+		},
+	}))
+	_, result := extract.Run(env, script.All())
+	if result != int64(3) {
+		t.Fatalf("%#v", result)
+	}
+
+	if !slices.Equal(entered, []string{"f [2] [x=2 f=#Func<f> y=1]"}) {
+		t.Fatalf("%q", entered)
+	}
+}
+
+func TestRuntimePanic(t *testing.T) {
+	bad := extract.NewModule(extract.MakeAtom("Bad"), map[extract.Ident]any{
+		extract.MakeIdent("boom"): extract.EvalFunc(func(env *extract.Env, args *extract.List) (*extract.Env, any) {
+			var list []int
+			return env, list[args.Len()]
+		}),
+	})
+
+	script, err := parser.ParseString(t.Name(), `(add 1 (Bad.boom))`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env := extract.New(context.Background(), extract.WithModules(bad))
+	_, result := extract.Run(env, script.All())
+	var perr *extract.RuntimePanicError
+	if !errors.As(result.(error), &perr) || len(perr.Stack) == 0 {
+		t.Fatalf("%#v", result)
+	}
+	var rerr runtime.Error
+	if !errors.As(perr, &rerr) {
+		t.Fatalf("%#v", perr.Val)
+	}
+}
+
+func TestCircularBinding(t *testing.T) {
+	a, b, c := extract.MakeIdent("a"), extract.MakeIdent("b"), extract.MakeIdent("c")
+
+	tests := []struct {
+		name  string
+		env   *extract.Env
+		chain []extract.Ident
+	}{
+		{"Self", extract.New(context.Background()).Let(a, a), []extract.Ident{a, a}},
+		{"Pair", extract.New(context.Background()).Let(a, b).Let(b, a), []extract.Ident{a, b, a}},
+		{"Tail", extract.New(context.Background()).Let(a, b).Let(b, c).Let(c, b), []extract.Ident{a, b, c, b}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, result := extract.Eval(test.env, a, nil)
+			var cerr *extract.CircularBindingError
+			if !errors.As(result.(error), &cerr) || !slices.Equal(cerr.Chain, test.chain) {
+				t.Fatalf("%#v", result)
+			}
+		})
+	}
+
+	env := extract.New(context.Background()).Let(a, b).Let(b, int64(3))
+	if _, result := extract.Eval(env, a, nil); result != int64(3) {
+		t.Fatalf("%#v", result)
+	}
+}
+
+func TestStreams(t *testing.T) {
+	m := extract.NewModule(extract.MakeAtom("Test"), make(map[extract.Ident]any))
+	m.RegisterFunc("echo", func(env *extract.Env) error {
+		line, err := env.Stdin().ReadString('\n')
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(env.Stdout(), line)
+		fmt.Fprint(env.Stderr(), len(line))
+		return nil
+	})
+
+	s, err := parser.ParseString(t.Name(), `(Test.echo)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr strings.Builder
+	env := extract.New(
+		context.Background(),
+		extract.WithModules(m),
+		extract.WithStdout(&stdout),
+		extract.WithStderr(&stderr),
+		extract.WithStdin(strings.NewReader("test\n")),
+	)
+	_, r := extract.Run(env, s.All())
+	if err, ok := r.(error); ok {
+		t.Fatal(err)
+	}
+	if stdout.String() != "test\n" || stderr.String() != "5" {
+		t.Fatalf("%q %q", stdout.String(), stderr.String())
+	}
+}
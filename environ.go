@@ -0,0 +1,238 @@
+package extract
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"deedles.dev/xsync"
+)
+
+// ErrEnvVarNotSet is wrapped by an [EnvVarError] when a required
+// environment variable is not set.
+var ErrEnvVarNotSet = errors.New("not set")
+
+// EnvVarError is returned when an environment variable that is
+// required is not set or can't be converted to the requested type.
+type EnvVarError struct {
+	Name string
+	Err  error
+}
+
+func (err *EnvVarError) Error() string {
+	return fmt.Sprintf("environment variable %q: %v", err.Name, err.Err)
+}
+
+func (err *EnvVarError) Unwrap() error {
+	return err.Err
+}
+
+func (err *EnvVarError) ErrorCode() Atom {
+	return codeEnvVar
+}
+
+// environ holds the environment variables visible to an Env. Variables
+// loaded by Env.load_dotenv are kept separately from those of the host
+// process so that loading them doesn't modify the environment of the
+// whole program.
+type environ struct {
+	noHost bool
+	vars   xsync.Map[string, string]
+}
+
+// lookup returns the value of the environment variable name. Variables
+// of the host process take precedence over loaded ones.
+func (e *environ) lookup(name string) (string, bool) {
+	if !e.noHost {
+		if v, ok := os.LookupEnv(name); ok {
+			return v, true
+		}
+	}
+	return e.vars.Load(name)
+}
+
+// load sets the variables in vars that aren't already set and returns
+// the ones that it set.
+func (e *environ) load(vars map[string]string) (loaded *Map) {
+	for k, v := range vars {
+		if _, ok := e.lookup(k); ok {
+			continue
+		}
+		if _, ok := e.vars.LoadOrStore(k, v); !ok {
+			loaded = loaded.Put(k, v)
+		}
+	}
+	return loaded
+}
+
+// parseDotenv parses the contents of a .env file. Each line is of the
+// form KEY=value, optionally preceded by export. Blank lines and lines
+// starting with # are ignored. Values may be surrounded by double
+// quotes, in which case \n, \t, \", and \\ escapes are interpreted, or
+// by single quotes, in which case they are used literally. Unquoted
+// values are trimmed of whitespace and of any comment that follows a
+// # preceded by whitespace.
+func parseDotenv(data string) (map[string]string, error) {
+	vars := make(map[string]string)
+	s := bufio.NewScanner(strings.NewReader(data))
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, val, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("line %v: invalid assignment", n)
+		}
+
+		val, err := dotenvValue(strings.TrimSpace(val))
+		if err != nil {
+			return nil, fmt.Errorf("line %v: %w", n, err)
+		}
+		vars[key] = val
+	}
+	return vars, s.Err()
+}
+
+func dotenvValue(val string) (string, error) {
+	if val == "" {
+		return "", nil
+	}
+
+	switch q := val[0]; q {
+	case '"', '\'':
+		end := strings.LastIndexByte(val, q)
+		if end == 0 {
+			return "", errors.New("unterminated quoted value")
+		}
+		if rest := strings.TrimSpace(val[end+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+			return "", errors.New("unexpected text after quoted value")
+		}
+		val = val[1:end]
+		if q == '\'' {
+			return val, nil
+		}
+		return strings.NewReplacer(`\n`, "\n", `\t`, "\t", `\"`, `"`, `\\`, `\`).Replace(val), nil
+
+	default:
+		if i := strings.Index(val, " #"); i >= 0 {
+			val = val[:i]
+		}
+		if i := strings.Index(val, "\t#"); i >= 0 {
+			val = val[:i]
+		}
+		return strings.TrimSpace(val), nil
+	}
+}
+
+// evalEnvVarArgs evaluates the name of an environment variable
+// followed by an optional default value. It returns the value of the
+// variable if it is set.
+func evalEnvVarArgs(env *Env, args *List) (name, val string, ok bool, def any, err error) {
+	if args.Len() != 1 && args.Len() != 2 {
+		return "", "", false, nil, &ArgumentNumError{Num: args.Len(), Expected: -1}
+	}
+
+	vals, err := evalArgs(env, args)
+	if err != nil {
+		return "", "", false, nil, err
+	}
+	name, isStr := vals[0].(string)
+	if !isStr {
+		return "", "", false, nil, NewTypeError(vals[0], reflect.TypeFor[string]())
+	}
+	if len(vals) == 2 {
+		def = vals[1]
+	}
+
+	val, ok = env.environ.lookup(name)
+	return name, val, ok, def, nil
+}
+
+// envGetter returns a function for the Env module that gets the value
+// of an environment variable and converts it with parse. If the
+// variable is not set, the function returns the default value that it
+// was given, or nil if none was given.
+func envGetter[T any](parse func(string) (T, error)) EvalFunc {
+	return func(env *Env, args *List) (*Env, any) {
+		name, val, ok, def, err := evalEnvVarArgs(env, args)
+		if err != nil {
+			return env, err
+		}
+		if !ok {
+			return env, def
+		}
+
+		v, err := parse(val)
+		if err != nil {
+			return env, &EnvVarError{Name: name, Err: err}
+		}
+		return env, v
+	}
+}
+
+func stdEnv() *Module {
+	m := Module{name: MakeAtom("Env")}
+	m.decls = map[Ident]any{
+		MakeIdent("get"): envGetter(func(val string) (string, error) {
+			return val, nil
+		}),
+		MakeIdent("get_int"): envGetter(func(val string) (int64, error) {
+			return strconv.ParseInt(strings.TrimSpace(val), 10, 64)
+		}),
+		MakeIdent("get_float"): envGetter(func(val string) (float64, error) {
+			return strconv.ParseFloat(strings.TrimSpace(val), 64)
+		}),
+		MakeIdent("get_bool"): envGetter(func(val string) (bool, error) {
+			return strconv.ParseBool(strings.TrimSpace(val))
+		}),
+		MakeIdent("fetch!"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			strs, err := evalStrings(env, args, 1, 1)
+			if err != nil {
+				return env, err
+			}
+
+			val, ok := env.environ.lookup(strs[0])
+			if !ok {
+				return env, &EnvVarError{Name: strs[0], Err: ErrEnvVarNotSet}
+			}
+			return env, val
+		}),
+		MakeIdent("load_dotenv"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() > 1 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: -1}
+			}
+			if err := env.checkFS(); err != nil {
+				return env, err
+			}
+
+			path := ".env"
+			if args.Len() == 1 {
+				strs, err := evalStrings(env, args, 1, 1)
+				if err != nil {
+					return env, err
+				}
+				path = strs[0]
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return env, errorResult(fsErrorReason(err))
+			}
+			vars, err := parseDotenv(string(data))
+			if err != nil {
+				return env, errorResult(err.Error())
+			}
+			return env, okResult(env.environ.load(vars))
+		}),
+	}
+
+	return &m
+}
@@ -0,0 +1,98 @@
+package extract_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"deedles.dev/extract"
+)
+
+func TestEnv(t *testing.T) {
+	t.Setenv("EXTRACT_TEST_PORT", "8080")
+	t.Setenv("EXTRACT_TEST_DEBUG", "true")
+	t.Setenv("EXTRACT_TEST_BAD", "nope")
+
+	const src = `
+	(list
+		(Env.get "EXTRACT_TEST_PORT")
+		(Env.get_int "EXTRACT_TEST_PORT")
+		(Env.get_bool "EXTRACT_TEST_DEBUG")
+		(Env.get_float "EXTRACT_TEST_PORT")
+		(Env.get "EXTRACT_TEST_MISSING")
+		(Env.get_int "EXTRACT_TEST_MISSING" 3)
+		(Env.fetch! "EXTRACT_TEST_PORT")
+	)
+	`
+	result := runScript(t, src, true)
+	checkList(t, result, "8080", int64(8080), true, 8080.0, nil, int64(3), "8080")
+
+	result = runScript(t, `(Env.get_int "EXTRACT_TEST_BAD")`, false)
+	var verr *extract.EnvVarError
+	if err, _ := result.(error); !errors.As(err, &verr) || verr.Name != "EXTRACT_TEST_BAD" {
+		t.Fatalf("%#v", result)
+	}
+	result = runScript(t, `(Env.fetch! "EXTRACT_TEST_MISSING")`, false)
+	if err, _ := result.(error); !errors.Is(err, extract.ErrEnvVarNotSet) {
+		t.Fatalf("%#v", result)
+	}
+}
+
+func TestEnvDotenv(t *testing.T) {
+	t.Setenv("EXTRACT_TEST_HOST", "host")
+
+	const src = `
+	(File.write (Path.join dir ".env") """
+		# A comment.
+		export EXTRACT_TEST_A=one # trailing comment
+		EXTRACT_TEST_B = "two\\nlines"
+		EXTRACT_TEST_C='#literal\\n'
+		EXTRACT_TEST_EMPTY=
+		EXTRACT_TEST_HOST=dotenv
+		""")
+	(File.write (Path.join dir "bad.env") "not an assignment")
+	(list
+		(Env.load_dotenv (Path.join dir ".env"))
+		(Env.get "EXTRACT_TEST_A")
+		(Env.get "EXTRACT_TEST_B")
+		(Env.get "EXTRACT_TEST_C")
+		(Env.get "EXTRACT_TEST_EMPTY")
+		(Env.get "EXTRACT_TEST_HOST")
+		(Env.load_dotenv (Path.join dir "missing"))
+		(Tuple.elem (Env.load_dotenv (Path.join dir "bad.env")) 0)
+	)
+	`
+	_, result := runInDir(t, src)
+	if err, ok := result.(error); ok {
+		t.Fatal(err)
+	}
+
+	ok, errAtom := extract.MakeAtom("ok"), extract.MakeAtom("error")
+	checkList(t, result,
+		extract.TupleOf(ok, extract.MapOf(
+			"EXTRACT_TEST_A", "one",
+			"EXTRACT_TEST_B", "two\nlines",
+			"EXTRACT_TEST_C", `#literal\n`,
+			"EXTRACT_TEST_EMPTY", "",
+		)),
+		"one",
+		"two\nlines",
+		`#literal\n`,
+		"",
+		"host",
+		extract.TupleOf(errAtom, extract.MakeAtom("enoent")),
+		errAtom,
+	)
+	if _, ok := os.LookupEnv("EXTRACT_TEST_A"); ok {
+		t.Fatal("dotenv modified process environment")
+	}
+}
+
+func TestWithoutHostEnvironment(t *testing.T) {
+	t.Setenv("EXTRACT_TEST_HOST", "host")
+
+	_, result := runInDir(t, `(Env.get "EXTRACT_TEST_HOST" :hidden)`, extract.WithoutHostEnvironment())
+	if result != extract.MakeAtom("hidden") {
+		t.Fatalf("%#v", result)
+	}
+}
@@ -0,0 +1,121 @@
+package extract
+
+import "errors"
+
+var (
+	// ErrArgumentNum is matched by every [ArgumentNumError] with
+	// [errors.Is].
+	ErrArgumentNum = errors.New("incorrect number of arguments")
+
+	// ErrType is matched by every [TypeError] with [errors.Is].
+	ErrType = errors.New("incorrect type")
+
+	// ErrUnbound is matched by every [NameError] with [errors.Is].
+	ErrUnbound = errors.New("identifier is not bound")
+)
+
+// ErrorCoder is implemented by errors that belong to a category that
+// is identified by an atom, such as :type for a [TypeError]. Errors
+// defined by embedders can implement it to give scripts a way to tell
+// them apart. See [ErrorCode].
+type ErrorCoder interface {
+	ErrorCode() Atom
+}
+
+// The codes of the errors defined by this package.
+var (
+	codeError              = MakeAtom("error")
+	codeArgumentNum        = MakeAtom("argument_num")
+	codeType               = MakeAtom("type")
+	codeUnbound            = MakeAtom("unbound")
+	codeNoMatch            = MakeAtom("no_match")
+	codeCircularBinding    = MakeAtom("circular_binding")
+	codeUndefinedModule    = MakeAtom("undefined_module")
+	codeIndex              = MakeAtom("index")
+	codeLimitExceeded      = MakeAtom("limit_exceeded")
+	codeStackOverflow      = MakeAtom("stack_overflow")
+	codePanic              = MakeAtom("panic")
+	codeCanceled           = MakeAtom("canceled")
+	codeAssertion          = MakeAtom("assertion")
+	codeImportCycle        = MakeAtom("import_cycle")
+	codeEnvVar             = MakeAtom("env_var")
+	codeTimeout            = MakeAtom("timeout")
+	codeFileSystemDisabled = MakeAtom("filesystem_disabled")
+	codeNetworkDisabled    = MakeAtom("network_disabled")
+)
+
+// sentinelCodes are the codes of the sentinel errors defined by this
+// package that aren't only returned wrapped in an ErrorCoder.
+var sentinelCodes = []struct {
+	err  error
+	code Atom
+}{
+	{ErrPatternMatch, codeNoMatch},
+	{ErrTimeout, codeTimeout},
+	{ErrFileSystemDisabled, codeFileSystemDisabled},
+	{ErrNetworkDisabled, codeNetworkDisabled},
+}
+
+// ErrorCode returns the code of the category that err belongs to. It
+// is the code of the first error in err's tree that implements
+// [ErrorCoder], such as :type for a [TypeError], or :error if there
+// isn't one. A failed pattern match is :no_match, [ErrTimeout] is
+// :timeout, and [ErrFileSystemDisabled] and [ErrNetworkDisabled] are
+// :filesystem_disabled and :network_disabled.
+//
+// Scripts can get the code of an error with Error.code, which
+// evaluates its argument and returns its code if it is an error, or
+// nil otherwise, such as
+//
+//	(Error.code (add 1 :a)) # :type
+func ErrorCode(err error) Atom {
+	var coder ErrorCoder
+	if errors.As(err, &coder) {
+		return coder.ErrorCode()
+	}
+	for _, s := range sentinelCodes {
+		if errors.Is(err, s.err) {
+			return s.code
+		}
+	}
+	return codeError
+}
+
+// evalError evaluates the only argument in args and returns it if it
+// is an error, without propagating it like other functions do.
+func evalError(env *Env, args *List) (err error, ok bool, argErr error) {
+	if args.Len() != 1 {
+		return nil, false, &ArgumentNumError{Num: args.Len(), Expected: 1}
+	}
+	_, v := Eval(env, args.Head(), nil)
+	err, ok = v.(error)
+	return err, ok, nil
+}
+
+func stdError() *Module {
+	m := Module{name: MakeAtom("Error")}
+	m.decls = map[Ident]any{
+		MakeIdent("code"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			err, ok, argErr := evalError(env, args)
+			if argErr != nil {
+				return env, argErr
+			}
+			if !ok {
+				return env, nil
+			}
+			return env, ErrorCode(err)
+		}),
+		MakeIdent("message"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			err, ok, argErr := evalError(env, args)
+			if argErr != nil {
+				return env, argErr
+			}
+			if !ok {
+				return env, nil
+			}
+			return env, err.Error()
+		}),
+	}
+
+	return &m
+}
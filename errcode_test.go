@@ -0,0 +1,57 @@
+package extract_test
+
+import (
+	"errors"
+	"testing"
+
+	"deedles.dev/extract"
+)
+
+func TestErrorsIs(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		err  error
+		code string
+	}{
+		{"Type", `(add 1 :a)`, extract.ErrType, "type"},
+		{"ArgumentNum", `(add 1)`, extract.ErrArgumentNum, "argument_num"},
+		{"Unbound", `(add 1 missing)`, extract.ErrUnbound, "unbound"},
+		{"NoMatch", `(defmodule Test (def (f 1) 1)) (Test.f 2)`, extract.ErrPatternMatch, "no_match"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err, ok := runScript(t, test.src, false).(error)
+			if !ok {
+				t.Fatal("no error")
+			}
+			if !errors.Is(err, test.err) {
+				t.Fatalf("%#v is not %v", err, test.err)
+			}
+			if code := extract.ErrorCode(err); code != extract.MakeAtom(test.code) {
+				t.Fatalf("code %v, expected :%v", code, test.code)
+			}
+		})
+	}
+
+	if code := extract.ErrorCode(errors.New("test")); code != extract.MakeAtom("error") {
+		t.Fatalf("code %v", code)
+	}
+}
+
+func TestErrorModule(t *testing.T) {
+	result := runScript(t, `[
+		(Error.code (add 1 :a))
+		(Error.code missing)
+		(Error.code 5)
+		(Error.message (add 1))
+		(Error.message "str")
+	]`, true)
+	checkList(t, result,
+		extract.MakeAtom("type"),
+		extract.MakeAtom("unbound"),
+		nil,
+		"incorrect number of arguments 1",
+		nil,
+	)
+}
@@ -0,0 +1,287 @@
+// Package errfmt prints errors from parsing and running Extract code
+// along with the line of source code that they refer to, with the
+// offending expression underlined, such as
+//
+//	incorrect type extract.Atom, expected one of [int64 float64]
+//		at double (script.ext:3:16)
+//	   2 |   (def (double x) (add x :a))
+//	     |                   ^^^^^^^^^^
+//
+// Syntax errors refer to the token where parsing failed. Runtime
+// errors refer to the call that first returned them, as recorded in
+// [extract.TracedError.Pos], falling back to the innermost function
+// call in their [extract.Trace], or, for failed assertions, to the
+// assertion. Errors without a position,
+// or with one in a different file than the source code that was
+// provided, are printed without the source code.
+package errfmt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"deedles.dev/extract"
+	"deedles.dev/extract/parser"
+	"deedles.dev/extract/scanner"
+)
+
+// ANSI escape sequences used when color is enabled.
+const (
+	colorReset   = "\x1b[0m"
+	colorMessage = "\x1b[1m"
+	colorGutter  = "\x1b[34m"
+	colorMarker  = "\x1b[1;31m"
+)
+
+// Option is an option that can be passed to [Fprint] and [Sprint].
+type Option func(*printer)
+
+// WithColor highlights the error message and the underline with ANSI
+// escape sequences, for output to a terminal.
+func WithColor(color bool) Option {
+	return func(p *printer) {
+		p.color = color
+	}
+}
+
+// Position returns the position in the source code that err refers
+// to, if it has one. See the package documentation for which errors
+// have positions.
+func Position(err error) (scanner.Position, bool) {
+	loc, ok := locate(err)
+	return loc.pos, ok
+}
+
+// location is where an error occurred. If call is not zero, the
+// position is that of a call to a function with that name.
+type location struct {
+	pos  scanner.Position
+	call extract.Ident
+}
+
+func locate(err error) (location, bool) {
+	var (
+		assertErr *extract.AssertionError
+		tokenErr  *parser.UnexpectedTokenError
+		operErr   *parser.OperatorError
+		depthErr  *parser.DepthError
+		runeErr   *scanner.UnexpectedRuneError
+		scanErr   *scanner.TokenError
+		tracedErr *extract.TracedError
+	)
+
+	switch {
+	case errors.As(err, &assertErr) && known(assertErr.Pos):
+		return location{pos: assertErr.Pos}, true
+	case errors.As(err, &tokenErr):
+		return location{pos: tokenErr.Position}, true
+	case errors.As(err, &operErr):
+		return location{pos: operErr.Position}, true
+	case errors.As(err, &depthErr):
+		return location{pos: depthErr.Position}, true
+	case errors.As(err, &runeErr):
+		return location{pos: runeErr.Position}, true
+	case errors.As(err, &scanErr):
+		return location{pos: scanErr.Position}, true
+	case errors.As(err, &tracedErr):
+		if known(tracedErr.Pos) {
+			return location{pos: tracedErr.Pos}, true
+		}
+		for _, f := range tracedErr.Trace {
+			if known(f.Pos) {
+				return location{pos: f.Pos, call: f.Func}, true
+			}
+		}
+	}
+	return location{}, false
+}
+
+// known returns true if pos is a position in a file rather than the
+// zero value.
+func known(pos scanner.Position) bool {
+	return pos.Line > 0 && pos.Col > 0
+}
+
+type printer struct {
+	color bool
+}
+
+// Fprint writes err to w, formatted with the %+v verb so that a trace
+// is included, followed by the line of src that it refers to if err
+// has a position in the file filename. The underline covers the
+// token at the position, or the whole expression if the token opens a
+// list, up to the end of the line.
+func Fprint(w io.Writer, err error, filename string, src []byte, opts ...Option) error {
+	_, werr := io.WriteString(w, Sprint(err, filename, src, opts...))
+	return werr
+}
+
+// Sprint is like [Fprint] but returns the formatted error as a
+// string.
+func Sprint(err error, filename string, src []byte, opts ...Option) string {
+	var p printer
+	for _, opt := range opts {
+		opt(&p)
+	}
+
+	var sb strings.Builder
+	msg := fmt.Sprintf("%+v", err)
+	first, rest, _ := strings.Cut(msg, "\n")
+	p.style(&sb, colorMessage, first)
+	sb.WriteByte('\n')
+	if rest != "" {
+		sb.WriteString(rest)
+		sb.WriteByte('\n')
+	}
+
+	loc, ok := locate(err)
+	if !ok || loc.pos.Filename != filename {
+		return sb.String()
+	}
+	p.annotate(&sb, loc, src)
+	return sb.String()
+}
+
+func (p *printer) style(sb *strings.Builder, color, str string) {
+	if !p.color {
+		sb.WriteString(str)
+		return
+	}
+	sb.WriteString(color)
+	sb.WriteString(str)
+	sb.WriteString(colorReset)
+}
+
+// annotate writes the line of src at loc with the expression there
+// underlined. It writes nothing if the source code doesn't match loc,
+// such as if it has been modified since it was run.
+func (p *printer) annotate(sb *strings.Builder, loc location, src []byte) {
+	line, ok := sourceLine(src, loc.pos.Line)
+	if !ok {
+		return
+	}
+	start, ok := runeOffset(line, loc.pos.Col)
+	if !ok {
+		return
+	}
+	width, ok := exprWidth(line[start:], loc.call)
+	if !ok {
+		return
+	}
+
+	// Whitespace before the expression is copied so that tabs line up.
+	var pad strings.Builder
+	for _, c := range string(line[:start]) {
+		if c == '\t' {
+			pad.WriteByte('\t')
+			continue
+		}
+		pad.WriteByte(' ')
+	}
+
+	num := strconv.Itoa(loc.pos.Line)
+	p.style(sb, colorGutter, fmt.Sprintf("%*v |", len(num)+3, num))
+	sb.WriteByte(' ')
+	sb.Write(line)
+	sb.WriteByte('\n')
+	p.style(sb, colorGutter, fmt.Sprintf("%*v |", len(num)+3, ""))
+	sb.WriteByte(' ')
+	sb.WriteString(pad.String())
+	p.style(sb, colorMarker, strings.Repeat("^", width))
+	sb.WriteByte('\n')
+}
+
+// sourceLine returns the nth line of src, starting at 1, without its
+// line break.
+func sourceLine(src []byte, n int) ([]byte, bool) {
+	for i := 1; ; i++ {
+		line, rest, found := bytes.Cut(src, []byte{'\n'})
+		if i == n {
+			return bytes.TrimSuffix(line, []byte{'\r'}), true
+		}
+		if !found {
+			return nil, false
+		}
+		src = rest
+	}
+}
+
+// runeOffset returns the byte offset of the rune in line at col,
+// starting at 1. A column just past the end of the line is allowed, as
+// that is where errors about the end of the input are reported.
+func runeOffset(line []byte, col int) (int, bool) {
+	off := 0
+	for range col - 1 {
+		if off >= len(line) {
+			return 0, false
+		}
+		_, size := utf8.DecodeRune(line[off:])
+		off += size
+	}
+	return off, true
+}
+
+// exprWidth returns the width in runes of the underline for the
+// expression at the start of line. If call is not zero, the expression
+// must be a call to a function with that name.
+func exprWidth(line []byte, call extract.Ident) (int, bool) {
+	if len(line) == 0 {
+		return 1, call == extract.Ident{}
+	}
+
+	s := scanner.New(bytes.NewReader(line))
+	if !s.Scan() {
+		return 1, call == extract.Ident{}
+	}
+	var end, depth int
+	for i := 0; ; i++ {
+		tok := s.Token()
+		switch tok.Val.(type) {
+		case scanner.Lparen, scanner.Lbracket:
+			depth++
+		case scanner.Rparen, scanner.Rbracket:
+			depth--
+		}
+		if i == 0 && call != (extract.Ident{}) {
+			if _, ok := tok.Val.(scanner.Lparen); !ok || !callsName(s, call) {
+				return 0, false
+			}
+		}
+
+		end = tok.End
+		if depth <= 0 || !s.Scan() {
+			break
+		}
+	}
+	return max(utf8.RuneCount(line[:end]), 1), true
+}
+
+// callsName returns true if the next tokens from s are the head of a
+// call to a function named name, such as name or Module.name.
+func callsName(s *scanner.Scanner, name extract.Ident) bool {
+	for {
+		if !s.Scan() {
+			return false
+		}
+		switch val := s.Token().Val.(type) {
+		case scanner.Ident:
+			if tok, ok := s.Peek(); !ok || tok.Val != (scanner.Dot{}) {
+				return string(val) == name.String()
+			}
+		case scanner.Atom:
+		default:
+			return false
+		}
+		if !s.Scan() {
+			return false
+		}
+		if _, ok := s.Token().Val.(scanner.Dot); !ok {
+			return false
+		}
+	}
+}
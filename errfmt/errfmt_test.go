@@ -0,0 +1,125 @@
+package errfmt_test
+
+import (
+	"context"
+	"testing"
+
+	"deedles.dev/extract"
+	"deedles.dev/extract/errfmt"
+	"deedles.dev/extract/parser"
+)
+
+func TestSprint(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		ex   string
+	}{
+		{
+			name: "Runtime",
+			src: `(defmodule Test
+  (def (double x) (add x :a))
+  (def (run x) (double x)))
+
+(Test.run 4)`,
+			ex: `incorrect type extract.Atom, expected one of [int64 float64]
+	at double (test.ext:3:16)
+	at run (test.ext:5:1)
+   2 |   (def (double x) (add x :a))
+     |                   ^^^^^^^^^^
+`,
+		},
+		{
+			name: "Syntax",
+			src:  "(add 1\n\t(sub 2 3)) )",
+			ex: `unexpected token ")" (scanner.Rparen) at test.ext:2:13
+   2 | 	(sub 2 3)) )
+     | 	           ^
+`,
+		},
+		{
+			name: "Assertion",
+			src:  "(Testing.assert_equal\n  (add 1 1)\n  3)",
+			ex: `assertion failed (test.ext:1:1): expected 3, got 2
+   1 | (Testing.assert_equal
+     | ^^^^^^^^^^^^^^^^^^^^^
+`,
+		},
+		{
+			name: "TopLevel",
+			src:  "(inspect 1)\n(add 1 :a)",
+			ex: `incorrect type extract.Atom, expected one of [int64 float64]
+   2 | (add 1 :a)
+     | ^^^^^^^^^^
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := run(test.src)
+			if err == nil {
+				t.Fatal("no error")
+			}
+			if str := errfmt.Sprint(err, "test.ext", []byte(test.src)); str != test.ex {
+				t.Fatalf("\n%v\nexpected\n%v", str, test.ex)
+			}
+		})
+	}
+}
+
+func TestSprintMismatch(t *testing.T) {
+	src := "(defmodule Test\n  (def (f x) (add x :a)))\n(Test.f 1)"
+	err := run(src)
+
+	// A different file.
+	str := errfmt.Sprint(err, "other.ext", []byte(src))
+	if str != "incorrect type extract.Atom, expected one of [int64 float64]\n\tat f (test.ext:3:1)\n" {
+		t.Fatal(str)
+	}
+
+	// The same file, but modified since it was run.
+	str = errfmt.Sprint(err, "test.ext", []byte("(f 1)\n\n(g 1)"))
+	if str != "incorrect type extract.Atom, expected one of [int64 float64]\n\tat f (test.ext:3:1)\n" {
+		t.Fatal(str)
+	}
+}
+
+func TestWithColor(t *testing.T) {
+	err := run("(add 1")
+	str := errfmt.Sprint(err, "test.ext", []byte("(add 1"), errfmt.WithColor(true))
+	const ex = "\x1b[1mincomplete input: unexpected EOF\x1b[0m\n"
+	if str != ex {
+		t.Fatalf("%q", str)
+	}
+
+	err = run("(add 1))")
+	str = errfmt.Sprint(err, "test.ext", []byte("(add 1))"), errfmt.WithColor(true))
+	const ex2 = "\x1b[1munexpected token \")\" (scanner.Rparen) at test.ext:1:8\x1b[0m\n" +
+		"\x1b[34m   1 |\x1b[0m (add 1))\n" +
+		"\x1b[34m     |\x1b[0m        \x1b[1;31m^\x1b[0m\n"
+	if str != ex2 {
+		t.Fatalf("%q", str)
+	}
+}
+
+func TestPosition(t *testing.T) {
+	pos, ok := errfmt.Position(run("(add 1 2))"))
+	if !ok || pos.String() != "test.ext:1:10" {
+		t.Fatal(pos, ok)
+	}
+	pos, ok = errfmt.Position(run("(add 1 :a)"))
+	if !ok || pos.String() != "test.ext:1:1" {
+		t.Fatal(pos, ok)
+	}
+}
+
+func run(src string) error {
+	script, err := parser.ParseString("test.ext", src)
+	if err != nil {
+		return err
+	}
+	_, r := extract.Run(extract.New(context.Background()), script.All())
+	err, _ = r.(error)
+	return err
+}
@@ -2,31 +2,108 @@
 package extract
 
 import (
+	"errors"
 	"fmt"
 	"iter"
 	"reflect"
+	"runtime/debug"
+	"slices"
+	"strings"
 	"unique"
+
+	"deedles.dev/extract/scanner"
 )
 
 // Pinned is an identifier that has been pinned. This is used to
 // signal during pattern matching that the value of an identifier
 // should be matched against instead of simply binding the identifier
 // to a new value.
+//
+// If Expr is not nil, the pin is of an arbitrary expression, such as
+// a module reference, instead of an identifier and Ident should be
+// ignored. The expression is evaluated once when the pattern is
+// compiled and the result is matched against.
 type Pinned struct {
 	Ident Ident
+	Expr  any
+}
+
+// String returns the pin as source code.
+func (p Pinned) String() string {
+	return Inspect(p)
 }
 
 // Eval returns an error every time because a Pinned should never
 // actually be used as an expression.
 func (p Pinned) Eval(env *Env, args *List) (*Env, any) {
+	if p.Expr != nil {
+		return env, errors.New("pinned expression used as expression")
+	}
 	return env, fmt.Errorf("pinned ident %q used as expression", p.Ident)
 }
 
+// Default is a function parameter with a default value. It is
+// created by the parser from a pattern followed by the default
+// operator and an expression, such as name \\ "default". If a
+// function is called without the arguments that correspond to its
+// trailing Default parameters, Value is evaluated and used instead.
+// Value is evaluated with the parameters before it bound, so it can
+// refer to them.
+type Default struct {
+	Pattern any
+	Value   any
+}
+
+// String returns the default parameter as source code.
+func (d Default) String() string {
+	return Inspect(d)
+}
+
+// Eval returns an error every time because a Default should never
+// actually be used as an expression.
+func (d Default) Eval(env *Env, args *List) (*Env, any) {
+	return env, errors.New("default parameter used as expression")
+}
+
+// Rest is a pattern that matches the remainder of a list. It is
+// created by the parser from usages of the rest operator, such as
+// &rest, and may only be used as the last element of a list pattern.
+type Rest struct {
+	Pattern any
+}
+
+// String returns the rest pattern as source code.
+func (r Rest) String() string {
+	return Inspect(r)
+}
+
+// Eval returns an error every time because a Rest should never
+// actually be used as an expression.
+func (r Rest) Eval(env *Env, args *List) (*Env, any) {
+	return env, errors.New("rest pattern used as expression")
+}
+
 // Call is a function call. It calls the first element of the
 // underlying list with the remainder of the list as arguments. If the
 // list is empty, it just returns the list.
+//
+// Pos is the location in the source code that the call was parsed
+// from, if any.
 type Call struct {
 	*List
+	Pos scanner.Position
+}
+
+// String returns the call as source code.
+func (call Call) String() string {
+	return Inspect(call)
+}
+
+// Equal returns true if other is a Call with elements that are equal
+// to those of call. Their positions are not compared.
+func (call Call) Equal(other any) bool {
+	o, ok := other.(Call)
+	return ok && call.List.Equal(o.List)
 }
 
 func (call Call) Eval(env *Env, args *List) (*Env, any) {
@@ -34,11 +111,80 @@ func (call Call) Eval(env *Env, args *List) (*Env, any) {
 		return env, call
 	}
 
-	env, r := Eval(env, call.Head(), call.Tail())
+	if env.hooks == nil && env.tracer == nil {
+		// Calls to functions that don't need the position of the call
+		// in their Env, which are most of them, are made directly,
+		// avoiding both evaluating the head and copying the Env.
+		switch f := env.directCallee(call.Head()).(type) {
+		case *Func:
+			if err := env.step(); err != nil {
+				return env, err
+			}
+			_, r := f.call(env, call.Pos, CollectList(EvalAll(env, call.Tail().All())))
+			return env.callResult(call.Pos, r, args)
+		case EvalFunc:
+			if err := env.step(); err != nil {
+				return env, err
+			}
+			renv, r := evalEvaluator(env, f, callArgs(call.Tail()))
+			return renv.callResult(call.Pos, traceCall(env.stack, call.Pos, r), args)
+		}
+	}
+
+	cenv := env.at(call.Pos)
+	if env.tracer != nil {
+		cenv = env.tracer.call(cenv, call)
+	}
+	renv, r := Eval(cenv, call.Head(), callArgs(call.Tail()))
+	if env.tracer != nil {
+		renv = env.tracer.result(env, renv, r)
+	}
+	return renv.callResult(call.Pos, traceCall(env.stack, call.Pos, r), args)
+}
+
+// callResult returns the result of a call at pos, r, calling it with
+// args if the call was itself the head of a call.
+func (env *Env) callResult(pos scanner.Position, r any, args *List) (*Env, any) {
 	if args.Len() == 0 {
 		return env, r
 	}
-	return Eval(env, r, args)
+	renv, r := Eval(env, r, args)
+	return renv, traceCall(env.stack, pos, r)
+}
+
+// callArgs returns args if it is non-nil or an empty, non-nil list
+// otherwise. This allows a call with no arguments to be distinguished
+// from a value that is being evaluated without being called. See
+// [Evaluator].
+func callArgs(args *List) *List {
+	if args == nil {
+		return new(List)
+	}
+	return args
+}
+
+// ListExpr is a list literal whose elements are evaluated to produce
+// a *List. Unlike a [Call], it is never called.
+type ListExpr struct {
+	*List
+	Pos scanner.Position
+}
+
+// String returns the list as source code.
+func (expr ListExpr) String() string {
+	return Inspect(expr)
+}
+
+// Equal returns true if other is a ListExpr with elements that are
+// equal to those of expr. Their positions are not compared.
+func (expr ListExpr) Equal(other any) bool {
+	o, ok := other.(ListExpr)
+	return ok && expr.List.Equal(o.List)
+}
+
+func (expr ListExpr) Eval(env *Env, args *List) (*Env, any) {
+	list := CollectList(EvalAll(env, expr.All()))
+	return Eval(env, list, args)
 }
 
 // Ident is an identifier for bound data, i.e. a declared
@@ -60,8 +206,23 @@ func (ident Ident) Eval(env *Env, args *List) (*Env, any) {
 	if !ok {
 		return env, &NameError{Ident: ident}
 	}
-	if c, ok := c.(Ident); ok && c == ident {
-		panic(fmt.Errorf("name %q is bound to itself", ident))
+
+	// Follow chains of identifiers bound to other identifiers directly
+	// so that a cycle can be detected instead of recursing forever.
+	var chain []Ident
+	for next, ok := c.(Ident); ok; next, ok = c.(Ident) {
+		if chain == nil {
+			chain = []Ident{ident}
+		}
+		if slices.Contains(chain, next) {
+			return env, &CircularBindingError{Chain: append(chain, next)}
+		}
+		chain = append(chain, next)
+
+		c, ok = env.Lookup(next)
+		if !ok {
+			return env, &NameError{Ident: next}
+		}
 	}
 	return Eval(env, c, args)
 }
@@ -80,6 +241,11 @@ type Ref struct {
 	Name Ident
 }
 
+// String returns the ref as source code.
+func (ref Ref) String() string {
+	return Inspect(ref)
+}
+
 func (ref Ref) Eval(env *Env, args *List) (*Env, any) {
 	env, in := Eval(env, ref.In, nil)
 	switch in := in.(type) {
@@ -111,10 +277,26 @@ type Atom struct {
 	h unique.Handle[string]
 }
 
+// builtinAtoms is the set of atoms made while the package's variables
+// were being initialized, which includes those that the runtime
+// returns, such as :ok and :error. It is not modified after
+// initialization, so it can be read without synchronization.
+var (
+	builtinAtoms     = make(map[string]struct{})
+	builtinAtomsDone bool
+)
+
+func init() {
+	builtinAtomsDone = true
+}
+
 // MakeAtom returns an atom representing the given string. The
 // returned atom will be equal to all other atoms returned from this
 // function when called with the same string.
 func MakeAtom(str string) Atom {
+	if !builtinAtomsDone {
+		builtinAtoms[str] = struct{}{}
+	}
 	return Atom{h: unique.Make(str)}
 }
 
@@ -123,6 +305,15 @@ func (atom Atom) String() string {
 	return atom.h.Value()
 }
 
+// Rune is a single Unicode code point. The parser creates these from
+// rune literals such as 'a'.
+type Rune rune
+
+// String returns the rune as a single-character string.
+func (r Rune) String() string {
+	return string(r)
+}
+
 // ArgumentNumError is returned when a function is called with the
 // wrong number of arguments. If the function has a specific number of
 // arguments that it expects, Expected will be >= 0.
@@ -138,6 +329,15 @@ func (err *ArgumentNumError) Error() string {
 	return fmt.Sprintf("incorrect number of arguments %v, expected %v", err.Num, err.Expected)
 }
 
+// Is returns true if target is [ErrArgumentNum].
+func (err *ArgumentNumError) Is(target error) bool {
+	return target == ErrArgumentNum
+}
+
+func (err *ArgumentNumError) ErrorCode() Atom {
+	return codeArgumentNum
+}
+
 // TypeError is returned by expressions that have incorrect types in
 // them in some way. Val is the value that is of the wrong type. If
 // there is information about types that were expected, the Expected
@@ -162,6 +362,15 @@ func (err *TypeError) Error() string {
 	return fmt.Sprintf("incorrect type %T, expected one of %v", err.Val, err.Expected)
 }
 
+// Is returns true if target is [ErrType].
+func (err *TypeError) Is(target error) bool {
+	return target == ErrType
+}
+
+func (err *TypeError) ErrorCode() Atom {
+	return codeType
+}
+
 // NameError is returned when an identifier was accessed but is not
 // bound in the scope.
 type NameError struct {
@@ -172,6 +381,39 @@ func (err *NameError) Error() string {
 	return fmt.Sprintf("%q is not bound", err.Ident)
 }
 
+// Is returns true if target is [ErrUnbound].
+func (err *NameError) Is(target error) bool {
+	return target == ErrUnbound
+}
+
+func (err *NameError) ErrorCode() Atom {
+	return codeUnbound
+}
+
+// CircularBindingError is returned when an identifier is evaluated
+// that is bound to another identifier which is, possibly through
+// further identifiers, bound back to one earlier in the chain. Chain
+// is the sequence of identifiers that were followed, ending with the
+// one that was repeated.
+type CircularBindingError struct {
+	Chain []Ident
+}
+
+func (err *CircularBindingError) Error() string {
+	var sb strings.Builder
+	for i, ident := range err.Chain {
+		if i > 0 {
+			sb.WriteString(" -> ")
+		}
+		sb.WriteString(ident.String())
+	}
+	return fmt.Sprintf("circular binding: %v", sb.String())
+}
+
+func (err *CircularBindingError) ErrorCode() Atom {
+	return codeCircularBinding
+}
+
 // UndefinedModuleError is returned when an attempt is made to access
 // a module that has not been defined.
 type UndefinedModuleError struct {
@@ -182,15 +424,124 @@ func (err *UndefinedModuleError) Error() string {
 	return fmt.Sprintf("module %q not found in runtime", err.Name)
 }
 
+func (err *UndefinedModuleError) ErrorCode() Atom {
+	return codeUndefinedModule
+}
+
+// IndexError is returned when an attempt is made to access an element
+// of a collection at an index that is out of range.
+type IndexError struct {
+	Index int64
+	Len   int
+}
+
+func (err *IndexError) Error() string {
+	return fmt.Sprintf("index %v out of range with length %v", err.Index, err.Len)
+}
+
+func (err *IndexError) ErrorCode() Atom {
+	return codeIndex
+}
+
+// LimitExceededError is returned when evaluation is aborted because
+// it exceeded a limit, such as the one set by [WithStepLimit].
+type LimitExceededError struct {
+	Limit int64
+}
+
+func (err *LimitExceededError) Error() string {
+	return fmt.Sprintf("evaluation exceeded limit of %v steps", err.Limit)
+}
+
+func (err *LimitExceededError) ErrorCode() Atom {
+	return codeLimitExceeded
+}
+
+// StackOverflowError is returned when a function call would exceed
+// the maximum call depth of an [Env]. See [WithMaxDepth].
+type StackOverflowError struct {
+	Depth int
+}
+
+func (err *StackOverflowError) Error() string {
+	return fmt.Sprintf("stack overflow: function calls nested more than %v deep", err.Depth)
+}
+
+func (err *StackOverflowError) ErrorCode() Atom {
+	return codeStackOverflow
+}
+
+// RuntimePanicError is returned when the evaluation of a value
+// panics. Val is the value that was passed to panic and Stack is the
+// Go stack trace of the goroutine at the time that the panic was
+// recovered.
+type RuntimePanicError struct {
+	Val   any
+	Stack []byte
+}
+
+func (err *RuntimePanicError) Error() string {
+	return fmt.Sprintf("panic during evaluation: %v", err.Val)
+}
+
+// Unwrap returns Val if it is an error.
+func (err *RuntimePanicError) Unwrap() error {
+	e, _ := err.Val.(error)
+	return e
+}
+
+func (err *RuntimePanicError) ErrorCode() Atom {
+	return codePanic
+}
+
+// CancelledError is returned when evaluation is aborted because the
+// context of the [Env] was canceled.
+type CancelledError struct {
+	Cause error
+}
+
+func (err *CancelledError) Error() string {
+	return fmt.Sprintf("evaluation canceled: %v", err.Cause)
+}
+
+func (err *CancelledError) Unwrap() error {
+	return err.Cause
+}
+
+func (err *CancelledError) ErrorCode() Atom {
+	return codeCanceled
+}
+
 // Eval evaluates a value, potentially passing arguments to it. If the
 // value implements [Evaluator], its Eval method is called. If not and
 // arguments were provided, the value is returned as the first element
 // of a list containing it and the arguments provided. Otherwise, the
 // value is returned unmodified.
+//
+// If the context of env has been canceled, Eval returns a
+// [CancelledError] without evaluating anything.
+//
+// If env has [Hooks], they are called before and after the
+// evaluation.
 func Eval(env *Env, expr any, args *List) (*Env, any) {
+	if err := env.step(); err != nil {
+		return env, err
+	}
+
+	if env.hooks == nil {
+		return eval(env, expr, args)
+	}
+
+	env.hooks.before(env, expr, args)
+	env, r := eval(env, expr, args)
+	env.hooks.after(env, expr, args, r)
+	return env, r
+}
+
+func eval(env *Env, expr any, args *List) (*Env, any) {
 	switch expr := expr.(type) {
 	case Evaluator:
-		return expr.Eval(env, args)
+		return evalEvaluator(env, expr, args)
 	default:
 		if args.Len() > 0 {
 			expr = args.Push(expr)
@@ -199,9 +550,24 @@ func Eval(env *Env, expr any, args *List) (*Env, any) {
 	}
 }
 
-// EvalAllWithRuntime is like [EvalAll], but also yields the [Env]
-// that results from each elements evaluation.
-func EvalAllWithRuntime[T any](env *Env, seq iter.Seq[T]) iter.Seq2[*Env, any] {
+// evalEvaluator calls expr.Eval, converting a panic into a
+// [RuntimePanicError] so that a misbehaving built-in function can't
+// crash the host program.
+func evalEvaluator(env *Env, expr Evaluator, args *List) (renv *Env, r any) {
+	defer func() {
+		p := recover()
+		if p == nil {
+			return
+		}
+		renv, r = env, &RuntimePanicError{Val: p, Stack: debug.Stack()}
+	}()
+
+	return expr.Eval(env, args)
+}
+
+// EvalAllWithEnv is like [EvalAll], but also yields the [Env] that
+// results from each elements evaluation.
+func EvalAllWithEnv[T any](env *Env, seq iter.Seq[T]) iter.Seq2[*Env, any] {
 	return func(yield func(*Env, any) bool) {
 		for v := range seq {
 			var ret any
@@ -214,12 +580,12 @@ func EvalAllWithRuntime[T any](env *Env, seq iter.Seq[T]) iter.Seq2[*Env, any] {
 }
 
 // EvalAll returns an iterator that evaluates each element in seq
-// using [Eval] and yields the results. It uses r as the base
+// using [Eval] and yields the results. It uses env as the base
 // [Env] for the evaluation and updates it with the result of each
 // elements evaluation.
 func EvalAll[T any](env *Env, seq iter.Seq[T]) iter.Seq[any] {
 	return func(yield func(any) bool) {
-		for _, v := range EvalAllWithRuntime(env, seq) {
+		for _, v := range EvalAllWithEnv(env, seq) {
 			if !yield(v) {
 				return
 			}
@@ -230,19 +596,30 @@ func EvalAll[T any](env *Env, seq iter.Seq[T]) iter.Seq[any] {
 // Evaluator is a value that can be evaluated, possibly with
 // arguments, such as a function.
 type Evaluator interface {
-	// Eval evaluates the value in the given [Runtime] with the given
-	// arguments. It returns the result of the evaluation and a new
-	// Runtime representing any modifications that the evaluation has
-	// made to it.
+	// Eval evaluates the value in the given [Env] with the given
+	// arguments. It returns the result of the evaluation and a new Env
+	// representing any modifications that the evaluation has made to
+	// it.
+	//
+	// If args is nil, the value is being evaluated without being
+	// called, such as when it is passed as an argument to another
+	// function. A call with no arguments will instead be passed a
+	// non-nil, empty list. Callable values should return themselves
+	// when args is nil.
 	//
-	// Most implementations will simply return the Runtime unmodified.
+	// Most implementations will simply return the Env unmodified.
 	Eval(env *Env, args *List) (*Env, any)
 }
 
-// EvalFunc is a func wrapper for [Evaluator].
+// EvalFunc is a func wrapper for [Evaluator]. When evaluated without
+// being called, it returns itself without calling the underlying
+// function.
 type EvalFunc func(env *Env, args *List) (*Env, any)
 
 func (f EvalFunc) Eval(env *Env, args *List) (*Env, any) {
+	if args == nil {
+		return env, f
+	}
 	return f(env, args)
 }
 
@@ -268,7 +645,7 @@ type Equaler interface {
 
 // IsEquatable returns true if val is capable of being equated.
 func IsEquatable(val any) bool {
-	if _, ok := val.(Equaler); ok {
+	if _, ok := val.(Equaler); ok || val == nil {
 		return true
 	}
 	return reflect.TypeOf(val).Comparable()
@@ -282,7 +659,15 @@ func IsEquatable(val any) bool {
 //
 // If the last step is reached and either type is not comparable, the
 // result is false.
+//
+// Collections, such as *List, implement Equaler by comparing their
+// elements with Equal, so two separately constructed lists with equal
+// elements are equal.
 func Equal(v1, v2 any) bool {
+	if v1 == nil || v2 == nil {
+		return v1 == v2
+	}
+
 	if v1, ok := v1.(Equaler); ok {
 		return v1.Equal(v2)
 	}
@@ -3,7 +3,7 @@ package extract_test
 import (
 	"context"
 	"errors"
-	"strings"
+	"slices"
 	"testing"
 
 	"deedles.dev/extract"
@@ -11,7 +11,7 @@ import (
 )
 
 func runScript(t *testing.T, src string, checkErrors bool) any {
-	s, err := parser.Parse(strings.NewReader(src))
+	s, err := parser.ParseString(t.Name(), src)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -72,12 +72,36 @@ func BenchmarkDefModule(b *testing.B) {
 
 		(Test.inc 2)
 		`
-		s, _ := parser.Parse(strings.NewReader(src))
+		s, _ := parser.ParseString("", src)
 		r := extract.New(context.Background())
 		extract.Run(r, s.All())
 	}
 }
 
+func BenchmarkCall(b *testing.B) {
+	def, err := parser.ParseString(b.Name(), `
+	(defmodule M
+		(def (fib 0) 0)
+		(def (fib 1) 1)
+		(def (fib n) (add (fib (sub n 1)) (fib (sub n 2)))))`)
+	if err != nil {
+		b.Fatal(err)
+	}
+	call, err := parser.ParseString(b.Name(), `(M.fib 18)`)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	env, _ := extract.Run(extract.New(context.Background()), def.All())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		if _, r := extract.Run(env, call.All()); r != int64(2584) {
+			b.Fatalf("%#v", r)
+		}
+	}
+}
+
 func TestIndirectFunctionCall(t *testing.T) {
 	const src = `
 	(defmodule Test
@@ -137,3 +161,354 @@ func TestPin(t *testing.T) {
 		t.Fatalf("%#v", result)
 	}
 }
+
+func TestMutualRecursion(t *testing.T) {
+	const src = `
+	(defmodule Test
+		(def (even? 0) :true)
+		(def (even? n) (odd? (sub n 1)))
+		(def (odd? 0) :false)
+		(def (odd? n) (even? (sub n 1)))
+	)
+
+	(Test.odd? 7)
+	`
+	result := runScript(t, src, true)
+	if result != extract.MakeAtom("true") {
+		t.Fatalf("%#v", result)
+	}
+}
+
+func TestDefaultArguments(t *testing.T) {
+	const src = `
+	(defmodule Test
+		(def (greet name greeting \\ "Hello" punct \\ ".") (String.format "%v, %v%v" greeting name punct))
+	)
+
+	(list (Test.greet "World") (Test.greet "World" "Hi") (Test.greet "World" "Hi" "!"))
+	`
+	result := runScript(t, src, true)
+	ex := []any{"Hello, World.", "Hi, World.", "Hi, World!"}
+	if s := slices.Collect(result.(*extract.List).All()); !slices.Equal(s, ex) {
+		t.Fatalf("%#v", s)
+	}
+
+	const earlier = `
+	(defmodule M
+		(def (f a b \\ (add a 1) (c d) \\ [b (mul b 2)]) [a b c d])
+	)
+
+	[(M.f 1) (M.f 1 5) (M.f 1 5 [0 0])]
+	`
+	result = runScript(t, earlier, true)
+	checkList(t, result,
+		extract.ListOf(int64(1), int64(2), int64(2), int64(4)),
+		extract.ListOf(int64(1), int64(5), int64(5), int64(10)),
+		extract.ListOf(int64(1), int64(5), int64(0), int64(0)),
+	)
+}
+
+func TestMultiClauseFunc(t *testing.T) {
+	const src = `
+	(let describe (func describe
+		((0) "zero")
+		((1) "one")
+		((n) (String.format "many: %v" n))
+	))
+
+	(list (describe 0) (describe 1) (describe 5))
+	`
+	result := runScript(t, src, true)
+	ex := []any{"zero", "one", "many: 5"}
+	if s := slices.Collect(result.(*extract.List).All()); !slices.Equal(s, ex) {
+		t.Fatalf("%#v", s)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	const src = `
+	(match (a (b 3)) (list 1 (list 2 3)))
+	(add a b)
+	`
+	result := runScript(t, src, true)
+	if result != int64(3) {
+		t.Fatalf("%#v", result)
+	}
+}
+
+func TestMatchError(t *testing.T) {
+	const src = `(match (a 2) (list 1 3))`
+	result := runScript(t, src, false)
+	if err, ok := result.(error); !ok || !errors.Is(err, extract.ErrPatternMatch) {
+		t.Fatalf("%#v", result)
+	}
+}
+
+func TestLetDestructure(t *testing.T) {
+	const src = `
+	(let (first (second _)) (list 1 (list 2 3)))
+	(add first second)
+	`
+	result := runScript(t, src, true)
+	if result != int64(3) {
+		t.Fatalf("%#v", result)
+	}
+}
+
+func TestPartial(t *testing.T) {
+	const src = `
+	(let inc (partial add 1))
+	(let add3 (partial (func (add3 a b c) (add a b c)) 1 2))
+	(list (inc 2) (add3 3))
+	`
+	result := runScript(t, src, true)
+	ex := []any{int64(3), int64(6)}
+	if s := slices.Collect(result.(*extract.List).All()); !slices.Equal(s, ex) {
+		t.Fatalf("%#v", s)
+	}
+}
+
+// countingValue counts the number of times that it is evaluated.
+type countingValue struct {
+	n *int
+}
+
+func (v countingValue) Eval(env *extract.Env, args *extract.List) (*extract.Env, any) {
+	*v.n++
+	return env, v
+}
+
+func TestPartialEvaluatesOnce(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{"Func", `(let f (partial (func (f v x) x) Host.value)) (f 1)`},
+		{"Builtin", `(let f (partial inspect Host.value)) (f)`},
+		{"Callback", `(List.map [Host.value] inspect)`},
+		{"Spawn", `(spawn (func (f p v) (send p :done)) (self) Host.value) (receive (:done :ok))`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var n int
+			m := extract.NewModule(extract.MakeAtom("Host"), map[extract.Ident]any{
+				extract.MakeIdent("value"): countingValue{n: &n},
+			})
+
+			s, err := parser.ParseString(t.Name(), test.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, result := extract.Run(extract.New(context.Background(), extract.WithModules(m)), s.All())
+			if err, ok := result.(error); ok {
+				t.Fatal(err)
+			}
+			if n != 1 {
+				t.Fatalf("bound argument evaluated %v times", n)
+			}
+		})
+	}
+}
+
+func TestOperators(t *testing.T) {
+	const src = `
+	(let x (1 + 2 * 3))
+	(x - 10 / 2 + (2 ^ 3) % 3)
+	`
+	result := runScript(t, src, true)
+	if result != int64(4) {
+		t.Fatalf("%#v", result)
+	}
+}
+
+func TestVariadic(t *testing.T) {
+	const src = `
+	(defmodule Test
+		(def (sum) 0)
+		(def (sum n &rest) (add n (sum2 rest)))
+		(def (sum2 ()) 0)
+		(def (sum2 (n &rest)) (add n (sum2 rest)))
+	)
+
+	(list (Test.sum) (Test.sum 1 2 3))
+	`
+	result := runScript(t, src, true)
+	ex := []any{int64(0), int64(6)}
+	if s := slices.Collect(result.(*extract.List).All()); !slices.Equal(s, ex) {
+		t.Fatalf("%#v", s)
+	}
+}
+
+func TestAnonymousRecursion(t *testing.T) {
+	const src = `
+	(let fact (func fact
+		((0) 1)
+		((n) (n * (fact (n - 1))))
+	))
+	(let sum (func sum ((0) 0) ((n) (n + (sum (n - 1))))))
+
+	(list (fact 5) (sum 4))
+	`
+	result := runScript(t, src, true)
+	ex := []any{int64(120), int64(10)}
+	if s := slices.Collect(result.(*extract.List).All()); !slices.Equal(s, ex) {
+		t.Fatalf("%#v", s)
+	}
+}
+
+func TestFuncInvalidClause(t *testing.T) {
+	tests := []struct{ src, ex string }{
+		{`(func f x)`, "x"},
+		{`(func f ((n)))`, "((n))"},
+	}
+	for _, test := range tests {
+		result := runScript(t, test.src, false)
+		err, ok := result.(error)
+		if !ok || err.Error() != "func clause must be a list of parameters followed by a body, not "+test.ex {
+			t.Fatalf("%v: %#v", test.src, result)
+		}
+	}
+}
+
+func TestRune(t *testing.T) {
+	const src = `
+	(defmodule Test
+		(def (vowel? 'a') :yes)
+		(def (vowel? _) :no)
+	)
+
+	(list (Test.vowel? 'a') (Test.vowel? 97) (String.from_runes (String.runes "test")))
+	`
+	result := runScript(t, src, true)
+	ex := []any{extract.MakeAtom("yes"), extract.MakeAtom("no"), "test"}
+	if s := slices.Collect(result.(*extract.List).All()); !slices.Equal(s, ex) {
+		t.Fatalf("%#v", s)
+	}
+}
+
+func TestPinExpr(t *testing.T) {
+	const src = `
+	(defmodule Limits
+		(def (max) 3)
+	)
+	(defmodule Test
+		(def (test \(Limits.max)) "max")
+		(def (test \(1 + 1)) "two")
+		(def (test _) "other")
+	)
+
+	(list (Test.test 3) (Test.test 2) (Test.test 1))
+	`
+	result := runScript(t, src, true)
+	ex := []any{"max", "two", "other"}
+	if s := slices.Collect(result.(*extract.List).All()); !slices.Equal(s, ex) {
+		t.Fatalf("%#v", s)
+	}
+}
+
+func TestLoop(t *testing.T) {
+	const src = `
+	(loop (10000 0)
+		((0 acc) acc)
+		((n acc) (recur (n - 1) (acc + n)))
+	)
+	`
+	result := runScript(t, src, true)
+	if result != int64(50005000) {
+		t.Fatalf("%#v", result)
+	}
+}
+
+func TestLoopRecurPosition(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{"Argument", `(loop (3) ((0) 0) ((n) (1 + (recur (n - 1)))))`},
+		{"NotLast", `(loop (3) ((0) 0) ((n) (recur (n - 1)) n))`},
+		{"Nested", `(loop (3) ((0) 0) ((n) (recur (recur (n - 1)))))`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := runScript(t, test.src, false)
+			if err, ok := result.(error); !ok || !errors.Is(err, extract.ErrRecurPosition) {
+				t.Fatalf("%#v", result)
+			}
+		})
+	}
+
+	const src = `
+	(loop (3 0)
+		((0 acc) acc)
+		((n acc) (let m (n - 1)) (recur m (acc + n))))
+	`
+	result := runScript(t, src, true)
+	if result != int64(6) {
+		t.Fatalf("%#v", result)
+	}
+}
+
+func TestBrackets(t *testing.T) {
+	const src = `
+	(let x 2)
+	(let [a b &rest] [1 x (x + 1) 4])
+	[b a rest]
+	`
+	result := runScript(t, src, true)
+	list := result.(*extract.List)
+	if list.Len() != 3 || list.Head() != int64(2) || list.Tail().Head() != int64(1) {
+		t.Fatalf("%#v", result)
+	}
+	if s := slices.Collect(list.Tail().Tail().Head().(*extract.List).All()); !slices.Equal(s, []any{int64(3), int64(4)}) {
+		t.Fatalf("%#v", s)
+	}
+}
+
+func TestEq(t *testing.T) {
+	const src = `
+	(let l (list 1 (list 2 "three")))
+	(defmodule Test
+		(def (pinned \l) :pinned)
+		(def (pinned _) :other)
+	)
+	(list
+		(eq l [1 [2 "three"]])
+		(eq l [1 [2 "four"]])
+		(eq l [1 2])
+		(eq :a :a)
+		(eq (eq 1 2) false)
+		(Test.pinned [1 [2 "three"]])
+		(Test.pinned [1 [2]])
+	)
+	`
+	result := runScript(t, src, true)
+	ex := []any{true, false, false, true, true, extract.MakeAtom("pinned"), extract.MakeAtom("other")}
+	if s := slices.Collect(result.(*extract.List).All()); !slices.Equal(s, ex) {
+		t.Fatalf("%#v", s)
+	}
+}
+
+func TestEqualExpr(t *testing.T) {
+	parse := func(src string) any {
+		s, err := parser.ParseString(t.Name(), src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s.Head()
+	}
+
+	call := parse(`(f 1 [2])`).(extract.Call)
+	if !extract.Equal(call, call) || !extract.Equal(call, parse("\n  (f 1 [2])")) {
+		t.Fatal("equal calls are not equal")
+	}
+	if extract.Equal(call, parse(`(f 1 [3])`)) {
+		t.Fatal("different calls are equal")
+	}
+	if extract.Equal(call, call.List) || extract.Equal(call.List, call) {
+		t.Fatal("call is equal to a list")
+	}
+	if extract.Equal(call, parse(`[f 1 [2]]`)) {
+		t.Fatal("call is equal to a list expression")
+	}
+}
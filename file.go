@@ -0,0 +1,204 @@
+package extract
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"reflect"
+	"strings"
+	"syscall"
+)
+
+// ErrFileSystemDisabled is returned by functions that access the
+// filesystem if the Env was created with [WithoutFileSystem].
+var ErrFileSystemDisabled = errors.New("filesystem access is disabled")
+
+// checkFS returns an error if filesystem access is disabled in env.
+func (env *Env) checkFS() error {
+	if env.noFS {
+		return ErrFileSystemDisabled
+	}
+	return nil
+}
+
+// fsErrorReason converts an error from a filesystem operation into a
+// reason for a tagged error result. Common errors are converted into
+// atoms named after the corresponding POSIX error codes, while others
+// are converted into their messages.
+func fsErrorReason(err error) any {
+	switch {
+	case errors.Is(err, syscall.ENOTEMPTY):
+		return MakeAtom("enotempty")
+	case errors.Is(err, fs.ErrNotExist):
+		return MakeAtom("enoent")
+	case errors.Is(err, fs.ErrExist):
+		return MakeAtom("eexist")
+	case errors.Is(err, fs.ErrPermission):
+		return MakeAtom("eacces")
+	default:
+		return err.Error()
+	}
+}
+
+func stdFile() *Module {
+	m := Module{name: MakeAtom("File")}
+	m.decls = map[Ident]any{
+		MakeIdent("read"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			path, err := evalPath(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return env, errorResult(fsErrorReason(err))
+			}
+			return env, okResult(string(data))
+		}),
+		MakeIdent("read_binary"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			path, err := evalPath(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return env, errorResult(fsErrorReason(err))
+			}
+			return env, okResult(&Binary{data: data})
+		}),
+		MakeIdent("write"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			return env, fileWrite(env, args, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+		}),
+		MakeIdent("append"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			return env, fileWrite(env, args, os.O_WRONLY|os.O_CREATE|os.O_APPEND)
+		}),
+		MakeIdent("exists?"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			path, err := evalPath(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			_, err = os.Stat(path)
+			return env, err == nil
+		}),
+		MakeIdent("stat"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			path, err := evalPath(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			info, err := os.Lstat(path)
+			if err != nil {
+				return env, errorResult(fsErrorReason(err))
+			}
+			return env, okResult(fileInfo(info))
+		}),
+		MakeIdent("stream"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			path, err := evalPath(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			return env, &Stream{seq: func(yield func(any, error) bool) {
+				file, err := os.Open(path)
+				if err != nil {
+					yield(nil, err)
+					return
+				}
+				defer file.Close()
+
+				s := bufio.NewScanner(file)
+				for s.Scan() {
+					if !yield(strings.TrimSuffix(s.Text(), "\r"), nil) {
+						return
+					}
+				}
+				if err := s.Err(); err != nil {
+					yield(nil, err)
+				}
+			}}
+		}),
+	}
+
+	return &m
+}
+
+// fileWrite writes the contents given in args to the file at the path
+// given in args, opening the file with flag.
+func fileWrite(env *Env, args *List, flag int) any {
+	if args.Len() != 2 {
+		return &ArgumentNumError{Num: args.Len(), Expected: 2}
+	}
+	if err := env.checkFS(); err != nil {
+		return err
+	}
+
+	vals, err := evalArgs(env, args)
+	if err != nil {
+		return err
+	}
+	path, ok := vals[0].(string)
+	if !ok {
+		return NewTypeError(vals[0], reflect.TypeFor[string]())
+	}
+	data, err := toBytes(vals[1])
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, flag, 0666)
+	if err != nil {
+		return errorResult(fsErrorReason(err))
+	}
+	_, err = file.Write(data)
+	if err := errors.Join(err, file.Close()); err != nil {
+		return errorResult(fsErrorReason(err))
+	}
+	return okAtom
+}
+
+// fileInfo converts info into a map describing it.
+func fileInfo(info fs.FileInfo) *Map {
+	var typ string
+	switch mode := info.Mode(); {
+	case mode.IsRegular():
+		typ = "regular"
+	case mode.IsDir():
+		typ = "directory"
+	case mode&fs.ModeSymlink != 0:
+		typ = "symlink"
+	default:
+		typ = "other"
+	}
+
+	return MapOf(
+		MakeAtom("name"), info.Name(),
+		MakeAtom("size"), info.Size(),
+		MakeAtom("type"), MakeAtom(typ),
+		MakeAtom("mode"), int64(info.Mode().Perm()),
+		MakeAtom("mtime"), info.ModTime().UnixMilli(),
+	)
+}
+
+// evalPath checks that filesystem access is enabled and then
+// evaluates a single path argument.
+func evalPath(env *Env, args *List) (string, error) {
+	if args.Len() != 1 {
+		return "", &ArgumentNumError{Num: args.Len(), Expected: 1}
+	}
+	if err := env.checkFS(); err != nil {
+		return "", err
+	}
+
+	_, head := Eval(env, args.Head(), nil)
+	if err, ok := head.(error); ok {
+		return "", err
+	}
+	path, ok := head.(string)
+	if !ok {
+		return "", NewTypeError(head, reflect.TypeFor[string]())
+	}
+	return path, nil
+}
@@ -0,0 +1,90 @@
+package extract_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"deedles.dev/extract"
+	"deedles.dev/extract/parser"
+)
+
+// runInDir runs src with dir bound to the path of a temporary
+// directory.
+func runInDir(t *testing.T, src string, opts ...extract.Option) (string, any) {
+	s, err := parser.ParseString(t.Name(), src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	env := extract.New(context.Background(), opts...)
+	env = env.Let(extract.MakeIdent("dir"), dir)
+	_, result := extract.Run(env, s.All())
+	return dir, result
+}
+
+func TestFile(t *testing.T) {
+	const src = `
+	(let path (String.format "%v/test.txt" dir))
+	(list
+		(File.exists? path)
+		(File.read path)
+		(File.write path "one\n")
+		(File.append path "two\nthree")
+		(File.read path)
+		(File.exists? path)
+		(Enum.to_list (File.stream path))
+		(Map.get (Tuple.elem (File.stat path) 1) :size)
+		(Map.get (Tuple.elem (File.stat dir) 1) :type)
+	)
+	`
+	dir, result := runInDir(t, src)
+	if err, ok := result.(error); ok {
+		t.Fatal(err)
+	}
+
+	ok, errAtom := extract.MakeAtom("ok"), extract.MakeAtom("error")
+	checkList(t, result,
+		false,
+		extract.TupleOf(errAtom, extract.MakeAtom("enoent")),
+		ok,
+		ok,
+		extract.TupleOf(ok, "one\ntwo\nthree"),
+		true,
+		extract.ListOf("one", "two", "three"),
+		int64(13),
+		extract.MakeAtom("directory"),
+	)
+
+	data, err := os.ReadFile(filepath.Join(dir, "test.txt"))
+	if err != nil || string(data) != "one\ntwo\nthree" {
+		t.Fatal(string(data), err)
+	}
+}
+
+func TestFileBinary(t *testing.T) {
+	const src = `
+	(let path (Path.join dir "test.bin"))
+	(File.write path b"\x00\xff")
+	(File.read_binary path)
+	`
+	_, result := runInDir(t, src)
+	checkTuple(t, result, extract.MakeAtom("ok"), extract.BinaryOf([]byte{0, 0xff}))
+}
+
+func TestFileStreamError(t *testing.T) {
+	_, result := runInDir(t, `(Enum.to_list (File.stream (String.format "%v/missing" dir)))`)
+	if err, _ := result.(error); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("%#v", result)
+	}
+}
+
+func TestWithoutFileSystem(t *testing.T) {
+	_, result := runInDir(t, `(File.write (String.format "%v/test.txt" dir) "test")`, extract.WithoutFileSystem())
+	if err, _ := result.(error); !errors.Is(err, extract.ErrFileSystemDisabled) {
+		t.Fatalf("%#v", result)
+	}
+}
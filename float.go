@@ -0,0 +1,127 @@
+package extract
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strconv"
+)
+
+// ErrFloatRange is returned when a float is converted to an integer
+// but it is not a finite number within the range of an int64.
+var ErrFloatRange = errors.New("float out of integer range")
+
+func stdFloat() *Module {
+	m := Module{name: MakeAtom("Float")}
+	m.decls = map[Ident]any{
+		MakeIdent("parse"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			strs, err := evalStrings(env, args, 1, 1)
+			if err != nil {
+				return env, err
+			}
+
+			f, err := strconv.ParseFloat(strs[0], 64)
+			if err != nil {
+				return env, errorResult(numErrorReason(err))
+			}
+			return env, okResult(f)
+		}),
+		MakeIdent("round"): floatRounder(math.Round),
+		MakeIdent("ceil"):  floatRounder(math.Ceil),
+		MakeIdent("floor"): floatRounder(math.Floor),
+		MakeIdent("truncate"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 1 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 1}
+			}
+
+			f, _, err := evalFloatOpt(env, args)
+			if err != nil {
+				return env, err
+			}
+			return env, math.Trunc(f)
+		}),
+		MakeIdent("to_integer"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 1 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 1}
+			}
+
+			f, _, err := evalFloatOpt(env, args)
+			if err != nil {
+				return env, err
+			}
+			f = math.Trunc(f)
+			if math.IsNaN(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+				return env, ErrFloatRange
+			}
+			return env, int64(f)
+		}),
+		MakeIdent("from_integer"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			ints, err := evalInts(env, args, 1, 1)
+			if err != nil {
+				return env, err
+			}
+			return env, float64(ints[0])
+		}),
+		MakeIdent("to_string"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			f, prec, err := evalFloatOpt(env, args)
+			if err != nil {
+				return env, err
+			}
+			if prec < 0 {
+				return env, Inspect(f)
+			}
+			return env, strconv.FormatFloat(f, 'f', int(prec), 64)
+		}),
+	}
+
+	return &m
+}
+
+// floatRounder returns a function for the Float module that rounds a
+// number to an optional number of decimal places using round.
+func floatRounder(round func(float64) float64) EvalFunc {
+	return func(env *Env, args *List) (*Env, any) {
+		f, prec, err := evalFloatOpt(env, args)
+		if err != nil {
+			return env, err
+		}
+		if prec <= 0 {
+			return env, round(f)
+		}
+
+		scale := math.Pow(10, float64(prec))
+		return env, round(f*scale) / scale
+	}
+}
+
+// evalFloatOpt evaluates a number followed by an optional integer
+// precision. The number is converted to a float64 if it is an int64.
+// If the precision is not given, it is -1.
+func evalFloatOpt(env *Env, args *List) (f float64, prec int64, err error) {
+	if args.Len() != 1 && args.Len() != 2 {
+		return 0, 0, &ArgumentNumError{Num: args.Len(), Expected: -1}
+	}
+
+	vals, err := evalArgs(env, args)
+	if err != nil {
+		return 0, 0, err
+	}
+	switch v := vals[0].(type) {
+	case float64:
+		f = v
+	case int64:
+		f = float64(v)
+	default:
+		return 0, 0, NewTypeError(v, reflect.TypeFor[float64](), reflect.TypeFor[int64]())
+	}
+
+	prec = -1
+	if len(vals) == 2 {
+		p, ok := vals[1].(int64)
+		if !ok {
+			return 0, 0, NewTypeError(vals[1], reflect.TypeFor[int64]())
+		}
+		prec = max(p, 0)
+	}
+	return f, prec, nil
+}
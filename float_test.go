@@ -0,0 +1,49 @@
+package extract_test
+
+import (
+	"errors"
+	"testing"
+
+	"deedles.dev/extract"
+)
+
+func TestFloat(t *testing.T) {
+	const src = `
+	(list
+		(Float.parse "1.5")
+		(Float.parse "one")
+		(Float.round 2.5)
+		(Float.round 3.14159 2)
+		(Float.ceil 1.01)
+		(Float.ceil 1.001 2)
+		(Float.floor -1.5)
+		(Float.floor 2)
+		(Float.truncate -1.7)
+		(Float.to_integer -1.7)
+		(Float.from_integer 3)
+		(Float.to_string 0.1)
+		(Float.to_string 2.0 3)
+	)
+	`
+	result := runScript(t, src, true)
+	checkList(t, result,
+		extract.TupleOf(extract.MakeAtom("ok"), 1.5),
+		extract.TupleOf(extract.MakeAtom("error"), extract.MakeAtom("invalid")),
+		3.0,
+		3.14,
+		2.0,
+		1.01,
+		-2.0,
+		2.0,
+		-1.0,
+		int64(-1),
+		3.0,
+		"0.1",
+		"2.000",
+	)
+
+	result = runScript(t, `(Float.to_integer (1.0 / 0.0))`, false)
+	if err, _ := result.(error); !errors.Is(err, extract.ErrFloatRange) {
+		t.Fatalf("%#v", result)
+	}
+}
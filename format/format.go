@@ -0,0 +1,268 @@
+// Package format implements canonical formatting of Extract code.
+//
+// Code is formatted by rendering each list on a single line if it
+// fits within [Width] columns. Lists that don't fit are broken across
+// lines with each element after the first on its own line, indented
+// with a tab, and the closing delimiter on its own line, such as
+//
+//	(defmodule Example
+//		(def (add a b) (+ a b))
+//	)
+//
+// If the first element of a broken list is an identifier, the second
+// element is kept on the same line as it.
+package format
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"deedles.dev/extract"
+)
+
+// Width is the number of columns that the formatter attempts to keep
+// lines within. Tabs are counted as [TabWidth] columns.
+const Width = 80
+
+// TabWidth is the number of columns that a tab is considered to take
+// up when measuring line lengths.
+const TabWidth = 4
+
+// node is an element of the tree that is rendered by the formatter.
+// It is either a leaf, which is rendered as its text, a group of
+// children surrounded by delimiters, or a sequence of children that
+// are rendered with nothing in between them, such as a ref.
+type node struct {
+	text        string
+	open, close string
+	children    []*node
+	glue        bool
+
+	// comment is true if the node is a leaf containing a comment. If
+	// trailing is also true, the comment was originally on the same
+	// line as the previous node.
+	comment  bool
+	trailing bool
+
+	// blank is true if the node was originally preceded by a blank
+	// line.
+	blank bool
+}
+
+func leaf(text string) *node {
+	return &node{text: text}
+}
+
+func (n *node) isGroup() bool {
+	return n.open != ""
+}
+
+// Format renders a parsed script, such as one returned by
+// [parser.Parse], as canonically formatted Extract code. Comments are
+// not present in a parsed script, so they can not be preserved. To
+// format source code while preserving comments, use [Source].
+func Format(list *extract.List) string {
+	nodes := make([]*node, 0, list.Len())
+	for expr := range list.All() {
+		nodes = append(nodes, fromExpr(expr))
+	}
+
+	var f formatter
+	f.top(nodes)
+	return f.buf.String()
+}
+
+// Expr renders a single parsed expression as canonically formatted
+// Extract code.
+func Expr(expr any) string {
+	var f formatter
+	f.node(fromExpr(expr), 0)
+	return f.buf.String()
+}
+
+func fromExpr(expr any) *node {
+	switch expr := expr.(type) {
+	case extract.Call:
+		return group("(", ")", expr.List)
+	case extract.ListExpr:
+		return group("[", "]", expr.List)
+	case *extract.List:
+		return group("[", "]", expr)
+	case extract.Ref:
+		return &node{glue: true, children: []*node{fromExpr(expr.In), leaf("."), leaf(expr.Name.String())}}
+	case extract.Pinned:
+		if expr.Expr != nil {
+			return &node{glue: true, children: []*node{leaf(`\`), fromExpr(expr.Expr)}}
+		}
+		return leaf(`\` + expr.Ident.String())
+	case extract.Rest:
+		return &node{glue: true, children: []*node{leaf("&"), fromExpr(expr.Pattern)}}
+	case extract.Default:
+		return &node{glue: true, children: []*node{fromExpr(expr.Pattern), leaf(` \\ `), fromExpr(expr.Value)}}
+	case extract.Ident:
+		return leaf(expr.String())
+	case extract.Atom:
+		return leaf(Atom(expr))
+	case string:
+		return leaf(String(expr))
+	case extract.Rune:
+		return leaf(Rune(expr))
+	case *extract.Binary:
+		return leaf(extract.Inspect(expr))
+	case int64:
+		return leaf(strconv.FormatInt(expr, 10))
+	case float64:
+		return leaf(Float(expr))
+	default:
+		return leaf(fmt.Sprint(expr))
+	}
+}
+
+func group(open, close string, list *extract.List) *node {
+	n := node{open: open, close: close, children: make([]*node, 0, list.Len())}
+	for expr := range list.All() {
+		n.children = append(n.children, fromExpr(expr))
+	}
+	return &n
+}
+
+// Atom returns the source representation of an atom.
+func Atom(atom extract.Atom) string {
+	return extract.Inspect(atom)
+}
+
+// String returns the source representation of a string, including
+// the surrounding quotes.
+func String(str string) string {
+	return extract.Inspect(str)
+}
+
+// Rune returns the source representation of a rune, including the
+// surrounding quotes.
+func Rune(r extract.Rune) string {
+	return extract.Inspect(r)
+}
+
+// Float returns the source representation of a float. Unlike the
+// default formatting of floats, the result always contains a decimal
+// point so that it is not parsed as an integer.
+func Float(f float64) string {
+	return extract.Inspect(f)
+}
+
+type formatter struct {
+	buf bytes.Buffer
+	col int
+}
+
+func (f *formatter) write(str string) {
+	f.buf.WriteString(str)
+	if i := strings.LastIndexByte(str, '\n'); i >= 0 {
+		f.col = width(str[i+1:])
+		return
+	}
+	f.col += width(str)
+}
+
+func (f *formatter) newline(indent int) {
+	f.write("\n" + strings.Repeat("\t", indent))
+}
+
+func width(str string) int {
+	return len([]rune(str)) + strings.Count(str, "\t")*(TabWidth-1)
+}
+
+// top renders a sequence of top-level nodes.
+func (f *formatter) top(nodes []*node) {
+	for i, n := range nodes {
+		if i > 0 {
+			f.separate(n, 0)
+		}
+		f.node(n, 0)
+	}
+	if len(nodes) > 0 {
+		f.write("\n")
+	}
+}
+
+// separate writes what goes between the previous node and n when they
+// are on separate lines, or just a space if n is a trailing comment.
+func (f *formatter) separate(n *node, indent int) {
+	if n.trailing {
+		f.write(" ")
+		return
+	}
+	if n.blank {
+		f.write("\n")
+	}
+	f.newline(indent)
+}
+
+// flat returns the single-line rendering of n. If n can't be rendered
+// on a single line because it contains a comment or a blank line, it
+// returns false.
+func flat(n *node) (string, bool) {
+	switch {
+	case n.comment:
+		return "", false
+
+	case n.isGroup(), n.glue:
+		var sb strings.Builder
+		sb.WriteString(n.open)
+		for i, c := range n.children {
+			if i > 0 && !n.glue {
+				sb.WriteByte(' ')
+			}
+			str, ok := flat(c)
+			if !ok || c.blank {
+				return "", false
+			}
+			sb.WriteString(str)
+		}
+		sb.WriteString(n.close)
+		return sb.String(), true
+
+	default:
+		return n.text, true
+	}
+}
+
+func (f *formatter) node(n *node, indent int) {
+	if str, ok := flat(n); ok && (f.col+width(str) <= Width || !n.isGroup() && !n.glue) {
+		f.write(str)
+		return
+	}
+
+	if n.glue {
+		for _, c := range n.children {
+			f.node(c, indent)
+		}
+		return
+	}
+	if !n.isGroup() {
+		f.write(n.text)
+		return
+	}
+
+	f.write(n.open)
+	children := n.children
+	if len(children) > 0 && !children[0].comment {
+		f.node(children[0], indent+1)
+		keep := !children[0].isGroup() && !children[0].glue && n.open == "("
+		children = children[1:]
+		if keep && len(children) > 0 && !children[0].comment && !children[0].blank {
+			if str, ok := flat(children[0]); ok && f.col+1+width(str) <= Width {
+				f.write(" " + str)
+				children = children[1:]
+			}
+		}
+	}
+	for _, c := range children {
+		f.separate(c, indent+1)
+		f.node(c, indent+1)
+	}
+	f.newline(indent)
+	f.write(n.close)
+}
@@ -0,0 +1,76 @@
+package format_test
+
+import (
+	"testing"
+
+	"deedles.dev/extract/format"
+	"deedles.dev/extract/parser"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		output string
+	}{
+		{"Simple", `(IO.println   "This is a \"test\".")`, "(IO.println \"This is a \\\"test\\\".\")\n"},
+		{"Literals", `(list 1 2.0 -3 'a' '\'' :atom Atom :"an atom" [1 2])`, "(list 1 2.0 -3 'a' '\\'' :atom Atom :\"an atom\" [1 2])\n"},
+		{"Binary", `(f b"a\xFF\n")`, "(f b\"a\\xff\\n\")\n"},
+		{"Patterns", `(def (f \x [a &rest] b \\ 2) x)`, "(def (f \\x [a &rest] b \\\\ 2) x)\n"},
+		{"Operators", `(1 + 2 * 3)`, "(add 1 (mul 2 3))\n"},
+		{"Break", `(defmodule Example (def (add a b) (+ a b)) (def (sub a b) (- a b)) (def (mul a b) (* a b)))`, "(defmodule Example\n\t(def (add a b) (add a b))\n\t(def (sub a b) (sub a b))\n\t(def (mul a b) (mul a b))\n)\n"},
+		{"Multiple", "(a)\n\n\n(b)", "(a)\n(b)\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			list, err := parser.ParseString(test.name, test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			output := format.Format(list)
+			if output != test.output {
+				t.Fatalf("\n%v\n!=\n%v", output, test.output)
+			}
+
+			_, err = parser.ParseString(test.name, output)
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		output string
+	}{
+		{"Simple", "(IO.println  \"test\")  ", "(IO.println \"test\")\n"},
+		{"Comments", "# Header.\n(add 1 # one\n 2)\n\n\n\n# Trailer.", "# Header.\n(add 1 # one\n\t2\n)\n\n# Trailer.\n"},
+		{"Blank", "(defmodule Example (def (a) 1)\n\n(def (b) 2)\n(def (c) 3))", "(defmodule Example\n\t(def (a) 1)\n\n\t(def (b) 2)\n\t(def (c) 3)\n)\n"},
+		{"Literals", `(list  1.50 \x &y A.b   (1 + 2))`, "(list 1.50 \\x &y A.b (1 + 2))\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			output, err := format.Source([]byte(test.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(output) != test.output {
+				t.Fatalf("\n%v\n!=\n%v", string(output), test.output)
+			}
+		})
+	}
+
+	_, err := format.Source([]byte(`(add 1`))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,118 @@
+package format
+
+import (
+	"bytes"
+	"strings"
+
+	"deedles.dev/extract/parser"
+	"deedles.dev/extract/scanner"
+)
+
+// Source formats Extract source code. Unlike [Format], it works
+// directly from the tokens of the source, so comments are preserved,
+// as are single blank lines between expressions and the original
+// spelling of literals. If src is not syntactically valid, Source
+// returns the error that parsing it produced.
+func Source(src []byte) ([]byte, error) {
+	_, err := parser.ParseBytes("", src)
+	if err != nil {
+		return nil, err
+	}
+
+	s := scanner.New(bytes.NewReader(src), scanner.WithTrivia())
+	b := builder{src: src}
+	for tok := range s.All() {
+		b.toks = append(b.toks, tok)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	var f formatter
+	f.top(b.seq())
+	return f.buf.Bytes(), nil
+}
+
+// builder builds the tree of nodes that is rendered by the formatter
+// from a sequence of tokens.
+type builder struct {
+	src  []byte
+	toks []scanner.Token
+	i    int
+}
+
+func (b *builder) next() (scanner.Token, bool) {
+	if b.i >= len(b.toks) {
+		return scanner.Token{}, false
+	}
+	tok := b.toks[b.i]
+	b.i++
+	return tok, true
+}
+
+func (b *builder) text(tok scanner.Token) string {
+	return string(b.src[tok.Offset:tok.End])
+}
+
+// seq builds nodes until it reaches either a closing delimiter or the
+// end of the tokens.
+func (b *builder) seq() []*node {
+	var nodes []*node
+	var newlines int
+	for {
+		tok, ok := b.next()
+		if !ok {
+			return nodes
+		}
+
+		var n *node
+		switch val := tok.Val.(type) {
+		case scanner.Whitespace:
+			newlines += strings.Count(string(val), "\n")
+			continue
+		case scanner.Rparen, scanner.Rbracket:
+			return nodes
+		case scanner.Comment:
+			n = &node{text: string(val), comment: true, trailing: len(nodes) > 0 && newlines == 0}
+		default:
+			n = b.operand(tok)
+		}
+
+		n.blank = len(nodes) > 0 && newlines > 1
+		nodes = append(nodes, n)
+		newlines = 0
+	}
+}
+
+// operand builds a node starting from tok, including any refs into it
+// and the operand of a prefix operator.
+func (b *builder) operand(tok scanner.Token) *node {
+	var n *node
+	switch tok.Val.(type) {
+	case scanner.Lparen, scanner.Lbracket:
+		n = &node{open: b.text(tok)}
+		n.children = b.seq()
+		n.close = map[string]string{"(": ")", "[": "]"}[n.open]
+	case scanner.Pin, scanner.Rest:
+		operand, ok := b.next()
+		if !ok {
+			return leaf(b.text(tok))
+		}
+		return &node{glue: true, children: []*node{leaf(b.text(tok)), b.operand(operand)}}
+	default:
+		n = leaf(b.text(tok))
+	}
+
+	for b.i < len(b.toks) {
+		if _, ok := b.toks[b.i].Val.(scanner.Dot); !ok {
+			break
+		}
+		b.i++
+		name, ok := b.next()
+		if !ok {
+			break
+		}
+		n = &node{glue: true, children: []*node{n, leaf("."), leaf(b.text(name))}}
+	}
+	return n
+}
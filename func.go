@@ -4,48 +4,180 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"slices"
+	"sync/atomic"
 
+	"deedles.dev/extract/scanner"
 	"deedles.dev/xiter"
 )
 
 var ErrPatternMatch = errors.New("arguments did not match defined patterns")
 
+// MatchError is returned when a value fails to match a pattern that
+// it was explicitly matched against, such as with match. It is
+// considered to be equivalent to [ErrPatternMatch] by [errors.Is].
+type MatchError struct {
+	Val any
+}
+
+func (err *MatchError) Error() string {
+	return fmt.Sprintf("no match of value %v", err.Val)
+}
+
+func (err *MatchError) Is(target error) bool {
+	return target == ErrPatternMatch
+}
+
+func (err *MatchError) ErrorCode() Atom {
+	return codeNoMatch
+}
+
 type funcVariant struct {
 	Pattern *Pattern
 	Body    *List
+
+	// program is the body compiled to bytecode. See [WithBytecode].
+	program *lazyProgram
+}
+
+func newFuncVariant(pattern *Pattern, body *List) funcVariant {
+	return funcVariant{Pattern: pattern, Body: body, program: new(lazyProgram)}
+}
+
+// run runs the body of the variant in env, which should have the
+// parameters bound.
+func (v *funcVariant) run(env *Env) any {
+	if env.useVM() {
+		_, r := v.program.get(v.Body).run(env)
+		return r
+	}
+	_, r := Run(env, v.Body.All())
+	return r
 }
 
+// Func is a function declared in Extract code, either with def or
+// func. A Func can have multiple variants, each with its own pattern
+// and body. When called, the body of the first variant whose pattern
+// matches the arguments is run.
 type Func struct {
-	env      *Env
-	name     Ident
-	variants []funcVariant
+	env  *Env
+	name Ident
+
+	// variants is replaced, rather than modified, by AddVariant so
+	// that a function in a module can be called by another process
+	// while the module is still being declared.
+	variants atomic.Pointer[[]funcVariant]
 }
 
+// NewFunc creates a function whose body is run in env. The name of
+// the function is bound to the function itself inside of its body,
+// allowing a function that is not declared in a module, such as one
+// created by func, to call itself recursively without first being
+// bound with let.
 func NewFunc(env *Env, name Ident, pattern *Pattern, body *List) *Func {
-	f := Func{
-		name:     name,
-		variants: []funcVariant{{Pattern: pattern, Body: body}},
-	}
+	f := Func{name: name}
+	f.AddVariant(pattern, body)
 	f.env = env.Let(name, &f)
 	return &f
 }
 
+// newModuleFunc creates a function that is declared in a module.
+// Unlike [NewFunc], the function's own name is not bound directly in
+// its environment. Instead, all names, including its own, are
+// resolved through the module when the function is called, allowing
+// functions in the same module to refer to each other regardless of
+// the order in which they were declared.
+func newModuleFunc(env *Env, name Ident, pattern *Pattern, body *List) *Func {
+	f := Func{env: env, name: name}
+	f.AddVariant(pattern, body)
+	return &f
+}
+
 func (f *Func) Eval(env *Env, args *List) (*Env, any) {
-	eargs := CollectList(EvalAll(env, args.All()))
-	for _, variant := range f.variants {
-		if fenv, ok := variant.Pattern.Match(f.env, eargs); ok {
-			_, r := Run(fenv, variant.Body.All())
-			return env, r
+	if args == nil {
+		return env, f
+	}
+
+	return f.call(env, env.pos, CollectList(EvalAll(env, args.All())))
+}
+
+// call calls f from a call at pos with arguments that have already
+// been evaluated.
+func (f *Func) call(env *Env, pos scanner.Position, eargs *List) (*Env, any) {
+	fenv, v, c, r := f.enter(env, pos, eargs)
+	if v == nil {
+		return env, r
+	}
+	return env, c.finish(v.run(fenv))
+}
+
+// activeCall is a call to a [Func] whose body is being run.
+type activeCall struct {
+	stack *stack
+	exit  func()
+}
+
+// finish finishes the call with r, the result of the body.
+func (c activeCall) finish(r any) any {
+	if c.exit != nil {
+		c.exit()
+	}
+	return traceError(c.stack, r)
+}
+
+// enter starts a call to f from a call at pos with arguments that
+// have already been evaluated. If one of f's variants matches them, it returns that
+// variant and the Env to run its body in, and the call must be
+// finished with the result of the body. Otherwise, the variant is nil
+// and r is the result of the call, which is an error.
+func (f *Func) enter(env *Env, pos scanner.Position, eargs *List) (fenv *Env, v *funcVariant, c activeCall, r any) {
+	cenv := f.env.inherit(env)
+	cenv.pos = pos
+	cenv.stack = cenv.stack.Push(Frame{Func: f.name, Pos: pos})
+	cenv.depth++
+	if cenv.maxDepth > 0 && cenv.depth > cenv.maxDepth {
+		return nil, nil, c, traceError(cenv.stack, &StackOverflowError{Depth: cenv.maxDepth})
+	}
+
+	c.stack = cenv.stack
+	if cenv.profile != nil {
+		c.exit = cenv.profile.enter(cenv, f, eargs.Len())
+	}
+	variants := *f.variants.Load()
+	for i := range variants {
+		if fenv, ok := variants[i].Pattern.Match(cenv, eargs); ok {
+			if cenv.tracer != nil {
+				cenv.tracer.match(cenv, f, eargs, i)
+			}
+			cenv.hooks.enter(fenv, f, eargs)
+			return fenv, &variants[i], c, nil
 		}
 	}
-	return env, ErrPatternMatch
+	if cenv.tracer != nil {
+		cenv.tracer.match(cenv, f, eargs, -1)
+	}
+	return nil, nil, c, c.finish(ErrPatternMatch)
+}
+
+// Name returns the name that the function was declared with.
+func (f *Func) Name() Ident {
+	return f.name
 }
 
 func (f *Func) AddVariant(pattern *Pattern, body *List) {
-	f.variants = append(f.variants, funcVariant{Pattern: pattern, Body: body})
+	var variants []funcVariant
+	if old := f.variants.Load(); old != nil {
+		variants = slices.Clip(*old)
+	}
+	variants = append(variants, newFuncVariant(pattern, body))
+	f.variants.Store(&variants)
 }
 
-func compileFuncPattern(env *Env, pattern any) (name Ident, cpattern *Pattern, err error) {
+// compileFuncPattern compiles the head of a function declaration. It
+// returns one pattern for each arity that the function can be called
+// with, starting with the one that accepts every argument, as more
+// than one is necessary if any of the parameters have default values.
+func compileFuncPattern(env *Env, pattern any) (name Ident, cpatterns []*Pattern, err error) {
 	switch pattern := pattern.(type) {
 	case Call:
 		if pattern.Len() == 0 {
@@ -57,12 +189,48 @@ func compileFuncPattern(env *Env, pattern any) (name Ident, cpattern *Pattern, e
 			return Ident{}, nil, NewTypeError(name, reflect.TypeFor[Ident]())
 		}
 
-		cpattern, err := CompilePattern(env, pattern.Tail())
+		params := make([]any, 0, pattern.Len()-1)
+		var defaults []any
+		for param := range pattern.Tail().All() {
+			switch param := param.(type) {
+			case Default:
+				if _, ok := param.Pattern.(Rest); ok {
+					return name, nil, errors.New("rest parameter can not have a default")
+				}
+				params = append(params, param.Pattern)
+				defaults = append(defaults, param.Value)
+			default:
+				if len(defaults) > 0 {
+					return name, nil, errors.New("parameters without defaults must come before parameters with defaults")
+				}
+				params = append(params, param)
+			}
+		}
+
+		root, err := listMatcher(env, ListOf(params...))
 		if err != nil {
 			return name, nil, err
 		}
 
-		return name, cpattern, nil
+		// prefixes[i] matches the parameters before the ith default,
+		// which are bound while it is evaluated.
+		prefixes := make([]matcher, len(defaults))
+		for i := range defaults {
+			prefix, err := listMatcher(env, ListOf(params[:len(params)-len(defaults)+i]...))
+			if err != nil {
+				return name, nil, err
+			}
+			prefixes[i] = prefix
+		}
+
+		cpatterns = make([]*Pattern, 0, len(defaults)+1)
+		cpatterns = append(cpatterns, &Pattern{root: root})
+		for i := len(defaults) - 1; i >= 0; i-- {
+			required := len(params) - len(defaults) + i
+			cpatterns = append(cpatterns, &Pattern{root: defaultsMatcher(root, required, prefixes[i:], defaults[i:])})
+		}
+
+		return name, cpatterns, nil
 
 	default:
 		return Ident{}, nil, NewTypeError(pattern, reflect.TypeFor[*List](), reflect.TypeFor[Ident]())
@@ -86,16 +254,29 @@ func CompilePattern(env *Env, format any) (*Pattern, error) {
 
 func compilePattern(env *Env, format any) (matcher, error) {
 	switch format := format.(type) {
-	case Atom, int64, float64, string:
+	case Atom, int64, float64, string, Rune, bool:
 		return equalityMatcher(format), nil
+	case *Binary:
+		return valueMatcher(format), nil
 	case Ident:
 		return assignMatcher(format), nil
 	case Pinned:
+		if format.Expr != nil {
+			return pinExprMatcher(env, format.Expr)
+		}
 		return pinMatcher(env, format.Ident)
+	case Default:
+		return nil, errors.New("default values are only allowed in function parameters")
+	case Rest:
+		return nil, errors.New("rest patterns are only allowed at the end of a list pattern")
 	case Call:
 		return listMatcher(env, format.List)
+	case ListExpr:
+		return listMatcher(env, format.List)
 	case *List:
 		return listMatcher(env, format)
+	case *Tuple:
+		return tupleMatcher(env, format)
 	default:
 		return nil, fmt.Errorf("unexpected type %T in pattern", format)
 	}
@@ -107,6 +288,14 @@ func equalityMatcher[T comparable](val T) matcher {
 	}
 }
 
+// valueMatcher matches values that are [Equal] to val. It is used for
+// literals that aren't comparable with ==.
+func valueMatcher(val any) matcher {
+	return func(env *Env, v any) (*Env, bool) {
+		return env, Equal(val, v)
+	}
+}
+
 func assignMatcher(name Ident) matcher {
 	return func(env *Env, val any) (*Env, bool) {
 		return env.Let(name, val), true
@@ -124,9 +313,30 @@ func pinMatcher(env *Env, name Ident) (matcher, error) {
 	}, nil
 }
 
+func pinExprMatcher(env *Env, expr any) (matcher, error) {
+	_, val := Eval(env, expr, nil)
+	if err, ok := val.(error); ok {
+		return nil, err
+	}
+
+	return func(env *Env, v any) (*Env, bool) {
+		return env, Equal(val, v)
+	}, nil
+}
+
 func listMatcher(env *Env, list *List) (matcher, error) {
+	var rest matcher
 	matchers := make([]matcher, 0, list.Len())
-	for part := range list.All() {
+	for i, part := range xiter.Enumerate(list.All()) {
+		if r, ok := part.(Rest); ok && i == list.Len()-1 {
+			m, err := compilePattern(env, r.Pattern)
+			if err != nil {
+				return nil, err
+			}
+			rest = m
+			break
+		}
+
 		matcher, err := compilePattern(env, part)
 		if err != nil {
 			return nil, err
@@ -135,17 +345,96 @@ func listMatcher(env *Env, list *List) (matcher, error) {
 	}
 
 	return func(env *Env, val any) (_ *Env, ok bool) {
+		if t, ok := val.(*Tuple); ok {
+			return matchTuple(env, matchers, rest, t)
+		}
+
 		vlist, ok := val.(*List)
-		if !ok || vlist.Len() != len(matchers) {
+		if !ok {
+			return env, false
+		}
+		if vlist.Len() != len(matchers) && (rest == nil || vlist.Len() < len(matchers)) {
 			return env, false
 		}
 
-		for i, v := range xiter.Enumerate(vlist.All()) {
-			env, ok = matchers[i](env, v)
+		for _, m := range matchers {
+			env, ok = m(env, vlist.Head())
 			if !ok {
 				return env, false
 			}
+			vlist = vlist.Tail()
+		}
+		if rest != nil {
+			return rest(env, vlist)
 		}
 		return env, true
 	}, nil
 }
+
+// tupleMatcher returns a matcher that matches tuples with the same
+// number of elements as tuple if each one matches the pattern in the
+// same position in tuple.
+func tupleMatcher(env *Env, tuple *Tuple) (matcher, error) {
+	matchers := make([]matcher, 0, tuple.Len())
+	for part := range tuple.All() {
+		matcher, err := compilePattern(env, part)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, matcher)
+	}
+
+	return func(env *Env, val any) (*Env, bool) {
+		t, ok := val.(*Tuple)
+		if !ok {
+			return env, false
+		}
+		return matchTuple(env, matchers, nil, t)
+	}, nil
+}
+
+// matchTuple matches the elements of t against matchers in order. If
+// rest is not nil, t may have more elements than there are matchers,
+// and rest is matched against a tuple of the remaining ones.
+func matchTuple(env *Env, matchers []matcher, rest matcher, t *Tuple) (_ *Env, ok bool) {
+	if t.Len() != len(matchers) && (rest == nil || t.Len() < len(matchers)) {
+		return env, false
+	}
+
+	for i, m := range matchers {
+		env, ok = m(env, t.At(i))
+		if !ok {
+			return env, false
+		}
+	}
+	if rest != nil {
+		return rest(env, &Tuple{elems: t.elems[len(matchers):]})
+	}
+	return env, true
+}
+
+// defaultsMatcher returns a matcher that matches lists of exactly
+// num elements by evaluating defaults, appending the results to the
+// list, and then passing the full list to root. Each default is
+// evaluated with the parameters before it bound by the corresponding
+// matcher in prefixes, so a default can refer to earlier parameters,
+// including ones with defaults of their own.
+func defaultsMatcher(root matcher, num int, prefixes []matcher, defaults []any) matcher {
+	return func(env *Env, val any) (*Env, bool) {
+		vlist, ok := val.(*List)
+		if !ok || vlist.Len() != num {
+			return env, false
+		}
+
+		full := slices.AppendSeq(make([]any, 0, num+len(defaults)), vlist.All())
+		for i, def := range defaults {
+			denv, ok := prefixes[i](env, ListOf(full...))
+			if !ok {
+				return env, false
+			}
+			_, v := Eval(denv, def, nil)
+			full = append(full, v)
+		}
+		return root(env, ListOf(full...))
+	}
+}
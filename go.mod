@@ -4,4 +4,11 @@ go 1.23.1
 
 require deedles.dev/xsync v0.0.0-20240920041009-6377909f36b4
 
-require deedles.dev/xiter v0.0.0-20240903181553-ec85411a9550
+require (
+	deedles.dev/xiter v0.0.0-20240903181553-ec85411a9550
+	github.com/BurntSushi/toml v1.6.0
+	golang.org/x/term v0.34.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require golang.org/x/sys v0.35.0 // indirect
@@ -0,0 +1,136 @@
+package extract
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// RegisterFunc declares a function in the module named name that
+// calls the Go function fn. It panics if fn is not a function or if m
+// is one of the standard library modules, which are shared by every
+// Env. To add functions to a standard library module, create a new
+// module with the same name and add it with [WithModules] instead.
+//
+// Arguments are converted to the types of fn's parameters: int64s to
+// any integer type, int64s and float64s to any float type, strings and
+// atoms to strings, strings to byte slices, and *Lists to slices,
+// converting their elements recursively. Values that are assignable
+// to a parameter's type, such as any *List to a *List parameter or
+// anything at all to an any parameter, are passed unconverted. If fn's
+// first parameter is a *Env or a context.Context, it is passed the
+// Env that the function is called in or that Env's context,
+// respectively, instead of an argument. Variadic functions are
+// supported.
+//
+// If fn's last result is an error and is not nil, calling the
+// function returns it. Otherwise, the other results are converted to
+// Extract values, with integers and floats becoming int64 and float64
+// and slices becoming *Lists. An unsigned integer that is too large
+// to be an int64 makes the call fail with an error wrapping
+// [ErrIntRange]. If there is only one other result, it is returned
+// directly, if there are several they are returned as a list, and if
+// there are none, the function returns :ok.
+func (m *Module) RegisterFunc(name string, fn any) {
+	if std[m.name] == m {
+		panic(fmt.Errorf("register %v.%v: standard library modules can't be modified", m.name, name))
+	}
+
+	f, err := wrapFunc(fn)
+	if err != nil {
+		panic(fmt.Errorf("register %v.%v: %w", m.name, name, err))
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.decls == nil {
+		m.decls = make(map[Ident]any)
+	}
+	m.decls[MakeIdent(name)] = f
+}
+
+func wrapFunc(fn any) (EvalFunc, error) {
+	fv := reflect.ValueOf(fn)
+	if fv.Kind() != reflect.Func || fv.IsNil() {
+		return nil, fmt.Errorf("%T is not a function", fn)
+	}
+
+	ft := fv.Type()
+	var injected func(env *Env) reflect.Value
+	if ft.NumIn() > 0 {
+		switch ft.In(0) {
+		case typeEnv:
+			injected = func(env *Env) reflect.Value { return reflect.ValueOf(env) }
+		case typeContext:
+			injected = func(env *Env) reflect.Value { return reflect.ValueOf(env.Context()) }
+		}
+	}
+
+	return func(env *Env, args *List) (*Env, any) {
+		in := make([]reflect.Value, 0, ft.NumIn())
+		if injected != nil {
+			in = append(in, injected(env))
+		}
+
+		min, max := ft.NumIn()-len(in), ft.NumIn()-len(in)
+		if ft.IsVariadic() {
+			min, max = min-1, -1
+		}
+		if args.Len() < min || (max >= 0 && args.Len() > max) {
+			return env, &ArgumentNumError{Num: args.Len(), Expected: max}
+		}
+
+		for arg := range EvalAll(env, args.All()) {
+			if err, ok := arg.(error); ok {
+				return env, err
+			}
+
+			t := paramType(ft, len(in))
+			v, err := toGo(arg, t)
+			if err != nil {
+				return env, err
+			}
+			in = append(in, v)
+		}
+
+		return env, fromGoResults(fv.Call(in))
+	}, nil
+}
+
+// paramType returns the type of the ith argument to a function of
+// type ft, taking variadic parameters into account.
+func paramType(ft reflect.Type, i int) reflect.Type {
+	if ft.IsVariadic() && i >= ft.NumIn()-1 {
+		return ft.In(ft.NumIn() - 1).Elem()
+	}
+	return ft.In(i)
+}
+
+func fromGoResults(out []reflect.Value) any {
+	if len(out) > 0 && out[len(out)-1].Type() == typeError {
+		if err := out[len(out)-1]; !err.IsNil() {
+			return err.Interface()
+		}
+		out = out[:len(out)-1]
+	}
+
+	switch len(out) {
+	case 0:
+		return okAtom
+	case 1:
+		r, err := fromGo(out[0])
+		if err != nil {
+			return err
+		}
+		return r
+	default:
+		var list *List
+		for i := len(out) - 1; i >= 0; i-- {
+			r, err := fromGo(out[i])
+			if err != nil {
+				return err
+			}
+			list = list.Push(r)
+		}
+		return list
+	}
+}
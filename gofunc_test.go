@@ -0,0 +1,111 @@
+package extract_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"deedles.dev/extract"
+	"deedles.dev/extract/parser"
+)
+
+func TestRegisterFunc(t *testing.T) {
+	errNegative := errors.New("negative")
+
+	m := extract.NewModule(extract.MakeAtom("Host"), nil)
+	m.RegisterFunc("repeat", strings.Repeat)
+	m.RegisterFunc("half", func(f float32) float32 { return f / 2 })
+	m.RegisterFunc("sum", func(nums ...int) (sum int) {
+		for _, n := range nums {
+			sum += n
+		}
+		return sum
+	})
+	m.RegisterFunc("sqrt", func(n int) (int, error) {
+		if n < 0 {
+			return 0, errNegative
+		}
+		for i := 0; ; i++ {
+			if i*i > n {
+				return i - 1, nil
+			}
+		}
+	})
+	m.RegisterFunc("split", func(s string) ([]string, int) {
+		parts := strings.Fields(s)
+		return parts, len(parts)
+	})
+	m.RegisterFunc("has_context", func(ctx context.Context) bool { return ctx != nil })
+	m.RegisterFunc("noop", func() {})
+	m.RegisterFunc("uint", func(n uint64) uint64 { return n })
+	m.RegisterFunc("max_uints", func() []uint64 { return []uint64{1, math.MaxUint64} })
+
+	tests := []struct {
+		name   string
+		src    string
+		result any
+	}{
+		{"Repeat", `(Host.repeat "ab" 3)`, "ababab"},
+		{"Float", `(Host.half 3)`, 1.5},
+		{"Variadic", `(list (Host.sum) (Host.sum 1 2 3))`, extract.ListOf(int64(0), int64(6))},
+		{"Result", `(Host.sqrt 10)`, int64(3)},
+		{"Error", `(Host.sqrt -1)`, errNegative},
+		{"Results", `(Host.split "a b")`, extract.ListOf(extract.ListOf("a", "b"), int64(2))},
+		{"Context", `(Host.has_context)`, true},
+		{"Nothing", `(Host.noop)`, extract.MakeAtom("ok")},
+		{"TypeError", `(Host.repeat 1 2)`, new(extract.TypeError)},
+		{"ArgumentNum", `(Host.repeat "a")`, new(extract.ArgumentNumError)},
+		{"Uint", `(Host.uint 3)`, int64(3)},
+		{"UintRange", `(Host.max_uints)`, extract.ErrIntRange},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			script, err := parser.ParseString(t.Name(), test.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			env := extract.New(context.Background(), extract.WithModules(m))
+			_, result := extract.Run(env, script.All())
+			err, _ = result.(error)
+			switch ex := test.result.(type) {
+			case *extract.TypeError:
+				if !errors.As(err, &ex) {
+					t.Fatalf("%#v", result)
+				}
+			case *extract.ArgumentNumError:
+				if !errors.As(err, &ex) {
+					t.Fatalf("%#v", result)
+				}
+			case error:
+				if !errors.Is(err, ex) {
+					t.Fatalf("%#v", result)
+				}
+			default:
+				if !extract.Equal(result, ex) {
+					t.Fatalf("%v", extract.Inspect(result))
+				}
+			}
+		})
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic")
+		}
+	}()
+	m.RegisterFunc("bad", 3)
+}
+
+func TestRegisterFuncStd(t *testing.T) {
+	env := extract.New(context.Background())
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic")
+		}
+	}()
+	env.GetModule(extract.MakeAtom("String")).RegisterFunc("noop", func() {})
+}
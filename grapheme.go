@@ -0,0 +1,64 @@
+package extract
+
+import (
+	"unicode"
+)
+
+const (
+	zeroWidthJoiner = '‍'
+	regionalStart   = '\U0001F1E6'
+	regionalEnd     = '\U0001F1FF'
+)
+
+// graphemes splits str into approximate extended grapheme clusters.
+// It keeps combining marks, variation selectors, and emoji modifiers
+// with the preceding rune, joins runes around a zero width joiner,
+// pairs regional indicators into flags, and keeps \r\n together. It
+// does not implement the full segmentation rules of Unicode Standard
+// Annex #29, but it handles the common cases that make splitting by
+// rune produce surprising results.
+func graphemes(str string) []string {
+	var clusters []string
+	start := 0
+	var prev rune
+	regional := 0
+	for i, r := range str {
+		if i > 0 && !extendsCluster(prev, r, regional) {
+			clusters = append(clusters, str[start:i])
+			start = i
+			regional = 0
+		}
+		if isRegional(r) {
+			regional++
+		}
+		prev = r
+	}
+	if start < len(str) {
+		clusters = append(clusters, str[start:])
+	}
+	return clusters
+}
+
+// extendsCluster returns true if r belongs in the same cluster as the
+// preceding rune, prev. regional is the number of regional indicators
+// in the current cluster.
+func extendsCluster(prev, r rune, regional int) bool {
+	switch {
+	case prev == '\r' && r == '\n':
+		return true
+	case prev == zeroWidthJoiner, r == zeroWidthJoiner:
+		return true
+	case isRegional(prev) && isRegional(r):
+		return regional%2 == 1
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc), unicode.Is(unicode.Variation_Selector, r):
+		return true
+	case r >= '\U0001F3FB' && r <= '\U0001F3FF': // Emoji skin tone modifiers.
+		return true
+	default:
+		return false
+	}
+}
+
+func isRegional(r rune) bool {
+	return r >= regionalStart && r <= regionalEnd
+}
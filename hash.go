@@ -0,0 +1,131 @@
+package extract
+
+import (
+	"encoding/binary"
+	"hash/maphash"
+	"math"
+	"reflect"
+)
+
+var hashSeed = maphash.MakeSeed()
+
+// Hasher is implemented by types that want to define their own hash.
+// Any two values that are [Equal] must have the same hash.
+type Hasher interface {
+	Hash() uint64
+}
+
+// Hash returns a hash of v that is consistent with [Equal], meaning
+// that any two values that are equal have the same hash. This allows
+// values to be used as keys in hash-based collections. Hashes are
+// only stable for the lifetime of the process.
+//
+// Values that aren't Hashers and can't be compared, and thus are
+// never equal to anything, are all hashed based only on their type.
+func Hash(v any) uint64 {
+	var h maphash.Hash
+	h.SetSeed(hashSeed)
+	writeHash(&h, v)
+	return h.Sum64()
+}
+
+// Type tags written before values to keep values of different types
+// that have the same representation, such as an atom and a string,
+// from trivially colliding.
+const (
+	tagNil byte = iota
+	tagInt
+	tagFloat
+	tagRune
+	tagBool
+	tagAtom
+	tagIdent
+	tagString
+	tagHasher
+	tagOther
+)
+
+func writeHash(h *maphash.Hash, v any) {
+	switch v := v.(type) {
+	case nil:
+		h.WriteByte(tagNil)
+	case Hasher:
+		h.WriteByte(tagHasher)
+		writeUint(h, v.Hash())
+	case int64:
+		h.WriteByte(tagInt)
+		writeUint(h, uint64(v))
+	case float64:
+		h.WriteByte(tagFloat)
+		if v == 0 {
+			// Make 0 and -0 hash the same as they are equal.
+			v = 0
+		}
+		writeUint(h, math.Float64bits(v))
+	case Rune:
+		h.WriteByte(tagRune)
+		writeUint(h, uint64(v))
+	case bool:
+		h.WriteByte(tagBool)
+		if v {
+			h.WriteByte(1)
+		} else {
+			h.WriteByte(0)
+		}
+	case Atom:
+		h.WriteByte(tagAtom)
+		h.WriteString(v.String())
+	case Ident:
+		h.WriteByte(tagIdent)
+		h.WriteString(v.String())
+	case string:
+		h.WriteByte(tagString)
+		h.WriteString(v)
+	default:
+		h.WriteByte(tagOther)
+		writeReflect(h, reflect.ValueOf(v))
+	}
+}
+
+func writeUint(h *maphash.Hash, v uint64) {
+	h.Write(binary.LittleEndian.AppendUint64(make([]byte, 0, 8), v))
+}
+
+// writeReflect hashes an arbitrary comparable value in a way that is
+// consistent with ==.
+func writeReflect(h *maphash.Hash, v reflect.Value) {
+	h.WriteString(v.Type().String())
+	if !v.Comparable() {
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		writeHash(h, v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		writeUint(h, uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		writeUint(h, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		writeHash(h, v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		writeHash(h, real(v.Complex()))
+		writeHash(h, imag(v.Complex()))
+	case reflect.String:
+		h.WriteString(v.String())
+	case reflect.Pointer, reflect.Chan, reflect.UnsafePointer:
+		writeUint(h, uint64(v.Pointer()))
+	case reflect.Interface:
+		if !v.IsNil() {
+			writeReflect(h, v.Elem())
+		}
+	case reflect.Array:
+		for i := range v.Len() {
+			writeReflect(h, v.Index(i))
+		}
+	case reflect.Struct:
+		for i := range v.NumField() {
+			writeReflect(h, v.Field(i))
+		}
+	}
+}
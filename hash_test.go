@@ -0,0 +1,45 @@
+package extract_test
+
+import (
+	"math"
+	"testing"
+
+	"deedles.dev/extract"
+)
+
+func TestHash(t *testing.T) {
+	equal := [][2]any{
+		{int64(3), int64(3)},
+		{0.0, math.Copysign(0, -1)},
+		{"test", "test"},
+		{extract.MakeAtom("test"), extract.MakeAtom("test")},
+		{extract.ListOf(int64(1), extract.ListOf("two")), extract.ListOf(int64(1), extract.ListOf("two"))},
+		{extract.ListOf(), (*extract.List)(nil)},
+	}
+	for _, vals := range equal {
+		if !extract.Equal(vals[0], vals[1]) {
+			t.Fatalf("%v != %v", extract.Inspect(vals[0]), extract.Inspect(vals[1]))
+		}
+		if extract.Hash(vals[0]) != extract.Hash(vals[1]) {
+			t.Fatalf("hash(%v) != hash(%v)", extract.Inspect(vals[0]), extract.Inspect(vals[1]))
+		}
+	}
+
+	distinct := []any{
+		int64(1),
+		1.0,
+		"test",
+		extract.MakeAtom("test"),
+		extract.ListOf(int64(1)),
+		extract.ListOf(int64(1), int64(2)),
+		extract.ListOf(int64(2), int64(1)),
+	}
+	seen := make(map[uint64]any)
+	for _, v := range distinct {
+		h := extract.Hash(v)
+		if prev, ok := seen[h]; ok {
+			t.Fatalf("hash(%v) == hash(%v)", extract.Inspect(v), extract.Inspect(prev))
+		}
+		seen[h] = v
+	}
+}
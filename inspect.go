@@ -0,0 +1,149 @@
+package extract
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Inspector is implemented by values that want to control how they
+// are rendered by [Inspect].
+type Inspector interface {
+	// Inspect returns a readable representation of the value. If
+	// possible, it should be valid Extract code that evaluates to an
+	// equivalent value.
+	Inspect() string
+}
+
+// Inspect returns a readable representation of v. For values that
+// can be written as literals, such as numbers, strings, atoms, and
+// lists of them, the result is valid Extract code that evaluates to
+// an equivalent value. Other values, such as functions, are rendered
+// in the form #Type<details>.
+func Inspect(v any) string {
+	var sb strings.Builder
+	inspect(&sb, v)
+	return sb.String()
+}
+
+var (
+	bareAtomRE  = regexp.MustCompile(`^[A-Z][A-Za-z0-9_]*[?!]?$`)
+	colonAtomRE = regexp.MustCompile(`^[A-Za-z0-9_]+[?!]?$`)
+)
+
+func inspect(sb *strings.Builder, v any) {
+	switch v := v.(type) {
+	case Inspector:
+		sb.WriteString(v.Inspect())
+
+	case nil:
+		sb.WriteString("#nil")
+	case bool:
+		sb.WriteString(strconv.FormatBool(v))
+	case int64:
+		sb.WriteString(strconv.FormatInt(v, 10))
+	case float64:
+		str := strconv.FormatFloat(v, 'f', -1, 64)
+		sb.WriteString(str)
+		if !strings.ContainsAny(str, ".NI") {
+			sb.WriteString(".0")
+		}
+	case string:
+		quote(sb, v, '"')
+	case Rune:
+		quote(sb, string(v), '\'')
+	case Ident:
+		sb.WriteString(v.String())
+	case Atom:
+		str := v.String()
+		switch {
+		case bareAtomRE.MatchString(str):
+			sb.WriteString(str)
+		case colonAtomRE.MatchString(str):
+			sb.WriteByte(':')
+			sb.WriteString(str)
+		default:
+			sb.WriteByte(':')
+			quote(sb, str, '"')
+		}
+
+	case *List:
+		inspectList(sb, "[", "]", v)
+	case Call:
+		inspectList(sb, "(", ")", v.List)
+	case ListExpr:
+		inspectList(sb, "[", "]", v.List)
+	case Ref:
+		inspect(sb, v.In)
+		sb.WriteByte('.')
+		sb.WriteString(v.Name.String())
+	case Pinned:
+		sb.WriteByte('\\')
+		if v.Expr != nil {
+			inspect(sb, v.Expr)
+			return
+		}
+		sb.WriteString(v.Ident.String())
+	case Default:
+		inspect(sb, v.Pattern)
+		sb.WriteString(` \\ `)
+		inspect(sb, v.Value)
+	case Rest:
+		sb.WriteByte('&')
+		inspect(sb, v.Pattern)
+
+	case *Func:
+		fmt.Fprintf(sb, "#Func<%v>", v.name)
+	case EvalFunc:
+		fmt.Fprintf(sb, "#Builtin<%p>", v)
+	case *Module:
+		fmt.Fprintf(sb, "#Module<%v>", Inspect(v.name))
+	case error:
+		fmt.Fprintf(sb, "#Error<%v>", v)
+	case fmt.Stringer:
+		sb.WriteString(v.String())
+	default:
+		fmt.Fprintf(sb, "#%T<%v>", v, v)
+	}
+}
+
+func inspectList(sb *strings.Builder, open, close string, list *List) {
+	sb.WriteString(open)
+	first := true
+	for v := range list.All() {
+		if !first {
+			sb.WriteByte(' ')
+		}
+		first = false
+		inspect(sb, v)
+	}
+	sb.WriteString(close)
+}
+
+func quote(sb *strings.Builder, str string, q rune) {
+	sb.WriteRune(q)
+	for _, c := range str {
+		switch c {
+		case q, '\\':
+			sb.WriteByte('\\')
+			sb.WriteRune(c)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\t':
+			sb.WriteString(`\t`)
+		default:
+			sb.WriteRune(c)
+		}
+	}
+	sb.WriteRune(q)
+}
+
+func kernelInspect(env *Env, args *List) (*Env, any) {
+	if args.Len() != 1 {
+		return env, &ArgumentNumError{Num: args.Len(), Expected: 1}
+	}
+
+	_, v := Eval(env, args.Head(), nil)
+	return env, Inspect(v)
+}
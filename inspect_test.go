@@ -0,0 +1,68 @@
+package extract_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"deedles.dev/extract"
+	"deedles.dev/extract/parser"
+)
+
+func TestInspect(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  any
+		output string
+	}{
+		{"Int", int64(-3), "-3"},
+		{"Float", 2.0, "2.0"},
+		{"String", "a \"quoted\"\nstring", `"a \"quoted\"\nstring"`},
+		{"Rune", extract.Rune('\''), `'\''`},
+		{"Atom", extract.MakeAtom("atom"), ":atom"},
+		{"BareAtom", extract.MakeAtom("Atom"), "Atom"},
+		{"QuotedAtom", extract.MakeAtom("an atom"), `:"an atom"`},
+		{"List", extract.ListOf(int64(1), "two", extract.ListOf(extract.MakeAtom("three"))), `[1 "two" [:three]]`},
+		{"Call", extract.Call{List: extract.ListOf(extract.MakeIdent("add"), int64(1), int64(2))}, "(add 1 2)"},
+		{"Ref", extract.Ref{In: extract.MakeAtom("IO"), Name: extract.MakeIdent("println")}, "IO.println"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			if output := extract.Inspect(test.input); output != test.output {
+				t.Fatal(output)
+			}
+		})
+	}
+}
+
+func TestInspectBuiltin(t *testing.T) {
+	const src = `
+	(defmodule Test
+		(def (f x) x)
+	)
+	(list (inspect (list 1 "two" :three 4.0 'c' [5])) (inspect Test.f))
+	`
+	result := runScript(t, src, true)
+	ex := []any{`[1 "two" :three 4.0 'c' [5]]`, "#Func<f>"}
+	if s := slices.Collect(result.(*extract.List).All()); !slices.Equal(s, ex) {
+		t.Fatalf("%#v", result)
+	}
+}
+
+func TestString(t *testing.T) {
+	list, err := parser.ParseString(t.Name(), `(IO.println [1 \x] (f a \\ 2 &rest))`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const ex = `[(IO.println [1 \x] (f a \\ 2 &rest))]`
+	if str := fmt.Sprint(list); str != ex {
+		t.Fatal(str)
+	}
+	if str := fmt.Sprint(list.Head()); str != ex[1:len(ex)-1] {
+		t.Fatal(str)
+	}
+}
@@ -0,0 +1,201 @@
+package extract
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+)
+
+// ErrInvalidBase is returned when a number is converted to or from a
+// string with a base that is not supported.
+var ErrInvalidBase = errors.New("base must be between 2 and 36")
+
+// ErrNegativeExponent is returned when an integer is raised to a
+// negative power, which would not produce an integer.
+var ErrNegativeExponent = errors.New("negative exponent")
+
+func stdInteger() *Module {
+	m := Module{name: MakeAtom("Integer")}
+	m.decls = map[Ident]any{
+		MakeIdent("parse"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			str, base, err := evalNumString(env, args, 10)
+			if err != nil {
+				return env, err
+			}
+			if base < 2 || base > 36 {
+				return env, ErrInvalidBase
+			}
+
+			n, err := strconv.ParseInt(str, int(base), 64)
+			if err != nil {
+				return env, errorResult(numErrorReason(err))
+			}
+			return env, okResult(n)
+		}),
+		MakeIdent("abs"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			ints, err := evalInts(env, args, 1, 1)
+			if err != nil {
+				return env, err
+			}
+			switch {
+			case ints[0] == math.MinInt64:
+				// Its absolute value isn't representable.
+				return env, errorResult(MakeAtom("out_of_range"))
+			case ints[0] < 0:
+				return env, -ints[0]
+			}
+			return env, ints[0]
+		}),
+		MakeIdent("pow"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			ints, err := evalInts(env, args, 2, 2)
+			if err != nil {
+				return env, err
+			}
+			if ints[1] < 0 {
+				return env, ErrNegativeExponent
+			}
+			return env, intPow(ints[0], ints[1])
+		}),
+		MakeIdent("gcd"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			ints, err := evalInts(env, args, 2, 2)
+			if err != nil {
+				return env, err
+			}
+
+			a, b := ints[0], ints[1]
+			for b != 0 {
+				a, b = b, a%b
+			}
+			switch {
+			case a == math.MinInt64:
+				return env, errorResult(MakeAtom("out_of_range"))
+			case a < 0:
+				a = -a
+			}
+			return env, a
+		}),
+		MakeIdent("digits"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			n, base, err := evalIntOpt(env, args, 10)
+			if err != nil {
+				return env, err
+			}
+			if base < 2 {
+				return env, ErrInvalidBase
+			}
+
+			if n == 0 {
+				return env, ListOf(int64(0))
+			}
+
+			var digits *List
+			for ; n != 0; n /= base {
+				d := n % base
+				if d < 0 {
+					d = -d
+				}
+				digits = digits.Push(d)
+			}
+			return env, digits
+		}),
+		MakeIdent("is_even?"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			ints, err := evalInts(env, args, 1, 1)
+			if err != nil {
+				return env, err
+			}
+			return env, ints[0]%2 == 0
+		}),
+		MakeIdent("is_odd?"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			ints, err := evalInts(env, args, 1, 1)
+			if err != nil {
+				return env, err
+			}
+			return env, ints[0]%2 != 0
+		}),
+		MakeIdent("clamp"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			ints, err := evalInts(env, args, 3, 3)
+			if err != nil {
+				return env, err
+			}
+			return env, max(ints[1], min(ints[0], ints[2]))
+		}),
+		MakeIdent("min"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			ints, err := evalInts(env, args, 1, math.MaxInt)
+			if err != nil {
+				return env, err
+			}
+			return env, slices.Min(ints)
+		}),
+		MakeIdent("max"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			ints, err := evalInts(env, args, 1, math.MaxInt)
+			if err != nil {
+				return env, err
+			}
+			return env, slices.Max(ints)
+		}),
+		MakeIdent("to_string"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			n, base, err := evalIntOpt(env, args, 10)
+			if err != nil {
+				return env, err
+			}
+			if base < 2 || base > 36 {
+				return env, ErrInvalidBase
+			}
+			return env, strconv.FormatInt(n, int(base))
+		}),
+	}
+
+	return &m
+}
+
+// evalInts evaluates between min and max arguments, all of which must
+// be integers.
+func evalInts(env *Env, args *List, min, max int) ([]int64, error) {
+	if args.Len() < min || args.Len() > max {
+		expected := -1
+		if min == max {
+			expected = min
+		}
+		return nil, &ArgumentNumError{Num: args.Len(), Expected: expected}
+	}
+
+	vals, err := evalArgs(env, args)
+	if err != nil {
+		return nil, err
+	}
+
+	ints := make([]int64, 0, len(vals))
+	for _, v := range vals {
+		n, ok := v.(int64)
+		if !ok {
+			return nil, NewTypeError(v, reflect.TypeFor[int64]())
+		}
+		ints = append(ints, n)
+	}
+	return ints, nil
+}
+
+// evalIntOpt evaluates an integer argument followed by an optional
+// integer argument that defaults to def.
+func evalIntOpt(env *Env, args *List, def int64) (n, opt int64, err error) {
+	if args.Len() != 1 && args.Len() != 2 {
+		return 0, 0, &ArgumentNumError{Num: args.Len(), Expected: -1}
+	}
+
+	vals, err := evalArgs(env, args)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, v := range vals {
+		if _, ok := v.(int64); !ok {
+			return 0, 0, NewTypeError(v, reflect.TypeFor[int64]())
+		}
+	}
+
+	opt = def
+	if len(vals) == 2 {
+		opt = vals[1].(int64)
+	}
+	return vals[0].(int64), opt, nil
+}
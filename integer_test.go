@@ -0,0 +1,64 @@
+package extract_test
+
+import (
+	"errors"
+	"testing"
+
+	"deedles.dev/extract"
+)
+
+func TestInteger(t *testing.T) {
+	const src = `
+	(list
+		(Integer.parse "12")
+		(Integer.parse "z" 36)
+		(Integer.parse "twelve")
+		(Integer.abs -3)
+		(Integer.pow 2 10)
+		(Integer.gcd 12 -18)
+		(Integer.digits 1203)
+		(Integer.digits -6 2)
+		(Integer.digits 0)
+		(Integer.is_even? 4)
+		(Integer.is_odd? 4)
+		(Integer.clamp 15 0 10)
+		(Integer.clamp -5 0 10)
+		(Integer.min 3 -1 2)
+		(Integer.max 3 -1 2)
+	)
+	`
+	result := runScript(t, src, true)
+	checkList(t, result,
+		extract.TupleOf(extract.MakeAtom("ok"), int64(12)),
+		extract.TupleOf(extract.MakeAtom("ok"), int64(35)),
+		extract.TupleOf(extract.MakeAtom("error"), extract.MakeAtom("invalid")),
+		int64(3),
+		int64(1024),
+		int64(6),
+		extract.ListOf(int64(1), int64(2), int64(0), int64(3)),
+		extract.ListOf(int64(1), int64(1), int64(0)),
+		extract.ListOf(int64(0)),
+		true,
+		false,
+		int64(10),
+		int64(0),
+		int64(-1),
+		int64(3),
+	)
+
+	result = runScript(t, `(Integer.pow 2 -1)`, false)
+	if err, _ := result.(error); !errors.Is(err, extract.ErrNegativeExponent) {
+		t.Fatalf("%#v", result)
+	}
+	result = runScript(t, `
+	(let min (sub -9223372036854775807 1))
+	[(Integer.abs min) (Integer.gcd min 0) (Integer.gcd min min) (Integer.gcd min 6)]
+	`, true)
+	outOfRange := extract.TupleOf(extract.MakeAtom("error"), extract.MakeAtom("out_of_range"))
+	checkList(t, result, outOfRange, outOfRange, outOfRange, int64(2))
+
+	result = runScript(t, `(Integer.abs 1.5)`, false)
+	if err, _ := result.(error); !errors.As(err, new(*extract.TypeError)) {
+		t.Fatalf("%#v", result)
+	}
+}
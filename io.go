@@ -0,0 +1,160 @@
+package extract
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+var (
+	eofAtom    = MakeAtom("eof")
+	stdoutAtom = MakeAtom("stdout")
+	stderrAtom = MakeAtom("stderr")
+)
+
+func stdIO() *Module {
+	m := Module{name: MakeAtom("IO")}
+	m.decls = map[Ident]any{
+		MakeIdent("print"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			return env, ioPrint(env, args, "")
+		}),
+		MakeIdent("println"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			return env, ioPrint(env, args, "\n")
+		}),
+		MakeIdent("write"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 1 && args.Len() != 2 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: -1}
+			}
+
+			vals, err := evalArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			w := env.Stdout()
+			if len(vals) == 2 {
+				w, err = ioDevice(env, vals[0])
+				if err != nil {
+					return env, err
+				}
+				vals = vals[1:]
+			}
+
+			str, ok := vals[0].(string)
+			if !ok {
+				return env, NewTypeError(vals[0], reflect.TypeFor[string]())
+			}
+
+			_, err = io.WriteString(w, str)
+			if err != nil {
+				return env, err
+			}
+			return env, okAtom
+		}),
+		MakeIdent("read_line"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 0 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 0}
+			}
+
+			line, err := ioReadLine(env)
+			if err != nil {
+				return env, err
+			}
+			if str, ok := line.(string); ok {
+				return env, strings.TrimSuffix(strings.TrimSuffix(str, "\n"), "\r")
+			}
+			return env, line
+		}),
+		MakeIdent("gets"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() > 1 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: -1}
+			}
+
+			if args.Len() == 1 {
+				_, prompt := Eval(env, args.Head(), nil)
+				if err, ok := prompt.(error); ok {
+					return env, err
+				}
+				_, err := io.WriteString(env.Stdout(), display(prompt))
+				if err != nil {
+					return env, err
+				}
+			}
+
+			line, err := ioReadLine(env)
+			if err != nil {
+				return env, err
+			}
+			return env, line
+		}),
+	}
+
+	return &m
+}
+
+// ioPrint writes args to the Env's standard output separated by
+// spaces and followed by end.
+func ioPrint(env *Env, args *List, end string) any {
+	vals, err := evalArgs(env, args)
+	if err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+	for i, v := range vals {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(display(v))
+	}
+	sb.WriteString(end)
+
+	_, err = io.WriteString(env.Stdout(), sb.String())
+	if err != nil {
+		return err
+	}
+	return okAtom
+}
+
+// ioReadLine reads a line, including the trailing newline, from the
+// Env's standard input. If it is at EOF, it returns :eof. Reads are
+// serialized, as the input is shared by concurrent processes.
+func ioReadLine(env *Env) (any, error) {
+	env.io.stdinMu.Lock()
+	defer env.io.stdinMu.Unlock()
+
+	line, err := env.io.stdin.ReadString('\n')
+	if err != nil {
+		if !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		if line == "" {
+			return eofAtom, nil
+		}
+	}
+	return line, nil
+}
+
+// ioDevice returns the writer that corresponds to the device atom v.
+func ioDevice(env *Env, v any) (io.Writer, error) {
+	switch v {
+	case stdoutAtom:
+		return env.Stdout(), nil
+	case stderrAtom:
+		return env.Stderr(), nil
+	default:
+		return nil, fmt.Errorf("unknown IO device: %v", Inspect(v))
+	}
+}
+
+// display returns v in the form that it should be printed in. Strings
+// are printed as is, while everything else is printed using
+// [Inspect].
+func display(v any) string {
+	if str, ok := v.(string); ok {
+		return str
+	}
+	return Inspect(v)
+}
@@ -0,0 +1,80 @@
+package extract_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"deedles.dev/extract"
+	"deedles.dev/extract/parser"
+)
+
+func TestIO(t *testing.T) {
+	const src = `
+	(IO.print "a" 1)
+	(IO.println "" [2 :b] 'c')
+	(IO.write :stderr "err")
+	(let name (IO.gets "Name: "))
+	(list name (IO.read_line) (IO.read_line) (IO.gets))
+	`
+	s, err := parser.ParseString(t.Name(), src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr strings.Builder
+	env := extract.New(
+		context.Background(),
+		extract.WithStdout(&stdout),
+		extract.WithStderr(&stderr),
+		extract.WithStdin(strings.NewReader("Extract\nline\r\nlast")),
+	)
+	_, result := extract.Run(env, s.All())
+	if err, ok := result.(error); ok {
+		t.Fatal(err)
+	}
+
+	ex := []any{"Extract\n", "line", "last", extract.MakeAtom("eof")}
+	if s := slices.Collect(result.(*extract.List).All()); !slices.Equal(s, ex) {
+		t.Fatalf("%#v", s)
+	}
+	if out := stdout.String(); out != "a 1 [2 :b] 'c'\nName: " {
+		t.Fatalf("%q", out)
+	}
+	if out := stderr.String(); out != "err" {
+		t.Fatalf("%q", out)
+	}
+}
+
+func TestIOErrors(t *testing.T) {
+	result := runScript(t, `(IO.write 3)`, false)
+	if err, _ := result.(error); !errors.As(err, new(*extract.TypeError)) {
+		t.Fatalf("%#v", result)
+	}
+
+	result = runScript(t, `(IO.write :nowhere "test")`, false)
+	if _, ok := result.(error); !ok {
+		t.Fatalf("%#v", result)
+	}
+}
+
+func TestIOReadLineConcurrent(t *testing.T) {
+	s, err := parser.ParseString("test", `
+	(let reader (func (reader parent)
+		(send parent [(IO.read_line) (IO.read_line)])))
+	(spawn reader (self))
+	(spawn reader (self))
+	(let (a b) (receive (v v)))
+	(let (c d) (receive (v v)))
+	(List.sort [a b c d])
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env := extract.New(context.Background(), extract.WithStdin(strings.NewReader("1\n2\n3\n4\n")))
+	_, result := extract.Run(env, s.All())
+	checkList(t, result, "1", "2", "3", "4")
+}
@@ -3,19 +3,41 @@ package extract
 import (
 	"errors"
 	"fmt"
+	"math"
 	"reflect"
+	"slices"
 )
 
+// ErrDivideByZero is returned when an integer is divided by zero.
+var ErrDivideByZero = errors.New("integer divide by zero")
+
 // kernel is the base scope containing the built-in, top-level
 // functions.
-var kernel = func() (ll *localList) {
+var kernel = func() (ll *scope) {
 	ll = ll.Push(MakeIdent("list"), EvalFunc(kernelList))
 	ll = ll.Push(MakeIdent("defmodule"), EvalFunc(kernelDefModule))
 	ll = ll.Push(MakeIdent("def"), EvalFunc(kernelDef))
+	ll = ll.Push(MakeIdent("doc"), EvalFunc(kernelDoc))
+	ll = ll.Push(MakeIdent("moduledoc"), EvalFunc(kernelModuleDoc))
 	ll = ll.Push(MakeIdent("func"), EvalFunc(kernelFunc))
 	ll = ll.Push(MakeIdent("let"), EvalFunc(kernelLet))
+	ll = ll.Push(MakeIdent("match"), EvalFunc(kernelMatch))
+	ll = ll.Push(MakeIdent("partial"), EvalFunc(kernelPartial))
+	ll = ll.Push(MakeIdent("loop"), EvalFunc(kernelLoop))
+	ll = ll.Push(MakeIdent("spawn"), EvalFunc(kernelSpawn))
+	ll = ll.Push(MakeIdent("self"), EvalFunc(kernelSelf))
+	ll = ll.Push(MakeIdent("send"), EvalFunc(kernelSend))
+	ll = ll.Push(MakeIdent("receive"), EvalFunc(kernelReceive))
 	ll = ll.Push(MakeIdent("add"), EvalFunc(kernelAdd))
 	ll = ll.Push(MakeIdent("sub"), EvalFunc(kernelSub))
+	ll = ll.Push(MakeIdent("mul"), EvalFunc(kernelMul))
+	ll = ll.Push(MakeIdent("div"), EvalFunc(kernelDiv))
+	ll = ll.Push(MakeIdent("rem"), EvalFunc(kernelRem))
+	ll = ll.Push(MakeIdent("pow"), EvalFunc(kernelPow))
+	ll = ll.Push(MakeIdent("inspect"), EvalFunc(kernelInspect))
+	ll = ll.Push(MakeIdent("eq"), EvalFunc(kernelEq))
+	ll = ll.Push(MakeIdent("true"), true)
+	ll = ll.Push(MakeIdent("false"), false)
 	return ll
 }()
 
@@ -60,31 +82,271 @@ func kernelDef(env *Env, args *List) (*Env, any) {
 		return env, errors.New("def used outside of module")
 	}
 
-	name, pattern, err := compileFuncPattern(env, args.Head())
+	name, patterns, err := compileFuncPattern(env, args.Head())
 	if err != nil {
 		return env, err
 	}
 
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	f, ok := m.decls[name].(*Func)
 	if !ok {
-		f = NewFunc(env, name, pattern, args.Tail())
+		f = newModuleFunc(env, name, patterns[0], args.Tail())
 		m.decls[name] = f
-		return env, f
+		patterns = patterns[1:]
 	}
-	f.AddVariant(pattern, args.Tail())
+	for _, pattern := range patterns {
+		f.AddVariant(pattern, args.Tail())
+	}
+	m.setDoc(name)
 	return env, f
 }
 
+// kernelDoc attaches documentation to the next function declared in
+// the current module with def, such as
+//
+//	(doc "Returns the sum of a and b.")
+//	(def (add a b) (+ a b))
+func kernelDoc(env *Env, args *List) (*Env, any) {
+	m, doc, err := evalDoc(env, args, "doc")
+	if err != nil {
+		return env, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pending = doc
+	return env, okAtom
+}
+
+// kernelModuleDoc attaches documentation to the current module.
+func kernelModuleDoc(env *Env, args *List) (*Env, any) {
+	m, doc, err := evalDoc(env, args, "moduledoc")
+	if err != nil {
+		return env, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.doc = doc
+	return env, okAtom
+}
+
+// evalDoc evaluates the single string argument of doc and moduledoc,
+// which must be used inside of a module.
+func evalDoc(env *Env, args *List, name string) (*Module, string, error) {
+	m := env.currentModule
+	if m == nil {
+		return nil, "", fmt.Errorf("%v used outside of module", name)
+	}
+	strs, err := evalStrings(env, args, 1, 1)
+	if err != nil {
+		return nil, "", err
+	}
+	return m, strs[0], nil
+}
+
 func kernelFunc(env *Env, args *List) (*Env, any) {
 	if args.Len() < 2 {
 		return env, &ArgumentNumError{Num: args.Len(), Expected: -1}
 	}
 
-	name, pattern, err := compileFuncPattern(env, args.Head())
+	if name, ok := args.Head().(Ident); ok {
+		return kernelFuncClauses(env, name, args.Tail())
+	}
+
+	name, patterns, err := compileFuncPattern(env, args.Head())
 	if err != nil {
 		return env, err
 	}
-	return env, NewFunc(env, name, pattern, args.Tail())
+
+	f := NewFunc(env, name, patterns[0], args.Tail())
+	for _, pattern := range patterns[1:] {
+		f.AddVariant(pattern, args.Tail())
+	}
+	return env, f
+}
+
+// kernelFuncClauses handles the multi-clause form of func, such as
+//
+//	(func name ((0) :zero) ((n) :other))
+//
+// in which each clause is a list of parameters followed by the body
+// for that variant.
+func kernelFuncClauses(env *Env, name Ident, clauses *List) (*Env, any) {
+	var f *Func
+	for expr := range clauses.All() {
+		clause, ok := expr.(Call)
+		if !ok || clause.Len() < 2 {
+			return env, fmt.Errorf("func clause must be a list of parameters followed by a body, not %v", Inspect(expr))
+		}
+		params, ok := clause.Head().(Call)
+		if !ok {
+			return env, NewTypeError(clause.Head(), reflect.TypeFor[Call]())
+		}
+
+		_, patterns, err := compileFuncPattern(env, Call{List: params.List.Push(name)})
+		if err != nil {
+			return env, err
+		}
+
+		if f == nil {
+			f = NewFunc(env, name, patterns[0], clause.Tail())
+			patterns = patterns[1:]
+		}
+		for _, pattern := range patterns {
+			f.AddVariant(pattern, clause.Tail())
+		}
+	}
+	return env, f
+}
+
+// ErrRecurPosition is returned by loop if recur is called anywhere
+// other than in tail position of one of its clauses.
+var ErrRecurPosition = errors.New("recur used outside of tail position of loop")
+
+var (
+	loopIdent    = MakeIdent("$loop")
+	recurIdent   = MakeIdent("recur")
+	loopKeyword  = MakeIdent("loop")
+	receiveIdent = MakeIdent("receive")
+)
+
+// recurSignal is returned by recur to signal to the enclosing loop
+// that it should run again with new arguments.
+type recurSignal struct {
+	loop *Func
+	args *List
+}
+
+func (sig *recurSignal) Error() string {
+	return ErrRecurPosition.Error()
+}
+
+// checkRecurBody checks the expressions of a body for calls to recur
+// that aren't in tail position. The last expression is in tail
+// position if tail is true.
+func checkRecurBody(body *List, tail bool) error {
+	last := body.Len() - 1
+	var i int
+	for expr := range body.All() {
+		if err := checkRecur(expr, tail && i == last); err != nil {
+			return err
+		}
+		i++
+	}
+	return nil
+}
+
+// checkRecur checks expr for calls to recur that aren't in tail
+// position. The bodies of receive clauses are in tail position if the
+// receive is. Nested loops bind their own recur, so only their initial
+// values are checked.
+func checkRecur(expr any, tail bool) error {
+	switch expr := expr.(type) {
+	case Call:
+		switch expr.Head() {
+		case recurIdent:
+			if !tail {
+				return ErrRecurPosition
+			}
+			return checkRecurBody(expr.Tail(), false)
+		case loopKeyword:
+			return checkRecur(expr.Tail().Head(), false)
+		case receiveIdent:
+			for clause := range expr.Tail().All() {
+				clause, ok := clause.(Call)
+				if !ok || clause.Len() < 2 {
+					return checkRecur(clause, false)
+				}
+				body := clause.Tail()
+				if clause.Head() == afterIdent {
+					if err := checkRecur(body.Head(), false); err != nil {
+						return err
+					}
+					body = body.Tail()
+				}
+				if err := checkRecurBody(body, tail); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		return checkRecurBody(expr.List, false)
+	case ListExpr:
+		return checkRecurBody(expr.List, false)
+	default:
+		return nil
+	}
+}
+
+// kernelLoop implements an iteration construct that runs in constant
+// stack space. It takes a list of initial values followed by clauses
+// in the same format as a multi-clause func, such as
+//
+//	(loop (10 0)
+//		((0 acc) acc)
+//		((n acc) (recur (n - 1) (acc + n))))
+//
+// The first clause matching the values is run. If the result of a
+// clause is a call to recur, the loop runs again with the arguments
+// passed to recur. Otherwise, the result of the clause is the result
+// of the loop. recur may only be called in tail position, either as
+// the last expression of a clause or of a receive in tail position,
+// and the loop fails with [ErrRecurPosition] otherwise.
+func kernelLoop(env *Env, args *List) (*Env, any) {
+	if args.Len() < 2 {
+		return env, &ArgumentNumError{Num: args.Len(), Expected: -1}
+	}
+
+	init, ok := args.Head().(Call)
+	if !ok {
+		return env, NewTypeError(args.Head(), reflect.TypeFor[Call]())
+	}
+
+	for clause := range args.Tail().All() {
+		clause, ok := clause.(Call)
+		if !ok || clause.Len() < 2 {
+			continue
+		}
+		if err := checkRecurBody(clause.Tail(), true); err != nil {
+			return env, err
+		}
+	}
+
+	var loop *Func
+	recur := EvalFunc(func(env *Env, args *List) (*Env, any) {
+		return env, &recurSignal{loop: loop, args: CollectList(EvalAll(env, args.All()))}
+	})
+	_, f := kernelFuncClauses(env.Let(recurIdent, recur), loopIdent, args.Tail())
+	loop, ok = f.(*Func)
+	if !ok {
+		return env, f
+	}
+
+	_, r := loop.Eval(env, callArgs(init.List))
+	for {
+		sig, ok := r.(*recurSignal)
+		if !ok || sig.loop != loop {
+			return env, r
+		}
+		_, r = loop.Eval(env, callArgs(sig.args))
+	}
+}
+
+func kernelEq(env *Env, args *List) (*Env, any) {
+	if args.Len() != 2 {
+		return env, &ArgumentNumError{Num: args.Len(), Expected: 2}
+	}
+
+	_, a := Eval(env, args.Head(), nil)
+	if err, ok := a.(error); ok {
+		return env, err
+	}
+	_, b := Eval(env, args.Tail().Head(), nil)
+	if err, ok := b.(error); ok {
+		return env, err
+	}
+	return env, Equal(a, b)
 }
 
 func kernelLet(env *Env, args *List) (*Env, any) {
@@ -94,13 +356,64 @@ func kernelLet(env *Env, args *List) (*Env, any) {
 
 	name, ok := args.Head().(Ident)
 	if !ok {
-		return env, NewTypeError(name, reflect.TypeFor[Atom]())
+		// Anything other than a plain identifier is treated as a pattern
+		// to destructure the value with.
+		return kernelMatch(env, args)
 	}
 
 	_, val := Run(env, args.Tail().All())
 	return env.Let(name, val), val
 }
 
+func kernelMatch(env *Env, args *List) (*Env, any) {
+	if args.Len() < 2 {
+		return env, &ArgumentNumError{Num: args.Len(), Expected: -1}
+	}
+
+	pattern, err := CompilePattern(env, args.Head())
+	if err != nil {
+		return env, err
+	}
+
+	_, val := Run(env, args.Tail().All())
+	if err, ok := val.(error); ok {
+		return env, err
+	}
+
+	menv, ok := pattern.Match(env, val)
+	if !ok {
+		return env, &MatchError{Val: val}
+	}
+	return menv, val
+}
+
+func kernelPartial(env *Env, args *List) (*Env, any) {
+	if args.Len() == 0 {
+		return env, &ArgumentNumError{Num: args.Len(), Expected: -1}
+	}
+
+	_, fn := Eval(env, args.Head(), nil)
+	if err, ok := fn.(error); ok {
+		return env, err
+	}
+
+	pre, err := evalArgs(env, args.Tail())
+	if err != nil {
+		return env, err
+	}
+	return env, EvalFunc(func(env *Env, args *List) (*Env, any) {
+		rest, err := evalArgs(env, args)
+		if err != nil {
+			return env, err
+		}
+		r, err := callFunc(env, fn, slices.Concat(pre, rest)...)
+		if err != nil {
+			return env, err
+		}
+		return env, r
+	})
+}
+
 func kernelAdd(env *Env, args *List) (*Env, any) {
 	if args.Len() < 2 {
 		return env, &ArgumentNumError{Num: args.Len(), Expected: -1}
@@ -162,3 +475,130 @@ func kernelSub(env *Env, args *List) (*Env, any) {
 		return env, NewTypeError(b, reflect.TypeFor[int64](), reflect.TypeFor[float64]())
 	}
 }
+
+func kernelMul(env *Env, args *List) (*Env, any) {
+	if args.Len() < 2 {
+		return env, &ArgumentNumError{Num: args.Len(), Expected: -1}
+	}
+
+	total := int64(1)
+	totalf := float64(1)
+	var isFloat bool
+	for arg := range EvalAll(env, args.All()) {
+		switch arg := arg.(type) {
+		case int64:
+			total *= arg
+		case float64:
+			totalf *= arg
+			isFloat = true
+		case error:
+			return env, arg
+		default:
+			return env, NewTypeError(arg, reflect.TypeFor[int64](), reflect.TypeFor[float64]())
+		}
+	}
+
+	if isFloat {
+		return env, float64(total) * totalf
+	}
+	return env, total
+}
+
+// evalNumbers evaluates exactly two numeric arguments. If either of
+// them is a float64, both are returned as float64s. Otherwise, they
+// are both returned as int64s.
+func evalNumbers(env *Env, args *List) (a, b any, err error) {
+	if args.Len() != 2 {
+		return nil, nil, &ArgumentNumError{Num: args.Len(), Expected: 2}
+	}
+
+	_, a = Eval(env, args.Head(), nil)
+	_, b = Eval(env, args.Tail().Head(), nil)
+	for _, v := range []any{a, b} {
+		switch v := v.(type) {
+		case int64, float64:
+		case error:
+			return nil, nil, v
+		default:
+			return nil, nil, NewTypeError(v, reflect.TypeFor[int64](), reflect.TypeFor[float64]())
+		}
+	}
+
+	ai, aok := a.(int64)
+	bi, bok := b.(int64)
+	switch {
+	case aok && bok:
+		return a, b, nil
+	case aok:
+		return float64(ai), b, nil
+	case bok:
+		return a, float64(bi), nil
+	default:
+		return a, b, nil
+	}
+}
+
+func kernelDiv(env *Env, args *List) (*Env, any) {
+	a, b, err := evalNumbers(env, args)
+	if err != nil {
+		return env, err
+	}
+
+	switch a := a.(type) {
+	case int64:
+		if b.(int64) == 0 {
+			return env, ErrDivideByZero
+		}
+		return env, a / b.(int64)
+	default:
+		return env, a.(float64) / b.(float64)
+	}
+}
+
+func kernelRem(env *Env, args *List) (*Env, any) {
+	a, b, err := evalNumbers(env, args)
+	if err != nil {
+		return env, err
+	}
+
+	switch a := a.(type) {
+	case int64:
+		if b.(int64) == 0 {
+			return env, ErrDivideByZero
+		}
+		return env, a % b.(int64)
+	default:
+		return env, math.Mod(a.(float64), b.(float64))
+	}
+}
+
+func kernelPow(env *Env, args *List) (*Env, any) {
+	a, b, err := evalNumbers(env, args)
+	if err != nil {
+		return env, err
+	}
+
+	switch a := a.(type) {
+	case int64:
+		b := b.(int64)
+		if b < 0 {
+			return env, math.Pow(float64(a), float64(b))
+		}
+		return env, intPow(a, b)
+	default:
+		return env, math.Pow(a.(float64), b.(float64))
+	}
+}
+
+// intPow returns a raised to the power of b, which must not be
+// negative.
+func intPow(a, b int64) int64 {
+	r := int64(1)
+	for ; b > 0; b >>= 1 {
+		if b&1 == 1 {
+			r *= a
+		}
+		a *= a
+	}
+	return r
+}
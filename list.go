@@ -1,9 +1,15 @@
 package extract
 
 import (
+	"cmp"
+	"hash/maphash"
 	"iter"
+	"reflect"
 	"slices"
+	"sort"
 	"sync"
+
+	"deedles.dev/xiter"
 )
 
 // List is a singly-linked list. It is the core building block of the
@@ -50,6 +56,45 @@ func CollectList[T any](seq iter.Seq[T]) (list *List) {
 	return ListOf((*s)...)
 }
 
+// String returns the list in the same form as [Inspect], such as
+// [1 2 3].
+func (list *List) String() string {
+	return Inspect(list)
+}
+
+// Equal returns true if other is a *List of the same length as list
+// whose elements are each equal, according to [Equal], to the
+// corresponding elements of list.
+func (list *List) Equal(other any) bool {
+	o, ok := other.(*List)
+	if !ok || list.Len() != o.Len() {
+		return false
+	}
+
+	for list != nil && o != nil {
+		if list == o {
+			return true
+		}
+		if !Equal(list.head, o.head) {
+			return false
+		}
+		list, o = list.tail, o.tail
+	}
+	return true
+}
+
+// Hash returns a hash of the elements of the list that is consistent
+// with [List.Equal].
+func (list *List) Hash() uint64 {
+	var h maphash.Hash
+	h.SetSeed(hashSeed)
+	writeUint(&h, uint64(list.Len()))
+	for v := range list.All() {
+		writeUint(&h, Hash(v))
+	}
+	return h.Sum64()
+}
+
 // Head returns the value at the head of the list. In other words, the
 // value of the this node in the linked list.
 func (list *List) Head() any {
@@ -119,3 +164,321 @@ func (list *List) All() iter.Seq[any] {
 		}
 	}
 }
+
+func stdList() *Module {
+	m := Module{name: MakeAtom("List")}
+	m.decls = map[Ident]any{
+		MakeIdent("map"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			list, fn, err := evalListFunc(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			vals := make([]any, 0, list.Len())
+			for v := range list.All() {
+				r, err := callFunc(env, fn, v)
+				if err != nil {
+					return env, err
+				}
+				vals = append(vals, r)
+			}
+			return env, ListOf(vals...)
+		}),
+		MakeIdent("filter"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			list, fn, err := evalListFunc(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			vals := make([]any, 0, list.Len())
+			for v := range list.All() {
+				ok, err := callPredicate(env, fn, v)
+				if err != nil {
+					return env, err
+				}
+				if ok {
+					vals = append(vals, v)
+				}
+			}
+			return env, ListOf(vals...)
+		}),
+		MakeIdent("reduce"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 3 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 3}
+			}
+
+			vals, err := evalArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			list, ok := vals[0].(*List)
+			if !ok {
+				return env, NewTypeError(vals[0], reflect.TypeFor[*List]())
+			}
+
+			acc, fn := vals[1], vals[2]
+			for v := range list.All() {
+				acc, err = callFunc(env, fn, v, acc)
+				if err != nil {
+					return env, err
+				}
+			}
+			return env, acc
+		}),
+		MakeIdent("reverse"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			list, err := evalList(env, args)
+			if err != nil {
+				return env, err
+			}
+			return env, PushAll(nil, list.All())
+		}),
+		MakeIdent("length"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			list, err := evalList(env, args)
+			if err != nil {
+				return env, err
+			}
+			return env, int64(list.Len())
+		}),
+		MakeIdent("nth"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			list, n, err := evalListInt(env, args)
+			if err != nil {
+				return env, err
+			}
+			if n < 0 || n >= int64(list.Len()) {
+				return env, &IndexError{Index: n, Len: list.Len()}
+			}
+
+			for range n {
+				list = list.Tail()
+			}
+			return env, list.Head()
+		}),
+		MakeIdent("take"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			list, n, err := evalListInt(env, args)
+			if err != nil {
+				return env, err
+			}
+			if n >= int64(list.Len()) {
+				return env, list
+			}
+			return env, CollectList(xiter.Limit(list.All(), int(max(n, 0))))
+		}),
+		MakeIdent("drop"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			list, n, err := evalListInt(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			for ; n > 0 && list.Len() > 0; n-- {
+				list = list.Tail()
+			}
+			return env, list
+		}),
+		MakeIdent("concat"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			lists, err := evalLists(env, args)
+			if err != nil {
+				return env, err
+			}
+			if len(lists) == 0 {
+				return env, (*List)(nil)
+			}
+
+			// The last list can be shared as the tail of the result.
+			r := lists[len(lists)-1]
+			for _, list := range slices.Backward(lists[:len(lists)-1]) {
+				r = pushAllBackward(r, list)
+			}
+			return env, r
+		}),
+		MakeIdent("flatten"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			list, err := evalList(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			var vals []any
+			var flatten func(*List)
+			flatten = func(list *List) {
+				for v := range list.All() {
+					if sub, ok := v.(*List); ok {
+						flatten(sub)
+						continue
+					}
+					vals = append(vals, v)
+				}
+			}
+			flatten(list)
+			return env, ListOf(vals...)
+		}),
+		MakeIdent("sort"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 1 && args.Len() != 2 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: -1}
+			}
+
+			vals, err := evalArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			list, ok := vals[0].(*List)
+			if !ok {
+				return env, NewTypeError(vals[0], reflect.TypeFor[*List]())
+			}
+
+			s := slices.Collect(list.All())
+			if len(vals) == 1 {
+				slices.SortStableFunc(s, Compare)
+				return env, ListOf(s...)
+			}
+
+			// sort.SliceStable only needs to know whether one element is
+			// less than another, so fn is called once per comparison.
+			fn := vals[1]
+			sort.SliceStable(s, func(i, j int) bool {
+				if err != nil {
+					return false
+				}
+				var less bool
+				less, err = callPredicate(env, fn, s[i], s[j])
+				return less
+			})
+			if err != nil {
+				return env, err
+			}
+			return env, ListOf(s...)
+		}),
+		MakeIdent("zip"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			lists, err := evalLists(env, args)
+			if err != nil {
+				return env, err
+			}
+			if len(lists) == 0 {
+				return env, (*List)(nil)
+			}
+
+			n := slices.MinFunc(lists, func(l1, l2 *List) int { return cmp.Compare(l1.Len(), l2.Len()) }).Len()
+			vals := make([]any, n)
+			for i := range vals {
+				tuple := make([]any, len(lists))
+				for j, list := range lists {
+					tuple[j] = list.Head()
+					lists[j] = list.Tail()
+				}
+				vals[i] = ListOf(tuple...)
+			}
+			return env, ListOf(vals...)
+		}),
+		MakeIdent("to_tuple"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			list, err := evalList(env, args)
+			if err != nil {
+				return env, err
+			}
+			return env, &Tuple{elems: slices.Collect(list.All())}
+		}),
+		MakeIdent("member?"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 2 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 2}
+			}
+
+			vals, err := evalArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			list, ok := vals[0].(*List)
+			if !ok {
+				return env, NewTypeError(vals[0], reflect.TypeFor[*List]())
+			}
+
+			for v := range list.All() {
+				if Equal(v, vals[1]) {
+					return env, true
+				}
+			}
+			return env, false
+		}),
+	}
+
+	return &m
+}
+
+// pushAllBackward pushes the elements of list onto r in reverse order,
+// resulting in a list that starts with the elements of list followed
+// by those of r.
+func pushAllBackward(r, list *List) *List {
+	for _, v := range slices.Backward(slices.Collect(list.All())) {
+		r = r.Push(v)
+	}
+	return r
+}
+
+// evalList evaluates a single *List argument.
+func evalList(env *Env, args *List) (*List, error) {
+	if args.Len() != 1 {
+		return nil, &ArgumentNumError{Num: args.Len(), Expected: 1}
+	}
+
+	_, head := Eval(env, args.Head(), nil)
+	if err, ok := head.(error); ok {
+		return nil, err
+	}
+	list, ok := head.(*List)
+	if !ok {
+		return nil, NewTypeError(head, reflect.TypeFor[*List]())
+	}
+	return list, nil
+}
+
+// evalLists evaluates any number of *List arguments.
+func evalLists(env *Env, args *List) ([]*List, error) {
+	vals, err := evalArgs(env, args)
+	if err != nil {
+		return nil, err
+	}
+
+	lists := make([]*List, 0, len(vals))
+	for _, v := range vals {
+		list, ok := v.(*List)
+		if !ok {
+			return nil, NewTypeError(v, reflect.TypeFor[*List]())
+		}
+		lists = append(lists, list)
+	}
+	return lists, nil
+}
+
+// evalListFunc evaluates a *List argument followed by a function.
+func evalListFunc(env *Env, args *List) (list *List, fn any, err error) {
+	if args.Len() != 2 {
+		return nil, nil, &ArgumentNumError{Num: args.Len(), Expected: 2}
+	}
+
+	vals, err := evalArgs(env, args)
+	if err != nil {
+		return nil, nil, err
+	}
+	list, ok := vals[0].(*List)
+	if !ok {
+		return nil, nil, NewTypeError(vals[0], reflect.TypeFor[*List]())
+	}
+	return list, vals[1], nil
+}
+
+// evalListInt evaluates a *List argument followed by an integer.
+func evalListInt(env *Env, args *List) (list *List, n int64, err error) {
+	if args.Len() != 2 {
+		return nil, 0, &ArgumentNumError{Num: args.Len(), Expected: 2}
+	}
+
+	vals, err := evalArgs(env, args)
+	if err != nil {
+		return nil, 0, err
+	}
+	list, ok := vals[0].(*List)
+	if !ok {
+		return nil, 0, NewTypeError(vals[0], reflect.TypeFor[*List]())
+	}
+	n, ok = vals[1].(int64)
+	if !ok {
+		return nil, 0, NewTypeError(vals[1], reflect.TypeFor[int64]())
+	}
+	return list, n, nil
+}
@@ -1,6 +1,7 @@
 package extract_test
 
 import (
+	"errors"
 	"slices"
 	"testing"
 
@@ -29,3 +30,72 @@ func TestCollectList(t *testing.T) {
 		t.Fatal(s)
 	}
 }
+
+func TestListModule(t *testing.T) {
+	const src = `
+	(let l [3 1 2])
+	(list
+		(List.map l (func (double n) (n * 2)))
+		(List.filter l (func (odd? n) (eq (n % 2) 1)))
+		(List.reduce l 0 add)
+		(List.reverse l)
+		(List.length l)
+		(List.nth l 1)
+		(List.take l 2)
+		(List.drop l 2)
+		(List.concat l [4] [] [5 6])
+		(List.flatten [1 [2 [3 []]] 4])
+		(List.sort [3 "a" 1 2.5])
+		(List.sort l (func gt ((3 _) true) ((2 1) true) ((_ _) false)))
+		(List.zip l [:a :b])
+		(List.member? l 2)
+		(List.member? l 4)
+	)
+	`
+	result := runScript(t, src, true)
+	ex := []any{
+		extract.ListOf(int64(6), int64(2), int64(4)),
+		extract.ListOf(int64(3), int64(1)),
+		int64(6),
+		extract.ListOf(int64(2), int64(1), int64(3)),
+		int64(3),
+		int64(1),
+		extract.ListOf(int64(3), int64(1)),
+		extract.ListOf(int64(2)),
+		extract.ListOf(int64(3), int64(1), int64(2), int64(4), int64(5), int64(6)),
+		extract.ListOf(int64(1), int64(2), int64(3), int64(4)),
+		extract.ListOf(int64(1), 2.5, int64(3), "a"),
+		extract.ListOf(int64(3), int64(2), int64(1)),
+		extract.ListOf(extract.ListOf(int64(3), extract.MakeAtom("a")), extract.ListOf(int64(1), extract.MakeAtom("b"))),
+		true,
+		false,
+	}
+	s := slices.Collect(result.(*extract.List).All())
+	if len(s) != len(ex) {
+		t.Fatalf("%v", s)
+	}
+	for i := range s {
+		if !extract.Equal(s[i], ex[i]) {
+			t.Errorf("%v: %v != %v", i, s[i], ex[i])
+		}
+	}
+}
+
+func TestListModuleErrors(t *testing.T) {
+	result := runScript(t, `(List.nth [1 2] 2)`, false)
+	var ierr *extract.IndexError
+	if !errors.As(result.(error), &ierr) || ierr.Index != 2 || ierr.Len != 2 {
+		t.Fatalf("%#v", result)
+	}
+
+	result = runScript(t, `(List.filter [1 2] (func (f n) n))`, false)
+	var terr *extract.TypeError
+	if !errors.As(result.(error), &terr) {
+		t.Fatalf("%#v", result)
+	}
+
+	result = runScript(t, `(List.sort [1 2 3] (func lt ((2 1) (add 1 :a)) ((_ _) false)))`, false)
+	if err, _ := result.(error); !errors.Is(err, extract.ErrType) {
+		t.Fatalf("%#v", result)
+	}
+}
@@ -12,10 +12,17 @@ type Int = int64
 // -1.3.
 type Float = float64
 
+// Rune is created from rune literal expressions such as 'a' or '\n'.
+type Rune = extract.Rune
+
 // String is created from string literal expressions such as
 // "example".
 type String = string
 
+// Binary is created from binary literal expressions such as
+// b"\x00data".
+type Binary = *extract.Binary
+
 // Atom is created from atom literal expressions such as :example,
 // :"example with spaces", or Example.
 type Atom = extract.Atom
@@ -27,9 +34,23 @@ type Ident = extract.Ident
 // elements of the list will be other types in this package.
 type List = extract.Call
 
+// Brackets is created from bracketed list literal expressions such as
+// [a b c]. Unlike a List, it evaluates to a list of its evaluated
+// elements instead of calling the first one.
+type Brackets = extract.ListExpr
+
 // Ref is created from module references such as Example.function.
 type Ref = extract.Ref
 
 // Pin is created from usages of the pin operator before an
-// identifier. It looks like \ident.
+// identifier or another expression. It looks like \ident or
+// \Module.name.
 type Pin = extract.Pinned
+
+// Default is created from a pattern followed by the default operator
+// and a value, such as name \\ "default".
+type Default = extract.Default
+
+// Rest is created from usages of the rest operator before a pattern
+// in a list pattern. It looks like &rest.
+type Rest = extract.Rest
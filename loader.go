@@ -0,0 +1,281 @@
+package extract
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// SourceExt is the file extension of Extract source files. It is added
+// to paths passed to import that don't already have an extension.
+const SourceExt = ".ext"
+
+func init() {
+	// import is added here rather than in the declaration of kernel
+	// because files that it runs start with kernel as their scope,
+	// which would otherwise be an initialization cycle.
+	kernel = kernel.Push(MakeIdent("import"), EvalFunc(kernelImport))
+}
+
+// ImportCycleError is returned when a file imports itself, either
+// directly or through other files. Cycle is the chain of imports,
+// starting and ending with the same file.
+type ImportCycleError struct {
+	Cycle []string
+}
+
+func (err *ImportCycleError) Error() string {
+	return fmt.Sprintf("import cycle: %v", strings.Join(err.Cycle, " -> "))
+}
+
+func (err *ImportCycleError) ErrorCode() Atom {
+	return codeImportCycle
+}
+
+// loader finds, runs, and caches the files imported by import. It is
+// shared by every Env derived from the same call to [New].
+type loader struct {
+	dirs []string
+	fsys fs.FS
+
+	m     sync.Mutex
+	files map[string]*loadedFile
+}
+
+// loadedFile is a file that has been imported. done is closed once
+// the file has finished running, at which point result is its result.
+type loadedFile struct {
+	done   chan struct{}
+	result any
+}
+
+// importChain is the list of files that are being imported by the
+// current evaluation, starting with the innermost.
+type importChain struct {
+	path string
+	next *importChain
+}
+
+// WithLoadPath sets the directories that import searches for files in,
+// in order. By default, only the current directory is searched.
+// Imports of paths starting with ./ or ../ are always resolved
+// relative to the directory of the importing file instead.
+func WithLoadPath(dirs ...string) Option {
+	return func(env *Env) {
+		env.loader = &loader{dirs: dirs, fsys: env.loader.fsys}
+	}
+}
+
+// WithLoadFS makes import find files in fsys instead of the host's
+// filesystem, such as for scripts embedded with go:embed. The load
+// path is then a list of directories in fsys, "." by default. Imports
+// from fsys are allowed even if [WithoutFileSystem] is used.
+func WithLoadFS(fsys fs.FS) Option {
+	return func(env *Env) {
+		env.loader = &loader{dirs: env.loader.dirs, fsys: fsys}
+	}
+}
+
+// fresh returns a new loader with the same configuration as l but
+// nothing loaded.
+func (l *loader) fresh() *loader {
+	return &loader{dirs: l.dirs, fsys: l.fsys}
+}
+
+// resolve finds the file that name refers to when it is imported by
+// the file from. It returns the file's path and contents.
+func (l *loader) resolve(name, from string) (string, []byte, error) {
+	if path.Ext(name) == "" {
+		name += SourceExt
+	}
+
+	var candidates []string
+	switch {
+	case strings.HasPrefix(name, "./") || strings.HasPrefix(name, "../"):
+		candidates = []string{l.join(l.dir(from), name)}
+	case l.fsys == nil && filepath.IsAbs(name):
+		candidates = []string{filepath.Clean(name)}
+	default:
+		dirs := l.dirs
+		if len(dirs) == 0 {
+			dirs = []string{"."}
+		}
+		for _, dir := range dirs {
+			candidates = append(candidates, l.join(dir, name))
+		}
+	}
+
+	for _, p := range candidates {
+		src, err := l.read(p)
+		if err == nil {
+			return p, src, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", nil, err
+		}
+	}
+	return "", nil, fmt.Errorf("import %q: %w", name, fs.ErrNotExist)
+}
+
+func (l *loader) dir(file string) string {
+	if file == "" {
+		return "."
+	}
+	if l.fsys != nil {
+		return path.Dir(file)
+	}
+	return filepath.Dir(file)
+}
+
+func (l *loader) join(dir, name string) string {
+	if l.fsys != nil {
+		return path.Join(dir, name)
+	}
+	if p, err := filepath.Abs(filepath.Join(dir, filepath.FromSlash(name))); err == nil {
+		return p
+	}
+	return filepath.Join(dir, filepath.FromSlash(name))
+}
+
+func (l *loader) read(p string) ([]byte, error) {
+	if l.fsys != nil {
+		return fs.ReadFile(l.fsys, p)
+	}
+	return os.ReadFile(p)
+}
+
+// load runs the file at p with the contents src in env, unless it has
+// already been run, and returns its result. If another process is
+// running the file, load waits for it to finish.
+func (l *loader) load(env *Env, p string, src []byte) any {
+	for c := env.importing; c != nil; c = c.next {
+		if c.path == p {
+			var cycle []string
+			for c := env.importing; ; c = c.next {
+				cycle = append(cycle, c.path)
+				if c.path == p {
+					break
+				}
+			}
+			slices.Reverse(cycle)
+			return &ImportCycleError{Cycle: append(cycle, p)}
+		}
+	}
+
+	l.m.Lock()
+	if l.files == nil {
+		l.files = make(map[string]*loadedFile)
+	}
+	if f, ok := l.files[p]; ok {
+		l.m.Unlock()
+		select {
+		case <-f.done:
+			return f.result
+		case <-env.Context().Done():
+			return env.Context().Err()
+		}
+	}
+	f := loadedFile{done: make(chan struct{})}
+	l.files[p] = &f
+	l.m.Unlock()
+	defer close(f.done)
+
+	f.result = l.run(env, p, src)
+	if _, ok := f.result.(error); ok {
+		l.m.Lock()
+		delete(l.files, p)
+		l.m.Unlock()
+	}
+	return f.result
+}
+
+func (l *loader) run(env *Env, p string, src []byte) any {
+	code, err := parseSource(p, src)
+	if err != nil {
+		return err
+	}
+
+	fenv := *env
+	fenv.currentModule = nil
+	fenv.locals = kernel
+	fenv.moduleSeq = 0
+	fenv.importing = &importChain{path: p, next: env.importing}
+	_, r := Run(&fenv, code.All())
+	return r
+}
+
+// LoadFS runs every file in fsys whose path matches pattern, using
+// the syntax of [fs.Glob], in env, in lexical order. Each file is run
+// as if it had been imported, so the modules that they declare become
+// available in env, and imports in them are resolved in fsys. A file
+// that is imported by another matched file is only run once. This
+// allows an application to embed its Extract code with go:embed and
+// load it at startup.
+//
+// The parser must have been registered, such as by importing
+// deedles.dev/extract/parser. LoadFS stops at the first file that
+// fails and returns its error.
+func LoadFS(env *Env, fsys fs.FS, pattern string) error {
+	paths, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return err
+	}
+
+	l := loader{fsys: fsys}
+	lenv := *env
+	lenv.loader = &l
+	for _, p := range paths {
+		src, err := l.read(p)
+		if err != nil {
+			return err
+		}
+		if err, ok := l.load(&lenv, p, src).(error); ok {
+			return err
+		}
+	}
+	return nil
+}
+
+// kernelImport runs the Extract source file at the given path, such as
+//
+//	(import "lib/shapes")
+//
+// making the modules that it declares available. The file extension
+// may be left off. A file is only run the first time that it is
+// imported, and every import of it returns the result of that run.
+// Paths starting with ./ or ../ are relative to the directory of the
+// importing file, and others are searched for in the load path. See
+// [WithLoadPath] and [WithLoadFS].
+func kernelImport(env *Env, args *List) (*Env, any) {
+	if args.Len() != 1 {
+		return env, &ArgumentNumError{Num: args.Len(), Expected: 1}
+	}
+
+	_, v := Eval(env, args.Head(), nil)
+	if err, ok := v.(error); ok {
+		return env, err
+	}
+	name, ok := v.(string)
+	if !ok {
+		return env, NewTypeError(v, reflect.TypeFor[string]())
+	}
+
+	if env.loader.fsys == nil {
+		if err := env.checkFS(); err != nil {
+			return env, err
+		}
+	}
+
+	p, src, err := env.loader.resolve(name, env.pos.Filename)
+	if err != nil {
+		return env, err
+	}
+	return env, env.loader.load(env, p, src)
+}
@@ -0,0 +1,129 @@
+package extract_test
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"deedles.dev/extract"
+	"deedles.dev/extract/parser"
+)
+
+func TestImport(t *testing.T) {
+	fsys := fstest.MapFS{
+		"lib/shapes.ext": {Data: []byte(`
+			(import "./util")
+			(defmodule Shapes
+				(def (area w h) (Util.double (mul w h) 0.5)))
+			:shapes`)},
+		"lib/util.ext": {Data: []byte(`
+			(IO.println "loading util")
+			(defmodule Util
+				(def (double v scale) (mul (mul v 2) scale)))`)},
+		"cycle/a.ext": {Data: []byte(`(import "cycle/b")`)},
+		"cycle/b.ext": {Data: []byte(`(import "cycle/a")`)},
+	}
+
+	run := func(t *testing.T, src string, opts ...extract.Option) (string, any) {
+		script, err := parser.ParseString(t.Name(), src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out strings.Builder
+		env := extract.New(context.Background(), append(opts, extract.WithStdout(&out))...)
+		_, r := extract.Run(env, script.All())
+		return out.String(), r
+	}
+
+	t.Run("FS", func(t *testing.T) {
+		out, r := run(t, `
+		(let a (import "lib/shapes"))
+		(let b (import "lib/shapes.ext"))
+		(import "lib/util")
+		(list a b (Shapes.area 3 4))`, extract.WithLoadFS(fsys), extract.WithoutFileSystem())
+		if out != "loading util\n" {
+			t.Fatalf("%q", out)
+		}
+		if ex := extract.ListOf(extract.MakeAtom("shapes"), extract.MakeAtom("shapes"), 12.0); !extract.Equal(r, ex) {
+			t.Fatal(extract.Inspect(r))
+		}
+	})
+
+	t.Run("LoadPath", func(t *testing.T) {
+		_, r := run(t, `(import "shapes") (Shapes.area 1 1)`, extract.WithLoadFS(fsys), extract.WithLoadPath("missing", "lib"))
+		if r != 1.0 {
+			t.Fatal(extract.Inspect(r))
+		}
+	})
+
+	t.Run("Cycle", func(t *testing.T) {
+		_, r := run(t, `(import "cycle/a")`, extract.WithLoadFS(fsys))
+		var cycle *extract.ImportCycleError
+		if err, _ := r.(error); !errors.As(err, &cycle) {
+			t.Fatal(extract.Inspect(r))
+		}
+		if err := cycle.Error(); err != "import cycle: cycle/a.ext -> cycle/b.ext -> cycle/a.ext" {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		_, r := run(t, `(import "missing")`, extract.WithLoadFS(fsys))
+		if err, _ := r.(error); !errors.Is(err, fs.ErrNotExist) {
+			t.Fatal(extract.Inspect(r))
+		}
+	})
+
+	t.Run("OS", func(t *testing.T) {
+		dir := t.TempDir()
+		err := os.WriteFile(filepath.Join(dir, "mod.ext"), []byte(`(defmodule Mod (def (f) :f))`), 0666)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, r := run(t, `(import "mod") (Mod.f)`, extract.WithLoadPath(dir))
+		if r != extract.MakeAtom("f") {
+			t.Fatal(extract.Inspect(r))
+		}
+
+		_, r = run(t, `(import "mod")`, extract.WithLoadPath(dir), extract.WithoutFileSystem())
+		if err, _ := r.(error); !errors.Is(err, extract.ErrFileSystemDisabled) {
+			t.Fatal(extract.Inspect(r))
+		}
+	})
+}
+
+func TestLoadFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"scripts/a.ext":    {Data: []byte(`(import "scripts/b") (defmodule A (def (f) (B.g)))`)},
+		"scripts/b.ext":    {Data: []byte(`(IO.println "b") (defmodule B (def (g) :b))`)},
+		"scripts/notes.md": {Data: []byte(`not a script`)},
+		"scripts/bad.txt":  {Data: []byte(`(`)},
+	}
+
+	var out strings.Builder
+	env := extract.New(context.Background(), extract.WithStdout(&out))
+	if err := extract.LoadFS(env, fsys, "scripts/*.ext"); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "b\n" {
+		t.Fatalf("%q", out.String())
+	}
+
+	script, err := parser.ParseString(t.Name(), `(A.f)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, r := extract.Run(env, script.All()); r != extract.MakeAtom("b") {
+		t.Fatal(extract.Inspect(r))
+	}
+
+	if err := extract.LoadFS(env, fsys, "scripts/*.txt"); err == nil {
+		t.Fatal("expected a parse error")
+	}
+}
@@ -0,0 +1,118 @@
+package extract
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+)
+
+// logger returns the logger that env's Logger module logs to.
+func (env *Env) logger() *slog.Logger {
+	if env.log == nil {
+		return slog.Default()
+	}
+	return env.log
+}
+
+// logAttr converts an Extract key and value into a slog.Attr.
+func logAttr(key, val any) (slog.Attr, error) {
+	var k string
+	switch key := key.(type) {
+	case Atom:
+		k = key.String()
+	case string:
+		k = key
+	default:
+		return slog.Attr{}, NewTypeError(key, reflect.TypeFor[Atom](), reflect.TypeFor[string]())
+	}
+
+	switch val := val.(type) {
+	case string:
+		return slog.String(k, val), nil
+	case int64:
+		return slog.Int64(k, val), nil
+	case float64:
+		return slog.Float64(k, val), nil
+	case bool:
+		return slog.Bool(k, val), nil
+	case Atom:
+		return slog.String(k, val.String()), nil
+	case *Map:
+		attrs := make([]any, 0, val.Len())
+		for k, v := range val.All() {
+			attr, err := logAttr(k, v)
+			if err != nil {
+				return slog.Attr{}, err
+			}
+			attrs = append(attrs, attr)
+		}
+		return slog.Group(k, attrs...), nil
+	default:
+		return slog.String(k, Inspect(val)), nil
+	}
+}
+
+// logFunc returns a function for the Logger module that logs at
+// level. The function takes a message followed either by alternating
+// keys and values or by a single map of them.
+func logFunc(level slog.Level) EvalFunc {
+	return func(env *Env, args *List) (*Env, any) {
+		if args.Len() == 0 {
+			return env, &ArgumentNumError{Num: args.Len(), Expected: -1}
+		}
+
+		vals, err := evalArgs(env, args)
+		if err != nil {
+			return env, err
+		}
+
+		log := env.logger()
+		if !log.Enabled(env.Context(), level) {
+			return env, okAtom
+		}
+
+		msg, ok := vals[0].(string)
+		if !ok {
+			msg = Inspect(vals[0])
+		}
+
+		fields := vals[1:]
+		if len(fields) == 1 {
+			m, ok := fields[0].(*Map)
+			if !ok {
+				return env, NewTypeError(fields[0], reflect.TypeFor[*Map]())
+			}
+			fields = fields[:0]
+			for k, v := range m.All() {
+				fields = append(fields, k, v)
+			}
+		}
+		if len(fields)%2 != 0 {
+			return env, fmt.Errorf("log fields must be pairs of keys and values, got %v values", len(fields))
+		}
+
+		attrs := make([]slog.Attr, 0, len(fields)/2)
+		for i := 0; i < len(fields); i += 2 {
+			attr, err := logAttr(fields[i], fields[i+1])
+			if err != nil {
+				return env, err
+			}
+			attrs = append(attrs, attr)
+		}
+
+		log.LogAttrs(env.Context(), level, msg, attrs...)
+		return env, okAtom
+	}
+}
+
+func stdLogger() *Module {
+	m := Module{name: MakeAtom("Logger")}
+	m.decls = map[Ident]any{
+		MakeIdent("debug"): logFunc(slog.LevelDebug),
+		MakeIdent("info"):  logFunc(slog.LevelInfo),
+		MakeIdent("warn"):  logFunc(slog.LevelWarn),
+		MakeIdent("error"): logFunc(slog.LevelError),
+	}
+
+	return &m
+}
@@ -0,0 +1,60 @@
+package extract_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"deedles.dev/extract"
+	"deedles.dev/extract/parser"
+)
+
+func TestLogger(t *testing.T) {
+	const src = `
+	(Logger.debug "hidden")
+	(Logger.info "started" :port 8080 :name "test")
+	(Logger.warn "slow" (Map.new :ms 1.5 :ok false))
+	(Logger.error :failed :reason [1 2] :details (Map.new :code 3))
+	`
+	s, err := parser.ParseString(t.Name(), src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	h := slog.NewTextHandler(&out, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	env := extract.New(context.Background(), extract.WithLogHandler(h))
+	_, result := extract.Run(env, s.All())
+	if err, ok := result.(error); ok {
+		t.Fatal(err)
+	}
+
+	const ex = `level=INFO msg=started port=8080 name=test
+level=WARN msg=slow ms=1.5 ok=false
+level=ERROR msg=:failed reason="[1 2]" details.code=3
+`
+	if out.String() != ex {
+		t.Fatalf("\n%v", out.String())
+	}
+}
+
+func TestLoggerFields(t *testing.T) {
+	result := runScript(t, `(Logger.info "odd" :key)`, false)
+	if err, _ := result.(error); !errors.As(err, new(*extract.TypeError)) {
+		t.Fatalf("%#v", result)
+	}
+	result = runScript(t, `(Logger.info "odd" :a 1 :b)`, false)
+	if _, ok := result.(error); !ok {
+		t.Fatalf("%#v", result)
+	}
+}
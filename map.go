@@ -0,0 +1,344 @@
+package extract
+
+import (
+	"iter"
+	"reflect"
+	"slices"
+	"strings"
+
+	"deedles.dev/xiter"
+)
+
+// Map is an immutable map from keys to values. Any value can be used
+// as a key, with keys being compared with [Equal] and hashed with
+// [Hash]. Like a *List, a nil *Map is a valid, empty map, and
+// operations that modify a map return a new map, leaving the original
+// unchanged.
+type Map struct {
+	pairs trie[any, any]
+}
+
+// MapOf returns a map containing the given keys and values, which are
+// given in alternating order, such as MapOf(k1, v1, k2, v2). It panics
+// if an odd number of arguments is given.
+func MapOf(kv ...any) (m *Map) {
+	if len(kv)%2 != 0 {
+		panic("MapOf called with an odd number of arguments")
+	}
+
+	for i := 0; i < len(kv); i += 2 {
+		m = m.Put(kv[i], kv[i+1])
+	}
+	return m
+}
+
+// CollectMap creates a new map from the pairs yielded by seq. If a key
+// is yielded more than once, the last value yielded with it is used.
+func CollectMap[K, V any](seq iter.Seq2[K, V]) (m *Map) {
+	for k, v := range seq {
+		m = m.Put(k, v)
+	}
+	return m
+}
+
+func (m *Map) trie() trie[any, any] {
+	if m == nil {
+		return trie[any, any]{}
+	}
+	return m.pairs
+}
+
+// Len returns the number of keys in the map.
+func (m *Map) Len() int {
+	return m.trie().Len()
+}
+
+// Get returns the value associated with key. If there is no such
+// value, it returns false as the second return value.
+func (m *Map) Get(key any) (any, bool) {
+	return m.trie().Get(Hash(key), key, Equal)
+}
+
+// Put returns a new map with key associated with val.
+func (m *Map) Put(key, val any) *Map {
+	return &Map{pairs: m.trie().Put(Hash(key), key, val, Equal)}
+}
+
+// Delete returns a new map without key.
+func (m *Map) Delete(key any) *Map {
+	if m.Len() == 0 {
+		return m
+	}
+	return &Map{pairs: m.trie().Delete(Hash(key), key, Equal)}
+}
+
+// All returns an iterator over the keys and values in the map. The
+// pairs are yielded in the order of their keys as defined by
+// [Compare].
+func (m *Map) All() iter.Seq2[any, any] {
+	return func(yield func(any, any) bool) {
+		type pair struct{ k, v any }
+		pairs := make([]pair, 0, m.Len())
+		for k, v := range m.trie().All() {
+			pairs = append(pairs, pair{k, v})
+		}
+		slices.SortFunc(pairs, func(p1, p2 pair) int { return Compare(p1.k, p2.k) })
+
+		for _, p := range pairs {
+			if !yield(p.k, p.v) {
+				return
+			}
+		}
+	}
+}
+
+// Equal returns true if other is a *Map with the same keys as m, each
+// associated with an equal value.
+func (m *Map) Equal(other any) bool {
+	o, ok := other.(*Map)
+	if !ok || m.Len() != o.Len() {
+		return false
+	}
+
+	for k, v := range m.trie().All() {
+		ov, ok := o.Get(k)
+		if !ok || !Equal(v, ov) {
+			return false
+		}
+	}
+	return true
+}
+
+// Hash returns a hash of the contents of the map that is consistent
+// with [Map.Equal].
+func (m *Map) Hash() uint64 {
+	h := uint64(m.Len())
+	for k, v := range m.trie().All() {
+		// Addition is commutative, so the order of the pairs doesn't
+		// affect the result.
+		h += Hash(ListOf(k, v))
+	}
+	return h
+}
+
+// Inspect returns a representation of the map in the form
+// #Map<k1 v1, k2 v2>.
+func (m *Map) Inspect() string {
+	var sb strings.Builder
+	sb.WriteString("#Map<")
+	first := true
+	for k, v := range m.All() {
+		if !first {
+			sb.WriteString(", ")
+		}
+		first = false
+		inspect(&sb, k)
+		sb.WriteByte(' ')
+		inspect(&sb, v)
+	}
+	sb.WriteByte('>')
+	return sb.String()
+}
+
+func (m *Map) String() string {
+	return m.Inspect()
+}
+
+func compareMaps(m1, m2 *Map) int {
+	if c := Compare(int64(m1.Len()), int64(m2.Len())); c != 0 {
+		return c
+	}
+
+	next1, stop1 := iter.Pull2(m1.All())
+	defer stop1()
+	next2, stop2 := iter.Pull2(m2.All())
+	defer stop2()
+	for {
+		k1, v1, ok := next1()
+		if !ok {
+			return 0
+		}
+		k2, v2, _ := next2()
+		if c := Compare(k1, k2); c != 0 {
+			return c
+		}
+		if c := Compare(v1, v2); c != 0 {
+			return c
+		}
+	}
+}
+
+func stdMap() *Module {
+	m := Module{name: MakeAtom("Map")}
+	m.decls = map[Ident]any{
+		MakeIdent("new"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len()%2 != 0 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: -1}
+			}
+
+			vals, err := evalArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			return env, MapOf(vals...)
+		}),
+		MakeIdent("get"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 2 && args.Len() != 3 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: -1}
+			}
+
+			m, vals, err := evalMapArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			v, ok := m.Get(vals[0])
+			if !ok && len(vals) == 2 {
+				return env, vals[1]
+			}
+			return env, v
+		}),
+		MakeIdent("put"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 3 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 3}
+			}
+
+			m, vals, err := evalMapArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			return env, m.Put(vals[0], vals[1])
+		}),
+		MakeIdent("delete"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 2 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 2}
+			}
+
+			m, vals, err := evalMapArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			return env, m.Delete(vals[0])
+		}),
+		MakeIdent("has_key?"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 2 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 2}
+			}
+
+			m, vals, err := evalMapArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			_, ok := m.Get(vals[0])
+			return env, ok
+		}),
+		MakeIdent("keys"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 1 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 1}
+			}
+
+			m, _, err := evalMapArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			return env, CollectList(xiter.V1(m.All()))
+		}),
+		MakeIdent("values"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 1 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 1}
+			}
+
+			m, _, err := evalMapArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			return env, CollectList(xiter.V2(m.All()))
+		}),
+		MakeIdent("merge"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() == 0 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: -1}
+			}
+
+			m, vals, err := evalMapArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			for _, v := range vals {
+				other, ok := v.(*Map)
+				if !ok {
+					return env, NewTypeError(v, reflect.TypeFor[*Map]())
+				}
+				for k, v := range other.All() {
+					m = m.Put(k, v)
+				}
+			}
+			return env, m
+		}),
+		MakeIdent("update"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 4 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 4}
+			}
+
+			m, vals, err := evalMapArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			key, v := vals[0], vals[1]
+			if cur, ok := m.Get(key); ok {
+				v, err = callFunc(env, vals[2], cur)
+				if err != nil {
+					return env, err
+				}
+			}
+			return env, m.Put(key, v)
+		}),
+		MakeIdent("to_list"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 1 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 1}
+			}
+
+			m, _, err := evalMapArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			pairs := make([]any, 0, m.Len())
+			for k, v := range m.All() {
+				pairs = append(pairs, ListOf(k, v))
+			}
+			return env, ListOf(pairs...)
+		}),
+		MakeIdent("from_list"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			list, err := evalList(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			var m *Map
+			for pair := range list.All() {
+				p, ok := pair.(*List)
+				if !ok || p.Len() != 2 {
+					return env, NewTypeError(pair, reflect.TypeFor[*List]())
+				}
+				m = m.Put(p.Head(), p.Tail().Head())
+			}
+			return env, m
+		}),
+	}
+
+	return &m
+}
+
+// evalMapArgs evaluates args, the first of which must be a *Map. It
+// returns the map and the rest of the evaluated arguments.
+func evalMapArgs(env *Env, args *List) (*Map, []any, error) {
+	vals, err := evalArgs(env, args)
+	if err != nil {
+		return nil, nil, err
+	}
+	m, ok := vals[0].(*Map)
+	if !ok {
+		return nil, nil, NewTypeError(vals[0], reflect.TypeFor[*Map]())
+	}
+	return m, vals[1:], nil
+}
@@ -0,0 +1,116 @@
+package extract_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"deedles.dev/extract"
+)
+
+func TestMap(t *testing.T) {
+	var m *extract.Map
+	for i := range 1000 {
+		m = m.Put(int64(i), i*2)
+	}
+	m = m.Put(extract.ListOf("key"), "list")
+	if m.Len() != 1001 {
+		t.Fatal(m.Len())
+	}
+
+	for i := range 1000 {
+		v, ok := m.Get(int64(i))
+		if !ok || v != i*2 {
+			t.Fatalf("%v: %v", i, v)
+		}
+	}
+	if v, _ := m.Get(extract.ListOf("key")); v != "list" {
+		t.Fatal(v)
+	}
+
+	deleted := m
+	for i := range 500 {
+		deleted = deleted.Delete(int64(i * 2))
+	}
+	if deleted.Len() != 501 || m.Len() != 1001 {
+		t.Fatal(deleted.Len(), m.Len())
+	}
+	if _, ok := deleted.Get(int64(4)); ok {
+		t.Fatal("4 should have been deleted")
+	}
+	if _, ok := deleted.Get(int64(5)); !ok {
+		t.Fatal("5 should not have been deleted")
+	}
+
+	var prev any
+	for k := range deleted.All() {
+		if prev != nil && extract.Compare(prev, k) >= 0 {
+			t.Fatalf("%v before %v", prev, k)
+		}
+		prev = k
+	}
+
+	m1 := extract.MapOf(extract.MakeAtom("a"), int64(1), extract.MakeAtom("b"), extract.ListOf(int64(2)))
+	m2 := extract.MapOf(extract.MakeAtom("b"), extract.ListOf(int64(2)), extract.MakeAtom("a"), int64(1))
+	if !extract.Equal(m1, m2) || extract.Hash(m1) != extract.Hash(m2) {
+		t.Fatal(m1, m2)
+	}
+	if str := extract.Inspect(m1); str != "#Map<:a 1, :b [2]>" {
+		t.Fatal(str)
+	}
+}
+
+func TestMapModule(t *testing.T) {
+	const src = `
+	(let m (Map.new :a 1 :b 2))
+	(list
+		(Map.get m :a)
+		(Map.get m :c)
+		(Map.get m :c 3)
+		(Map.put m :c 3)
+		(Map.delete m :a)
+		(Map.has_key? m :b)
+		(Map.has_key? m :c)
+		(Map.keys m)
+		(Map.values m)
+		(Map.merge m (Map.new :b 3) (Map.new :c 4))
+		(Map.update m :a 0 (func (inc n) (n + 1)))
+		(Map.update m :c 0 (func (inc n) (n + 1)))
+		(Map.to_list m)
+		(Map.from_list [[:x 1] [:y 2]])
+	)
+	`
+	result := runScript(t, src, true)
+
+	a, b, c := extract.MakeAtom("a"), extract.MakeAtom("b"), extract.MakeAtom("c")
+	ex := []any{
+		int64(1),
+		nil,
+		int64(3),
+		extract.MapOf(a, int64(1), b, int64(2), c, int64(3)),
+		extract.MapOf(b, int64(2)),
+		true,
+		false,
+		extract.ListOf(a, b),
+		extract.ListOf(int64(1), int64(2)),
+		extract.MapOf(a, int64(1), b, int64(3), c, int64(4)),
+		extract.MapOf(a, int64(2), b, int64(2)),
+		extract.MapOf(a, int64(1), b, int64(2), c, int64(0)),
+		extract.ListOf(extract.ListOf(a, int64(1)), extract.ListOf(b, int64(2))),
+		extract.MapOf(extract.MakeAtom("x"), int64(1), extract.MakeAtom("y"), int64(2)),
+	}
+	r := slices.Collect(result.(*extract.List).All())
+	if len(r) != len(ex) {
+		t.Fatalf("%v", r)
+	}
+	for i := range r {
+		if !extract.Equal(r[i], ex[i]) {
+			t.Errorf("%v: %v != %v", i, r[i], ex[i])
+		}
+	}
+
+	result = runScript(t, `(Map.get [1 2] 1)`, false)
+	if err, _ := result.(error); !errors.As(err, new(*extract.TypeError)) {
+		t.Fatalf("%#v", result)
+	}
+}
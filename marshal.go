@@ -0,0 +1,283 @@
+package extract
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+)
+
+// Marshal converts a Go value to an Extract value. Booleans, numbers,
+// and strings are converted as described by [Module.RegisterFunc].
+// Slices and arrays become *Lists, except for byte slices, which
+// become *Binaries, and Go maps become *Maps, with their keys and
+// values converted recursively. Pointers and interfaces are replaced
+// by the values they point to, with nil becoming nil. An unsigned
+// integer that is too large to be an int64 becomes an error wrapping
+// [ErrIntRange].
+//
+// Structs become *Maps with an atom key for each exported field. The
+// key defaults to the name of the field converted to snake_case, but
+// can be changed with an extract struct tag, such as
+//
+//	Name string `extract:"username"`
+//
+// A tag of "-" omits the field, and a tag with an omitempty option,
+// such as `extract:"username,omitempty"`, omits it if it is the zero
+// value. Fields of embedded structs are treated as if they were
+// fields of the outer struct. Structs without any exported fields,
+// as well as values that are already Extract values, such as atoms
+// and *Lists, and values of kinds that have no Extract equivalent,
+// such as functions, are returned unconverted.
+func Marshal(v any) any {
+	return marshal(reflect.ValueOf(v))
+}
+
+func isExtractValue(v any) bool {
+	switch v.(type) {
+	case Atom, Ident, Rune, *List, *Map, *Tuple, *Binary, Evaluator:
+		return true
+	default:
+		return false
+	}
+}
+
+func marshal(v reflect.Value) any {
+	if !v.IsValid() {
+		return nil
+	}
+	if v.CanInterface() && isExtractValue(v.Interface()) {
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return marshal(v.Elem())
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return BinaryOf(v.Bytes())
+		}
+
+		var list *List
+		for i := v.Len() - 1; i >= 0; i-- {
+			list = list.Push(marshal(v.Index(i)))
+		}
+		return list
+
+	case reflect.Map:
+		var m *Map
+		for iter := v.MapRange(); iter.Next(); {
+			m = m.Put(marshal(iter.Key()), marshal(iter.Value()))
+		}
+		return m
+
+	case reflect.Struct:
+		fields := structFields(v.Type())
+		if len(fields) == 0 {
+			return v.Interface()
+		}
+
+		var m *Map
+		for _, f := range fields {
+			fv, err := v.FieldByIndexErr(f.index)
+			if err != nil || f.omitEmpty && fv.IsZero() {
+				continue
+			}
+			m = m.Put(f.key, marshal(fv))
+		}
+		return m
+
+	default:
+		r, err := fromGo(v)
+		if err != nil {
+			return err
+		}
+		return r
+	}
+}
+
+// Unmarshal converts the Extract value val into the Go value pointed
+// to by ptr, reversing the conversions performed by [Marshal]. Keys of
+// a *Map that don't correspond to any field of a struct are ignored,
+// and fields without a corresponding key are left unmodified. Keys may
+// be either atoms or strings. Byte slices may be unmarshaled from
+// either strings or binaries. If a value can't be converted, a
+// [TypeError] is returned.
+func Unmarshal(val any, ptr any) error {
+	pv := reflect.ValueOf(ptr)
+	if pv.Kind() != reflect.Pointer || pv.IsNil() {
+		return errors.New("unmarshal destination must be a non-nil pointer")
+	}
+	return unmarshal(val, pv.Elem())
+}
+
+func unmarshal(val any, dst reflect.Value) error {
+	t := dst.Type()
+	if val == nil {
+		dst.SetZero()
+		return nil
+	}
+	if reflect.TypeOf(val).AssignableTo(t) {
+		dst.Set(reflect.ValueOf(val))
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		if dst.IsNil() {
+			dst.Set(reflect.New(t.Elem()))
+		}
+		return unmarshal(val, dst.Elem())
+
+	case reflect.Struct:
+		m, ok := val.(*Map)
+		if !ok {
+			return NewTypeError(val, reflect.TypeFor[*Map]())
+		}
+		for _, f := range structFields(t) {
+			fval, ok := m.Get(f.key)
+			if !ok {
+				fval, ok = m.Get(f.key.String())
+			}
+			if !ok {
+				continue
+			}
+
+			fv, err := dst.FieldByIndexErr(f.index)
+			if err != nil {
+				fv = allocFieldByIndex(dst, f.index)
+			}
+			if err := unmarshal(fval, fv); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Map:
+		m, ok := val.(*Map)
+		if !ok {
+			return NewTypeError(val, reflect.TypeFor[*Map]())
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.MakeMapWithSize(t, m.Len()))
+		}
+		for k, v := range m.All() {
+			kv := reflect.New(t.Key()).Elem()
+			if err := unmarshal(k, kv); err != nil {
+				return err
+			}
+			vv := reflect.New(t.Elem()).Elem()
+			if err := unmarshal(v, vv); err != nil {
+				return err
+			}
+			dst.SetMapIndex(kv, vv)
+		}
+		return nil
+
+	case reflect.Slice:
+		if s, ok := val.(string); ok && t.Elem().Kind() == reflect.Uint8 {
+			dst.SetBytes([]byte(s))
+			return nil
+		}
+		if b, ok := val.(*Binary); ok && t.Elem().Kind() == reflect.Uint8 {
+			dst.SetBytes(b.Bytes())
+			return nil
+		}
+
+		list, ok := val.(*List)
+		if !ok {
+			return NewTypeError(val, typeList)
+		}
+		s := reflect.MakeSlice(t, list.Len(), list.Len())
+		i := 0
+		for v := range list.All() {
+			if err := unmarshal(v, s.Index(i)); err != nil {
+				return err
+			}
+			i++
+		}
+		dst.Set(s)
+		return nil
+
+	case reflect.Array:
+		list, ok := val.(*List)
+		if !ok || list.Len() != t.Len() {
+			return NewTypeError(val, t)
+		}
+		i := 0
+		for v := range list.All() {
+			if err := unmarshal(v, dst.Index(i)); err != nil {
+				return err
+			}
+			i++
+		}
+		return nil
+
+	default:
+		v, err := toGo(val, t)
+		if err != nil {
+			return err
+		}
+		dst.Set(v)
+		return nil
+	}
+}
+
+// allocFieldByIndex is like FieldByIndex, but allocates any nil
+// embedded struct pointers along the way.
+func allocFieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+type structField struct {
+	key       Atom
+	index     []int
+	omitEmpty bool
+}
+
+// structFields returns the fields of the struct type t that are
+// converted by Marshal and Unmarshal.
+func structFields(t reflect.Type) []structField {
+	var fields []structField
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() {
+			continue
+		}
+		if f.Anonymous {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				continue
+			}
+		}
+
+		name, opts, _ := strings.Cut(f.Tag.Get("extract"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = snakeCase(f.Name)
+		}
+
+		fields = append(fields, structField{
+			key:       MakeAtom(name),
+			index:     f.Index,
+			omitEmpty: opts == "omitempty",
+		})
+	}
+	return fields
+}
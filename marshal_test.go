@@ -0,0 +1,70 @@
+package extract_test
+
+import (
+	"reflect"
+	"testing"
+
+	"deedles.dev/extract"
+)
+
+type Base struct {
+	ID int
+}
+
+type user struct {
+	Base
+	Name     string   `extract:"username"`
+	Tags     []string `extract:",omitempty"`
+	Password string   `extract:"-"`
+	Scores   map[string]float64
+	Manager  *user
+	Raw      []byte
+	private  int
+}
+
+func TestMarshal(t *testing.T) {
+	u := user{
+		Base:     Base{ID: 3},
+		Name:     "test",
+		Password: "secret",
+		Scores:   map[string]float64{"a": 1.5},
+		Manager:  &user{Name: "boss"},
+		Raw:      []byte("raw"),
+		private:  2,
+	}
+
+	ex := extract.MapOf(
+		extract.MakeAtom("id"), int64(3),
+		extract.MakeAtom("username"), "test",
+		extract.MakeAtom("scores"), extract.MapOf("a", 1.5),
+		extract.MakeAtom("manager"), extract.MapOf(
+			extract.MakeAtom("id"), int64(0),
+			extract.MakeAtom("username"), "boss",
+			extract.MakeAtom("scores"), (*extract.Map)(nil),
+			extract.MakeAtom("manager"), nil,
+			extract.MakeAtom("raw"), extract.BinaryOf(nil),
+		),
+		extract.MakeAtom("raw"), extract.BinaryOf([]byte("raw")),
+	)
+
+	v := extract.Marshal(u)
+	if !extract.Equal(v, ex) {
+		t.Fatal(extract.Inspect(v))
+	}
+
+	var out user
+	err := extract.Unmarshal(v, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u.Password, u.private = "", 0
+	u.Manager.Scores = map[string]float64{}
+	if !reflect.DeepEqual(out, u) {
+		t.Fatalf("%#v", out)
+	}
+
+	err = extract.Unmarshal(extract.MapOf(extract.MakeAtom("id"), "three"), &out)
+	if _, ok := err.(*extract.TypeError); !ok {
+		t.Fatalf("%#v", err)
+	}
+}
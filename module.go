@@ -0,0 +1,127 @@
+package extract
+
+import "reflect"
+
+// evalModuleArgs evaluates args, the first of which must be the name
+// of a module that is defined in env. It returns the module and the
+// rest of the evaluated arguments.
+func evalModuleArgs(env *Env, args *List) (*Module, []any, error) {
+	vals, err := evalArgs(env, args)
+	if err != nil {
+		return nil, nil, err
+	}
+	name, ok := vals[0].(Atom)
+	if !ok {
+		return nil, nil, NewTypeError(vals[0], reflect.TypeFor[Atom]())
+	}
+	m := env.GetModule(name)
+	if m == nil {
+		return nil, nil, &UndefinedModuleError{Name: name}
+	}
+	return m, vals[1:], nil
+}
+
+// evalModuleDecl evaluates a module name followed by the name of a
+// declaration in it, given as an atom.
+func evalModuleDecl(env *Env, args *List) (*Module, Ident, error) {
+	m, vals, err := evalModuleArgs(env, args)
+	if err != nil {
+		return nil, Ident{}, err
+	}
+	name, ok := vals[0].(Atom)
+	if !ok {
+		return nil, Ident{}, NewTypeError(vals[0], reflect.TypeFor[Atom]())
+	}
+	return m, MakeIdent(name.String()), nil
+}
+
+func stdModule() *Module {
+	m := Module{name: MakeAtom("Module")}
+	m.decls = map[Ident]any{
+		MakeIdent("list"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 0 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 0}
+			}
+
+			var names []any
+			for m := range env.Modules() {
+				names = append(names, m.Name())
+			}
+			return env, ListOf(names...)
+		}),
+		MakeIdent("functions"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 1 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 1}
+			}
+
+			m, _, err := evalModuleArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			var names []any
+			for ident := range m.All() {
+				names = append(names, MakeAtom(ident.String()))
+			}
+			return env, ListOf(names...)
+		}),
+		MakeIdent("defines?"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 2 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 2}
+			}
+
+			m, ident, err := evalModuleDecl(env, args)
+			if err != nil {
+				return env, err
+			}
+			_, ok := m.Lookup(ident)
+			return env, ok
+		}),
+		MakeIdent("get"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 2 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 2}
+			}
+
+			m, ident, err := evalModuleDecl(env, args)
+			if err != nil {
+				return env, err
+			}
+			v, ok := m.Lookup(ident)
+			if !ok {
+				return env, &NameError{Ident: ident}
+			}
+			return env, v
+		}),
+		MakeIdent("doc"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 1 && args.Len() != 2 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: -1}
+			}
+
+			if args.Len() == 1 {
+				m, _, err := evalModuleArgs(env, args)
+				if err != nil {
+					return env, err
+				}
+				if m.Doc() == "" {
+					return env, nil
+				}
+				return env, m.Doc()
+			}
+
+			m, ident, err := evalModuleDecl(env, args)
+			if err != nil {
+				return env, err
+			}
+			if _, ok := m.Lookup(ident); !ok {
+				return env, &NameError{Ident: ident}
+			}
+			doc, ok := m.FuncDoc(ident)
+			if !ok {
+				return env, nil
+			}
+			return env, doc
+		}),
+	}
+
+	return &m
+}
@@ -0,0 +1,79 @@
+package extract_test
+
+import (
+	"errors"
+	"testing"
+
+	"deedles.dev/extract"
+)
+
+func TestModule(t *testing.T) {
+	const src = `
+	(defmodule Test
+		(def (inc v) (add v 1))
+		(def (dec v) (sub v 1)))
+	(let inc (Module.get :Test :inc))
+	(list
+		(List.member? (Module.list) :Test)
+		(List.member? (Module.list) :String)
+		(Module.functions :Test)
+		(Module.defines? :Test :inc)
+		(Module.defines? :Test :other)
+		(inc 2)
+		(List.member? (Module.functions :Module) :get)
+	)
+	`
+	result := runScript(t, src, true)
+	checkList(t, result,
+		true,
+		true,
+		extract.ListOf(extract.MakeAtom("dec"), extract.MakeAtom("inc")),
+		true,
+		false,
+		int64(3),
+		true,
+	)
+}
+
+func TestModuleErrors(t *testing.T) {
+	result := runScript(t, `(Module.functions :Missing)`, false)
+	if err, _ := result.(error); !errors.As(err, new(*extract.UndefinedModuleError)) {
+		t.Fatalf("%#v", result)
+	}
+	result = runScript(t, `(Module.get :String :missing)`, false)
+	if err, _ := result.(error); !errors.As(err, new(*extract.NameError)) {
+		t.Fatalf("%#v", result)
+	}
+	result = runScript(t, `(Module.defines? :String "to_upper")`, false)
+	if err, _ := result.(error); !errors.As(err, new(*extract.TypeError)) {
+		t.Fatalf("%#v", result)
+	}
+}
+
+func TestModuleDoc(t *testing.T) {
+	const src = `
+	(defmodule Test
+		(moduledoc "Things for testing.")
+		(doc "Increments v.")
+		(def (inc v) (add v 1))
+		(def (dec v) (sub v 1)))
+	(list
+		(Module.doc :Test)
+		(Module.doc :Test :inc)
+		(Module.doc :Test :dec)
+		(Module.doc :String)
+	)
+	`
+	result := runScript(t, src, true)
+	checkList(t, result,
+		"Things for testing.",
+		"Increments v.",
+		nil,
+		nil,
+	)
+
+	result = runScript(t, `(doc "outside")`, false)
+	if _, ok := result.(error); !ok {
+		t.Fatalf("%#v", result)
+	}
+}
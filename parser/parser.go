@@ -2,48 +2,184 @@
 package parser
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
+	"iter"
+	"os"
+	"slices"
+	"strings"
 
 	"deedles.dev/extract"
 	"deedles.dev/extract/literal"
 	"deedles.dev/extract/scanner"
 )
 
+// ErrIncomplete is returned, wrapped, when the input ends partway
+// through an expression, such as inside of an unclosed list or string.
+// This differs from other syntax errors in that the input might
+// become valid if more of it is provided, which is useful for
+// interactive use.
+var ErrIncomplete = errors.New("incomplete input")
+
+// DefaultMaxDepth is the maximum nesting depth of expressions that is
+// allowed by default. See [WithMaxDepth].
+const DefaultMaxDepth = 1000
+
+// Option is an option that can be passed to the parsing functions.
+type Option func(*parser)
+
+// WithMaxDepth sets the maximum nesting depth of expressions. Input
+// that nests expressions more deeply than this, such as by nesting
+// lists inside of each other, results in a [DepthError] instead of
+// possibly exhausting the stack. A depth of 0 or less means that
+// there is no limit.
+func WithMaxDepth(depth int) Option {
+	return func(p *parser) {
+		p.maxDepth = depth
+	}
+}
+
+func init() {
+	extract.RegisterParser(func(filename string, src []byte) (*extract.List, error) {
+		return ParseBytes(filename, src)
+	})
+}
+
 // Parse parses an Extract script from r.
-func Parse(r io.Reader) (*extract.List, error) {
-	return ParseScanner(scanner.New(r))
+func Parse(r io.Reader, opts ...Option) (*extract.List, error) {
+	return ParseScanner(scanner.New(r), opts...)
+}
+
+// ParseString parses an Extract script from src. The filename is
+// used only for the positions recorded in the result and in errors
+// and may be empty.
+func ParseString(filename, src string, opts ...Option) (*extract.List, error) {
+	return ParseScanner(scanner.New(strings.NewReader(src), scanner.WithFilename(filename)), opts...)
+}
+
+// ParseBytes is like [ParseString] but parses from a byte slice.
+func ParseBytes(filename string, src []byte, opts ...Option) (*extract.List, error) {
+	return ParseScanner(scanner.New(bytes.NewReader(src), scanner.WithFilename(filename)), opts...)
+}
+
+// ParseFile opens and parses the Extract script at path.
+func ParseFile(path string, opts ...Option) (*extract.List, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ParseScanner(scanner.New(file, scanner.WithFilename(path)), opts...)
+}
+
+// ParseExpr parses exactly one Extract expression from src. It is an
+// error for src to be empty or to contain anything after the
+// expression other than whitespace and comments.
+func ParseExpr(src string, opts ...Option) (expr any, err error) {
+	p := newParser(scanner.New(strings.NewReader(src)), opts)
+	defer p.recover(&err)
+
+	expr = p.expr()
+	if isOper(expr) {
+		p.raise(fmt.Errorf("operator %v used outside of a list", expr))
+	}
+	if p.peek() != nil {
+		p.raiseUnexpectedToken(p.scan(), nil)
+	}
+	return expr, nil
+}
+
+// All returns an iterator that parses top-level expressions from r
+// one at a time, yielding each as soon as it has been parsed. If an
+// error is encountered, it is yielded and the iteration stops.
+func All(r io.Reader, opts ...Option) iter.Seq2[any, error] {
+	return AllScanner(scanner.New(r), opts...)
+}
+
+// AllScanner is like [All] but reads tokens from s.
+func AllScanner(s *scanner.Scanner, opts ...Option) iter.Seq2[any, error] {
+	return func(yield func(any, error) bool) {
+		p := newParser(s, opts)
+		for {
+			expr, ok, err := p.next()
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !ok || !yield(expr, nil) {
+				return
+			}
+		}
+	}
+}
+
+// next parses the next top-level expression. If there are no more
+// expressions, it returns false.
+func (p *parser) next() (expr any, ok bool, err error) {
+	defer p.recover(&err)
+
+	if p.peek() == nil {
+		return nil, false, nil
+	}
+
+	expr = p.expr()
+	if isOper(expr) {
+		p.raise(fmt.Errorf("operator %v used outside of a list", expr))
+	}
+	return expr, true, nil
 }
 
 // ParseScanner parses an Extract script from s.
-func ParseScanner(s *scanner.Scanner) (*extract.List, error) {
-	p := parser{s: s}
+func ParseScanner(s *scanner.Scanner, opts ...Option) (*extract.List, error) {
+	p := newParser(s, opts)
 	return p.Parse()
 }
 
 type parser struct {
-	s   *scanner.Scanner
-	tok scanner.Token
+	s        *scanner.Scanner
+	depth    int
+	maxDepth int
+}
+
+func newParser(s *scanner.Scanner, opts []Option) *parser {
+	p := parser{s: s, maxDepth: DefaultMaxDepth}
+	for _, opt := range opts {
+		opt(&p)
+	}
+	return &p
 }
 
 func (p *parser) Parse() (list *extract.List, err error) {
-	defer func() {
-		switch r := recover().(type) {
-		case nil:
-		case raise:
-			err = r.err
-		default:
-			panic(r)
-		}
-	}()
+	defer p.recover(&err)
 
-	return p.listInner(), nil
+	list = p.listInner()
+	if p.peek() != nil {
+		p.raiseUnexpectedToken(p.scan(), nil)
+	}
+	return list, nil
+}
+
+// recover recovers from a call to raise, setting *err to the raised
+// error. It must be deferred directly.
+func (p *parser) recover(err *error) {
+	switch r := recover().(type) {
+	case nil:
+	case raise:
+		*err = r.err
+	default:
+		panic(r)
+	}
 }
 
 type raise struct{ err error }
 
 func (p *parser) raise(err error) {
+	if errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, ErrIncomplete) {
+		err = fmt.Errorf("%w: %w", ErrIncomplete, err)
+	}
 	panic(raise{err: err})
 }
 
@@ -53,20 +189,14 @@ func (p *parser) raiseUnexpectedEOF() {
 
 func (p *parser) raiseUnexpectedToken(got scanner.Token, ex any) {
 	p.raise(&UnexpectedTokenError{
-		Line:     got.Line,
-		Col:      got.Col,
+		Position: got.Position,
 		Got:      got.Val,
 		Expected: ex,
 	})
 }
 
 func (p *parser) scan() scanner.Token {
-	if p.tok.Val != nil {
-		tok := p.tok
-		p.tok.Val = nil
-		return tok
-	}
-
+	p.peek()
 	if !p.s.Scan() {
 		if p.s.Err() != nil {
 			p.raise(p.s.Err())
@@ -78,28 +208,26 @@ func (p *parser) scan() scanner.Token {
 	return p.s.Token()
 }
 
-func (p *parser) unscan(tok scanner.Token) {
-	if p.tok.Val != nil {
-		panic("unscanned twice")
-	}
-
-	p.tok = tok
-}
-
+// peek returns the value of the next token without consuming it. Any
+// whitespace and comment tokens, which are only produced if the
+// scanner was created with [scanner.WithTrivia], are skipped.
 func (p *parser) peek() any {
-	if p.tok.Val != nil {
-		return p.tok.Val
-	}
+	for {
+		tok, ok := p.s.Peek()
+		if !ok {
+			if p.s.Err() != nil {
+				p.raise(p.s.Err())
+			}
+			return nil
+		}
 
-	if !p.s.Scan() {
-		if p.s.Err() != nil {
-			p.raise(p.s.Err())
+		switch tok.Val.(type) {
+		case scanner.Whitespace, scanner.Comment:
+			p.s.Scan()
+		default:
+			return tok.Val
 		}
-		return nil
 	}
-
-	p.tok = p.s.Token()
-	return p.tok.Val
 }
 
 func expect[T any](p *parser) (tok scanner.Token, v T) {
@@ -112,52 +240,104 @@ func expect[T any](p *parser) (tok scanner.Token, v T) {
 	return tok, v
 }
 
-func (p *parser) list() literal.List {
-	expect[scanner.Lparen](p)
-	list := p.listInner()
+func (p *parser) list(start scanner.Token) any {
+	exprs := p.exprs()
 	expect[scanner.Rparen](p)
-	return literal.List{List: list}
+
+	if slices.ContainsFunc(exprs, isOper) {
+		return p.operators(start, exprs)
+	}
+	return literal.List{List: extract.ListOf(exprs...), Pos: start.Position}
 }
 
-func (p *parser) listInner() *extract.List {
+func (p *parser) brackets(start scanner.Token) literal.Brackets {
 	var exprs []any
+	for p.peek() != (scanner.Rbracket{}) && p.peek() != nil {
+		expr := p.expr()
+		if isOper(expr) {
+			p.raise(&OperatorError{Position: start.Position, Err: errors.New("operators are not allowed in bracketed lists")})
+		}
+		exprs = append(exprs, expr)
+	}
+	expect[scanner.Rbracket](p)
+
+	return literal.Brackets{List: extract.ListOf(exprs...), Pos: start.Position}
+}
+
+func (p *parser) listInner() *extract.List {
+	exprs := p.exprs()
+	if i := slices.IndexFunc(exprs, isOper); i >= 0 {
+		p.raise(fmt.Errorf("operator %v used outside of a list", exprs[i]))
+	}
+	return extract.ListOf(exprs...)
+}
+
+func (p *parser) exprs() (exprs []any) {
 	for p.peek() != (scanner.Rparen{}) && p.peek() != nil {
 		exprs = append(exprs, p.expr())
 	}
-	return extract.ListOf(exprs...)
+	return exprs
 }
 
 func (p *parser) expr() (expr any) {
 	tok := p.scan()
+
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.maxDepth > 0 && p.depth > p.maxDepth {
+		p.raise(&DepthError{Position: tok.Position, Max: p.maxDepth})
+	}
+
 	switch t := tok.Val.(type) {
 	case scanner.Int:
 		expr = literal.Int(t)
 	case scanner.Float:
 		expr = literal.Float(t)
+	case scanner.Rune:
+		expr = literal.Rune(t)
 	case scanner.String:
 		expr = literal.String(t)
+	case scanner.Binary:
+		expr = extract.BinaryOf([]byte(t))
 	case scanner.Atom:
 		expr = extract.MakeAtom(string(t))
 	case scanner.Ident:
 		expr = extract.MakeIdent(string(t))
 	case scanner.Pin:
-		_, ident := expect[scanner.Ident](p)
-		return literal.Pin{Ident: extract.MakeIdent(string(ident))}
+		switch pinned := p.expr().(type) {
+		case extract.Ident:
+			return literal.Pin{Ident: pinned}
+		default:
+			return literal.Pin{Expr: pinned}
+		}
+	case scanner.Rest:
+		return literal.Rest{Pattern: p.expr()}
+	case scanner.Oper:
+		return t
 	case scanner.Lparen:
-		p.unscan(tok)
-		expr = p.list()
+		expr = p.list(tok)
+	case scanner.Lbracket:
+		expr = p.brackets(tok)
 	default:
-		p.raiseUnexpectedToken(p.scan(), nil)
+		p.raiseUnexpectedToken(tok, nil)
 		return nil
 	}
 
 	if p.peek() == (scanner.Dot{}) {
 		expr = p.ref(expr)
 	}
+	if p.peek() == (scanner.Default{}) {
+		expr = p.def(expr)
+	}
 
 	return expr
 }
 
+func (p *parser) def(pattern any) literal.Default {
+	expect[scanner.Default](p)
+	return literal.Default{Pattern: pattern, Value: p.expr()}
+}
+
 func (p *parser) ref(in any) literal.Ref {
 	expect[scanner.Dot](p)
 	switch name := p.expr().(type) {
@@ -169,19 +349,111 @@ func (p *parser) ref(in any) literal.Ref {
 	}
 }
 
+// opers maps operators to the names of the kernel functions that
+// they are translated into.
+var opers = map[scanner.Oper]extract.Ident{
+	scanner.OperAdd: extract.MakeIdent("add"),
+	scanner.OperSub: extract.MakeIdent("sub"),
+	scanner.OperMul: extract.MakeIdent("mul"),
+	scanner.OperDiv: extract.MakeIdent("div"),
+	scanner.OperRem: extract.MakeIdent("rem"),
+	scanner.OperPow: extract.MakeIdent("pow"),
+}
+
+func isOper(expr any) bool {
+	_, ok := expr.(scanner.Oper)
+	return ok
+}
+
+// operators translates the contents of a list containing operators
+// into calls. A list that starts with an operator, such as (+ 1 2),
+// is a regular prefix call. Otherwise, the list must alternate
+// between operands and operators, such as (1 + 2 * 3), and is parsed
+// according to operator precedence.
+func (p *parser) operators(start scanner.Token, exprs []any) any {
+	if op, ok := exprs[0].(scanner.Oper); ok {
+		if slices.ContainsFunc(exprs[1:], isOper) {
+			p.raise(&OperatorError{Position: start.Position, Err: errors.New("prefix operator call contains more operators")})
+		}
+		return literal.List{List: extract.ListOf(exprs[1:]...).Push(opers[op]), Pos: start.Position}
+	}
+
+	if len(exprs)%2 == 0 {
+		p.raise(&OperatorError{Position: start.Position, Err: errors.New("operator is missing an operand")})
+	}
+	for i, expr := range exprs {
+		if isOper(expr) != (i%2 == 1) {
+			p.raise(&OperatorError{Position: start.Position, Err: errors.New("operators and operands must alternate")})
+		}
+	}
+
+	expr, _ := climb(start.Position, exprs, 0)
+	return expr
+}
+
+// climb parses exprs, which must alternate between operands and
+// operators, using precedence climbing. It returns the resulting
+// expression and the remaining unconsumed elements.
+func climb(pos scanner.Position, exprs []any, prec int) (any, []any) {
+	lhs, exprs := exprs[0], exprs[1:]
+	for len(exprs) > 0 {
+		op := exprs[0].(scanner.Oper)
+		if op.Precedence() < prec {
+			break
+		}
+
+		next := op.Precedence() + 1
+		if op.RightAssoc() {
+			next = op.Precedence()
+		}
+
+		var rhs any
+		rhs, exprs = climb(pos, exprs[1:], next)
+		lhs = literal.List{List: extract.ListOf(opers[op], lhs, rhs), Pos: pos}
+	}
+	return lhs, exprs
+}
+
+// OperatorError is returned when a list containing operators is
+// malformed. Its position is the beginning of the list.
+type OperatorError struct {
+	scanner.Position
+	Err error
+}
+
+func (err *OperatorError) Error() string {
+	return fmt.Sprintf("invalid operator expression at %v: %v", err.Position, err.Err)
+}
+
+func (err *OperatorError) Unwrap() error {
+	return err.Err
+}
+
+// DepthError is returned when expressions are nested more deeply than
+// the maximum depth set with [WithMaxDepth]. Its position is the
+// beginning of the expression that exceeded the limit.
+type DepthError struct {
+	scanner.Position
+	Max int
+}
+
+func (err *DepthError) Error() string {
+	return fmt.Sprintf("expressions nested more than %v deep at %v", err.Max, err.Position)
+}
+
 // UnexpectedTokenError is returned from an attempt to parse a script
 // if the script has a token somewhere that it shouldn't be. If there
 // was a specific token that was supposed to be there, it will be
 // indicated with the Expected field.
 type UnexpectedTokenError struct {
-	Line, Col int
-	Got       any
-	Expected  any
+	scanner.Position
+	Got      any
+	Expected any
 }
 
 func (err *UnexpectedTokenError) Error() string {
 	if err.Expected == nil {
-		return fmt.Sprintf("unexpected token %q (%[1]T) at %v:%v", err.Got, err.Line, err.Col)
+		return fmt.Sprintf("unexpected token %q (%[1]T) at %v", err.Got, err.Position)
 	}
-	return fmt.Sprintf("unexpected token %q (%[1]T) at %v:%v, expected %q (%[4]T)", err.Got, err.Line, err.Col, err.Expected)
+	return fmt.Sprintf("unexpected token %q (%[1]T) at %v, expected %q (%[3]T)", err.Got, err.Position, err.Expected)
 }
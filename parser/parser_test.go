@@ -1,13 +1,18 @@
 package parser_test
 
 import (
+	"errors"
+	"io"
 	"iter"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"deedles.dev/extract"
 	"deedles.dev/extract/literal"
 	"deedles.dev/extract/parser"
+	"deedles.dev/extract/scanner"
 )
 
 func checkList(t *testing.T, got literal.List, ex literal.List) {
@@ -43,6 +48,28 @@ func TestParse(t *testing.T) {
 				"This is a test.",
 			)},
 		)}},
+		{"Operators", `(1 + 2 * 3 ^ 2 ^ 1 - 4)`, literal.List{List: extract.ListOf(
+			literal.List{List: extract.ListOf(
+				extract.MakeIdent("sub"),
+				literal.List{List: extract.ListOf(
+					extract.MakeIdent("add"),
+					int64(1),
+					literal.List{List: extract.ListOf(
+						extract.MakeIdent("mul"),
+						int64(2),
+						literal.List{List: extract.ListOf(
+							extract.MakeIdent("pow"),
+							int64(3),
+							literal.List{List: extract.ListOf(extract.MakeIdent("pow"), int64(2), int64(1))},
+						)},
+					)},
+				)},
+				int64(4),
+			)},
+		)}},
+		{"PrefixOperator", `(* 2 -3)`, literal.List{List: extract.ListOf(
+			literal.List{List: extract.ListOf(extract.MakeIdent("mul"), int64(2), int64(-3))},
+		)}},
 	}
 
 	for _, test := range tests {
@@ -57,3 +84,117 @@ func TestParse(t *testing.T) {
 		})
 	}
 }
+
+func TestParseFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.ext")
+	err := os.WriteFile(path, []byte("(add 1 2)\n(add 3"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = parser.ParseFile(path)
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("%#v", err)
+	}
+
+	list, err := parser.ParseString("test.ext", `(add 1 2)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pos := list.Head().(literal.List).Pos; pos.Filename != "test.ext" || pos.Line != 1 || pos.Col != 1 {
+		t.Fatal(pos)
+	}
+}
+
+func TestParseExpr(t *testing.T) {
+	expr, err := parser.ParseExpr(`(add 1 2) # comment`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkList(t, expr.(literal.List), literal.List{List: extract.ListOf(extract.MakeIdent("add"), int64(1), int64(2))})
+
+	_, err = parser.ParseExpr(`(add 1 2) 3`)
+	var tokerr *parser.UnexpectedTokenError
+	if !errors.As(err, &tokerr) || tokerr.Got != scanner.Int(3) {
+		t.Fatalf("%#v", err)
+	}
+
+	_, err = parser.ParseExpr(``)
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("%#v", err)
+	}
+}
+
+func TestAll(t *testing.T) {
+	var exprs []any
+	for expr, err := range parser.All(strings.NewReader(`1 (add 2 3) "four" )`)) {
+		if err != nil {
+			var tokerr *parser.UnexpectedTokenError
+			if !errors.As(err, &tokerr) || tokerr.Got != (scanner.Rparen{}) {
+				t.Fatalf("%#v", err)
+			}
+			break
+		}
+		exprs = append(exprs, expr)
+	}
+
+	if len(exprs) != 3 || exprs[0] != int64(1) || exprs[2] != "four" {
+		t.Fatalf("%#v", exprs)
+	}
+}
+
+func TestIncomplete(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		incomplete bool
+		err        bool
+	}{
+		{"List", "(add 1 (sub 3", true, true},
+		{"String", `(add "test`, true, true},
+		{"Heredoc", "\"\"\"\ntest\n\"", true, true},
+		{"Unexpected", "(add 1))", false, true},
+		{"Complete", "(add 1 2)", false, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := parser.ParseString("", test.input)
+			if errors.Is(err, parser.ErrIncomplete) != test.incomplete || (err != nil) != test.err {
+				t.Fatalf("%#v", err)
+			}
+		})
+	}
+}
+
+func TestParseTrivia(t *testing.T) {
+	s := scanner.New(strings.NewReader("# comment\n(add 1 # one\n 2)\n"), scanner.WithTrivia())
+	list, err := parser.ParseScanner(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkList(t, literal.List{List: list}, literal.List{List: extract.ListOf(
+		literal.List{List: extract.ListOf(extract.MakeIdent("add"), int64(1), int64(2))},
+	)})
+}
+
+func TestMaxDepth(t *testing.T) {
+	deep := strings.Repeat("(", 100000) + strings.Repeat(")", 100000)
+	_, err := parser.ParseString("", deep)
+	var deperr *parser.DepthError
+	if !errors.As(err, &deperr) || deperr.Max != parser.DefaultMaxDepth {
+		t.Fatalf("%#v", err)
+	}
+
+	_, err = parser.ParseString("", "(a (b [c \\(d)]))", parser.WithMaxDepth(4))
+	if !errors.As(err, &deperr) || deperr.Line != 1 || deperr.Col != 11 {
+		t.Fatalf("%#v", err)
+	}
+
+	_, err = parser.ParseString("", "(a (b [c \\(d)]))", parser.WithMaxDepth(6))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
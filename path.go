@@ -0,0 +1,123 @@
+package extract
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+func stdPath() *Module {
+	m := Module{name: MakeAtom("Path")}
+	m.decls = map[Ident]any{
+		MakeIdent("join"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() == 0 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: -1}
+			}
+
+			vals, err := evalArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			if list, ok := vals[0].(*List); ok && len(vals) == 1 {
+				vals = slices.Collect(list.All())
+			}
+
+			parts := make([]string, 0, len(vals))
+			for _, v := range vals {
+				part, ok := v.(string)
+				if !ok {
+					return env, NewTypeError(v, reflect.TypeFor[string]())
+				}
+				parts = append(parts, part)
+			}
+			return env, filepath.Join(parts...)
+		}),
+		MakeIdent("split"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			strs, err := evalStrings(env, args, 1, 1)
+			if err != nil {
+				return env, err
+			}
+			return env, CollectList(slices.Values(splitPath(strs[0])))
+		}),
+		MakeIdent("dirname"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			strs, err := evalStrings(env, args, 1, 1)
+			if err != nil {
+				return env, err
+			}
+			return env, filepath.Dir(strs[0])
+		}),
+		MakeIdent("basename"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			strs, err := evalStrings(env, args, 1, 2)
+			if err != nil {
+				return env, err
+			}
+
+			base := filepath.Base(strs[0])
+			if len(strs) == 2 {
+				base = strings.TrimSuffix(base, strs[1])
+			}
+			return env, base
+		}),
+		MakeIdent("extname"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			strs, err := evalStrings(env, args, 1, 1)
+			if err != nil {
+				return env, err
+			}
+			return env, filepath.Ext(strs[0])
+		}),
+		MakeIdent("expand"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if err := env.checkFS(); err != nil {
+				return env, err
+			}
+			strs, err := evalStrings(env, args, 1, 1)
+			if err != nil {
+				return env, err
+			}
+
+			path := strs[0]
+			if path == "~" || strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+				home, err := os.UserHomeDir()
+				if err != nil {
+					return env, err
+				}
+				path = home + path[1:]
+			}
+
+			abs, err := filepath.Abs(path)
+			if err != nil {
+				return env, err
+			}
+			return env, abs
+		}),
+		MakeIdent("absolute?"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			strs, err := evalStrings(env, args, 1, 1)
+			if err != nil {
+				return env, err
+			}
+			return env, filepath.IsAbs(strs[0])
+		}),
+	}
+
+	return &m
+}
+
+// splitPath splits path into its components. If the path is absolute,
+// the first component is the root, such as "/".
+func splitPath(path string) []string {
+	vol := filepath.VolumeName(path)
+	rest := path[len(vol):]
+
+	var parts []string
+	if len(rest) > 0 && os.IsPathSeparator(rest[0]) {
+		parts = append(parts, vol+string(filepath.Separator))
+	} else if vol != "" {
+		parts = append(parts, vol)
+	}
+
+	for _, part := range strings.FieldsFunc(rest, func(r rune) bool { return r < 0x80 && os.IsPathSeparator(uint8(r)) }) {
+		parts = append(parts, part)
+	}
+	return parts
+}
@@ -0,0 +1,56 @@
+package extract_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"deedles.dev/extract"
+)
+
+func TestPath(t *testing.T) {
+	const src = `
+	(list
+		(Path.join "a" "b" "../c.txt")
+		(Path.join ["/" "usr" "bin"])
+		(Path.split "/usr/local//bin/")
+		(Path.split "rel/path")
+		(Path.dirname "/usr/bin/env")
+		(Path.basename "/tmp/file.tar.gz")
+		(Path.basename "/tmp/file.tar.gz" ".gz")
+		(Path.extname "/tmp/file.tar.gz")
+		(Path.absolute? "/tmp")
+		(Path.absolute? "tmp")
+		(Path.expand "~")
+	)
+	`
+	if filepath.Separator != '/' {
+		t.Skip("test assumes slash separated paths")
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip(err)
+	}
+
+	result := runScript(t, src, true)
+	checkList(t, result,
+		"a/c.txt",
+		"/usr/bin",
+		extract.ListOf("/", "usr", "local", "bin"),
+		extract.ListOf("rel", "path"),
+		"/usr/bin",
+		"file.tar.gz",
+		"file.tar",
+		".gz",
+		true,
+		false,
+		home,
+	)
+
+	_, result = runInDir(t, `(Path.expand "a")`, extract.WithoutFileSystem())
+	if err, _ := result.(error); !errors.Is(err, extract.ErrFileSystemDisabled) {
+		t.Fatalf("%#v", result)
+	}
+}
@@ -0,0 +1,222 @@
+package extract
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"time"
+
+	"deedles.dev/xsync/otp"
+)
+
+var afterIdent = MakeIdent("after")
+
+// Process is a handle to an Extract process. Each process runs on its
+// own goroutine and has a mailbox that other processes can send
+// messages to. Processes are comparable and two handles to the same
+// process are equal.
+//
+// An [Env] created by [New] belongs to a root process which has a
+// mailbox but is not backed by its own goroutine.
+type Process struct {
+	proc *otp.Proc
+	mb   *otp.Mailbox
+}
+
+func newRootProcess() Process {
+	return Process{mb: new(otp.Mailbox)}
+}
+
+// Spawn starts a new process which calls fn with args in an Env
+// derived from env. The new process gets its own context, which is
+// canceled if either the process exits or the context of env is
+// canceled.
+func Spawn(env *Env, fn any, args *List) Process {
+	started := make(chan Process, 1)
+	proc := otp.Go(func(ctx context.Context) error {
+		self := <-started
+
+		stop := context.AfterFunc(env.Context(), self.Stop)
+		defer stop()
+
+		penv := env.WithContext(ctx)
+		penv.self = self
+		_, err := callFunc(penv, fn, slices.Collect(args.All())...)
+		return err
+	})
+
+	p := Process{proc: proc, mb: proc.Mailbox()}
+	started <- p
+	return p
+}
+
+// Send delivers msg to the process's mailbox. It never blocks.
+func (p Process) Send(msg any) {
+	p.mb.Send(msg)
+}
+
+// Stop requests that the process exit by canceling its context. It
+// is a no-op for a root process.
+func (p Process) Stop() {
+	if p.proc != nil {
+		p.proc.Stop()
+	}
+}
+
+// Wait blocks until the process exits and returns the error that it
+// exited with, if any. It returns immediately for a root process.
+func (p Process) Wait() error {
+	if p.proc == nil {
+		return nil
+	}
+	return p.proc.Wait()
+}
+
+// Done returns a channel that is closed when the process exits. It
+// returns nil, and thus blocks forever, for a root process.
+func (p Process) Done() <-chan struct{} {
+	if p.proc == nil {
+		return nil
+	}
+	return p.proc.Done()
+}
+
+func (p Process) String() string {
+	return fmt.Sprintf("#Process<%p>", p.mb)
+}
+
+func kernelSpawn(env *Env, args *List) (*Env, any) {
+	if args.Len() == 0 {
+		return env, &ArgumentNumError{Num: args.Len(), Expected: -1}
+	}
+
+	_, fn := Eval(env, args.Head(), nil)
+	if err, ok := fn.(error); ok {
+		return env, err
+	}
+
+	fargs := CollectList(EvalAll(env, args.Tail().All()))
+	return env, Spawn(env, fn, fargs)
+}
+
+func kernelSelf(env *Env, args *List) (*Env, any) {
+	if args.Len() != 0 {
+		return env, &ArgumentNumError{Num: args.Len(), Expected: 0}
+	}
+	return env, env.self
+}
+
+func kernelSend(env *Env, args *List) (*Env, any) {
+	if args.Len() != 2 {
+		return env, &ArgumentNumError{Num: args.Len(), Expected: 2}
+	}
+
+	_, dst := Eval(env, args.Head(), nil)
+	p, ok := dst.(Process)
+	if !ok {
+		return env, NewTypeError(dst, reflect.TypeFor[Process]())
+	}
+
+	_, msg := Eval(env, args.Tail().Head(), nil)
+	if err, ok := msg.(error); ok {
+		return env, err
+	}
+
+	p.Send(msg)
+	return env, msg
+}
+
+type receiveClause struct {
+	pattern *Pattern
+	body    *List
+}
+
+// receiveSignal is sent to a mailbox to wake up a blocked receive
+// when it times out or its context is canceled. Each receive uses a
+// distinct pointer so that it only ever matches its own signal.
+type receiveSignal struct{ _ byte }
+
+// kernelReceive waits for a message matching one of its clauses. An
+// optional final clause of the form (after ms body...) is run if no
+// message matches within the given number of milliseconds.
+func kernelReceive(env *Env, args *List) (*Env, any) {
+	var clauses []receiveClause
+	timeout := time.Duration(-1)
+	var after *List
+	for expr := range args.All() {
+		clause, ok := expr.(Call)
+		if !ok || clause.Len() < 2 {
+			return env, fmt.Errorf("receive clause must be a pattern followed by a body, not %v", Inspect(expr))
+		}
+
+		if clause.Head() == afterIdent {
+			if after != nil {
+				return env, errors.New("receive may only have one after clause")
+			}
+			_, ms := Eval(env, clause.Tail().Head(), nil)
+			n, ok := ms.(int64)
+			if !ok {
+				return env, NewTypeError(ms, reflect.TypeFor[int64]())
+			}
+			timeout = time.Duration(n) * time.Millisecond
+			after = callArgs(clause.Tail().Tail())
+			continue
+		}
+
+		pattern, err := CompilePattern(env, clause.Head())
+		if err != nil {
+			return env, err
+		}
+		clauses = append(clauses, receiveClause{pattern: pattern, body: clause.Tail()})
+	}
+
+	var match *receiveClause
+	var menv *Env
+	try := func(msg any) bool {
+		if _, ok := msg.(*receiveSignal); ok {
+			return false
+		}
+		for i := range clauses {
+			if e, ok := clauses[i].pattern.Match(env, msg); ok {
+				match, menv = &clauses[i], e
+				return true
+			}
+		}
+		return false
+	}
+
+	mb := env.self.mb
+	if timeout == 0 {
+		if _, ok := otp.TryRecv(mb, try); !ok {
+			_, r := Run(env, after.All())
+			return env, r
+		}
+		_, r := Run(menv, match.body.All())
+		return env, r
+	}
+
+	sig := new(receiveSignal)
+	defer otp.TryRecv(mb, func(msg *receiveSignal) bool { return msg == sig })
+	if timeout > 0 {
+		t := time.AfterFunc(timeout, func() { mb.Send(sig) })
+		defer t.Stop()
+	}
+	stop := context.AfterFunc(env.Context(), func() { mb.Send(sig) })
+	defer stop()
+
+	otp.Recv(mb, func(msg any) bool {
+		return msg == sig || try(msg)
+	})
+	if match == nil {
+		if err := env.Context().Err(); err != nil {
+			return env, err
+		}
+		_, r := Run(env, after.All())
+		return env, r
+	}
+
+	_, r := Run(menv, match.body.All())
+	return env, r
+}
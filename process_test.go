@@ -0,0 +1,78 @@
+package extract_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"deedles.dev/extract"
+)
+
+func TestSpawnReceive(t *testing.T) {
+	const src = `
+	(let echo (func (echo parent) (receive
+		((:ping v) (send parent (list :pong v)))
+	)))
+
+	(let child (spawn echo (self)))
+	(send child (list :ping 3))
+	(receive
+		((:pong v) v)
+		(after 1000 :timeout)
+	)
+	`
+	result := runScript(t, src, true)
+	if result != int64(3) {
+		t.Fatalf("%#v", result)
+	}
+}
+
+func TestReceiveTimeout(t *testing.T) {
+	const src = `
+	(receive
+		(:never :received)
+		(after 10 :timeout)
+	)
+	`
+	result := runScript(t, src, true)
+	if result != extract.MakeAtom("timeout") {
+		t.Fatalf("%#v", result)
+	}
+}
+
+func TestSpawnDuringDefmodule(t *testing.T) {
+	// A process that uses a module while another is still declaring it
+	// should see either the old or the new declarations. Run with -race
+	// to check that it doesn't race with def.
+	var src strings.Builder
+	src.WriteString(`
+	(let reader (func (reader parent) (loop (0 nil)
+		((500 _) (send parent :done))
+		((n _) (recur (add n 1) (Error.code (A.h 1)))))))
+	(spawn reader (self))
+	(defmodule A
+		(doc "h")
+		(def (h) 0)
+	`)
+	for i := range 200 {
+		fmt.Fprintf(&src, "(def (f%v) %v)\n", i, i)
+	}
+	src.WriteString(`
+		(def (h x) x))
+	(receive
+		(:done (A.h 2))
+		(after 5000 :timeout))
+	`)
+	result := runScript(t, src.String(), true)
+	if result != int64(2) {
+		t.Fatalf("%#v", result)
+	}
+}
+
+func TestReceiveInvalidClause(t *testing.T) {
+	result := runScript(t, `(receive 5)`, false)
+	err, ok := result.(error)
+	if !ok || err.Error() != "receive clause must be a pattern followed by a body, not 5" {
+		t.Fatalf("%#v", result)
+	}
+}
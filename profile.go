@@ -0,0 +1,300 @@
+package extract
+
+import (
+	"bytes"
+	"cmp"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Profile records how many times each function declared in Extract
+// code is called and how long the calls take. A Profile is filled in
+// by evaluating code in an Env created with [WithProfile] and is safe
+// to use concurrently.
+type Profile struct {
+	m       sync.Mutex
+	start   time.Time
+	keys    []ProfileKey
+	ids     map[ProfileKey]int
+	entries []ProfileEntry
+	samples map[string]*profileSample
+}
+
+// ProfileKey identifies a function in a [Profile]. Module is empty
+// for functions that were not declared in a module. Calls with
+// different numbers of arguments are recorded separately.
+type ProfileKey struct {
+	Module string
+	Name   string
+	Arity  int
+}
+
+func (key ProfileKey) String() string {
+	if key.Module == "" {
+		return fmt.Sprintf("%v/%v", key.Name, key.Arity)
+	}
+	return fmt.Sprintf("%v.%v/%v", key.Module, key.Name, key.Arity)
+}
+
+// ProfileEntry is the data recorded in a [Profile] for a single
+// function. Total is the time spent in calls to the function,
+// including the functions that it called, and Self is the time spent
+// in the function itself.
+type ProfileEntry struct {
+	ProfileKey
+	Calls       int64
+	Total, Self time.Duration
+}
+
+// profileSample is the data recorded for a single call stack. stack
+// holds the IDs of the functions in it, starting with the innermost.
+type profileSample struct {
+	stack []int
+	calls int64
+	self  time.Duration
+}
+
+// profileFrame is a function call that is in progress.
+type profileFrame struct {
+	id       int
+	parent   *profileFrame
+	children time.Duration
+}
+
+// NewProfile returns a new, empty Profile.
+func NewProfile() *Profile {
+	return &Profile{
+		start:   time.Now(),
+		ids:     make(map[ProfileKey]int),
+		samples: make(map[string]*profileSample),
+	}
+}
+
+// WithProfile records calls to functions declared in Extract code
+// that are evaluated in the Env in p.
+func WithProfile(p *Profile) Option {
+	return func(env *Env) {
+		env.profile = p
+	}
+}
+
+func (p *Profile) id(key ProfileKey) int {
+	id, ok := p.ids[key]
+	if !ok {
+		id = len(p.keys)
+		p.ids[key] = id
+		p.keys = append(p.keys, key)
+		p.entries = append(p.entries, ProfileEntry{ProfileKey: key})
+	}
+	return id
+}
+
+// enter records the start of a call to f with arity arguments in
+// env. It returns a function that records the end of the call.
+func (p *Profile) enter(env *Env, f *Func, arity int) func() {
+	key := ProfileKey{Name: f.name.String(), Arity: arity}
+	if m := f.env.currentModule; m != nil {
+		key.Module = m.name.String()
+	}
+
+	p.m.Lock()
+	frame := profileFrame{id: p.id(key), parent: env.profileFrame}
+	p.m.Unlock()
+	env.profileFrame = &frame
+
+	start := time.Now()
+	return func() {
+		p.exit(&frame, time.Since(start))
+	}
+}
+
+func (p *Profile) exit(frame *profileFrame, elapsed time.Duration) {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	self := elapsed - frame.children
+	if frame.parent != nil {
+		frame.parent.children += elapsed
+	}
+
+	entry := &p.entries[frame.id]
+	entry.Calls++
+	entry.Self += self
+	if !frame.recursive() {
+		entry.Total += elapsed
+	}
+
+	var stack []int
+	var sb strings.Builder
+	for f := frame; f != nil; f = f.parent {
+		stack = append(stack, f.id)
+		fmt.Fprintf(&sb, "%v,", f.id)
+	}
+	sample, ok := p.samples[sb.String()]
+	if !ok {
+		sample = &profileSample{stack: stack}
+		p.samples[sb.String()] = sample
+	}
+	sample.calls++
+	sample.self += self
+}
+
+// recursive returns true if the function of frame is also being
+// called further up the stack, in which case its time has already
+// been counted in the total of the outer call.
+func (frame *profileFrame) recursive() bool {
+	for f := frame.parent; f != nil; f = f.parent {
+		if f.id == frame.id {
+			return true
+		}
+	}
+	return false
+}
+
+// Entries returns the data recorded for every function that was
+// called, sorted by total time, highest first.
+func (p *Profile) Entries() []ProfileEntry {
+	p.m.Lock()
+	entries := slices.Clone(p.entries)
+	p.m.Unlock()
+
+	slices.SortFunc(entries, func(e1, e2 ProfileEntry) int {
+		return cmp.Or(
+			cmp.Compare(e2.Total, e1.Total),
+			cmp.Compare(e2.Calls, e1.Calls),
+			cmp.Compare(e1.String(), e2.String()),
+		)
+	})
+	return entries
+}
+
+// WriteTable writes a summary of the profile to w as a table with
+// one row per function, in the order returned by [Profile.Entries].
+func (p *Profile) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "calls\ttotal\tself\t function\n")
+	for _, e := range p.Entries() {
+		fmt.Fprintf(tw, "%v\t%v\t%v\t %v\n", e.Calls, e.Total, e.Self, e)
+	}
+	return tw.Flush()
+}
+
+// WritePprof writes the profile to w in the gzipped protocol buffer
+// format read by the pprof tool, with the number of calls and the
+// time spent in each call stack as sample values. This allows the
+// profile to be explored with pprof and other tools that support its
+// format, such as to display a flame graph.
+func (p *Profile) WritePprof(w io.Writer) error {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	strs := map[string]int64{"": 0}
+	table := []string{""}
+	str := func(s string) int64 {
+		i, ok := strs[s]
+		if !ok {
+			i = int64(len(table))
+			strs[s] = i
+			table = append(table, s)
+		}
+		return i
+	}
+
+	var pb protoBuffer
+	valueType := func(typ, unit string) []byte {
+		var vt protoBuffer
+		vt.int(1, str(typ))
+		vt.int(2, str(unit))
+		return vt.Bytes()
+	}
+	pb.bytes(1, valueType("calls", "count"))
+	pb.bytes(1, valueType("time", "nanoseconds"))
+
+	keys := make([]string, 0, len(p.samples))
+	for k := range p.samples {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		s := p.samples[k]
+		locs := make([]int64, 0, len(s.stack))
+		for _, id := range s.stack {
+			locs = append(locs, int64(id)+1)
+		}
+
+		var sample protoBuffer
+		sample.packed(1, locs...)
+		sample.packed(2, s.calls, s.self.Nanoseconds())
+		pb.bytes(2, sample.Bytes())
+	}
+
+	for i, key := range p.keys {
+		id := int64(i) + 1
+
+		var line protoBuffer
+		line.int(1, id)
+		var loc protoBuffer
+		loc.int(1, id)
+		loc.bytes(4, line.Bytes())
+		pb.bytes(4, loc.Bytes())
+
+		var fn protoBuffer
+		fn.int(1, id)
+		fn.int(2, str(key.String()))
+		fn.int(3, str(key.String()))
+		pb.bytes(5, fn.Bytes())
+	}
+
+	for _, s := range table {
+		pb.bytes(6, []byte(s))
+	}
+	pb.int(9, p.start.UnixNano())
+	pb.int(10, time.Since(p.start).Nanoseconds())
+
+	gz := gzip.NewWriter(w)
+	if _, err := gz.Write(pb.Bytes()); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// protoBuffer encodes a protocol buffer message.
+type protoBuffer struct {
+	bytes.Buffer
+}
+
+func (pb *protoBuffer) varint(v uint64) {
+	for v >= 0x80 {
+		pb.WriteByte(byte(v) | 0x80)
+		v >>= 7
+	}
+	pb.WriteByte(byte(v))
+}
+
+func (pb *protoBuffer) int(field int, v int64) {
+	if v == 0 {
+		return
+	}
+	pb.varint(uint64(field) << 3)
+	pb.varint(uint64(v))
+}
+
+func (pb *protoBuffer) bytes(field int, b []byte) {
+	pb.varint(uint64(field)<<3 | 2)
+	pb.varint(uint64(len(b)))
+	pb.Write(b)
+}
+
+func (pb *protoBuffer) packed(field int, vs ...int64) {
+	var packed protoBuffer
+	for _, v := range vs {
+		packed.varint(uint64(v))
+	}
+	pb.bytes(field, packed.Bytes())
+}
@@ -0,0 +1,79 @@
+package extract_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"deedles.dev/extract"
+	"deedles.dev/extract/parser"
+)
+
+func TestProfile(t *testing.T) {
+	script, err := parser.ParseString("test.ext", `
+	(defmodule Fact
+		(def (fact 0) 1)
+		(def (fact n) (mul n (fact (sub n 1))))
+		(def (fact n acc) (add acc (fact n))))
+	(let double (func double ((x) (mul x 2))))
+	(double (Fact.fact 5))
+	(Fact.fact 3 1)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prof := extract.NewProfile()
+	env := extract.New(context.Background(), extract.WithProfile(prof))
+	_, r := extract.Run(env, script.All())
+	if r != int64(7) {
+		t.Fatal(r)
+	}
+
+	calls := make(map[extract.ProfileKey]int64)
+	for _, e := range prof.Entries() {
+		if e.Self < 0 {
+			t.Fatalf("%v: self %v, total %v", e, e.Self, e.Total)
+		}
+		calls[e.ProfileKey] = e.Calls
+	}
+	ex := map[extract.ProfileKey]int64{
+		{Module: "Fact", Name: "fact", Arity: 1}: 10,
+		{Module: "Fact", Name: "fact", Arity: 2}: 1,
+		{Name: "double", Arity: 1}:               1,
+	}
+	if len(calls) != len(ex) {
+		t.Fatal(calls)
+	}
+	for k, n := range ex {
+		if calls[k] != n {
+			t.Fatalf("%v: %v", k, calls[k])
+		}
+	}
+
+	var table strings.Builder
+	if err := prof.WriteTable(&table); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(table.String(), "Fact.fact/1") || !strings.HasPrefix(strings.TrimSpace(table.String()), "calls") {
+		t.Fatal(table.String())
+	}
+
+	var buf bytes.Buffer
+	if err := prof.WritePprof(&buf); err != nil {
+		t.Fatal(err)
+	}
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte("Fact.fact/1")) || !bytes.Contains(data, []byte("nanoseconds")) {
+		t.Fatalf("%q", data)
+	}
+}
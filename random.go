@@ -0,0 +1,165 @@
+package extract
+
+import (
+	"errors"
+	"math/rand/v2"
+	"reflect"
+	"slices"
+	"sync"
+)
+
+// ErrEmpty is returned when an element is requested from an empty
+// collection.
+var ErrEmpty = errors.New("collection is empty")
+
+// lockedRand is a source of random numbers that is safe for concurrent
+// use, as an Env's processes may use it simultaneously.
+type lockedRand struct {
+	m sync.Mutex
+	r *rand.Rand
+}
+
+// int64N returns a random integer in [0, n).
+func (r *lockedRand) int64N(n int64) int64 {
+	if r == nil {
+		return rand.Int64N(n)
+	}
+	r.m.Lock()
+	defer r.m.Unlock()
+	return r.r.Int64N(n)
+}
+
+// uint64 returns a random 64-bit integer.
+func (r *lockedRand) uint64() uint64 {
+	if r == nil {
+		return rand.Uint64()
+	}
+	r.m.Lock()
+	defer r.m.Unlock()
+	return r.r.Uint64()
+}
+
+// float64 returns a random float in [0, 1).
+func (r *lockedRand) float64() float64 {
+	if r == nil {
+		return rand.Float64()
+	}
+	r.m.Lock()
+	defer r.m.Unlock()
+	return r.r.Float64()
+}
+
+// shuffle randomly permutes s.
+func (r *lockedRand) shuffle(s []any) {
+	swap := func(i, j int) { s[i], s[j] = s[j], s[i] }
+	if r == nil {
+		rand.Shuffle(len(s), swap)
+		return
+	}
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.r.Shuffle(len(s), swap)
+}
+
+// between returns a random integer in the inclusive range [lo, hi].
+func (r *lockedRand) between(lo, hi int64) int64 {
+	n := uint64(hi - lo)
+	if n == 1<<64-1 {
+		return int64(r.uint64())
+	}
+	if n >= 1<<63-1 {
+		for {
+			v := r.uint64()
+			if v <= n {
+				return lo + int64(v)
+			}
+		}
+	}
+	return lo + r.int64N(int64(n)+1)
+}
+
+func stdRandom() *Module {
+	m := Module{name: MakeAtom("Random")}
+	m.decls = map[Ident]any{
+		MakeIdent("int"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 1 && args.Len() != 2 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: -1}
+			}
+
+			vals, err := evalArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			switch v := vals[0].(type) {
+			case Range:
+				if len(vals) != 1 {
+					return env, &ArgumentNumError{Num: args.Len(), Expected: 1}
+				}
+				n := v.Len()
+				if n == 0 {
+					return env, ErrEmpty
+				}
+				return env, v.First + env.rand.between(0, n-1)*v.Step
+			case int64:
+				if len(vals) != 2 {
+					return env, &ArgumentNumError{Num: args.Len(), Expected: 2}
+				}
+				hi, ok := vals[1].(int64)
+				if !ok {
+					return env, NewTypeError(vals[1], reflect.TypeFor[int64]())
+				}
+				if v > hi {
+					return env, ErrEmpty
+				}
+				return env, env.rand.between(v, hi)
+			default:
+				return env, NewTypeError(v, reflect.TypeFor[Range](), reflect.TypeFor[int64]())
+			}
+		}),
+		MakeIdent("float"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 0 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 0}
+			}
+			return env, env.rand.float64()
+		}),
+		MakeIdent("bytes"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			ints, err := evalInts(env, args, 1, 1)
+			if err != nil {
+				return env, err
+			}
+
+			buf := make([]byte, max(ints[0], 0))
+			for i := range buf {
+				buf[i] = byte(env.rand.uint64())
+			}
+			return env, &Binary{data: buf}
+		}),
+		MakeIdent("shuffle"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			list, err := evalList(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			s := slices.Collect(list.All())
+			env.rand.shuffle(s)
+			return env, ListOf(s...)
+		}),
+		MakeIdent("choice"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			list, err := evalList(env, args)
+			if err != nil {
+				return env, err
+			}
+			if list.Len() == 0 {
+				return env, ErrEmpty
+			}
+
+			for range env.rand.int64N(int64(list.Len())) {
+				list = list.Tail()
+			}
+			return env, list.Head()
+		}),
+	}
+
+	return &m
+}
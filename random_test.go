@@ -0,0 +1,71 @@
+package extract_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"deedles.dev/extract"
+	"deedles.dev/extract/parser"
+)
+
+func TestRandom(t *testing.T) {
+	const src = `
+	(list
+		(Random.int 1 6)
+		(Random.int (Range.new 10 20 5))
+		(Random.float)
+		(Binary.size (Random.bytes 4))
+		(Random.shuffle [1 2 3 4 5])
+		(Random.choice [:a :b :c])
+	)
+	`
+	s, err := parser.ParseString(t.Name(), src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(seed uint64) []any {
+		_, result := extract.Run(extract.New(context.Background(), extract.WithRandSeed(seed)), s.All())
+		if err, ok := result.(error); ok {
+			t.Fatal(err)
+		}
+		return slices.Collect(result.(*extract.List).All())
+	}
+
+	r := run(1)
+	if n := r[0].(int64); n < 1 || n > 6 {
+		t.Errorf("int: %v", n)
+	}
+	if n := r[1].(int64); n != 10 && n != 15 && n != 20 {
+		t.Errorf("int with range: %v", n)
+	}
+	if f := r[2].(float64); f < 0 || f >= 1 {
+		t.Errorf("float: %v", f)
+	}
+	if r[3] != int64(4) {
+		t.Errorf("bytes: %v", r[3])
+	}
+	shuffled := slices.Collect(r[4].(*extract.List).All())
+	slices.SortFunc(shuffled, extract.Compare)
+	if !slices.Equal(shuffled, []any{int64(1), int64(2), int64(3), int64(4), int64(5)}) {
+		t.Errorf("shuffle: %v", r[4])
+	}
+
+	again := run(1)
+	for i := range r {
+		if !extract.Equal(r[i], again[i]) {
+			t.Errorf("%v: not deterministic: %v != %v", i, r[i], again[i])
+		}
+	}
+}
+
+func TestRandomEmpty(t *testing.T) {
+	for _, src := range []string{`(Random.choice [])`, `(Random.int 2 1)`} {
+		result := runScript(t, src, false)
+		if err, _ := result.(error); !errors.Is(err, extract.ErrEmpty) {
+			t.Errorf("%v: %#v", src, result)
+		}
+	}
+}
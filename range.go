@@ -0,0 +1,178 @@
+package extract
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"hash/maphash"
+	"iter"
+	"math"
+	"reflect"
+)
+
+// ErrZeroStep is returned when a range is created with a step of 0.
+var ErrZeroStep = errors.New("range step must not be 0")
+
+// Range is an inclusive range of integers from First to Last, counting
+// by Step. If Step is negative, the range counts down. A range whose
+// Last can't be reached from First by counting by Step, such as a
+// range from 3 to 1 with a step of 1, is empty.
+type Range struct {
+	First, Last, Step int64
+}
+
+// Len returns the number of integers in the range. If the range
+// contains more integers than can be counted by an int64, which is
+// only possible for a range spanning almost all of them with a step of
+// 1 or -1, the result is capped at [math.MaxInt64].
+func (r Range) Len() int64 {
+	var span, step uint64
+	switch {
+	case r.Step > 0 && r.First <= r.Last:
+		span, step = uint64(r.Last-r.First), uint64(r.Step)
+	case r.Step < 0 && r.First >= r.Last:
+		span, step = uint64(r.First-r.Last), -uint64(r.Step)
+	default:
+		return 0
+	}
+	return int64(min(span/step, math.MaxInt64-1) + 1)
+}
+
+// Contains returns true if n is one of the integers in the range.
+func (r Range) Contains(n int64) bool {
+	if r.Len() == 0 {
+		return false
+	}
+	lo, hi := min(r.First, r.Last), max(r.First, r.Last)
+	return n >= lo && n <= hi && (n-r.First)%r.Step == 0
+}
+
+// All returns an iterator over the integers in the range.
+func (r Range) All() iter.Seq[int64] {
+	return func(yield func(int64) bool) {
+		n := r.First
+		for range r.Len() {
+			if !yield(n) {
+				return
+			}
+			n += r.Step
+		}
+	}
+}
+
+// Enumerate yields the integers in the range.
+func (r Range) Enumerate() iter.Seq2[any, error] {
+	return func(yield func(any, error) bool) {
+		for n := range r.All() {
+			if !yield(n, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Hash returns a hash of the range.
+func (r Range) Hash() uint64 {
+	var h maphash.Hash
+	h.SetSeed(hashSeed)
+	h.WriteString("range")
+	writeUint(&h, uint64(r.First))
+	writeUint(&h, uint64(r.Last))
+	writeUint(&h, uint64(r.Step))
+	return h.Sum64()
+}
+
+// Compare orders ranges by their first integer, then by their last,
+// and then by their step.
+func (r Range) Compare(other any) int {
+	o := other.(Range)
+	return cmp.Or(
+		cmp.Compare(r.First, o.First),
+		cmp.Compare(r.Last, o.Last),
+		cmp.Compare(r.Step, o.Step),
+	)
+}
+
+// Inspect returns a representation of the range in the form
+// #Range<first..last> or, if the step is not 1, #Range<first..last//step>.
+func (r Range) Inspect() string {
+	if r.Step == 1 {
+		return fmt.Sprintf("#Range<%v..%v>", r.First, r.Last)
+	}
+	return fmt.Sprintf("#Range<%v..%v//%v>", r.First, r.Last, r.Step)
+}
+
+func (r Range) String() string {
+	return r.Inspect()
+}
+
+func stdRange() *Module {
+	m := Module{name: MakeAtom("Range")}
+	m.decls = map[Ident]any{
+		MakeIdent("new"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			ints, err := evalInts(env, args, 2, 3)
+			if err != nil {
+				return env, err
+			}
+
+			r := Range{First: ints[0], Last: ints[1], Step: 1}
+			if len(ints) == 3 {
+				r.Step = ints[2]
+			}
+			if r.Step == 0 {
+				return env, ErrZeroStep
+			}
+			return env, r
+		}),
+		MakeIdent("to_list"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 1 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 1}
+			}
+
+			r, _, err := evalRangeArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			return env, CollectList(r.All())
+		}),
+		MakeIdent("member?"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 2 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 2}
+			}
+
+			r, vals, err := evalRangeArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			n, ok := vals[0].(int64)
+			return env, ok && r.Contains(n)
+		}),
+		MakeIdent("size"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 1 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 1}
+			}
+
+			r, _, err := evalRangeArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			return env, r.Len()
+		}),
+	}
+
+	return &m
+}
+
+// evalRangeArgs evaluates args, the first of which must be a Range.
+// It returns the range and the rest of the evaluated arguments.
+func evalRangeArgs(env *Env, args *List) (Range, []any, error) {
+	vals, err := evalArgs(env, args)
+	if err != nil {
+		return Range{}, nil, err
+	}
+	r, ok := vals[0].(Range)
+	if !ok {
+		return Range{}, nil, NewTypeError(vals[0], reflect.TypeFor[Range]())
+	}
+	return r, vals[1:], nil
+}
@@ -0,0 +1,50 @@
+package extract_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"deedles.dev/extract"
+)
+
+func TestRange(t *testing.T) {
+	const src = `
+	(let r (Range.new 1 10 3))
+	(list
+		(Range.to_list r)
+		(Range.to_list (Range.new 3 1 -1))
+		(Range.to_list (Range.new 3 1))
+		(Range.size r)
+		(Range.member? r 7)
+		(Range.member? r 8)
+		(Range.member? r :a)
+		(Enum.sum (Range.new 1 100))
+		(Enum.to_list (Stream.take (Range.new 1 1000000000) 3))
+		(inspect r)
+	)
+	`
+	result := runScript(t, src, true)
+	checkList(t, result,
+		extract.ListOf(int64(1), int64(4), int64(7), int64(10)),
+		extract.ListOf(int64(3), int64(2), int64(1)),
+		extract.ListOf(),
+		int64(4),
+		true,
+		false,
+		false,
+		int64(5050),
+		extract.ListOf(int64(1), int64(2), int64(3)),
+		"#Range<1..10//3>",
+	)
+
+	result = runScript(t, `(Range.new 1 2 0)`, false)
+	if err, _ := result.(error); !errors.Is(err, extract.ErrZeroStep) {
+		t.Fatalf("%#v", result)
+	}
+
+	r := extract.Range{First: math.MinInt64, Last: math.MaxInt64, Step: 1}
+	if r.Len() != math.MaxInt64 || !r.Contains(0) {
+		t.Fatal(r.Len())
+	}
+}
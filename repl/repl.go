@@ -0,0 +1,375 @@
+// Package repl implements an interactive read-eval-print loop for
+// Extract.
+package repl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"deedles.dev/extract"
+	"deedles.dev/extract/debugger"
+	"deedles.dev/extract/errfmt"
+	"deedles.dev/extract/parser"
+)
+
+const (
+	// Prompt is shown when the REPL is waiting for a new entry.
+	Prompt = "> "
+
+	// ContinuePrompt is shown when the REPL is waiting for more input
+	// to complete an entry that spans multiple lines.
+	ContinuePrompt = "... "
+)
+
+// entryName returns the filename in the positions of the nth entry of
+// code entered into a REPL, starting at 1. Each entry has its own
+// filename so that errors can be shown with the entry they refer to.
+func entryName(n int) string {
+	return fmt.Sprintf("<repl:%v>", n)
+}
+
+// LineReader is a source of lines of input for a REPL.
+type LineReader interface {
+	// ReadLine shows prompt, if appropriate, and then reads and
+	// returns a single line of input without the trailing line break.
+	// It returns io.EOF when there is no more input.
+	ReadLine(prompt string) (string, error)
+}
+
+type readerLines struct {
+	r      *bufio.Reader
+	prompt io.Writer
+}
+
+// NewReader returns a LineReader that reads lines from r, writing
+// prompts to w. If w is nil, prompts are not shown.
+func NewReader(r io.Reader, w io.Writer) LineReader {
+	return &readerLines{r: bufio.NewReader(r), prompt: w}
+}
+
+func (lr *readerLines) ReadLine(prompt string) (string, error) {
+	if lr.prompt != nil {
+		io.WriteString(lr.prompt, prompt)
+	}
+
+	line, err := lr.r.ReadString('\n')
+	if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+		return "", err
+	}
+	return strings.TrimSuffix(line, "\n"), nil
+}
+
+// REPL is a read-eval-print loop. Each entry is evaluated in the Env
+// that resulted from the previous one, so bindings persist between
+// entries. Results are written to the Env's stdout using
+// [extract.Inspect] and errors to its stderr.
+type REPL struct {
+	env      *extract.Env
+	lines    LineReader
+	baseline map[extract.Ident]any
+	debugger *debugger.Debugger
+	color    bool
+
+	// entries is the source code of each entry that has been
+	// evaluated, by filename.
+	entries map[string]string
+}
+
+// New returns a REPL that evaluates input read from lines in env.
+func New(env *extract.Env, lines LineReader) *REPL {
+	r := REPL{
+		env:      env,
+		lines:    lines,
+		baseline: make(map[extract.Ident]any),
+		entries:  make(map[string]string),
+	}
+	for ident, val := range env.All() {
+		r.baseline[ident] = val
+	}
+	return &r
+}
+
+// SetDebugger sets the debugger that breakpoints set with the :break
+// command are added to. The debugger's hooks should be installed in
+// the REPL's Env.
+func (r *REPL) SetDebugger(d *debugger.Debugger) {
+	r.debugger = d
+}
+
+// SetColor sets whether errors are highlighted with ANSI escape
+// sequences. See [errfmt.WithColor].
+func (r *REPL) SetColor(color bool) {
+	r.color = color
+}
+
+// Env returns the Env that the next entry will be evaluated in.
+func (r *REPL) Env() *extract.Env {
+	return r.env
+}
+
+// Run reads and evaluates entries until the input ends or the :quit
+// command is entered. It returns nil in either of those cases, or the
+// error that caused reading input to fail otherwise.
+func (r *REPL) Run() error {
+	for {
+		src, err := r.read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		if !r.Eval(src) {
+			return nil
+		}
+	}
+}
+
+// read reads a single entry, which may span multiple lines if the
+// first line is incomplete.
+func (r *REPL) read() (string, error) {
+	var sb strings.Builder
+	prompt := Prompt
+	for {
+		line, err := r.lines.ReadLine(prompt)
+		if err != nil {
+			if sb.Len() > 0 && errors.Is(err, io.EOF) {
+				return sb.String(), nil
+			}
+			return "", err
+		}
+		sb.WriteString(line)
+
+		src := sb.String()
+		if r.command(src) != nil {
+			return src, nil
+		}
+		if _, err := parser.ParseString(entryName(len(r.entries)+1), src); !errors.Is(err, parser.ErrIncomplete) {
+			return src, nil
+		}
+
+		sb.WriteByte('\n')
+		prompt = ContinuePrompt
+	}
+}
+
+// command returns the handler of the special command in src, if src
+// is one.
+func (r *REPL) command(src string) func(*REPL, string) bool {
+	name, _, _ := strings.Cut(strings.TrimSpace(src), " ")
+	return commands[name]
+}
+
+// Eval evaluates a single entry, which may be either Extract code or a
+// special command, and prints the result. It returns false if the REPL
+// should exit.
+func (r *REPL) Eval(src string) bool {
+	if cmd := r.command(src); cmd != nil {
+		_, arg, _ := strings.Cut(strings.TrimSpace(src), " ")
+		return cmd(r, strings.TrimSpace(arg))
+	}
+
+	name := entryName(len(r.entries) + 1)
+	r.entries[name] = src
+	list, err := parser.ParseString(name, src)
+	if err != nil {
+		r.printError(err)
+		return true
+	}
+	if list.Len() == 0 {
+		return true
+	}
+
+	env, result := extract.Run(r.env, list.All())
+	r.env = env
+	if err, ok := result.(error); ok {
+		r.printError(err)
+		return true
+	}
+	fmt.Fprintln(r.env.Stdout(), extract.Inspect(result))
+	return true
+}
+
+// printError prints err along with the part of the entry that it
+// refers to, if any, which may be a previous entry, such as one that
+// defined the function that the error occurred in.
+func (r *REPL) printError(err error) {
+	pos, _ := errfmt.Position(err)
+	io.WriteString(r.env.Stderr(), "error: ")
+	errfmt.Fprint(r.env.Stderr(), err, pos.Filename, []byte(r.entries[pos.Filename]), errfmt.WithColor(r.color))
+}
+
+// commands are the special commands that the REPL understands. Each
+// returns false if the REPL should exit.
+var commands map[string]func(r *REPL, arg string) bool
+
+func init() {
+	commands = map[string]func(r *REPL, arg string) bool{
+		":quit":  func(r *REPL, arg string) bool { return false },
+		":env":   (*REPL).printEnv,
+		":doc":   (*REPL).printDoc,
+		":break": (*REPL).setBreak,
+		":help":  (*REPL).printHelp,
+	}
+}
+
+// printEnv prints the bindings that have been made since the REPL
+// started.
+func (r *REPL) printEnv(arg string) bool {
+	type binding struct {
+		name string
+		val  any
+	}
+	var bindings []binding
+	for ident, val := range r.env.All() {
+		if base, ok := r.baseline[ident]; ok && unchanged(base, val) {
+			continue
+		}
+		bindings = append(bindings, binding{name: ident.String(), val: val})
+	}
+	slices.SortFunc(bindings, func(b1, b2 binding) int { return strings.Compare(b1.name, b2.name) })
+
+	out := r.env.Stdout()
+	for _, b := range bindings {
+		fmt.Fprintf(out, "%v = %v\n", b.name, extract.Inspect(b.val))
+	}
+	return true
+}
+
+// unchanged returns true if val is the same as base. Built-in
+// functions are never [extract.Equal], even to themselves, so they are
+// compared by their representations instead, which identify them.
+func unchanged(base, val any) bool {
+	return extract.Equal(base, val) || extract.Inspect(base) == extract.Inspect(val)
+}
+
+func (r *REPL) printHelp(arg string) bool {
+	fmt.Fprint(r.env.Stdout(), `Enter Extract expressions to evaluate them. Incomplete expressions
+continue onto the next line.
+
+Commands:
+	:break  set a breakpoint at a line or a function, such as :break 3
+	        or :break Example.run, or list the breakpoints
+	:doc    show the documentation of a module or a function, such as
+	        :doc String or :doc String.to_upper
+	:env    show the bindings made in this session
+	:help   show this help
+	:quit   exit the REPL
+`)
+	return true
+}
+
+// printDoc prints the documentation of the module or module function
+// named by arg, such as String or String.to_upper.
+func (r *REPL) printDoc(arg string) bool {
+	if arg == "" {
+		fmt.Fprintln(r.env.Stderr(), "error: usage: :doc Module or :doc Module.func")
+		return true
+	}
+
+	name, fn, isFunc := strings.Cut(arg, ".")
+	m := r.env.GetModule(extract.MakeAtom(name))
+	if m == nil {
+		fmt.Fprintf(r.env.Stderr(), "error: %v\n", &extract.UndefinedModuleError{Name: extract.MakeAtom(name)})
+		return true
+	}
+
+	doc := m.Doc()
+	if isFunc {
+		ident := extract.MakeIdent(fn)
+		if _, ok := m.Lookup(ident); !ok {
+			fmt.Fprintf(r.env.Stderr(), "error: %v\n", &extract.NameError{Ident: ident})
+			return true
+		}
+		doc, _ = m.FuncDoc(ident)
+	}
+	if doc == "" {
+		fmt.Fprintf(r.env.Stdout(), "no documentation for %v\n", arg)
+		return true
+	}
+	fmt.Fprintln(r.env.Stdout(), strings.TrimSpace(doc))
+	return true
+}
+
+// setBreak adds a breakpoint at the location in arg to the REPL's
+// debugger, or lists the breakpoints if arg is empty.
+func (r *REPL) setBreak(arg string) bool {
+	if r.debugger == nil {
+		fmt.Fprintln(r.env.Stderr(), "error: debugging is not enabled")
+		return true
+	}
+
+	if arg == "" {
+		for _, bp := range r.debugger.Breakpoints() {
+			fmt.Fprintf(r.env.Stdout(), "%v\t%v\n", bp.ID, bp.Loc)
+		}
+		return true
+	}
+
+	bp, err := r.debugger.Break(arg)
+	if err != nil {
+		fmt.Fprintf(r.env.Stderr(), "error: %v\n", err)
+		return true
+	}
+	fmt.Fprintf(r.env.Stdout(), "breakpoint %v at %v\n", bp.ID, bp.Loc)
+	return true
+}
+
+// Complete finds completions for the word that ends at byte offset pos
+// in line. It returns the offset at which the word starts and the
+// possible replacements for it in sorted order. Words are completed
+// from the identifiers bound in the REPL's Env, the names of its
+// modules, and, for words of the form Module.prefix, the functions
+// declared in the named module.
+func (r *REPL) Complete(line string, pos int) (start int, candidates []string) {
+	start = pos
+	for start > 0 && isWordByte(line[start-1]) {
+		start--
+	}
+	word := line[start:pos]
+	if word == "" {
+		return start, nil
+	}
+
+	if name, prefix, ok := strings.Cut(word, "."); ok {
+		m := r.env.GetModule(extract.MakeAtom(name))
+		if m == nil {
+			return start, nil
+		}
+		for ident := range m.All() {
+			if strings.HasPrefix(ident.String(), prefix) {
+				candidates = append(candidates, name+"."+ident.String())
+			}
+		}
+		return start, candidates
+	}
+
+	for ident := range r.env.All() {
+		if strings.HasPrefix(ident.String(), word) {
+			candidates = append(candidates, ident.String())
+		}
+	}
+	for m := range r.env.Modules() {
+		if strings.HasPrefix(m.Name().String(), word) {
+			candidates = append(candidates, m.Name().String())
+		}
+	}
+	slices.Sort(candidates)
+	return start, slices.Compact(candidates)
+}
+
+// isWordByte returns true if c can be part of a word that Complete
+// completes.
+func isWordByte(c byte) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		return true
+	default:
+		return strings.IndexByte("_?!.", c) >= 0
+	}
+}
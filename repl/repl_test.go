@@ -0,0 +1,170 @@
+package repl_test
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"deedles.dev/extract"
+	"deedles.dev/extract/debugger"
+	"deedles.dev/extract/repl"
+)
+
+func runREPL(t *testing.T, input string) (stdout, stderr string) {
+	var out, errOut strings.Builder
+	env := extract.New(context.Background(), extract.WithStdout(&out), extract.WithStderr(&errOut))
+	r := repl.New(env, repl.NewReader(strings.NewReader(input), nil))
+	if err := r.Run(); err != nil {
+		t.Fatal(err)
+	}
+	return out.String(), errOut.String()
+}
+
+func TestREPL(t *testing.T) {
+	const input = `(let x 2)
+(add x
+  3)
+(defmodule Test
+  (def (double v) (mul v 2)))
+(Test.double x)
+:env
+(add 1 :a)
+x
+:quit
+"not evaluated"
+`
+	stdout, stderr := runREPL(t, input)
+
+	const ex = "2\n5\nTest\n4\nx = 2\n2\n"
+	if stdout != ex {
+		t.Fatalf("%q", stdout)
+	}
+	if !strings.HasPrefix(stderr, "error: incorrect type") {
+		t.Fatalf("%q", stderr)
+	}
+}
+
+func TestREPLIncomplete(t *testing.T) {
+	stdout, stderr := runREPL(t, "(add 1 2)\n(add 1")
+	if stdout != "3\n" || !strings.Contains(stderr, "incomplete input") {
+		t.Fatalf("%q %q", stdout, stderr)
+	}
+}
+
+func TestREPLErrorSource(t *testing.T) {
+	// Both runtime errors occur in f, so they are shown with the
+	// first entry rather than the one that was evaluated.
+	const input = `(defmodule Test (def (f x) (add x :a)) (def (g x) (f x)))
+(Test.f 1)
+(list 1 2))
+(add 0                                            (add 0 (Test.g 1)))
+`
+	_, stderr := runREPL(t, input)
+
+	const ex = `error: incorrect type extract.Atom, expected one of [int64 float64]
+	at f (<repl:2>:1:1)
+   1 | (defmodule Test (def (f x) (add x :a)) (def (g x) (f x)))
+     |                            ^^^^^^^^^^
+error: unexpected token ")" (scanner.Rparen) at <repl:3>:1:11
+   1 | (list 1 2))
+     |           ^
+error: incorrect type extract.Atom, expected one of [int64 float64]
+	at f (<repl:1>:1:51)
+	at g (<repl:4>:1:58)
+   1 | (defmodule Test (def (f x) (add x :a)) (def (g x) (f x)))
+     |                            ^^^^^^^^^^
+`
+	if stderr != ex {
+		t.Fatalf("%v", stderr)
+	}
+}
+
+func TestREPLPrompts(t *testing.T) {
+	var prompts strings.Builder
+	env := extract.New(context.Background(), extract.WithStdout(&prompts))
+	r := repl.New(env, repl.NewReader(strings.NewReader("(list\n1)\n"), &prompts))
+	if err := r.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if ex := repl.Prompt + repl.ContinuePrompt + "[1]\n" + repl.Prompt; prompts.String() != ex {
+		t.Fatalf("%q", prompts.String())
+	}
+}
+
+func TestREPLDoc(t *testing.T) {
+	const input = `(defmodule Test
+  (moduledoc "Things for testing.")
+  (doc "Doubles v.")
+  (def (double v) (mul v 2))
+  (def (triple v) (mul v 3)))
+:doc Test
+:doc Test.double
+:doc Test.triple
+:doc Test.missing
+`
+	stdout, stderr := runREPL(t, input)
+
+	const ex = "Test\nThings for testing.\nDoubles v.\nno documentation for Test.triple\n"
+	if stdout != ex {
+		t.Fatalf("%q", stdout)
+	}
+	if !strings.HasPrefix(stderr, "error: ") {
+		t.Fatalf("%q", stderr)
+	}
+}
+
+func TestREPLComplete(t *testing.T) {
+	env := extract.New(context.Background())
+	r := repl.New(env, repl.NewReader(strings.NewReader("(let value 3)\n"), nil))
+	if err := r.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		line       string
+		start      int
+		candidates []string
+	}{
+		{"(add val", 5, []string{"value"}},
+		{"(Stri", 1, []string{"String"}},
+		{"(String.to_u", 1, []string{"String.to_upper"}},
+		{"(Missing.a", 1, nil},
+		{"(add ", 5, nil},
+	}
+	for _, test := range tests {
+		start, candidates := r.Complete(test.line, len(test.line))
+		if start != test.start || !slices.Equal(candidates, test.candidates) {
+			t.Errorf("%q: %v %q", test.line, start, candidates)
+		}
+	}
+}
+
+func TestREPLBreak(t *testing.T) {
+	const input = `(defmodule Test (def (double v) (mul v 2)))
+:break Test.double
+(Test.double 4)
+locals
+c
+:break
+`
+	var out strings.Builder
+	lines := repl.NewReader(strings.NewReader(input), nil)
+	d := debugger.New(lines, &out)
+	env := extract.New(context.Background(), extract.WithStdout(&out), extract.WithHooks(d.Hooks()))
+	r := repl.New(env, lines)
+	r.SetDebugger(d)
+	if err := r.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	const ex = "Test\nbreakpoint 1 at Test.double\nbreakpoint 1 at <repl:2>:1:1: (double 4)\nv = 4\n8\n1\tTest.double\n"
+	if out.String() != ex {
+		t.Fatalf("%q", out.String())
+	}
+
+	_, stderr := runREPL(t, ":break 1\n")
+	if stderr != "error: debugging is not enabled\n" {
+		t.Fatalf("%q", stderr)
+	}
+}
@@ -0,0 +1,141 @@
+package repl
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// maxHistory is the number of lines that a Terminal remembers.
+const maxHistory = 1000
+
+// Terminal is a LineReader that reads from an interactive terminal. It
+// supports line editing, moving through previously entered lines with
+// the up and down arrow keys, and completion with the tab key.
+type Terminal struct {
+	// Complete, if it is not nil, is called when the tab key is
+	// pressed to find completions for the word before the cursor. It
+	// has the same semantics as [REPL.Complete].
+	Complete func(line string, pos int) (start int, candidates []string)
+
+	in      *os.File
+	t       *term.Terminal
+	history history
+}
+
+// NewTerminal returns a Terminal that reads from in, which must be a
+// terminal, and writes prompts and echoed input to out. The terminal
+// is only put into raw mode while a line is being read so that output
+// written while evaluating input is not affected.
+func NewTerminal(in *os.File, out io.Writer) *Terminal {
+	t := Terminal{in: in}
+	t.t = term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{in, out}, "")
+	t.t.History = &t.history
+	t.t.AutoCompleteCallback = t.autoComplete
+	return &t
+}
+
+// IsTerminal returns true if f is a terminal that can be used with
+// NewTerminal.
+func IsTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+func (t *Terminal) ReadLine(prompt string) (string, error) {
+	fd := int(t.in.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return "", err
+	}
+	defer term.Restore(fd, state)
+
+	if w, h, err := term.GetSize(fd); err == nil {
+		t.t.SetSize(w, h)
+	}
+	t.t.SetPrompt(prompt)
+	return t.t.ReadLine()
+}
+
+// LoadHistory reads previously entered lines, one per line, from r.
+// The last line read is the most recent.
+func (t *Terminal) LoadHistory(r io.Reader) error {
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		t.history.Add(s.Text())
+	}
+	return s.Err()
+}
+
+// SaveHistory writes the remembered lines to w in the format read by
+// LoadHistory.
+func (t *Terminal) SaveHistory(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, line := range t.history.lines {
+		bw.WriteString(line)
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+func (t *Terminal) autoComplete(line string, pos int, key rune) (string, int, bool) {
+	if key != '\t' || t.Complete == nil {
+		return "", 0, false
+	}
+
+	start, candidates := t.Complete(line, pos)
+	if len(candidates) == 0 {
+		return "", 0, false
+	}
+
+	prefix := commonPrefix(candidates)
+	if len(prefix) <= pos-start && len(candidates) > 1 {
+		io.WriteString(t.t, strings.Join(candidates, "  ")+"\n")
+		return "", 0, false
+	}
+	return line[:start] + prefix + line[pos:], start + len(prefix), true
+}
+
+// commonPrefix returns the longest prefix shared by all of strs.
+func commonPrefix(strs []string) string {
+	prefix := strs[0]
+	for _, str := range strs[1:] {
+		n := 0
+		for n < len(prefix) && n < len(str) && prefix[n] == str[n] {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+	return prefix
+}
+
+// history is a bounded list of lines that implements [term.History].
+type history struct {
+	lines []string
+}
+
+func (h *history) Add(line string) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	if len(h.lines) > 0 && h.lines[len(h.lines)-1] == line {
+		return
+	}
+	h.lines = append(h.lines, line)
+	if len(h.lines) > maxHistory {
+		h.lines = h.lines[len(h.lines)-maxHistory:]
+	}
+}
+
+func (h *history) Len() int {
+	return len(h.lines)
+}
+
+func (h *history) At(i int) string {
+	return h.lines[len(h.lines)-1-i]
+}
@@ -0,0 +1,49 @@
+package scanner
+
+// Oper is an infix operator token.
+type Oper rune
+
+// Operators supported by the scanner.
+const (
+	OperAdd Oper = '+'
+	OperSub Oper = '-'
+	OperMul Oper = '*'
+	OperDiv Oper = '/'
+	OperRem Oper = '%'
+	OperPow Oper = '^'
+)
+
+type operInfo struct {
+	prec  int
+	right bool
+}
+
+var opers = map[Oper]operInfo{
+	OperAdd: {prec: 1},
+	OperSub: {prec: 1},
+	OperMul: {prec: 2},
+	OperDiv: {prec: 2},
+	OperRem: {prec: 2},
+	OperPow: {prec: 3, right: true},
+}
+
+// IsOper returns true if c is an operator rune.
+func IsOper(c rune) bool {
+	_, ok := opers[Oper(c)]
+	return ok
+}
+
+// Precedence returns the precedence of the operator. Operators with
+// higher precedence bind more tightly.
+func (op Oper) Precedence() int {
+	return opers[op].prec
+}
+
+// RightAssoc returns true if the operator is right-associative.
+func (op Oper) RightAssoc() bool {
+	return opers[op].right
+}
+
+func (op Oper) String() string {
+	return string(op)
+}
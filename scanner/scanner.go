@@ -15,30 +15,79 @@ import (
 // Scanner produces Extract parser tokens from an io.Reader.
 type Scanner struct {
 	r         *bufio.Reader
+	filename  string
+	trivia    bool
 	line, col int
-	c         rune
-	err       error
+	offset    int
 
-	buf strings.Builder
-	tok Token
+	prevLine, prevCol, prevOffset int
+	c                             rune
+	err                           error
+
+	buf    strings.Builder
+	tok    Token
+	next   Token
+	peeked bool
+}
+
+// Option is an option that can be passed to [New].
+type Option func(*Scanner)
+
+// WithFilename sets the filename that is recorded in the positions of
+// tokens and errors produced by the Scanner.
+func WithFilename(name string) Option {
+	return func(s *Scanner) {
+		s.filename = name
+	}
+}
+
+// WithTrivia makes the Scanner produce [Whitespace] and [Comment]
+// tokens instead of skipping over whitespace and comments. Combined
+// with the byte offsets of tokens, this allows the exact input to be
+// reconstructed from the tokens, which is useful for tools such as
+// syntax highlighters and formatters.
+func WithTrivia() Option {
+	return func(s *Scanner) {
+		s.trivia = true
+	}
 }
 
 // New returns a new Scanner which reads from r. The Scanner starts
 // before the first token, so the user must call [Scan] at least once
 // before accessing tokens.
-func New(r io.Reader) *Scanner {
-	return &Scanner{
+func New(r io.Reader, opts ...Option) *Scanner {
+	s := Scanner{
 		r:    bufio.NewReader(r),
 		line: 1, col: 1,
 	}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	s.tok.Filename = s.filename
+	return &s
 }
 
 // Scan advances the scanner to the next token. The current token can
 // be retrieved using [Token]. If there are no more tokens, possibly
 // because of an error, Scan returns false.
 func (s *Scanner) Scan() bool {
+	if s.peeked {
+		s.tok, s.next, s.peeked = s.next, Token{}, false
+		return true
+	}
+
+	return s.scan()
+}
+
+func (s *Scanner) scan() bool {
+	if s.err != nil {
+		return false
+	}
+
+	s.tok.Val = nil
 	s.start()
-	return s.err == nil
+	s.tok.End = s.offset
+	return s.tok.Val != nil && (s.err == nil || errors.Is(s.err, io.EOF))
 }
 
 // Token returns the current token. See [Scan].
@@ -46,6 +95,32 @@ func (s *Scanner) Token() Token {
 	return s.tok
 }
 
+// Peek returns the token that the next call to [Scan] will advance
+// to without advancing the scanner. If there is no next token, it
+// returns false, in which case [Err] may return an error.
+func (s *Scanner) Peek() (Token, bool) {
+	if s.peeked {
+		return s.next, true
+	}
+
+	cur := s.tok
+	defer func() { s.tok = cur }()
+
+	if !s.scan() {
+		return Token{}, false
+	}
+
+	s.next, s.peeked = s.tok, true
+	return s.next, true
+}
+
+// Pos returns the current position of the scanner in its input,
+// which is just past the end of the most recently scanned or peeked
+// token.
+func (s *Scanner) Pos() Position {
+	return Position{Filename: s.filename, Line: s.line, Col: s.col, Offset: s.offset}
+}
+
 // Err returns whatever error caused the scanner to stop, or nil if
 // the scanner has not yet stopped or if the scanner stopped because
 // it completely drained the underlying io.Reader without any errors.
@@ -77,17 +152,15 @@ func (s *Scanner) raise(err error) {
 
 func (s *Scanner) raiseToken(err error) {
 	s.raise(&TokenError{
-		Line: s.tok.Line,
-		Col:  s.tok.Col,
-		Err:  err,
+		Position: s.tok.Position,
+		Err:      err,
 	})
 }
 
 func (s *Scanner) raiseUnexpectedRune() {
 	s.raise(&UnexpectedRuneError{
-		Line: s.line,
-		Col:  s.col - 1,
-		Rune: s.c,
+		Position: s.pos(),
+		Rune:     s.c,
 	})
 }
 
@@ -98,11 +171,15 @@ func (s *Scanner) raiseUnexpectedEOF(literal string) {
 }
 
 func (s *Scanner) read() bool {
-	s.c, _, s.err = s.r.ReadRune()
+	var size int
+	s.c, size, s.err = s.r.ReadRune()
 	if s.err != nil {
 		return false
 	}
 
+	s.prevLine, s.prevCol, s.prevOffset = s.line, s.col, s.offset
+	s.offset += size
+
 	switch s.c {
 	case '\n':
 		s.col = 1
@@ -113,11 +190,17 @@ func (s *Scanner) read() bool {
 	return true
 }
 
+// pos returns the position of the most recently read rune.
+func (s *Scanner) pos() Position {
+	return Position{Filename: s.filename, Line: s.prevLine, Col: s.prevCol, Offset: s.prevOffset}
+}
+
 func (s *Scanner) unread() {
 	err := s.r.UnreadRune()
 	if err != nil {
 		panic(err) // If this happens, there's a bug.
 	}
+	s.line, s.col, s.offset = s.prevLine, s.prevCol, s.prevOffset
 }
 
 func (s *Scanner) start() {
@@ -133,20 +216,27 @@ func (s *Scanner) start() {
 
 	defer s.buf.Reset()
 
-	s.tok.Line = s.line
-	s.tok.Col = s.col
-
 	for {
 		if !s.read() {
 			return
 		}
-		if !unicode.IsSpace(s.c) {
+		if s.trivia || !unicode.IsSpace(s.c) {
 			break
 		}
 	}
+	s.tok.Position = s.pos()
+
+	if unicode.IsSpace(s.c) {
+		s.whitespace()
+		return
+	}
 
 	switch s.c {
 	case '#':
+		if s.trivia {
+			s.comment()
+			return
+		}
 		for s.c != '\n' {
 			if !s.read() {
 				return
@@ -160,13 +250,33 @@ func (s *Scanner) start() {
 	case ')':
 		s.tok.Val = Rparen{}
 		return
+	case '[':
+		s.tok.Val = Lbracket{}
+		return
+	case ']':
+		s.tok.Val = Rbracket{}
+		return
 	case '.':
 		s.tok.Val = Dot{}
 		return
 	case '\\':
+		if b, err := s.r.Peek(1); err == nil && b[0] == '\\' {
+			s.read()
+			s.tok.Val = Default{}
+			return
+		}
 		s.tok.Val = Pin{}
 		return
+	case '&':
+		s.tok.Val = Rest{}
+		return
 	case '"':
+		if b, err := s.r.Peek(2); err == nil && string(b) == `""` {
+			s.read()
+			s.read()
+			s.heredoc()
+			return
+		}
 		s.string()
 		return
 	case ':':
@@ -181,11 +291,30 @@ func (s *Scanner) start() {
 		return
 	}
 
+	if s.c == '-' {
+		if b, err := s.r.Peek(1); err == nil && b[0] >= '0' && b[0] <= '9' {
+			s.buf.WriteRune(s.c)
+			s.int()
+			return
+		}
+	}
+	if IsOper(s.c) {
+		s.tok.Val = Oper(s.c)
+		return
+	}
+
 	if s.c >= '0' && s.c <= '9' {
 		s.buf.WriteRune(s.c)
 		s.int()
 		return
 	}
+	if s.c == 'b' {
+		if b, err := s.r.Peek(1); err == nil && b[0] == '"' {
+			s.read()
+			s.binary()
+			return
+		}
+	}
 	if s.c >= 'a' && s.c <= 'z' {
 		s.buf.WriteRune(s.c)
 		s.ident()
@@ -200,6 +329,38 @@ func (s *Scanner) start() {
 	s.raiseUnexpectedRune()
 }
 
+func (s *Scanner) whitespace() {
+	s.buf.WriteRune(s.c)
+	for {
+		if !s.read() {
+			break
+		}
+		if !unicode.IsSpace(s.c) {
+			s.unread()
+			break
+		}
+		s.buf.WriteRune(s.c)
+	}
+
+	s.tok.Val = Whitespace(s.buf.String())
+}
+
+func (s *Scanner) comment() {
+	s.buf.WriteRune(s.c)
+	for {
+		if !s.read() {
+			break
+		}
+		if s.c == '\n' {
+			s.unread()
+			break
+		}
+		s.buf.WriteRune(s.c)
+	}
+
+	s.tok.Val = Comment(s.buf.String())
+}
+
 func (s *Scanner) atomcolon() {
 	if !s.read() {
 		s.raiseUnexpectedEOF("atom")
@@ -255,7 +416,7 @@ func (s *Scanner) int() {
 func (s *Scanner) float() {
 	for {
 		if !s.read() {
-			return
+			break
 		}
 
 		if s.c >= '0' && s.c <= '9' {
@@ -301,6 +462,119 @@ func (s *Scanner) string() {
 	}
 }
 
+// binary scans a binary literal, such as b"\x00data". In addition to
+// the escape sequences allowed in strings, binary literals may contain
+// arbitrary bytes in the form \xHH.
+func (s *Scanner) binary() {
+	for {
+		if !s.read() {
+			s.raiseUnexpectedEOF("binary")
+			return
+		}
+
+		switch s.c {
+		case '\\':
+			if !s.read() {
+				s.raiseUnexpectedEOF("binary")
+				return
+			}
+			if s.c == 'x' {
+				s.buf.WriteByte(s.hexByte())
+				continue
+			}
+			s.escape('"')
+			s.buf.WriteRune(s.c)
+
+		case '"':
+			s.tok.Val = Binary(s.buf.String())
+			return
+
+		default:
+			s.buf.WriteRune(s.c)
+		}
+	}
+}
+
+// hexByte scans the two hexadecimal digits of a \xHH escape sequence.
+func (s *Scanner) hexByte() byte {
+	var b byte
+	for range 2 {
+		if !s.read() {
+			s.raiseUnexpectedEOF("binary")
+			return 0
+		}
+
+		d, err := strconv.ParseUint(string(s.c), 16, 8)
+		if err != nil {
+			s.raiseToken(fmt.Errorf("invalid hexadecimal digit %q in escape sequence", s.c))
+		}
+		b = b<<4 | byte(d)
+	}
+	return b
+}
+
+// heredoc scans a triple-quoted string. The first line break after
+// the opening quotes is discarded, as is the indentation of the line
+// containing the closing quotes, which is also stripped from the
+// beginning of every other line. If the closing quotes are not on
+// their own line, the smallest indentation of any non-blank line is
+// stripped instead.
+func (s *Scanner) heredoc() {
+	var quotes int
+	for quotes < 3 {
+		if !s.read() {
+			s.raiseUnexpectedEOF("string")
+			return
+		}
+
+		if s.c == '"' {
+			quotes++
+			continue
+		}
+		if s.c == '\\' {
+			if !s.read() {
+				s.raiseUnexpectedEOF("string")
+				return
+			}
+			s.escape('"')
+		}
+
+		for range quotes {
+			s.buf.WriteByte('"')
+		}
+		quotes = 0
+		s.buf.WriteRune(s.c)
+	}
+
+	s.tok.Val = String(dedent(s.buf.String()))
+}
+
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+func dedent(str string) string {
+	lines := strings.Split(strings.TrimPrefix(str, "\n"), "\n")
+
+	last := lines[len(lines)-1]
+	indent := indentation(last)
+	closing := indent == len(last)
+	if closing {
+		lines[len(lines)-1] = ""
+	} else {
+		for _, line := range lines {
+			if strings.TrimSpace(line) != "" {
+				indent = min(indent, indentation(line))
+			}
+		}
+	}
+
+	for i, line := range lines {
+		lines[i] = line[min(indent, indentation(line)):]
+	}
+	return strings.Join(lines, "\n")
+}
+
 func (s *Scanner) rune() {
 	if !s.read() {
 		s.raiseUnexpectedEOF("rune")
@@ -334,14 +608,14 @@ func (s *Scanner) rune() {
 		return
 	}
 
-	s.tok.Val = Int(val)
+	s.tok.Val = Rune(val)
 }
 
 func (s *Scanner) ident() {
 loop:
 	for {
 		if !s.read() {
-			return
+			break
 		}
 
 		switch s.c {
@@ -377,53 +651,150 @@ func (s *Scanner) escape(q rune) {
 	}
 }
 
+// Position is a location in a source file. Line and Col both start
+// at 1 and count runes. Offset is the byte offset from the beginning
+// of the input, starting at 0.
+type Position struct {
+	Filename  string
+	Line, Col int
+	Offset    int
+}
+
+func (p Position) String() string {
+	if p.Filename == "" {
+		return fmt.Sprintf("%v:%v", p.Line, p.Col)
+	}
+	return fmt.Sprintf("%v:%v:%v", p.Filename, p.Line, p.Col)
+}
+
 // Token is an Extract language parser token. If the token is valid,
-// Val will be one of the token types defined in this package.
+// Val will be one of the token types defined in this package. The
+// token's text spans from its Offset up to, but not including, End.
 type Token struct {
-	Line, Col int
-	Val       any
+	Position
+	End int
+	Val any
+}
+
+// Kind returns the kind of the token based on the type of its value.
+func (t Token) Kind() TokenKind {
+	switch t.Val.(type) {
+	case Whitespace:
+		return KindWhitespace
+	case Comment:
+		return KindComment
+	case Lparen, Rparen, Lbracket, Rbracket:
+		return KindDelim
+	case Dot, Pin, Default, Rest, Oper:
+		return KindOperator
+	case Int, Float:
+		return KindNumber
+	case Rune:
+		return KindRune
+	case String, Binary:
+		return KindString
+	case Ident:
+		return KindIdent
+	case Atom:
+		return KindAtom
+	default:
+		return KindInvalid
+	}
+}
+
+// TokenKind is a broad classification of tokens, such as might be
+// used for syntax highlighting.
+type TokenKind int
+
+const (
+	KindInvalid TokenKind = iota
+	KindWhitespace
+	KindComment
+	KindDelim
+	KindOperator
+	KindNumber
+	KindRune
+	KindString
+	KindIdent
+	KindAtom
+)
+
+var kindNames = [...]string{
+	KindInvalid:    "invalid",
+	KindWhitespace: "whitespace",
+	KindComment:    "comment",
+	KindDelim:      "delimiter",
+	KindOperator:   "operator",
+	KindNumber:     "number",
+	KindRune:       "rune",
+	KindString:     "string",
+	KindIdent:      "identifier",
+	KindAtom:       "atom",
+}
+
+func (k TokenKind) String() string {
+	if k < 0 || int(k) >= len(kindNames) {
+		return fmt.Sprintf("TokenKind(%d)", int(k))
+	}
+	return kindNames[k]
 }
 
 // Token value type.
 type (
-	Lparen struct{}
-	Rparen struct{}
-	Dot    struct{}
-	Pin    struct{}
+	Lparen   struct{}
+	Rparen   struct{}
+	Lbracket struct{}
+	Rbracket struct{}
+	Dot      struct{}
+	Pin      struct{}
+	Default  struct{}
+	Rest     struct{}
 
 	Int    int64
 	Float  float64
+	Rune   rune
 	String string
+	Binary string
 	Ident  string
 	Atom   string
+
+	// Whitespace and Comment are only produced when the scanner is
+	// created with WithTrivia. The text of a Comment includes the
+	// leading # but not the line break that ends it.
+	Whitespace string
+	Comment    string
 )
 
-func (t Lparen) String() string { return "(" }
-func (t Rparen) String() string { return ")" }
-func (t Dot) String() string    { return "." }
-func (t Pin) String() string    { return "\\" }
+func (t Lparen) String() string   { return "(" }
+func (t Rparen) String() string   { return ")" }
+func (t Lbracket) String() string { return "[" }
+func (t Rbracket) String() string { return "]" }
+func (t Dot) String() string      { return "." }
+func (t Pin) String() string      { return "\\" }
+func (t Default) String() string  { return "\\\\" }
+func (t Rest) String() string     { return "&" }
 
 // UnexpectedRuneError is yielded when an unexpected rune is found
 // during the course of scanning.
 type UnexpectedRuneError struct {
-	Line, Col int
-	Rune      rune
+	Position
+	Rune rune
 }
 
 func (err *UnexpectedRuneError) Error() string {
-	return fmt.Sprintf("unexpected rune %q (%v:%v)", err.Rune, err.Line, err.Col)
+	return fmt.Sprintf("unexpected rune %q (%v)", err.Rune, err.Position)
 }
 
 // TokenError is yielded when an unexpected error occurs during the
-// scanning of a token. Line and Col are for the beginning of the
-// token, not the exact location of the error.
+// scanning of a token. Its position is the beginning of the token,
+// not the exact location of the error.
 type TokenError struct {
-	Line, Col int
-	Err       error
+	Position
+	Err error
 }
 
 func (err *TokenError) Error() string {
-	return fmt.Sprintf("error in token (%v:%v): %v", err.Line, err.Col, err.Err)
+	return fmt.Sprintf("error in token (%v): %v", err.Position, err.Err)
 }
 
 func (err *TokenError) Unwrap() error {
@@ -2,6 +2,7 @@ package scanner_test
 
 import (
 	"errors"
+	"slices"
 	"strings"
 	"testing"
 
@@ -35,7 +36,7 @@ func TestScan(t *testing.T) {
 			scanner.Lparen{},
 			scanner.String("test"),
 			scanner.Int(30),
-			scanner.Int('a'),
+			scanner.Rune('a'),
 			scanner.Float(1.2),
 			scanner.Atom("test2"),
 			scanner.Atom("Test3"),
@@ -51,6 +52,48 @@ func TestScan(t *testing.T) {
 			scanner.Rparen{},
 			scanner.String("This is not."),
 		}},
+		{"Operators", `(1 + -2 * 3.0 - x)`, []any{
+			scanner.Lparen{},
+			scanner.Int(1),
+			scanner.OperAdd,
+			scanner.Int(-2),
+			scanner.OperMul,
+			scanner.Float(3),
+			scanner.OperSub,
+			scanner.Ident("x"),
+			scanner.Rparen{},
+		}},
+		{"Heredoc", "\"\"\"\n\tline one\n\t  \"quoted\"\n\n\tline \\\"\"\"three\n\t\"\"\"", []any{
+			scanner.String("line one\n  \"quoted\"\n\nline \"\"\"three\n"),
+		}},
+		{"Binary", `(b"a\x00\xfF\n" b "")`, []any{
+			scanner.Lparen{},
+			scanner.Binary("a\x00\xff\n"),
+			scanner.Ident("b"),
+			scanner.String(""),
+			scanner.Rparen{},
+		}},
+		{"EOF", `1 2.5 test`, []any{
+			scanner.Int(1),
+			scanner.Float(2.5),
+			scanner.Ident("test"),
+		}},
+		{"Rest", `(a &rest)`, []any{
+			scanner.Lparen{},
+			scanner.Ident("a"),
+			scanner.Rest{},
+			scanner.Ident("rest"),
+			scanner.Rparen{},
+		}},
+		{"Default", `(\pinned arg \\ 3)`, []any{
+			scanner.Lparen{},
+			scanner.Pin{},
+			scanner.Ident("pinned"),
+			scanner.Ident("arg"),
+			scanner.Default{},
+			scanner.Int(3),
+			scanner.Rparen{},
+		}},
 	}
 
 	for _, test := range tests {
@@ -62,10 +105,92 @@ func TestScan(t *testing.T) {
 }
 
 func TestUnexpectedRune(t *testing.T) {
-	s := scanner.New(strings.NewReader(`(test ^t)`))
+	s := scanner.New(strings.NewReader(`(test $t)`))
 	xiter.Drain(s.All())
 	var err *scanner.UnexpectedRuneError
 	if !errors.As(s.Err(), &err) {
 		t.Fatalf("%#v", s.Err())
 	}
 }
+
+func TestPosition(t *testing.T) {
+	s := scanner.New(strings.NewReader("(add\n  1 # comment\n  \"two\")"), scanner.WithFilename("test.ext"))
+	ex := []scanner.Position{
+		{Filename: "test.ext", Line: 1, Col: 1, Offset: 0},
+		{Filename: "test.ext", Line: 1, Col: 2, Offset: 1},
+		{Filename: "test.ext", Line: 2, Col: 3, Offset: 7},
+		{Filename: "test.ext", Line: 3, Col: 3, Offset: 21},
+		{Filename: "test.ext", Line: 3, Col: 8, Offset: 26},
+	}
+
+	var i int
+	for tok := range s.All() {
+		if tok.Position != ex[i] {
+			t.Fatalf("%v: %v != %v", tok.Val, tok.Position, ex[i])
+		}
+		i++
+	}
+	if s.Err() != nil {
+		t.Fatal(s.Err())
+	}
+}
+
+func TestPeek(t *testing.T) {
+	s := scanner.New(strings.NewReader(`(add 1)`))
+	if !s.Scan() || s.Token().Val != (scanner.Lparen{}) {
+		t.Fatal(s.Token())
+	}
+
+	tok, ok := s.Peek()
+	if !ok || tok.Val != scanner.Ident("add") {
+		t.Fatal(tok)
+	}
+	if s.Token().Val != (scanner.Lparen{}) {
+		t.Fatal(s.Token())
+	}
+	if pos := s.Pos(); pos.Line != 1 || pos.Col != 5 || pos.Offset != 4 {
+		t.Fatal(pos)
+	}
+
+	checkTokens(t, s, []any{
+		scanner.Ident("add"),
+		scanner.Int(1),
+		scanner.Rparen{},
+	})
+	if _, ok := s.Peek(); ok {
+		t.Fatal("peeked past end of input")
+	}
+}
+
+func TestTrivia(t *testing.T) {
+	const src = "(add 1 # one\n\t2)"
+	s := scanner.New(strings.NewReader(src), scanner.WithTrivia())
+
+	var sb strings.Builder
+	var kinds []scanner.TokenKind
+	for tok := range s.All() {
+		sb.WriteString(src[tok.Offset:tok.End])
+		kinds = append(kinds, tok.Kind())
+	}
+	if s.Err() != nil {
+		t.Fatal(s.Err())
+	}
+
+	if sb.String() != src {
+		t.Fatalf("%q", sb.String())
+	}
+	ex := []scanner.TokenKind{
+		scanner.KindDelim,
+		scanner.KindIdent,
+		scanner.KindWhitespace,
+		scanner.KindNumber,
+		scanner.KindWhitespace,
+		scanner.KindComment,
+		scanner.KindWhitespace,
+		scanner.KindNumber,
+		scanner.KindDelim,
+	}
+	if !slices.Equal(kinds, ex) {
+		t.Fatal(kinds)
+	}
+}
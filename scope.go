@@ -0,0 +1,114 @@
+package extract
+
+import (
+	"cmp"
+	"iter"
+	"slices"
+	"unsafe"
+)
+
+// scope is an immutable set of local bindings. It is implemented as a
+// persistent hash array mapped trie, so lookups take effectively
+// constant time regardless of how many bindings are in scope, while
+// pushing a new binding only copies the nodes along a single path
+// through the trie.
+//
+// Every binding records the number of bindings that had been pushed
+// when it was, which allows the order of bindings to be recovered
+// when necessary, such as for determining whether a binding was made
+// before or after a module was entered.
+//
+// A nil *scope is an empty scope.
+type scope struct {
+	bindings trie[Ident, binding]
+	n        int
+}
+
+type binding struct {
+	ident Ident
+	val   any
+	seq   int
+}
+
+// hashIdent hashes the address of ident's interned string rather than
+// its contents. Every Ident made from the same string shares the same
+// interned copy, so this is consistent with ==, and it takes constant
+// time regardless of the identifier's length.
+func hashIdent(ident Ident) uint64 {
+	// The address is mixed with the SplitMix64 finalizer so that the
+	// low bits, which are used first by the trie, aren't mostly zero
+	// due to alignment.
+	h := uint64(uintptr(unsafe.Pointer(unsafe.StringData(ident.h.Value()))))
+	h = (h ^ h>>30) * 0xbf58476d1ce4e5b9
+	h = (h ^ h>>27) * 0x94d049bb133111eb
+	return h ^ h>>31
+}
+
+func eqIdent(i1, i2 Ident) bool {
+	return i1 == i2
+}
+
+// Len returns the total number of bindings that have been pushed,
+// including ones that have since been shadowed.
+func (s *scope) Len() int {
+	if s == nil {
+		return 0
+	}
+	return s.n
+}
+
+// Push returns a new scope with ident bound to val, shadowing any
+// previous binding of ident.
+func (s *scope) Push(ident Ident, val any) *scope {
+	n := s.Len() + 1
+	b := binding{ident: ident, val: val, seq: n}
+
+	var bindings trie[Ident, binding]
+	if s != nil {
+		bindings = s.bindings
+	}
+	return &scope{bindings: bindings.Put(hashIdent(ident), ident, b, eqIdent), n: n}
+}
+
+// Get returns the binding of ident, if there is one.
+func (s *scope) Get(ident Ident) (binding, bool) {
+	if s == nil {
+		return binding{}, false
+	}
+	return s.bindings.Get(hashIdent(ident), ident, eqIdent)
+}
+
+// All returns an iterator over the bindings in the scope in no
+// particular order. Shadowed bindings are not yielded.
+func (s *scope) All() iter.Seq[binding] {
+	return func(yield func(binding) bool) {
+		if s == nil {
+			return
+		}
+		for _, b := range s.bindings.All() {
+			if !yield(b) {
+				return
+			}
+		}
+	}
+}
+
+// Since returns an iterator over the bindings in the scope that were
+// pushed after the first n, ordered from most to least recently
+// pushed. Shadowed bindings are not yielded.
+func (s *scope) Since(n int) iter.Seq[binding] {
+	return func(yield func(binding) bool) {
+		var since []binding
+		for b := range s.All() {
+			if b.seq > n {
+				since = append(since, b)
+			}
+		}
+		slices.SortFunc(since, func(b1, b2 binding) int { return cmp.Compare(b2.seq, b1.seq) })
+		for _, b := range since {
+			if !yield(b) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,346 @@
+package extract
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"reflect"
+	"time"
+)
+
+// ErrNetworkDisabled is returned by functions that access the network
+// if the Env was created with [WithoutNetwork].
+var ErrNetworkDisabled = errors.New("network access is disabled")
+
+// Socket is a network socket opened by the Socket module. It is either
+// a connection, a listener that accepts connections, or a packet
+// socket, such as one listening for UDP datagrams.
+type Socket struct {
+	conn   net.Conn
+	ln     net.Listener
+	packet net.PacketConn
+}
+
+func (s *Socket) String() string {
+	if s.conn != nil {
+		return fmt.Sprintf("#Socket<%v->%v>", s.conn.LocalAddr(), s.conn.RemoteAddr())
+	}
+	return fmt.Sprintf("#Socket<%v>", s.localAddr())
+}
+
+// localAddr returns the local address of the socket.
+func (s *Socket) localAddr() net.Addr {
+	switch {
+	case s.conn != nil:
+		return s.conn.LocalAddr()
+	case s.ln != nil:
+		return s.ln.Addr()
+	default:
+		return s.packet.LocalAddr()
+	}
+}
+
+// deadliner is implemented by connections that support deadlines.
+type deadliner interface {
+	SetDeadline(time.Time) error
+}
+
+// withDeadline runs op with d's deadline set from ctx and timeout,
+// whichever is sooner. If ctx is canceled while op is running, the
+// deadline is moved to the present so that op returns immediately.
+func withDeadline(ctx context.Context, d deadliner, timeout time.Duration, op func() error) error {
+	deadline, ok := ctx.Deadline()
+	if timeout >= 0 {
+		t := time.Now().Add(timeout)
+		if !ok || t.Before(deadline) {
+			deadline = t
+		}
+	}
+	d.SetDeadline(deadline)
+	defer d.SetDeadline(time.Time{})
+
+	stop := context.AfterFunc(ctx, func() { d.SetDeadline(time.Now()) })
+	defer stop()
+
+	err := op()
+	if err != nil && ctx.Err() != nil {
+		return &CancelledError{Cause: context.Cause(ctx)}
+	}
+	return err
+}
+
+// socketResult converts err into a tagged error result, or returns
+// nil if err is nil. Errors caused by the Env's context being
+// canceled are returned directly instead.
+func socketResult(err error) any {
+	var cerr *CancelledError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &cerr):
+		return err
+	case errors.Is(err, os.ErrDeadlineExceeded):
+		return errorResult(MakeAtom("timeout"))
+	case errors.Is(err, io.EOF), errors.Is(err, net.ErrClosed):
+		return errorResult(MakeAtom("closed"))
+	default:
+		return errorResult(err.Error())
+	}
+}
+
+// checkNet returns an error if network access is disabled in env.
+func (env *Env) checkNet() error {
+	if env.noNet {
+		return ErrNetworkDisabled
+	}
+	return nil
+}
+
+func stdSocket() *Module {
+	m := Module{name: MakeAtom("Socket")}
+	m.decls = map[Ident]any{
+		MakeIdent("connect"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			network, address, err := evalNetArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			var d net.Dialer
+			conn, err := d.DialContext(env.Context(), network, address)
+			if err != nil {
+				return env, socketResult(err)
+			}
+			return env, okResult(&Socket{conn: conn})
+		}),
+		MakeIdent("listen"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			network, address, err := evalNetArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			var lc net.ListenConfig
+			switch network {
+			case "udp", "udp4", "udp6", "unixgram":
+				packet, err := lc.ListenPacket(env.Context(), network, address)
+				if err != nil {
+					return env, socketResult(err)
+				}
+				return env, okResult(&Socket{packet: packet})
+			default:
+				ln, err := lc.Listen(env.Context(), network, address)
+				if err != nil {
+					return env, socketResult(err)
+				}
+				return env, okResult(&Socket{ln: ln})
+			}
+		}),
+		MakeIdent("accept"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			s, timeout, err := evalSocketTimeout(env, args, 1)
+			if err != nil {
+				return env, err
+			}
+			ln, ok := s.ln.(deadlineListener)
+			if !ok {
+				return env, fmt.Errorf("%v is not a listener that supports deadlines", s)
+			}
+
+			var conn net.Conn
+			err = withDeadline(env.Context(), ln, timeout, func() (err error) {
+				conn, err = ln.Accept()
+				return err
+			})
+			if err != nil {
+				return env, socketResult(err)
+			}
+			return env, okResult(&Socket{conn: conn})
+		}),
+		MakeIdent("send"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 2 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 2}
+			}
+
+			vals, err := evalArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			s, ok := vals[0].(*Socket)
+			if !ok || s.conn == nil {
+				return env, NewTypeError(vals[0], reflect.TypeFor[*Socket]())
+			}
+			data, ok := vals[1].(string)
+			if !ok {
+				return env, NewTypeError(vals[1], reflect.TypeFor[string]())
+			}
+
+			err = withDeadline(env.Context(), s.conn, -1, func() error {
+				_, err := io.WriteString(s.conn, data)
+				return err
+			})
+			if err != nil {
+				return env, socketResult(err)
+			}
+			return env, okAtom
+		}),
+		MakeIdent("send_to"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 3 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 3}
+			}
+
+			vals, err := evalArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			s, ok := vals[0].(*Socket)
+			if !ok || s.packet == nil {
+				return env, NewTypeError(vals[0], reflect.TypeFor[*Socket]())
+			}
+			address, ok := vals[1].(string)
+			if !ok {
+				return env, NewTypeError(vals[1], reflect.TypeFor[string]())
+			}
+			data, ok := vals[2].(string)
+			if !ok {
+				return env, NewTypeError(vals[2], reflect.TypeFor[string]())
+			}
+
+			addr, err := net.ResolveUDPAddr(s.packet.LocalAddr().Network(), address)
+			if err != nil {
+				return env, socketResult(err)
+			}
+			err = withDeadline(env.Context(), s.packet, -1, func() error {
+				_, err := s.packet.WriteTo([]byte(data), addr)
+				return err
+			})
+			if err != nil {
+				return env, socketResult(err)
+			}
+			return env, okAtom
+		}),
+		MakeIdent("recv"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			s, timeout, err := evalSocketTimeout(env, args, 1)
+			if err != nil {
+				return env, err
+			}
+
+			buf := make([]byte, 64*1024)
+			var n int
+			var from net.Addr
+			switch {
+			case s.conn != nil:
+				err = withDeadline(env.Context(), s.conn, timeout, func() (err error) {
+					n, err = s.conn.Read(buf)
+					return err
+				})
+			case s.packet != nil:
+				err = withDeadline(env.Context(), s.packet, timeout, func() (err error) {
+					n, from, err = s.packet.ReadFrom(buf)
+					return err
+				})
+			default:
+				return env, NewTypeError(s, reflect.TypeFor[*Socket]())
+			}
+			if n == 0 && err != nil {
+				return env, socketResult(err)
+			}
+
+			if from != nil {
+				return env, okResult(ListOf(string(buf[:n]), from.String()))
+			}
+			return env, okResult(string(buf[:n]))
+		}),
+		MakeIdent("address"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			s, _, err := evalSocketTimeout(env, args, 0)
+			if err != nil {
+				return env, err
+			}
+			return env, s.localAddr().String()
+		}),
+		MakeIdent("close"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			s, _, err := evalSocketTimeout(env, args, 0)
+			if err != nil {
+				return env, err
+			}
+
+			var c io.Closer = s.conn
+			switch {
+			case s.ln != nil:
+				c = s.ln
+			case s.packet != nil:
+				c = s.packet
+			}
+			if err := c.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+				return env, socketResult(err)
+			}
+			return env, okAtom
+		}),
+	}
+
+	return &m
+}
+
+// deadlineListener is a listener that supports deadlines, such as a
+// *net.TCPListener.
+type deadlineListener interface {
+	net.Listener
+	SetDeadline(time.Time) error
+}
+
+// evalNetArgs checks that network access is enabled and evaluates a
+// network, either as an atom such as :tcp or as a string, and an
+// address.
+func evalNetArgs(env *Env, args *List) (network, address string, err error) {
+	if args.Len() != 2 {
+		return "", "", &ArgumentNumError{Num: args.Len(), Expected: 2}
+	}
+	if err := env.checkNet(); err != nil {
+		return "", "", err
+	}
+
+	vals, err := evalArgs(env, args)
+	if err != nil {
+		return "", "", err
+	}
+	switch v := vals[0].(type) {
+	case Atom:
+		network = v.String()
+	case string:
+		network = v
+	default:
+		return "", "", NewTypeError(v, reflect.TypeFor[Atom](), reflect.TypeFor[string]())
+	}
+	address, ok := vals[1].(string)
+	if !ok {
+		return "", "", NewTypeError(vals[1], reflect.TypeFor[string]())
+	}
+	return network, address, nil
+}
+
+// evalSocketTimeout evaluates a *Socket argument followed by up to
+// maxOpt optional timeouts in milliseconds. If no timeout is given,
+// the returned timeout is -1.
+func evalSocketTimeout(env *Env, args *List, maxOpt int) (*Socket, time.Duration, error) {
+	if args.Len() < 1 || args.Len() > 1+maxOpt {
+		return nil, 0, &ArgumentNumError{Num: args.Len(), Expected: -1}
+	}
+
+	vals, err := evalArgs(env, args)
+	if err != nil {
+		return nil, 0, err
+	}
+	s, ok := vals[0].(*Socket)
+	if !ok {
+		return nil, 0, NewTypeError(vals[0], reflect.TypeFor[*Socket]())
+	}
+
+	timeout := time.Duration(-1)
+	if len(vals) == 2 {
+		timeout, err = durationMS(vals[1])
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	return s, timeout, nil
+}
@@ -0,0 +1,70 @@
+package extract_test
+
+import (
+	"errors"
+	"testing"
+
+	"deedles.dev/extract"
+)
+
+func TestSocketTCP(t *testing.T) {
+	const src = `
+	(let (:ok ln) (Socket.listen :tcp "127.0.0.1:0"))
+	(let server (func (server parent)
+		(let (:ok conn) (Socket.accept ln 1000))
+		(let (:ok data) (Socket.recv conn 1000))
+		(Socket.send conn (String.to_upper data))
+		(send parent :done)
+		(receive
+			(:stop (Socket.close conn))
+			(after 5000 :timeout))
+	))
+	(let pid (spawn server (self)))
+
+	(let (:ok conn) (Socket.connect :tcp (Socket.address ln)))
+	(Socket.send conn "hello")
+	(let reply (Socket.recv conn 1000))
+	(let done (receive (:done :done) (after 1000 :timeout)))
+	(let timeout (Socket.recv conn 10))
+	(send pid :stop)
+	(list
+		done
+		reply
+		timeout
+		(Socket.close conn)
+		(Socket.close ln)
+	)
+	`
+	result := runScript(t, src, true)
+	ok, errAtom := extract.MakeAtom("ok"), extract.MakeAtom("error")
+	checkList(t, result,
+		extract.MakeAtom("done"),
+		extract.TupleOf(ok, "HELLO"),
+		extract.TupleOf(errAtom, extract.MakeAtom("timeout")),
+		ok,
+		ok,
+	)
+}
+
+func TestSocketUDP(t *testing.T) {
+	const src = `
+	(let (:ok a) (Socket.listen :udp "127.0.0.1:0"))
+	(let (:ok b) (Socket.listen :udp "127.0.0.1:0"))
+	(Socket.send_to a (Socket.address b) "ping")
+	(let (:ok (data _)) (Socket.recv b 1000))
+	(Socket.close a)
+	(Socket.close b)
+	data
+	`
+	result := runScript(t, src, true)
+	if result != "ping" {
+		t.Fatalf("%#v", result)
+	}
+}
+
+func TestWithoutNetwork(t *testing.T) {
+	_, result := runInDir(t, `(Socket.listen :tcp "127.0.0.1:0")`, extract.WithoutNetwork())
+	if err, _ := result.(error); !errors.Is(err, extract.ErrNetworkDisabled) {
+		t.Fatalf("%#v", result)
+	}
+}
@@ -1,16 +1,81 @@
 package extract
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
+	"regexp"
 	"slices"
+	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"deedles.dev/xiter"
+)
+
+var (
+	okAtom    = MakeAtom("ok")
+	errorAtom = MakeAtom("error")
 )
 
+// okResult returns the tagged result tuple {:ok, v}.
+func okResult(v any) *Tuple {
+	return TupleOf(okAtom, v)
+}
+
+// errorResult returns the tagged result tuple {:error, reason}.
+func errorResult(reason any) *Tuple {
+	return TupleOf(errorAtom, reason)
+}
+
+// numErrorReason converts an error from strconv into an atom that
+// describes why the conversion failed.
+func numErrorReason(err error) Atom {
+	if errors.Is(err, strconv.ErrRange) {
+		return MakeAtom("out_of_range")
+	}
+	return MakeAtom("invalid")
+}
+
 // std is the Extract standard library in the form of a map of module
 // names to modules.
 var std = map[Atom]*Module{
-	MakeAtom("String"): stdString(),
+	MakeAtom("String"):     stdString(),
+	MakeAtom("Task"):       stdTask(),
+	MakeAtom("Supervisor"): stdSupervisor(),
+	MakeAtom("IO"):         stdIO(),
+	MakeAtom("List"):       stdList(),
+	MakeAtom("Enum"):       stdEnum(),
+	MakeAtom("Map"):        stdMap(),
+	MakeAtom("Stream"):     stdStream(),
+	MakeAtom("Integer"):    stdInteger(),
+	MakeAtom("Float"):      stdFloat(),
+	MakeAtom("Bitwise"):    stdBitwise(),
+	MakeAtom("Atom"):       stdAtom(),
+	MakeAtom("Tuple"):      stdTuple(),
+	MakeAtom("Range"):      stdRange(),
+	MakeAtom("File"):       stdFile(),
+	MakeAtom("Path"):       stdPath(),
+	MakeAtom("Dir"):        stdDir(),
+	MakeAtom("Timer"):      stdTimer(),
+	MakeAtom("Context"):    stdContext(),
+	MakeAtom("Error"):      stdError(),
+	MakeAtom("Random"):     stdRandom(),
+	MakeAtom("Socket"):     stdSocket(),
+	MakeAtom("Logger"):     stdLogger(),
+	MakeAtom("Module"):     stdModule(),
+	MakeAtom("Table"):      stdTable(),
+	MakeAtom("Binary"):     stdBinary(),
+	MakeAtom("Gzip"):       stdGzip(),
+	MakeAtom("Zlib"):       stdZlib(),
+	MakeAtom("Zip"):        stdZip(),
+	MakeAtom("UUID"):       stdUUID(),
+	MakeAtom("Config"):     stdConfig(),
+	MakeAtom("Template"):   stdTemplate(),
+	MakeAtom("Env"):        stdEnv(),
+	MakeAtom("Testing"):    stdTesting(),
+	MakeAtom("Bench"):      stdBench(),
 }
 
 func stdString() *Module {
@@ -42,6 +107,280 @@ func stdString() *Module {
 
 			return env, strings.ToLower(str)
 		}),
+		MakeIdent("runes"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 1 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 1}
+			}
+
+			_, head := Eval(env, args.Head(), nil)
+			str, ok := head.(string)
+			if !ok {
+				return env, NewTypeError(head, reflect.TypeFor[string]())
+			}
+
+			return env, CollectList(xiter.Map(slices.Values([]rune(str)), func(r rune) Rune { return Rune(r) }))
+		}),
+		MakeIdent("from_runes"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 1 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 1}
+			}
+
+			_, head := Eval(env, args.Head(), nil)
+			list, ok := head.(*List)
+			if !ok {
+				return env, NewTypeError(head, reflect.TypeFor[*List]())
+			}
+
+			var sb strings.Builder
+			for v := range list.All() {
+				r, ok := v.(Rune)
+				if !ok {
+					return env, NewTypeError(v, reflect.TypeFor[Rune]())
+				}
+				sb.WriteRune(rune(r))
+			}
+			return env, sb.String()
+		}),
+		MakeIdent("split"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			str, sep, n, err := evalSplitArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			return env, CollectList(slices.Values(strings.SplitN(str, sep, n)))
+		}),
+		MakeIdent("split_regex"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			str, pattern, n, err := evalSplitArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return env, err
+			}
+			return env, CollectList(slices.Values(re.Split(str, n)))
+		}),
+		MakeIdent("join"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 1 && args.Len() != 2 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: -1}
+			}
+
+			vals, err := evalArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			list, ok := vals[0].(*List)
+			if !ok {
+				return env, NewTypeError(vals[0], reflect.TypeFor[*List]())
+			}
+			var sep string
+			if len(vals) == 2 {
+				sep, ok = vals[1].(string)
+				if !ok {
+					return env, NewTypeError(vals[1], reflect.TypeFor[string]())
+				}
+			}
+
+			var sb strings.Builder
+			first := true
+			for v := range list.All() {
+				str, ok := v.(string)
+				if !ok {
+					return env, NewTypeError(v, reflect.TypeFor[string]())
+				}
+				if !first {
+					sb.WriteString(sep)
+				}
+				first = false
+				sb.WriteString(str)
+			}
+			return env, sb.String()
+		}),
+		MakeIdent("trim"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			strs, err := evalStrings(env, args, 1, 2)
+			if err != nil {
+				return env, err
+			}
+			if len(strs) == 1 {
+				return env, strings.TrimSpace(strs[0])
+			}
+			return env, strings.Trim(strs[0], strs[1])
+		}),
+		MakeIdent("trim_leading"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			strs, err := evalStrings(env, args, 1, 2)
+			if err != nil {
+				return env, err
+			}
+			if len(strs) == 1 {
+				return env, strings.TrimLeftFunc(strs[0], unicode.IsSpace)
+			}
+			return env, strings.TrimLeft(strs[0], strs[1])
+		}),
+		MakeIdent("trim_trailing"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			strs, err := evalStrings(env, args, 1, 2)
+			if err != nil {
+				return env, err
+			}
+			if len(strs) == 1 {
+				return env, strings.TrimRightFunc(strs[0], unicode.IsSpace)
+			}
+			return env, strings.TrimRight(strs[0], strs[1])
+		}),
+		MakeIdent("pad_leading"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			str, padding, err := evalPadArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			return env, padding + str
+		}),
+		MakeIdent("pad_trailing"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			str, padding, err := evalPadArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			return env, str + padding
+		}),
+		MakeIdent("replace"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			strs, err := evalStrings(env, args, 3, 3)
+			if err != nil {
+				return env, err
+			}
+			return env, strings.ReplaceAll(strs[0], strs[1], strs[2])
+		}),
+		MakeIdent("replace_first"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			strs, err := evalStrings(env, args, 3, 3)
+			if err != nil {
+				return env, err
+			}
+			return env, strings.Replace(strs[0], strs[1], strs[2], 1)
+		}),
+		MakeIdent("length"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			strs, err := evalStrings(env, args, 1, 1)
+			if err != nil {
+				return env, err
+			}
+			return env, int64(utf8.RuneCountInString(strs[0]))
+		}),
+		MakeIdent("at"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			str, i, err := evalStringInt(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			runes := []rune(str)
+			n, ok := resolveIndex(i, len(runes))
+			if !ok {
+				return env, &IndexError{Index: i, Len: len(runes)}
+			}
+			return env, Rune(runes[n])
+		}),
+		MakeIdent("slice"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			str, start, length, err := evalSliceArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			runes := []rune(str)
+			lo, hi := sliceBounds(start, length, len(runes))
+			return env, string(runes[lo:hi])
+		}),
+		MakeIdent("graphemes"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			strs, err := evalStrings(env, args, 1, 1)
+			if err != nil {
+				return env, err
+			}
+			return env, CollectList(slices.Values(graphemes(strs[0])))
+		}),
+		MakeIdent("grapheme_length"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			strs, err := evalStrings(env, args, 1, 1)
+			if err != nil {
+				return env, err
+			}
+			return env, int64(len(graphemes(strs[0])))
+		}),
+		MakeIdent("grapheme_at"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			str, i, err := evalStringInt(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			clusters := graphemes(str)
+			n, ok := resolveIndex(i, len(clusters))
+			if !ok {
+				return env, &IndexError{Index: i, Len: len(clusters)}
+			}
+			return env, clusters[n]
+		}),
+		MakeIdent("grapheme_slice"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			str, start, length, err := evalSliceArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			clusters := graphemes(str)
+			lo, hi := sliceBounds(start, length, len(clusters))
+			return env, strings.Join(clusters[lo:hi], "")
+		}),
+		MakeIdent("contains?"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			strs, err := evalStrings(env, args, 2, 2)
+			if err != nil {
+				return env, err
+			}
+			return env, strings.Contains(strs[0], strs[1])
+		}),
+		MakeIdent("starts_with?"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			strs, err := evalStrings(env, args, 2, 2)
+			if err != nil {
+				return env, err
+			}
+			return env, strings.HasPrefix(strs[0], strs[1])
+		}),
+		MakeIdent("ends_with?"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			strs, err := evalStrings(env, args, 2, 2)
+			if err != nil {
+				return env, err
+			}
+			return env, strings.HasSuffix(strs[0], strs[1])
+		}),
+		MakeIdent("index_of"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			strs, err := evalStrings(env, args, 2, 2)
+			if err != nil {
+				return env, err
+			}
+
+			i := strings.Index(strs[0], strs[1])
+			if i < 0 {
+				return env, int64(-1)
+			}
+			return env, int64(utf8.RuneCountInString(strs[0][:i]))
+		}),
+		MakeIdent("to_integer"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			str, base, err := evalNumString(env, args, 10)
+			if err != nil {
+				return env, err
+			}
+			if base < 2 || base > 36 {
+				return env, ErrInvalidBase
+			}
+
+			n, err := strconv.ParseInt(str, int(base), 64)
+			if err != nil {
+				return env, errorResult(numErrorReason(err))
+			}
+			return env, okResult(n)
+		}),
+		MakeIdent("to_float"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			strs, err := evalStrings(env, args, 1, 1)
+			if err != nil {
+				return env, err
+			}
+
+			f, err := strconv.ParseFloat(strs[0], 64)
+			if err != nil {
+				return env, errorResult(numErrorReason(err))
+			}
+			return env, okResult(f)
+		}),
 		MakeIdent("format"): EvalFunc(func(env *Env, args *List) (*Env, any) {
 			if args.Len() == 0 {
 				return env, &ArgumentNumError{Num: args.Len(), Expected: -1}
@@ -60,3 +399,264 @@ func stdString() *Module {
 
 	return &m
 }
+
+// evalStrings evaluates between min and max arguments, all of which
+// must be strings.
+func evalStrings(env *Env, args *List, min, max int) ([]string, error) {
+	if args.Len() < min || args.Len() > max {
+		expected := -1
+		if min == max {
+			expected = min
+		}
+		return nil, &ArgumentNumError{Num: args.Len(), Expected: expected}
+	}
+
+	vals, err := evalArgs(env, args)
+	if err != nil {
+		return nil, err
+	}
+
+	strs := make([]string, 0, len(vals))
+	for _, v := range vals {
+		str, ok := v.(string)
+		if !ok {
+			return nil, NewTypeError(v, reflect.TypeFor[string]())
+		}
+		strs = append(strs, str)
+	}
+	return strs, nil
+}
+
+// evalPadArgs evaluates the arguments to the padding functions of the
+// String module, which are a string, the length in runes to pad it
+// to, and an optional string to pad it with that defaults to a space.
+// It returns the string and the padding that needs to be added to it.
+func evalPadArgs(env *Env, args *List) (str, padding string, err error) {
+	if args.Len() != 2 && args.Len() != 3 {
+		return "", "", &ArgumentNumError{Num: args.Len(), Expected: -1}
+	}
+
+	vals, err := evalArgs(env, args)
+	if err != nil {
+		return "", "", err
+	}
+	str, ok := vals[0].(string)
+	if !ok {
+		return "", "", NewTypeError(vals[0], reflect.TypeFor[string]())
+	}
+	n, ok := vals[1].(int64)
+	if !ok {
+		return "", "", NewTypeError(vals[1], reflect.TypeFor[int64]())
+	}
+	pad := " "
+	if len(vals) == 3 {
+		pad, ok = vals[2].(string)
+		if !ok {
+			return "", "", NewTypeError(vals[2], reflect.TypeFor[string]())
+		}
+		if pad == "" {
+			return "", "", errors.New("padding must not be empty")
+		}
+	}
+
+	need := int(n) - utf8.RuneCountInString(str)
+	if need <= 0 {
+		return str, "", nil
+	}
+	runes := []rune(pad)
+	padded := make([]rune, need)
+	for i := range padded {
+		padded[i] = runes[i%len(runes)]
+	}
+	return str, string(padded), nil
+}
+
+// evalNumString evaluates a string argument followed by an optional
+// integer, such as a base, that defaults to def.
+func evalNumString(env *Env, args *List, def int64) (string, int64, error) {
+	if args.Len() == 1 {
+		strs, err := evalStrings(env, args, 1, 1)
+		if err != nil {
+			return "", 0, err
+		}
+		return strs[0], def, nil
+	}
+	if args.Len() != 2 {
+		return "", 0, &ArgumentNumError{Num: args.Len(), Expected: -1}
+	}
+	return evalStringInt(env, args)
+}
+
+// evalStringInt evaluates exactly two arguments, a string and an
+// integer.
+func evalStringInt(env *Env, args *List) (string, int64, error) {
+	if args.Len() != 2 {
+		return "", 0, &ArgumentNumError{Num: args.Len(), Expected: 2}
+	}
+
+	vals, err := evalArgs(env, args)
+	if err != nil {
+		return "", 0, err
+	}
+	str, ok := vals[0].(string)
+	if !ok {
+		return "", 0, NewTypeError(vals[0], reflect.TypeFor[string]())
+	}
+	i, ok := vals[1].(int64)
+	if !ok {
+		return "", 0, NewTypeError(vals[1], reflect.TypeFor[int64]())
+	}
+	return str, i, nil
+}
+
+// evalSliceArgs evaluates the arguments to the slicing functions of
+// the String module, which are a string, a start index, and a length.
+func evalSliceArgs(env *Env, args *List) (str string, start, length int64, err error) {
+	if args.Len() != 3 {
+		return "", 0, 0, &ArgumentNumError{Num: args.Len(), Expected: 3}
+	}
+
+	vals, err := evalArgs(env, args)
+	if err != nil {
+		return "", 0, 0, err
+	}
+	str, ok := vals[0].(string)
+	if !ok {
+		return "", 0, 0, NewTypeError(vals[0], reflect.TypeFor[string]())
+	}
+	for _, v := range vals[1:] {
+		if _, ok := v.(int64); !ok {
+			return "", 0, 0, NewTypeError(v, reflect.TypeFor[int64]())
+		}
+	}
+	return str, vals[1].(int64), vals[2].(int64), nil
+}
+
+// resolveIndex resolves i as an index into a sequence of length n.
+// Negative indices count backwards from the end of the sequence. If
+// the index is out of range, it returns false.
+func resolveIndex(i int64, n int) (int, bool) {
+	if i < 0 {
+		i += int64(n)
+	}
+	if i < 0 || i >= int64(n) {
+		return 0, false
+	}
+	return int(i), true
+}
+
+// sliceBounds returns the bounds of the slice of a sequence of length
+// n that starts at start and contains up to length elements. A
+// negative start counts backwards from the end of the sequence. The
+// bounds are clamped to the sequence, so a slice that is entirely out
+// of range is empty.
+func sliceBounds(start, length int64, n int) (lo, hi int) {
+	if start < 0 {
+		start = max(start+int64(n), 0)
+	}
+	start = min(start, int64(n))
+	end := start + min(max(length, 0), int64(n)-start)
+	return int(start), int(end)
+}
+
+// evalSplitArgs evaluates the arguments to the split functions of the
+// String module, which are a string, a separator, and an optional
+// limit on the number of substrings to return. If no limit is given,
+// n is -1, meaning no limit.
+func evalSplitArgs(env *Env, args *List) (str, sep string, n int, err error) {
+	if args.Len() != 2 && args.Len() != 3 {
+		return "", "", 0, &ArgumentNumError{Num: args.Len(), Expected: -1}
+	}
+
+	vals, err := evalArgs(env, args)
+	if err != nil {
+		return "", "", 0, err
+	}
+	for _, v := range vals[:2] {
+		if _, ok := v.(string); !ok {
+			return "", "", 0, NewTypeError(v, reflect.TypeFor[string]())
+		}
+	}
+
+	n = -1
+	if len(vals) == 3 {
+		limit, ok := vals[2].(int64)
+		if !ok {
+			return "", "", 0, NewTypeError(vals[2], reflect.TypeFor[int64]())
+		}
+		n = int(limit)
+	}
+	return vals[0].(string), vals[1].(string), n, nil
+}
+
+// evalArgs evaluates every element of args, returning the first error
+// that any of them evaluate to.
+func evalArgs(env *Env, args *List) ([]any, error) {
+	vals := make([]any, 0, args.Len())
+	for v := range EvalAll(env, args.All()) {
+		if err, ok := v.(error); ok {
+			return nil, err
+		}
+		vals = append(vals, v)
+	}
+	return vals, nil
+}
+
+// callFunc calls fn with already evaluated args, such as for calling
+// a callback that was passed to a standard library function. Functions
+// declared in Extract code are called directly so that the arguments
+// aren't evaluated again. Other functions, such as builtins, evaluate
+// their arguments themselves, so arguments that would do something
+// when evaluated are wrapped in [evaluated] first.
+func callFunc(env *Env, fn any, args ...any) (any, error) {
+	var r any
+	if f, ok := fn.(*Func); ok {
+		_, r = f.call(env, env.pos, ListOf(args...))
+	} else {
+		wrapped := make([]any, len(args))
+		for i, arg := range args {
+			if _, ok := arg.(Evaluator); ok {
+				arg = evaluated{v: arg}
+			}
+			wrapped[i] = arg
+		}
+		_, r = Eval(env, fn, callArgs(ListOf(wrapped...)))
+	}
+	if err, ok := r.(error); ok {
+		return nil, err
+	}
+	return r, nil
+}
+
+// evaluated is a value that has already been evaluated. Evaluating it
+// results in the value itself, rather than in what the value would
+// evaluate to, so that a builtin that is passed it as an argument
+// doesn't evaluate the value a second time.
+type evaluated struct {
+	v any
+}
+
+func (e evaluated) Eval(env *Env, args *List) (*Env, any) {
+	if args == nil {
+		return env, e.v
+	}
+	return Eval(env, e.v, args)
+}
+
+func (e evaluated) Inspect() string {
+	return Inspect(e.v)
+}
+
+// callPredicate calls fn like [callFunc] but requires that the result
+// is a bool.
+func callPredicate(env *Env, fn any, args ...any) (bool, error) {
+	r, err := callFunc(env, fn, args...)
+	if err != nil {
+		return false, err
+	}
+	ok, isBool := r.(bool)
+	if !isBool {
+		return false, NewTypeError(r, reflect.TypeFor[bool]())
+	}
+	return ok, nil
+}
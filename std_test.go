@@ -0,0 +1,210 @@
+package extract_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"deedles.dev/extract"
+)
+
+// checkList checks that result is a list whose elements are equal to
+// those of ex according to [extract.Equal].
+func checkList(t *testing.T, result any, ex ...any) {
+	t.Helper()
+
+	list, ok := result.(*extract.List)
+	if !ok {
+		t.Fatalf("%#v", result)
+	}
+	r := slices.Collect(list.All())
+	if len(r) != len(ex) {
+		t.Fatalf("%v", r)
+	}
+	for i := range r {
+		if !extract.Equal(r[i], ex[i]) {
+			t.Errorf("%v: %v != %v", i, extract.Inspect(r[i]), extract.Inspect(ex[i]))
+		}
+	}
+}
+
+// checkTuple checks that result is a tuple whose elements are equal to
+// those of ex according to [extract.Equal].
+func checkTuple(t *testing.T, result any, ex ...any) {
+	t.Helper()
+
+	if !extract.Equal(result, extract.TupleOf(ex...)) {
+		t.Fatalf("%v != %v", extract.Inspect(result), extract.Inspect(extract.TupleOf(ex...)))
+	}
+}
+
+func TestStringSplitJoin(t *testing.T) {
+	const src = `
+	(list
+		(String.split "a,b,,c" ",")
+		(String.split "a,b,c" "," 2)
+		(String.split_regex "a1b22c" "[0-9]+")
+		(String.split_regex "a1b22c" "[0-9]+" 2)
+		(String.join ["a" "b" "c"])
+		(String.join ["" "b" "c"] ", ")
+	)
+	`
+	result := runScript(t, src, true)
+	checkList(t, result,
+		extract.ListOf("a", "b", "", "c"),
+		extract.ListOf("a", "b,c"),
+		extract.ListOf("a", "b", "c"),
+		extract.ListOf("a", "b22c"),
+		"abc",
+		", b, c",
+	)
+
+	result = runScript(t, `(String.join ["a" 1])`, false)
+	if err, _ := result.(error); !errors.As(err, new(*extract.TypeError)) {
+		t.Fatalf("%#v", result)
+	}
+	result = runScript(t, `(String.split_regex "a" "(")`, false)
+	if _, ok := result.(error); !ok {
+		t.Fatalf("%#v", result)
+	}
+}
+
+func TestStringTrimPadReplace(t *testing.T) {
+	const src = `
+	(list
+		(String.trim "  a b \n")
+		(String.trim "xxaxx" "x")
+		(String.trim_leading "  a  ")
+		(String.trim_leading "xxaxx" "x")
+		(String.trim_trailing "  a  ")
+		(String.trim_trailing "xxaxx" "x")
+		(String.pad_leading "7" 3)
+		(String.pad_leading "7" 6 "ab")
+		(String.pad_trailing "é" 3 "-")
+		(String.pad_trailing "long" 2)
+		(String.replace "a-b-c" "-" "+")
+		(String.replace_first "a-b-c" "-" "+")
+	)
+	`
+	result := runScript(t, src, true)
+	checkList(t, result,
+		"a b",
+		"a",
+		"a  ",
+		"axx",
+		"  a",
+		"xxa",
+		"  7",
+		"ababa7",
+		"é--",
+		"long",
+		"a+b+c",
+		"a+b-c",
+	)
+
+	for _, src := range []string{
+		`(String.trim 1)`,
+		`(String.pad_leading "a" "3")`,
+		`(String.replace "a" "b")`,
+		`(String.pad_trailing "a" 3 "")`,
+	} {
+		result := runScript(t, src, false)
+		if _, ok := result.(error); !ok {
+			t.Errorf("%v: %#v", src, result)
+		}
+	}
+}
+
+func TestStringRunes(t *testing.T) {
+	const src = `
+	(let s "héllo")
+	(let flag "a🇯🇵é👍🏽")
+	(list
+		(String.length s)
+		(String.at s 1)
+		(String.at s -1)
+		(String.slice s 1 3)
+		(String.slice s -2 10)
+		(String.slice s 10 2)
+		(String.length flag)
+		(String.graphemes flag)
+		(String.grapheme_length flag)
+		(String.grapheme_at flag 1)
+		(String.grapheme_slice flag 1 2)
+	)
+	`
+	result := runScript(t, src, true)
+	checkList(t, result,
+		int64(5),
+		extract.Rune('é'),
+		extract.Rune('o'),
+		"éll",
+		"lo",
+		"",
+		int64(7),
+		extract.ListOf("a", "🇯🇵", "é", "👍🏽"),
+		int64(4),
+		"🇯🇵",
+		"🇯🇵é",
+	)
+
+	result = runScript(t, `(String.at "abc" 3)`, false)
+	if err, _ := result.(error); !errors.As(err, new(*extract.IndexError)) {
+		t.Fatalf("%#v", result)
+	}
+}
+
+func TestStringPredicates(t *testing.T) {
+	const src = `
+	(list
+		(String.contains? "extract" "tra")
+		(String.contains? "extract" "z")
+		(String.starts_with? "extract" "ex")
+		(String.starts_with? "extract" "tract")
+		(String.ends_with? "extract" "tract")
+		(String.ends_with? "extract" "ex")
+		(String.index_of "héllo" "llo")
+		(String.index_of "héllo" "z")
+	)
+	`
+	result := runScript(t, src, true)
+	checkList(t, result, true, false, true, false, true, false, int64(2), int64(-1))
+}
+
+func TestNumericConversion(t *testing.T) {
+	const src = `
+	(list
+		(String.to_integer "42")
+		(String.to_integer "-ff" 16)
+		(String.to_integer "4.2")
+		(String.to_integer "99999999999999999999")
+		(String.to_float "4.25")
+		(String.to_float "x")
+		(Integer.to_string 42)
+		(Integer.to_string 255 16)
+		(Integer.to_string -5 2)
+		(Float.to_string 2.0)
+		(Float.to_string 3.14159 2)
+	)
+	`
+	ok, errAtom := extract.MakeAtom("ok"), extract.MakeAtom("error")
+	result := runScript(t, src, true)
+	checkList(t, result,
+		extract.TupleOf(ok, int64(42)),
+		extract.TupleOf(ok, int64(-255)),
+		extract.TupleOf(errAtom, extract.MakeAtom("invalid")),
+		extract.TupleOf(errAtom, extract.MakeAtom("out_of_range")),
+		extract.TupleOf(ok, 4.25),
+		extract.TupleOf(errAtom, extract.MakeAtom("invalid")),
+		"42",
+		"ff",
+		"-101",
+		"2.0",
+		"3.14",
+	)
+
+	result = runScript(t, `(Integer.to_string 3 1)`, false)
+	if err, _ := result.(error); !errors.Is(err, extract.ErrInvalidBase) {
+		t.Fatalf("%#v", result)
+	}
+}
@@ -0,0 +1,176 @@
+package extract
+
+import (
+	"fmt"
+	"iter"
+	"reflect"
+)
+
+// Stream is a lazy, possibly infinite, sequence of values. Operations
+// on a stream, such as mapping a function over it, produce new
+// streams without evaluating anything until the stream is enumerated,
+// such as by a function from the Enum module.
+type Stream struct {
+	seq iter.Seq2[any, error]
+}
+
+// StreamOf returns a stream that yields the values yielded by seq.
+func StreamOf(seq iter.Seq[any]) *Stream {
+	return &Stream{seq: func(yield func(any, error) bool) {
+		for v := range seq {
+			if !yield(v, nil) {
+				return
+			}
+		}
+	}}
+}
+
+// Enumerate yields the values of the stream, evaluating whatever is
+// necessary to produce them.
+func (s *Stream) Enumerate() iter.Seq2[any, error] {
+	return s.seq
+}
+
+// Inspect returns a representation of the stream in the form
+// #Stream<address>. The contents of the stream are not evaluated.
+func (s *Stream) Inspect() string {
+	return fmt.Sprintf("#Stream<%p>", s)
+}
+
+func stdStream() *Module {
+	m := Module{name: MakeAtom("Stream")}
+	m.decls = map[Ident]any{
+		MakeIdent("map"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			e, fn, err := evalEnumFunc(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			return env, &Stream{seq: func(yield func(any, error) bool) {
+				for v, err := range e.Enumerate() {
+					if err == nil {
+						v, err = callFunc(env, fn, v)
+					}
+					if !yield(v, err) || err != nil {
+						return
+					}
+				}
+			}}
+		}),
+		MakeIdent("filter"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			e, fn, err := evalEnumFunc(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			return env, &Stream{seq: func(yield func(any, error) bool) {
+				for v, err := range e.Enumerate() {
+					ok := true
+					if err == nil {
+						ok, err = callPredicate(env, fn, v)
+					}
+					if err != nil {
+						yield(nil, err)
+						return
+					}
+					if ok && !yield(v, nil) {
+						return
+					}
+				}
+			}}
+		}),
+		MakeIdent("take"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 2 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 2}
+			}
+
+			vals, err := evalArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			e, err := enumerable(vals[0])
+			if err != nil {
+				return env, err
+			}
+			n, ok := vals[1].(int64)
+			if !ok {
+				return env, NewTypeError(vals[1], reflect.TypeFor[int64]())
+			}
+
+			return env, &Stream{seq: func(yield func(any, error) bool) {
+				if n <= 0 {
+					return
+				}
+
+				i := n
+				for v, err := range e.Enumerate() {
+					if !yield(v, err) || err != nil {
+						return
+					}
+					i--
+					if i <= 0 {
+						return
+					}
+				}
+			}}
+		}),
+		MakeIdent("iterate"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 2 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 2}
+			}
+
+			vals, err := evalArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			start, fn := vals[0], vals[1]
+			return env, &Stream{seq: func(yield func(any, error) bool) {
+				v := start
+				for {
+					if !yield(v, nil) {
+						return
+					}
+
+					var err error
+					v, err = callFunc(env, fn, v)
+					if err != nil {
+						yield(nil, err)
+						return
+					}
+				}
+			}}
+		}),
+		MakeIdent("cycle"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			e, err := evalEnum(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			return env, &Stream{seq: func(yield func(any, error) bool) {
+				for {
+					empty := true
+					for v, err := range e.Enumerate() {
+						empty = false
+						if !yield(v, err) || err != nil {
+							return
+						}
+					}
+					if empty {
+						return
+					}
+
+					// Enumerating something like a list doesn't evaluate
+					// anything, so count a step between passes to make
+					// sure that an infinite cycle can still be stopped.
+					if err := env.step(); err != nil {
+						yield(nil, err)
+						return
+					}
+				}
+			}}
+		}),
+	}
+
+	return &m
+}
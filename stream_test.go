@@ -0,0 +1,66 @@
+package extract_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"deedles.dev/extract"
+	"deedles.dev/extract/parser"
+)
+
+func TestStream(t *testing.T) {
+	const src = `
+	(let naturals (Stream.iterate 1 (func (inc n) (n + 1))))
+	(let evens (Stream.filter naturals (func (even? n) (eq (n % 2) 0))))
+	(list
+		(Enum.to_list (Stream.take (Stream.map evens (func (square n) (n * n))) 3))
+		(Enum.to_list (Stream.take (Stream.cycle [:a :b]) 5))
+		(Enum.sum (Stream.take naturals 100))
+		(Enum.find naturals (func (big? n) (eq (n * n) 144)))
+	)
+	`
+	result := runScript(t, src, true)
+
+	a, b := extract.MakeAtom("a"), extract.MakeAtom("b")
+	ex := []any{
+		extract.ListOf(int64(4), int64(16), int64(36)),
+		extract.ListOf(a, b, a, b, a),
+		int64(5050),
+		int64(12),
+	}
+	r := slices.Collect(result.(*extract.List).All())
+	if len(r) != len(ex) {
+		t.Fatalf("%v", r)
+	}
+	for i := range r {
+		if !extract.Equal(r[i], ex[i]) {
+			t.Errorf("%v: %v != %v", i, r[i], ex[i])
+		}
+	}
+}
+
+func TestStreamError(t *testing.T) {
+	result := runScript(t, `(Enum.to_list (Stream.map [1 :a 3] (func (inc n) (n + 1))))`, false)
+	if _, ok := result.(error); !ok {
+		t.Fatalf("%#v", result)
+	}
+}
+
+func TestStreamCancel(t *testing.T) {
+	s, err := parser.ParseString(t.Name(), `(Enum.count (Stream.cycle [1]))`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, result := extract.Run(extract.New(ctx), s.All())
+	var cerr *extract.CancelledError
+	if err, _ := result.(error); !errors.As(err, &cerr) {
+		t.Fatalf("%#v", result)
+	}
+}
@@ -0,0 +1,250 @@
+package extract
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+
+	"deedles.dev/xsync/otp"
+)
+
+// ErrTooManyRestarts is returned by a supervisor that exits because
+// its children exited more often than its restart limit allows.
+var ErrTooManyRestarts = errors.New("supervisor exceeded restart limit")
+
+// Strategy determines how a [Supervisor] reacts to one of its
+// children exiting.
+type Strategy int
+
+const (
+	// OneForOne restarts only the child that exited.
+	OneForOne Strategy = iota
+
+	// OneForAll stops all of the other children and then restarts all
+	// of them when any one of them exits.
+	OneForAll
+)
+
+var strategies = map[Atom]Strategy{
+	MakeAtom("one_for_one"): OneForOne,
+	MakeAtom("one_for_all"): OneForAll,
+}
+
+// ChildSpec describes a child process of a [Supervisor]. The child is
+// started by calling Func with Args.
+type ChildSpec struct {
+	Func any
+	Args *List
+}
+
+// Supervisor starts a set of child processes and restarts them
+// whenever they exit. If the supervisor's children exit more than
+// MaxRestarts times within Period, the supervisor stops all of them
+// and exits with [ErrTooManyRestarts].
+type Supervisor struct {
+	Strategy    Strategy
+	MaxRestarts int
+	Period      time.Duration
+
+	proc     Process
+	m        sync.Mutex
+	children []Process
+}
+
+// StartSupervisor starts a supervisor for the given children with
+// a default restart limit of 3 restarts every 5 seconds. The
+// supervisor, along with all of its children, is stopped when the
+// context of env is canceled.
+func StartSupervisor(env *Env, strategy Strategy, specs []ChildSpec) *Supervisor {
+	s := Supervisor{
+		Strategy:    strategy,
+		MaxRestarts: 3,
+		Period:      5 * time.Second,
+		children:    make([]Process, len(specs)),
+	}
+
+	started := make(chan struct{})
+	proc := otp.Go(func(ctx context.Context) error {
+		<-started
+		stop := context.AfterFunc(env.Context(), s.proc.Stop)
+		defer stop()
+
+		return s.run(env.WithContext(ctx), specs)
+	})
+	s.proc = Process{proc: proc, mb: proc.Mailbox()}
+	close(started)
+
+	return &s
+}
+
+func (s *Supervisor) run(env *Env, specs []ChildSpec) error {
+	mb := s.proc.mb
+	done := new(receiveSignal)
+	stop := context.AfterFunc(env.Context(), func() { mb.Send(done) })
+	defer stop()
+	defer s.stopAll()
+
+	for i := range specs {
+		s.start(env, i, specs[i])
+	}
+
+	var restarts []time.Time
+	for {
+		switch msg := otp.Recv[any](mb, nil).(type) {
+		case *receiveSignal:
+			return nil
+
+		case otp.MonitoredProcessExited:
+			i := s.indexOf(msg.Proc)
+			if i < 0 {
+				continue
+			}
+
+			now := time.Now()
+			restarts = append(restarts, now)
+			restarts = slices.DeleteFunc(restarts, func(t time.Time) bool { return now.Sub(t) > s.Period })
+			if len(restarts) > s.MaxRestarts {
+				return ErrTooManyRestarts
+			}
+
+			switch s.Strategy {
+			case OneForOne:
+				s.start(env, i, specs[i])
+			case OneForAll:
+				s.stopAll()
+				for i := range specs {
+					s.start(env, i, specs[i])
+				}
+			}
+		}
+	}
+}
+
+func (s *Supervisor) start(env *Env, i int, spec ChildSpec) {
+	child := Spawn(env, spec.Func, spec.Args)
+	child.proc.Monitor(s.proc.mb)
+
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.children[i] = child
+}
+
+func (s *Supervisor) indexOf(proc *otp.Proc) int {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return slices.IndexFunc(s.children, func(p Process) bool { return p.proc == proc })
+}
+
+// stopAll stops all of the children and waits for them to exit.
+func (s *Supervisor) stopAll() {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	for i, child := range s.children {
+		if child.proc == nil {
+			continue
+		}
+		child.proc.Unmonitor(s.proc.mb)
+		child.Stop()
+		child.Wait()
+		s.children[i] = Process{}
+	}
+}
+
+// Children returns the currently running children of the
+// supervisor in the order of the specs that they were started from.
+func (s *Supervisor) Children() []Process {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return slices.Clone(s.children)
+}
+
+// Stop stops the supervisor and all of its children and waits for
+// them to exit.
+func (s *Supervisor) Stop() {
+	s.proc.Stop()
+	s.proc.Wait()
+}
+
+// Wait waits for the supervisor to exit and returns the error that it
+// exited with, if any.
+func (s *Supervisor) Wait() error {
+	return s.proc.Wait()
+}
+
+func (s *Supervisor) String() string {
+	return fmt.Sprintf("#Supervisor<%p>", s)
+}
+
+func stdSupervisor() *Module {
+	m := Module{name: MakeAtom("Supervisor")}
+	m.decls = map[Ident]any{
+		MakeIdent("start"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 2 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 2}
+			}
+
+			_, children := Eval(env, args.Head(), nil)
+			clist, ok := children.(*List)
+			if !ok {
+				return env, NewTypeError(children, reflect.TypeFor[*List]())
+			}
+
+			specs := make([]ChildSpec, 0, clist.Len())
+			for child := range clist.All() {
+				spec, ok := child.(*List)
+				if !ok || spec.Len() == 0 {
+					return env, NewTypeError(child, reflect.TypeFor[*List]())
+				}
+				specs = append(specs, ChildSpec{Func: spec.Head(), Args: spec.Tail()})
+			}
+
+			_, name := Eval(env, args.Tail().Head(), nil)
+			atom, ok := name.(Atom)
+			if !ok {
+				return env, NewTypeError(name, reflect.TypeFor[Atom]())
+			}
+			strategy, ok := strategies[atom]
+			if !ok {
+				return env, fmt.Errorf("unknown supervisor strategy %v", atom)
+			}
+
+			return env, StartSupervisor(env, strategy, specs)
+		}),
+		MakeIdent("children"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 1 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 1}
+			}
+
+			_, head := Eval(env, args.Head(), nil)
+			s, ok := head.(*Supervisor)
+			if !ok {
+				return env, NewTypeError(head, reflect.TypeFor[*Supervisor]())
+			}
+
+			return env, CollectList(slices.Values(s.Children()))
+		}),
+		MakeIdent("stop"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 1 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 1}
+			}
+
+			_, head := Eval(env, args.Head(), nil)
+			s, ok := head.(*Supervisor)
+			if !ok {
+				return env, NewTypeError(head, reflect.TypeFor[*Supervisor]())
+			}
+
+			s.Stop()
+			return env, s
+		}),
+	}
+
+	return &m
+}
@@ -0,0 +1,22 @@
+package extract_test
+
+import (
+	"testing"
+
+	"deedles.dev/extract"
+)
+
+func TestSupervisorRestart(t *testing.T) {
+	const src = `
+	(let worker (func (worker parent) (send parent :started)))
+	(let sup (Supervisor.start (list (list worker (self))) :one_for_one))
+	(receive (:started :ok))
+	(let result (receive (:started :restarted) (after 1000 :timeout)))
+	(Supervisor.stop sup)
+	result
+	`
+	result := runScript(t, src, true)
+	if result != extract.MakeAtom("restarted") {
+		t.Fatalf("%#v", result)
+	}
+}
@@ -0,0 +1,291 @@
+package extract
+
+import (
+	"fmt"
+	"iter"
+	"reflect"
+
+	"deedles.dev/xsync"
+)
+
+// Table is a mutable, concurrency-safe key-value store. Unlike a *Map,
+// a Table is shared by every process and binding that has a reference
+// to it, so changes made to it are visible everywhere. Keys are
+// compared with [Equal] and hashed with [Hash].
+//
+// A Table is implemented as a concurrent map from hashes to immutable
+// buckets, each of which is replaced atomically when modified.
+type Table struct {
+	buckets xsync.Map[uint64, *Map]
+}
+
+// NewTable returns a new, empty table.
+func NewTable() *Table {
+	return new(Table)
+}
+
+// Get returns the value associated with key. If there is no such
+// value, it returns false as the second return value.
+func (t *Table) Get(key any) (any, bool) {
+	b, _ := t.buckets.Load(Hash(key))
+	return b.Get(key)
+}
+
+// Put associates key with val, replacing any existing value.
+func (t *Table) Put(key, val any) {
+	h := Hash(key)
+	for {
+		b, loaded := t.buckets.LoadOrStore(h, MapOf(key, val))
+		if !loaded || t.buckets.CompareAndSwap(h, b, b.Put(key, val)) {
+			return
+		}
+	}
+}
+
+// PutNew associates key with val if key isn't already in the table.
+// It returns true if it did so.
+func (t *Table) PutNew(key, val any) bool {
+	h := Hash(key)
+	for {
+		b, loaded := t.buckets.LoadOrStore(h, MapOf(key, val))
+		if !loaded {
+			return true
+		}
+		if _, ok := b.Get(key); ok {
+			return false
+		}
+		if t.buckets.CompareAndSwap(h, b, b.Put(key, val)) {
+			return true
+		}
+	}
+}
+
+// Delete removes key from the table.
+func (t *Table) Delete(key any) {
+	h := Hash(key)
+	for {
+		b, ok := t.buckets.Load(h)
+		if !ok {
+			return
+		}
+		if _, ok := b.Get(key); !ok {
+			return
+		}
+
+		n := b.Delete(key)
+		if n.Len() == 0 {
+			if t.buckets.CompareAndDelete(h, b) {
+				return
+			}
+			continue
+		}
+		if t.buckets.CompareAndSwap(h, b, n) {
+			return
+		}
+	}
+}
+
+// Update atomically replaces the value associated with key with the
+// result of calling update with the current value, or associates it
+// with def if it isn't in the table. update may be called more than
+// once if the table is modified concurrently. If update returns an
+// error, the table is not modified and the error is returned.
+func (t *Table) Update(key, def any, update func(any) (any, error)) (any, error) {
+	h := Hash(key)
+	for {
+		b, ok := t.buckets.Load(h)
+		v := def
+		if cur, exists := b.Get(key); exists {
+			var err error
+			v, err = update(cur)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if !ok {
+			if _, loaded := t.buckets.LoadOrStore(h, MapOf(key, v)); !loaded {
+				return v, nil
+			}
+			continue
+		}
+		if t.buckets.CompareAndSwap(h, b, b.Put(key, v)) {
+			return v, nil
+		}
+	}
+}
+
+// Snapshot returns an immutable copy of the contents of the table.
+// Changes made to the table while the snapshot is being taken may or
+// may not be included in it.
+func (t *Table) Snapshot() (m *Map) {
+	t.buckets.Range(func(_ uint64, b *Map) bool {
+		for k, v := range b.trie().All() {
+			m = m.Put(k, v)
+		}
+		return true
+	})
+	return m
+}
+
+// Len returns the number of keys in the table.
+func (t *Table) Len() (n int) {
+	t.buckets.Range(func(_ uint64, b *Map) bool {
+		n += b.Len()
+		return true
+	})
+	return n
+}
+
+// All returns an iterator over a snapshot of the keys and values in
+// the table, in the order of their keys as defined by [Compare].
+func (t *Table) All() iter.Seq2[any, any] {
+	return func(yield func(any, any) bool) {
+		for k, v := range t.Snapshot().All() {
+			if !yield(k, v) {
+				return
+			}
+		}
+	}
+}
+
+// Enumerate yields the pairs in a snapshot of the table as two element
+// lists of the form [key value].
+func (t *Table) Enumerate() iter.Seq2[any, error] {
+	return t.Snapshot().Enumerate()
+}
+
+func (t *Table) String() string {
+	return fmt.Sprintf("#Table<%p>", t)
+}
+
+func stdTable() *Module {
+	m := Module{name: MakeAtom("Table")}
+	m.decls = map[Ident]any{
+		MakeIdent("new"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 0 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 0}
+			}
+			return env, NewTable()
+		}),
+		MakeIdent("put"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 3 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 3}
+			}
+
+			t, vals, err := evalTableArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			t.Put(vals[0], vals[1])
+			return env, t
+		}),
+		MakeIdent("put_new"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 3 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 3}
+			}
+
+			t, vals, err := evalTableArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			return env, t.PutNew(vals[0], vals[1])
+		}),
+		MakeIdent("get"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 2 && args.Len() != 3 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: -1}
+			}
+
+			t, vals, err := evalTableArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			v, ok := t.Get(vals[0])
+			if !ok && len(vals) == 2 {
+				return env, vals[1]
+			}
+			return env, v
+		}),
+		MakeIdent("delete"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 2 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 2}
+			}
+
+			t, vals, err := evalTableArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			t.Delete(vals[0])
+			return env, t
+		}),
+		MakeIdent("has_key?"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 2 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 2}
+			}
+
+			t, vals, err := evalTableArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			_, ok := t.Get(vals[0])
+			return env, ok
+		}),
+		MakeIdent("update"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 4 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 4}
+			}
+
+			t, vals, err := evalTableArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			v, err := t.Update(vals[0], vals[1], func(cur any) (any, error) {
+				return callFunc(env, vals[2], cur)
+			})
+			if err != nil {
+				return env, err
+			}
+			return env, v
+		}),
+		MakeIdent("size"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 1 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 1}
+			}
+
+			t, _, err := evalTableArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			return env, int64(t.Len())
+		}),
+		MakeIdent("to_map"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 1 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 1}
+			}
+
+			t, _, err := evalTableArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			return env, t.Snapshot()
+		}),
+	}
+
+	return &m
+}
+
+// evalTableArgs evaluates args, the first of which must be a *Table.
+// It returns the table and the rest of the evaluated arguments.
+func evalTableArgs(env *Env, args *List) (*Table, []any, error) {
+	vals, err := evalArgs(env, args)
+	if err != nil {
+		return nil, nil, err
+	}
+	t, ok := vals[0].(*Table)
+	if !ok {
+		return nil, nil, NewTypeError(vals[0], reflect.TypeFor[*Table]())
+	}
+	return t, vals[1:], nil
+}
@@ -0,0 +1,70 @@
+package extract_test
+
+import (
+	"sync"
+	"testing"
+
+	"deedles.dev/extract"
+)
+
+func TestTable(t *testing.T) {
+	const src = `
+	(let t (Table.new))
+	(Table.put t [1 2] :list)
+	(Table.put t :a 1)
+	(let t1 (Task.async (func (f) (Table.update t :a 0 (func (inc v) (add v 1))))))
+	(Task.await t1)
+	(list
+		(Table.get t [1 2])
+		(Table.get t :a)
+		(Table.get t :missing :default)
+		(Table.put_new t :a 5)
+		(Table.put_new t :b 5)
+		(Table.has_key? t :b)
+		(Table.size (Table.delete t :b))
+		(Table.has_key? t :b)
+		(Enum.to_list t)
+		(Map.get (Table.to_map t) :a)
+	)
+	`
+	a := extract.MakeAtom("a")
+	result := runScript(t, src, true)
+	checkList(t, result,
+		extract.MakeAtom("list"),
+		int64(2),
+		extract.MakeAtom("default"),
+		false,
+		true,
+		true,
+		int64(2),
+		false,
+		extract.ListOf(
+			extract.ListOf(a, int64(2)),
+			extract.ListOf(extract.ListOf(int64(1), int64(2)), extract.MakeAtom("list")),
+		),
+		int64(2),
+	)
+}
+
+func TestTableConcurrent(t *testing.T) {
+	table := extract.NewTable()
+	var wg sync.WaitGroup
+	for range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			table.Update("count", int64(1), func(v any) (any, error) {
+				return v.(int64) + 1, nil
+			})
+		}()
+	}
+	wg.Wait()
+
+	v, ok := table.Get("count")
+	if !ok || v != int64(100) {
+		t.Fatalf("%#v", v)
+	}
+	if table.Len() != 1 {
+		t.Fatal(table.Len())
+	}
+}
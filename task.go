@@ -0,0 +1,120 @@
+package extract
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"deedles.dev/xsync"
+)
+
+// ErrTimeout is returned when an operation that was given a timeout
+// does not complete in time.
+var ErrTimeout = errors.New("timed out")
+
+// Task is the result of an asynchronous function call started with
+// Task.async. Its result can be retrieved with Task.await.
+type Task struct {
+	result *xsync.Future[any]
+	cancel context.CancelFunc
+}
+
+// StartTask calls fn with args on a new goroutine in an Env derived
+// from env. The Env's context is canceled if the task is canceled or
+// if the context of env is.
+func StartTask(env *Env, fn any, args *List) *Task {
+	ctx, cancel := context.WithCancel(env.Context())
+	result, complete := xsync.NewFuture[any]()
+	go func() {
+		defer cancel()
+		_, r := Eval(env.WithContext(ctx), fn, callArgs(args))
+		complete(r)
+	}()
+
+	return &Task{result: result, cancel: cancel}
+}
+
+// Await waits for the task to complete and returns its result. If ctx
+// is canceled first, it returns the cause of the cancellation
+// instead.
+func (t *Task) Await(ctx context.Context) any {
+	select {
+	case <-t.result.Done():
+		return t.result.Get()
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+// Cancel cancels the context of the task. The task may not exit
+// immediately.
+func (t *Task) Cancel() {
+	t.cancel()
+}
+
+func (t *Task) String() string {
+	return fmt.Sprintf("#Task<%p>", t)
+}
+
+func stdTask() *Module {
+	m := Module{name: MakeAtom("Task")}
+	m.decls = map[Ident]any{
+		MakeIdent("async"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() == 0 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: -1}
+			}
+
+			_, fn := Eval(env, args.Head(), nil)
+			if err, ok := fn.(error); ok {
+				return env, err
+			}
+
+			fargs := CollectList(EvalAll(env, args.Tail().All()))
+			return env, StartTask(env, fn, fargs)
+		}),
+		MakeIdent("await"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 1 && args.Len() != 2 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: -1}
+			}
+
+			_, head := Eval(env, args.Head(), nil)
+			task, ok := head.(*Task)
+			if !ok {
+				return env, NewTypeError(head, reflect.TypeFor[*Task]())
+			}
+
+			ctx := env.Context()
+			if args.Len() == 2 {
+				_, ms := Eval(env, args.Tail().Head(), nil)
+				n, ok := ms.(int64)
+				if !ok {
+					return env, NewTypeError(ms, reflect.TypeFor[int64]())
+				}
+
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeoutCause(ctx, time.Duration(n)*time.Millisecond, ErrTimeout)
+				defer cancel()
+			}
+
+			return env, task.Await(ctx)
+		}),
+		MakeIdent("cancel"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 1 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 1}
+			}
+
+			_, head := Eval(env, args.Head(), nil)
+			task, ok := head.(*Task)
+			if !ok {
+				return env, NewTypeError(head, reflect.TypeFor[*Task]())
+			}
+
+			task.Cancel()
+			return env, task
+		}),
+	}
+
+	return &m
+}
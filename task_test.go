@@ -0,0 +1,33 @@
+package extract_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"deedles.dev/extract"
+)
+
+func TestTask(t *testing.T) {
+	const src = `
+	(let t1 (Task.async (func (f a b) (add a b)) 1 2))
+	(let t2 (Task.async String.to_upper "test"))
+	(list (Task.await t1) (Task.await t2))
+	`
+	result := runScript(t, src, true)
+	ex := []any{int64(3), "TEST"}
+	if s := slices.Collect(result.(*extract.List).All()); !slices.Equal(s, ex) {
+		t.Fatalf("%#v", s)
+	}
+}
+
+func TestTaskTimeout(t *testing.T) {
+	const src = `
+	(let t (Task.async (func (f) (receive (:never :received)))))
+	(Task.await t 10)
+	`
+	result := runScript(t, src, false)
+	if err, ok := result.(error); !ok || !errors.Is(err, extract.ErrTimeout) {
+		t.Fatalf("%#v", result)
+	}
+}
@@ -0,0 +1,115 @@
+package extract
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+)
+
+// Template is a compiled text/template created by Template.compile.
+type Template struct {
+	t *template.Template
+}
+
+func (t *Template) String() string {
+	return fmt.Sprintf("#Template<%p>", t)
+}
+
+// compileTemplate parses src as a text/template. Referring to a key
+// that is missing from the data that a template is rendered with is an
+// error.
+func compileTemplate(src string) (*Template, error) {
+	t, err := template.New("").Option("missingkey=error").Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	return &Template{t: t}, nil
+}
+
+// templateData converts v into a form that text/template can work
+// with. *Maps become map[string]any, with atom and string keys used as
+// is and other keys converted with [Inspect], so that templates can
+// access their fields as {{.name}}. *Lists and *Tuples become []any.
+func templateData(v any) any {
+	switch v := v.(type) {
+	case *Map:
+		m := make(map[string]any, v.Len())
+		for k, v := range v.All() {
+			var key string
+			switch k := k.(type) {
+			case Atom:
+				key = k.String()
+			case string:
+				key = k
+			default:
+				key = Inspect(k)
+			}
+			m[key] = templateData(v)
+		}
+		return m
+	case *List:
+		s := make([]any, 0, v.Len())
+		for v := range v.All() {
+			s = append(s, templateData(v))
+		}
+		return s
+	case *Tuple:
+		s := make([]any, 0, v.Len())
+		for v := range v.All() {
+			s = append(s, templateData(v))
+		}
+		return s
+	default:
+		return v
+	}
+}
+
+func stdTemplate() *Module {
+	m := Module{name: MakeAtom("Template")}
+	m.decls = map[Ident]any{
+		MakeIdent("compile"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			strs, err := evalStrings(env, args, 1, 1)
+			if err != nil {
+				return env, err
+			}
+
+			t, err := compileTemplate(strs[0])
+			if err != nil {
+				return env, errorResult(err.Error())
+			}
+			return env, okResult(t)
+		}),
+		MakeIdent("render"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 2 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 2}
+			}
+
+			vals, err := evalArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+
+			var t *Template
+			switch tmpl := vals[0].(type) {
+			case *Template:
+				t = tmpl
+			case string:
+				t, err = compileTemplate(tmpl)
+				if err != nil {
+					return env, errorResult(err.Error())
+				}
+			default:
+				return env, NewTypeError(tmpl, reflect.TypeFor[*Template](), reflect.TypeFor[string]())
+			}
+
+			var sb strings.Builder
+			if err := t.t.Execute(&sb, templateData(vals[1])); err != nil {
+				return env, errorResult(err.Error())
+			}
+			return env, okResult(sb.String())
+		}),
+	}
+
+	return &m
+}
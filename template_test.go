@@ -0,0 +1,29 @@
+package extract_test
+
+import (
+	"testing"
+
+	"deedles.dev/extract"
+)
+
+func TestTemplate(t *testing.T) {
+	const src = `
+	(let (:ok tmpl) (Template.compile "{{range .items}}{{.}},{{end}} {{.name}}"))
+	(list
+		(Template.render tmpl (Map.new :items [1 :two "three"] :name 'x'))
+		(Template.render "{{.a.b}}" (Map.new "a" (Map.new :b 2.5)))
+		(Template.render "{{.missing}}" (Map.new))
+		(Template.compile "{{")
+	)
+	`
+	result := runScript(t, src, true).(*extract.List)
+
+	ok := extract.MakeAtom("ok")
+	checkTuple(t, result.Head(), ok, "1,two,three, x")
+	checkTuple(t, result.Tail().Head(), ok, "2.5")
+	for v := range result.Tail().Tail().All() {
+		if v.(*extract.Tuple).At(0) != extract.MakeAtom("error") {
+			t.Fatal(v)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package extract
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Timer is a handle to a message that has been scheduled to be sent
+// to a process by Timer.send_after.
+type Timer struct {
+	t    *time.Timer
+	stop func() bool
+}
+
+// Cancel cancels the timer. It returns false if the message has
+// already been sent or the timer was already canceled.
+func (t *Timer) Cancel() bool {
+	t.stop()
+	return t.t.Stop()
+}
+
+func (t *Timer) String() string {
+	return fmt.Sprintf("#Timer<%p>", t)
+}
+
+// durationMS converts v, a number of milliseconds, to a Duration.
+func durationMS(v any) (time.Duration, error) {
+	n, ok := v.(int64)
+	if !ok {
+		return 0, NewTypeError(v, reflect.TypeFor[int64]())
+	}
+	return time.Duration(n) * time.Millisecond, nil
+}
+
+// timerScale returns a function for the Timer module that converts a
+// number of units of size d into milliseconds.
+func timerScale(d time.Duration) EvalFunc {
+	return func(env *Env, args *List) (*Env, any) {
+		if args.Len() != 1 {
+			return env, &ArgumentNumError{Num: args.Len(), Expected: 1}
+		}
+
+		_, head := Eval(env, args.Head(), nil)
+		switch n := head.(type) {
+		case int64:
+			return env, n * d.Milliseconds()
+		case float64:
+			return env, int64(n * float64(d.Milliseconds()))
+		case error:
+			return env, n
+		default:
+			return env, NewTypeError(head, reflect.TypeFor[int64](), reflect.TypeFor[float64]())
+		}
+	}
+}
+
+func stdTimer() *Module {
+	m := Module{name: MakeAtom("Timer")}
+	m.decls = map[Ident]any{
+		MakeIdent("sleep"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 1 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 1}
+			}
+
+			_, head := Eval(env, args.Head(), nil)
+			if err, ok := head.(error); ok {
+				return env, err
+			}
+			d, err := durationMS(head)
+			if err != nil {
+				return env, err
+			}
+
+			t := time.NewTimer(d)
+			defer t.Stop()
+			select {
+			case <-t.C:
+				return env, okAtom
+			case <-env.Context().Done():
+				return env, &CancelledError{Cause: context.Cause(env.Context())}
+			}
+		}),
+		MakeIdent("send_after"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 3 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 3}
+			}
+
+			vals, err := evalArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			p, ok := vals[0].(Process)
+			if !ok {
+				return env, NewTypeError(vals[0], reflect.TypeFor[Process]())
+			}
+			d, err := durationMS(vals[2])
+			if err != nil {
+				return env, err
+			}
+
+			msg := vals[1]
+			t := time.AfterFunc(d, func() { p.Send(msg) })
+			stop := context.AfterFunc(env.Context(), func() { t.Stop() })
+			return env, &Timer{t: t, stop: stop}
+		}),
+		MakeIdent("cancel"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 1 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 1}
+			}
+
+			_, head := Eval(env, args.Head(), nil)
+			t, ok := head.(*Timer)
+			if !ok {
+				return env, NewTypeError(head, reflect.TypeFor[*Timer]())
+			}
+			return env, t.Cancel()
+		}),
+		MakeIdent("seconds"): timerScale(time.Second),
+		MakeIdent("minutes"): timerScale(time.Minute),
+		MakeIdent("hours"):   timerScale(time.Hour),
+	}
+
+	return &m
+}
@@ -0,0 +1,61 @@
+package extract_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"deedles.dev/extract"
+	"deedles.dev/extract/parser"
+)
+
+func TestTimer(t *testing.T) {
+	const src = `
+	(Timer.send_after (self) (list :tick 1) 10)
+	(let cancelled (Timer.send_after (self) :never 10))
+	(list
+		(Timer.cancel cancelled)
+		(Timer.sleep 20)
+		(receive
+			(:never :received)
+			((:tick n) n)
+			(after 1000 :timeout)
+		)
+		(receive
+			(:never :received)
+			(after 10 :timeout)
+		)
+		(Timer.seconds 2)
+		(Timer.minutes 0.5)
+		(Timer.hours 1)
+	)
+	`
+	result := runScript(t, src, true)
+	checkList(t, result,
+		true,
+		extract.MakeAtom("ok"),
+		int64(1),
+		extract.MakeAtom("timeout"),
+		int64(2000),
+		int64(30000),
+		int64(3600000),
+	)
+}
+
+func TestTimerSleepCancel(t *testing.T) {
+	s, err := parser.ParseString(t.Name(), `(Timer.sleep 10000)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, result := extract.Run(extract.New(ctx), s.All())
+	var cerr *extract.CancelledError
+	if err, _ := result.(error); !errors.As(err, &cerr) || time.Since(start) > time.Second {
+		t.Fatalf("%#v", result)
+	}
+}
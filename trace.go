@@ -0,0 +1,176 @@
+package extract
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"deedles.dev/extract/scanner"
+)
+
+// Frame is an entry in a [Trace]. It records the name of a function
+// that was called and the position of the call in the source code, if
+// it is known.
+type Frame struct {
+	Func Ident
+	Pos  scanner.Position
+}
+
+func (f Frame) String() string {
+	name := "<anonymous>"
+	if f.Func != (Ident{}) {
+		name = f.Func.String()
+	}
+
+	if f.Pos == (scanner.Position{}) {
+		return name
+	}
+	return fmt.Sprintf("%v (%v)", name, f.Pos)
+}
+
+// Trace is a snapshot of the function calls in progress at some point
+// during evaluation, starting with the innermost.
+type Trace []Frame
+
+func (t Trace) String() string {
+	var sb strings.Builder
+	for i, f := range t {
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString("at ")
+		sb.WriteString(f.String())
+	}
+	return sb.String()
+}
+
+// stack is an immutable stack of frames.
+type stack struct {
+	Frame
+	next *stack
+}
+
+func (s *stack) Push(f Frame) *stack {
+	return &stack{Frame: f, next: s}
+}
+
+func (s *stack) Trace() (t Trace) {
+	for ; s != nil; s = s.next {
+		t = append(t, s.Frame)
+	}
+	return t
+}
+
+// TracedError is an error that has a [Trace] attached to it. Errors
+// that are returned from a call are wrapped in a TracedError recording
+// the position of the call and the function calls that were in
+// progress where they were first returned. Use [errors.As] or
+// [errors.Is] to check for the underlying error.
+//
+// A TracedError formatted with the %+v verb includes the trace after
+// the error message.
+type TracedError struct {
+	Err   error
+	Trace Trace
+
+	// Pos is the position of the call that first returned the error,
+	// if it is known.
+	Pos scanner.Position
+}
+
+func (err *TracedError) Error() string {
+	return err.Err.Error()
+}
+
+func (err *TracedError) Unwrap() error {
+	return err.Err
+}
+
+func (err *TracedError) Format(f fmt.State, verb rune) {
+	switch {
+	case verb == 'v' && f.Flag('+') && len(err.Trace) > 0:
+		fmt.Fprintf(f, "%v\n%v", err.Err, indent(err.Trace.String()))
+	case verb == 'q':
+		fmt.Fprintf(f, "%q", err.Error())
+	default:
+		fmt.Fprint(f, err.Error())
+	}
+}
+
+func indent(str string) string {
+	return "\t" + strings.ReplaceAll(str, "\n", "\n\t")
+}
+
+// traceError wraps r in a TracedError with the trace of s if r is an
+// error that does not already have a trace.
+func traceError(s *stack, r any) any {
+	return traceCall(s, scanner.Position{}, r)
+}
+
+// traceCall is like traceError but also records pos as the position
+// of the call that returned r.
+func traceCall(s *stack, pos scanner.Position, r any) any {
+	err, ok := r.(error)
+	if !ok {
+		return r
+	}
+	if _, ok := err.(*recurSignal); ok {
+		return r
+	}
+
+	var traced *TracedError
+	if errors.As(err, &traced) {
+		return r
+	}
+	return &TracedError{Err: err, Trace: s.Trace(), Pos: pos}
+}
+
+// tracer writes a log of the calls made during evaluation. See
+// [WithTrace].
+type tracer struct {
+	m sync.Mutex
+	w io.Writer
+}
+
+func (t *tracer) printf(depth int, format string, args ...any) {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	fmt.Fprintf(t.w, "%v%v\n", strings.Repeat("  ", depth), fmt.Sprintf(format, args...))
+}
+
+// call logs a call before it is evaluated and returns the Env to
+// evaluate it in.
+func (t *tracer) call(env *Env, call Call) *Env {
+	t.printf(env.traceDepth, "%v (%v)", call, call.Pos)
+	env.traceDepth++
+	return env
+}
+
+// result logs the result of a call and returns renv, the Env that
+// resulted from the call, with the depth restored to that of env.
+func (t *tracer) result(env, renv *Env, r any) *Env {
+	if err, ok := r.(error); ok {
+		t.printf(env.traceDepth, "error: %v", err)
+	} else {
+		t.printf(env.traceDepth, "=> %v", Inspect(r))
+	}
+
+	if renv.traceDepth == env.traceDepth {
+		return renv
+	}
+	e := *renv
+	e.traceDepth = env.traceDepth
+	return &e
+}
+
+// match logs which variant of f, if any, args matched.
+func (t *tracer) match(env *Env, f *Func, args *List, variant int) {
+	if variant < 0 {
+		t.printf(env.traceDepth, "%v %v matched no variants", f.name, Inspect(args))
+		return
+	}
+	t.printf(env.traceDepth, "%v %v matched variant %v", f.name, Inspect(args), variant+1)
+}
@@ -0,0 +1,85 @@
+package extract_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"deedles.dev/extract"
+	"deedles.dev/extract/parser"
+)
+
+func TestTrace(t *testing.T) {
+	const src = `
+	(defmodule Test
+		(def (outer x) (inner x))
+		(def (inner x) (add x missing))
+	)
+	(Test.outer 1)
+	`
+	result := runScript(t, src, false)
+
+	var traced *extract.TracedError
+	if !errors.As(result.(error), &traced) {
+		t.Fatalf("%#v", result)
+	}
+	var nameErr *extract.NameError
+	if !errors.As(traced, &nameErr) || nameErr.Ident != extract.MakeIdent("missing") {
+		t.Fatalf("%#v", traced.Err)
+	}
+
+	if len(traced.Trace) != 2 {
+		t.Fatal(traced.Trace)
+	}
+	ex := []struct {
+		name      string
+		line, col int
+	}{
+		{"inner", 3, 18},
+		{"outer", 6, 2},
+	}
+	for i, f := range traced.Trace {
+		if f.Func != extract.MakeIdent(ex[i].name) || f.Pos.Line != ex[i].line || f.Pos.Col != ex[i].col {
+			t.Fatalf("frame %v: %v", i, f)
+		}
+	}
+
+	const output = "\"missing\" is not bound\n\tat inner (TestTrace:3:18)\n\tat outer (TestTrace:6:2)"
+	if str := fmt.Sprintf("%+v", traced); str != output {
+		t.Fatalf("%q", str)
+	}
+	if str := fmt.Sprint(traced); str != traced.Err.Error() {
+		t.Fatalf("%q", str)
+	}
+}
+
+func TestWithTrace(t *testing.T) {
+	script, err := parser.ParseString("test.ext", `
+(let f (func f ((0) :zero) ((n) (f (sub n 1)))))
+(f 1)
+(add 1 :a)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf strings.Builder
+	env := extract.New(context.Background(), extract.WithTrace(&buf))
+	extract.Run(env, script.All())
+
+	const ex = `(f 1) (test.ext:3:1)
+  f [1] matched variant 2
+  (f (sub n 1)) (test.ext:2:33)
+    (sub n 1) (test.ext:2:36)
+    => 0
+    f [0] matched variant 1
+  => :zero
+=> :zero
+(add 1 :a) (test.ext:4:1)
+error: incorrect type extract.Atom, expected one of [int64 float64]
+`
+	if got := buf.String(); !strings.HasSuffix(got, ex) {
+		t.Fatal(got)
+	}
+}
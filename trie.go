@@ -0,0 +1,221 @@
+package extract
+
+import (
+	"iter"
+	"math/bits"
+	"slices"
+)
+
+// trie is an immutable hash array mapped trie. It is the basis of
+// persistent hash-based collections, such as scopes and maps, and
+// allows them to look up keys in effectively constant time while only
+// copying the nodes along a single path through the trie when
+// modified. Hashing and comparison of keys is left to the user, who
+// must provide the hash of a key and a function for comparing keys to
+// every method that needs them.
+//
+// The zero value is an empty trie.
+type trie[K, V any] struct {
+	root *trieNode[K, V]
+	len  int
+}
+
+// trieNode is a node of the trie. Each set bit in bitmap corresponds
+// to an element of entries, in order.
+type trieNode[K, V any] struct {
+	bitmap  uint32
+	entries []trieEntry[K, V]
+}
+
+// trieEntry is either a pointer to a child node or a leaf containing
+// the pairs whose keys have the given hash. Except in the case of a
+// full hash collision, a leaf only contains one pair.
+type trieEntry[K, V any] struct {
+	child *trieNode[K, V]
+	hash  uint64
+	pairs []triePair[K, V]
+}
+
+type triePair[K, V any] struct {
+	key K
+	val V
+}
+
+const (
+	trieBits = 5
+	trieMask = 1<<trieBits - 1
+)
+
+func trieIndex(bitmap uint32, hash uint64, shift int) (bit uint32, i int) {
+	bit = uint32(1) << (hash >> shift & trieMask)
+	return bit, bits.OnesCount32(bitmap & (bit - 1))
+}
+
+// Len returns the number of keys in the trie.
+func (t trie[K, V]) Len() int {
+	return t.len
+}
+
+// Get returns the value associated with key, if there is one.
+func (t trie[K, V]) Get(hash uint64, key K, eq func(K, K) bool) (v V, ok bool) {
+	node := t.root
+	for shift := 0; node != nil; shift += trieBits {
+		bit, i := trieIndex(node.bitmap, hash, shift)
+		if node.bitmap&bit == 0 {
+			return v, false
+		}
+
+		e := &node.entries[i]
+		if e.child != nil {
+			node = e.child
+			continue
+		}
+		if e.hash == hash {
+			for _, p := range e.pairs {
+				if eq(p.key, key) {
+					return p.val, true
+				}
+			}
+		}
+		return v, false
+	}
+	return v, false
+}
+
+// Put returns a new trie with key associated with val, replacing any
+// previous association of key.
+func (t trie[K, V]) Put(hash uint64, key K, val V, eq func(K, K) bool) trie[K, V] {
+	root, added := t.root.put(hash, 0, triePair[K, V]{key: key, val: val}, eq)
+	if added {
+		t.len++
+	}
+	t.root = root
+	return t
+}
+
+// Delete returns a new trie without key.
+func (t trie[K, V]) Delete(hash uint64, key K, eq func(K, K) bool) trie[K, V] {
+	root, deleted := t.root.delete(hash, 0, key, eq)
+	if deleted {
+		t.len--
+		t.root = root
+	}
+	return t
+}
+
+// All returns an iterator over the pairs in the trie in an
+// unspecified order.
+func (t trie[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		t.root.all(yield)
+	}
+}
+
+func (node *trieNode[K, V]) all(yield func(K, V) bool) bool {
+	if node == nil {
+		return true
+	}
+
+	for _, e := range node.entries {
+		if e.child != nil {
+			if !e.child.all(yield) {
+				return false
+			}
+			continue
+		}
+		for _, p := range e.pairs {
+			if !yield(p.key, p.val) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (node *trieNode[K, V]) put(hash uint64, shift int, p triePair[K, V], eq func(K, K) bool) (*trieNode[K, V], bool) {
+	if node == nil {
+		node = new(trieNode[K, V])
+	}
+
+	bit, i := trieIndex(node.bitmap, hash, shift)
+	if node.bitmap&bit == 0 {
+		return &trieNode[K, V]{
+			bitmap:  node.bitmap | bit,
+			entries: slices.Insert(slices.Clip(node.entries), i, trieEntry[K, V]{hash: hash, pairs: []triePair[K, V]{p}}),
+		}, true
+	}
+
+	e := node.entries[i]
+	added := true
+	switch {
+	case e.child != nil:
+		e.child, added = e.child.put(hash, shift+trieBits, p, eq)
+
+	case e.hash == hash:
+		j := slices.IndexFunc(e.pairs, func(old triePair[K, V]) bool { return eq(old.key, p.key) })
+		e.pairs = slices.Clone(e.pairs)
+		if j >= 0 {
+			e.pairs[j] = p
+			added = false
+			break
+		}
+		e.pairs = append(e.pairs, p)
+
+	default:
+		var child *trieNode[K, V]
+		for _, old := range e.pairs {
+			child, _ = child.put(e.hash, shift+trieBits, old, eq)
+		}
+		child, _ = child.put(hash, shift+trieBits, p, eq)
+		e = trieEntry[K, V]{child: child}
+	}
+
+	entries := slices.Clone(node.entries)
+	entries[i] = e
+	return &trieNode[K, V]{bitmap: node.bitmap, entries: entries}, added
+}
+
+func (node *trieNode[K, V]) delete(hash uint64, shift int, key K, eq func(K, K) bool) (*trieNode[K, V], bool) {
+	if node == nil {
+		return nil, false
+	}
+
+	bit, i := trieIndex(node.bitmap, hash, shift)
+	if node.bitmap&bit == 0 {
+		return node, false
+	}
+
+	e := node.entries[i]
+	switch {
+	case e.child != nil:
+		child, deleted := e.child.delete(hash, shift+trieBits, key, eq)
+		if !deleted {
+			return node, false
+		}
+		e.child = child
+
+	case e.hash == hash:
+		j := slices.IndexFunc(e.pairs, func(p triePair[K, V]) bool { return eq(p.key, key) })
+		if j < 0 {
+			return node, false
+		}
+		e.pairs = slices.Delete(slices.Clone(e.pairs), j, j+1)
+
+	default:
+		return node, false
+	}
+
+	if e.child == nil && len(e.pairs) == 0 || e.child != nil && e.child.bitmap == 0 {
+		if node.bitmap == bit {
+			return nil, true
+		}
+		return &trieNode[K, V]{
+			bitmap:  node.bitmap &^ bit,
+			entries: slices.Delete(slices.Clone(node.entries), i, i+1),
+		}, true
+	}
+
+	entries := slices.Clone(node.entries)
+	entries[i] = e
+	return &trieNode[K, V]{bitmap: node.bitmap, entries: entries}, true
+}
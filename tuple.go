@@ -0,0 +1,214 @@
+package extract
+
+import (
+	"cmp"
+	"hash/maphash"
+	"iter"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Tuple is a fixed-size, immutable sequence of values. Unlike a *List,
+// any element of a tuple can be accessed in constant time, but
+// building a modified copy of one takes time proportional to its size.
+// A nil *Tuple is a valid, empty tuple.
+type Tuple struct {
+	elems []any
+}
+
+// TupleOf returns a tuple containing the values provided in the same
+// order.
+func TupleOf(vals ...any) *Tuple {
+	return &Tuple{elems: slices.Clone(vals)}
+}
+
+// Len returns the number of elements in the tuple.
+func (t *Tuple) Len() int {
+	if t == nil {
+		return 0
+	}
+	return len(t.elems)
+}
+
+// At returns the element of the tuple at index i. It panics if i is
+// out of range.
+func (t *Tuple) At(i int) any {
+	return t.elems[i]
+}
+
+// All returns an iterator over the elements of the tuple.
+func (t *Tuple) All() iter.Seq[any] {
+	if t == nil {
+		return func(func(any) bool) {}
+	}
+	return slices.Values(t.elems)
+}
+
+// Enumerate yields the elements of the tuple.
+func (t *Tuple) Enumerate() iter.Seq2[any, error] {
+	return func(yield func(any, error) bool) {
+		for v := range t.All() {
+			if !yield(v, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Equal returns true if other is a *Tuple of the same length as t
+// with elements that are equal to those of t.
+func (t *Tuple) Equal(other any) bool {
+	o, ok := other.(*Tuple)
+	if !ok || t.Len() != o.Len() {
+		return false
+	}
+	for i := range t.Len() {
+		if !Equal(t.At(i), o.At(i)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Hash returns a hash of the elements of the tuple that is consistent
+// with [Tuple.Equal].
+func (t *Tuple) Hash() uint64 {
+	var h maphash.Hash
+	h.SetSeed(hashSeed)
+	h.WriteString("tuple")
+	writeUint(&h, uint64(t.Len()))
+	for v := range t.All() {
+		writeUint(&h, Hash(v))
+	}
+	return h.Sum64()
+}
+
+// Compare orders tuples first by size and then element by element.
+func (t *Tuple) Compare(other any) int {
+	o := other.(*Tuple)
+	if c := cmp.Compare(t.Len(), o.Len()); c != 0 {
+		return c
+	}
+	for i := range t.Len() {
+		if c := Compare(t.At(i), o.At(i)); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// Inspect returns a representation of the tuple in the form
+// #Tuple<v1 v2>.
+func (t *Tuple) Inspect() string {
+	var sb strings.Builder
+	sb.WriteString("#Tuple<")
+	for i := range t.Len() {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		inspect(&sb, t.At(i))
+	}
+	sb.WriteByte('>')
+	return sb.String()
+}
+
+func (t *Tuple) String() string {
+	return Inspect(t)
+}
+
+func stdTuple() *Module {
+	m := Module{name: MakeAtom("Tuple")}
+	m.decls = map[Ident]any{
+		MakeIdent("new"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			vals, err := evalArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			return env, &Tuple{elems: vals}
+		}),
+		MakeIdent("size"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 1 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 1}
+			}
+
+			t, _, err := evalTupleArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			return env, int64(t.Len())
+		}),
+		MakeIdent("elem"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 2 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 2}
+			}
+
+			t, vals, err := evalTupleArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			i, err := tupleIndex(t, vals[0])
+			if err != nil {
+				return env, err
+			}
+			return env, t.At(i)
+		}),
+		MakeIdent("put_elem"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 3 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 3}
+			}
+
+			t, vals, err := evalTupleArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			i, err := tupleIndex(t, vals[0])
+			if err != nil {
+				return env, err
+			}
+
+			r := TupleOf(t.elems...)
+			r.elems[i] = vals[1]
+			return env, r
+		}),
+		MakeIdent("to_list"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 1 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 1}
+			}
+
+			t, _, err := evalTupleArgs(env, args)
+			if err != nil {
+				return env, err
+			}
+			return env, CollectList(t.All())
+		}),
+	}
+
+	return &m
+}
+
+// evalTupleArgs evaluates args, the first of which must be a *Tuple.
+// It returns the tuple and the rest of the evaluated arguments.
+func evalTupleArgs(env *Env, args *List) (*Tuple, []any, error) {
+	vals, err := evalArgs(env, args)
+	if err != nil {
+		return nil, nil, err
+	}
+	t, ok := vals[0].(*Tuple)
+	if !ok {
+		return nil, nil, NewTypeError(vals[0], reflect.TypeFor[*Tuple]())
+	}
+	return t, vals[1:], nil
+}
+
+// tupleIndex checks that v is a valid index into t.
+func tupleIndex(t *Tuple, v any) (int, error) {
+	i, ok := v.(int64)
+	if !ok {
+		return 0, NewTypeError(v, reflect.TypeFor[int64]())
+	}
+	if i < 0 || i >= int64(t.Len()) {
+		return 0, &IndexError{Index: i, Len: t.Len()}
+	}
+	return int(i), nil
+}
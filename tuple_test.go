@@ -0,0 +1,84 @@
+package extract_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"deedles.dev/extract"
+)
+
+func TestTuple(t *testing.T) {
+	const src = `
+	(let t (Tuple.new 1 :two "three"))
+	(list
+		(Tuple.size t)
+		(Tuple.elem t 1)
+		(Tuple.put_elem t 0 :one)
+		t
+		(Tuple.to_list t)
+		(List.to_tuple [1 2])
+		(eq (List.to_tuple [1 2]) (Tuple.new 1 2))
+		(Enum.count t)
+		(inspect t)
+	)
+	`
+	result := runScript(t, src, true)
+	two := extract.MakeAtom("two")
+	checkList(t, result,
+		int64(3),
+		two,
+		extract.TupleOf(extract.MakeAtom("one"), two, "three"),
+		extract.TupleOf(int64(1), two, "three"),
+		extract.ListOf(int64(1), two, "three"),
+		extract.TupleOf(int64(1), int64(2)),
+		true,
+		int64(3),
+		`#Tuple<1 :two "three">`,
+	)
+
+	result = runScript(t, `(Tuple.elem (Tuple.new 1) 1)`, false)
+	if err, _ := result.(error); !errors.As(err, new(*extract.IndexError)) {
+		t.Fatalf("%#v", result)
+	}
+	if extract.Compare(extract.TupleOf(int64(2)), extract.TupleOf(int64(1), int64(1))) >= 0 {
+		t.Fatal("smaller tuple should sort first")
+	}
+}
+
+func TestTuplePattern(t *testing.T) {
+	const src = `
+	(let (:ok x) (Integer.parse "12"))
+	(let [a &rest] (Tuple.new 1 2 3))
+	(defmodule Test
+		(def (unwrap (:ok v)) v)
+		(def (unwrap (:error _)) :failed))
+	(list x a rest (Test.unwrap (Integer.parse "3")) (Test.unwrap (Integer.parse "x")))
+	`
+	result := runScript(t, src, true)
+	checkList(t, result,
+		int64(12),
+		int64(1),
+		extract.TupleOf(int64(2), int64(3)),
+		int64(3),
+		extract.MakeAtom("failed"),
+	)
+
+	result = runScript(t, `(let (a b) (Tuple.new 1))`, false)
+	if _, ok := result.(error); !ok {
+		t.Fatalf("%#v", result)
+	}
+
+	env := extract.New(context.Background())
+	p, err := extract.CompilePattern(env, extract.TupleOf(extract.MakeAtom("ok"), extract.MakeIdent("v")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := p.Match(env, extract.ListOf(extract.MakeAtom("ok"), int64(1))); ok {
+		t.Fatal("tuple pattern matched a list")
+	}
+	menv, ok := p.Match(env, extract.TupleOf(extract.MakeAtom("ok"), int64(1)))
+	if v, _ := menv.Lookup(extract.MakeIdent("v")); !ok || v != int64(1) {
+		t.Fatalf("%v %#v", ok, v)
+	}
+}
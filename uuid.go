@@ -0,0 +1,128 @@
+package extract
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+// uuid is the 16 bytes of a UUID as described by RFC 9562.
+type uuid [16]byte
+
+// newUUIDv4 returns a random version 4 UUID.
+func newUUIDv4(r *lockedRand) (u uuid) {
+	binary.BigEndian.PutUint64(u[:8], r.uint64())
+	binary.BigEndian.PutUint64(u[8:], r.uint64())
+	u.setVersion(4)
+	return u
+}
+
+// newUUIDv7 returns a version 7 UUID, which begins with the current
+// Unix time in milliseconds so that UUIDs created later sort after
+// earlier ones.
+func newUUIDv7(r *lockedRand, now time.Time) (u uuid) {
+	binary.BigEndian.PutUint64(u[8:], r.uint64())
+	binary.BigEndian.PutUint64(u[:8], uint64(now.UnixMilli())<<16|r.uint64()&0xffff)
+	u.setVersion(7)
+	return u
+}
+
+func (u *uuid) setVersion(v byte) {
+	u[6] = u[6]&0x0f | v<<4
+	u[8] = u[8]&0x3f | 0x80
+}
+
+// parseUUID parses a UUID in the standard hyphenated form, optionally
+// surrounded by braces or prefixed by urn:uuid:, or as 32 hexadecimal
+// digits without hyphens. Both upper and lower case are accepted.
+func parseUUID(str string) (u uuid, ok bool) {
+	switch {
+	case len(str) == 38 && str[0] == '{' && str[37] == '}':
+		str = str[1:37]
+	case len(str) > 9 && strings.EqualFold(str[:9], "urn:uuid:"):
+		str = str[9:]
+	}
+
+	switch len(str) {
+	case 32:
+	case 36:
+		if str[8] != '-' || str[13] != '-' || str[18] != '-' || str[23] != '-' {
+			return u, false
+		}
+		str = str[:8] + str[9:13] + str[14:18] + str[19:23] + str[24:]
+	default:
+		return u, false
+	}
+
+	_, err := hex.Decode(u[:], []byte(str))
+	return u, err == nil
+}
+
+// String returns u in the standard lowercase hyphenated form.
+func (u uuid) String() string {
+	var buf [36]byte
+	hex.Encode(buf[:8], u[:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return string(buf[:])
+}
+
+func stdUUID() *Module {
+	m := Module{name: MakeAtom("UUID")}
+	m.decls = map[Ident]any{
+		MakeIdent("v4"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 0 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 0}
+			}
+			return env, newUUIDv4(env.rand).String()
+		}),
+		MakeIdent("v7"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			if args.Len() != 0 {
+				return env, &ArgumentNumError{Num: args.Len(), Expected: 0}
+			}
+			return env, newUUIDv7(env.rand, time.Now()).String()
+		}),
+		MakeIdent("parse"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			strs, err := evalStrings(env, args, 1, 1)
+			if err != nil {
+				return env, err
+			}
+
+			u, ok := parseUUID(strs[0])
+			if !ok {
+				return env, errorResult(MakeAtom("invalid"))
+			}
+			return env, okResult(u.String())
+		}),
+		MakeIdent("valid?"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			strs, err := evalStrings(env, args, 1, 1)
+			if err != nil {
+				return env, err
+			}
+
+			_, ok := parseUUID(strs[0])
+			return env, ok
+		}),
+		MakeIdent("version"): EvalFunc(func(env *Env, args *List) (*Env, any) {
+			strs, err := evalStrings(env, args, 1, 1)
+			if err != nil {
+				return env, err
+			}
+
+			u, ok := parseUUID(strs[0])
+			if !ok {
+				return env, errorResult(MakeAtom("invalid"))
+			}
+			return env, okResult(int64(u[6] >> 4))
+		}),
+	}
+
+	return &m
+}
@@ -0,0 +1,47 @@
+package extract_test
+
+import (
+	"regexp"
+	"testing"
+
+	"deedles.dev/extract"
+)
+
+func TestUUID(t *testing.T) {
+	const src = `
+	(let a (UUID.v4))
+	(let b (UUID.v7))
+	(list
+		a
+		b
+		(UUID.version a)
+		(UUID.version b)
+		(eq a (UUID.v4))
+		(UUID.parse "{6BA7B810-9DAD-11D1-80B4-00C04FD430C8}")
+		(UUID.parse "urn:uuid:6ba7b8109dad11d180b400c04fd430c8")
+		(UUID.parse "6ba7b810-9dad-11d1-80b4-00c04fd430c")
+		(UUID.valid? "6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+		(UUID.valid? "6ba7b810_9dad_11d1_80b4_00c04fd430c8")
+	)
+	`
+	result := runScript(t, src, true).(*extract.List)
+
+	re := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[47][0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	a, _ := result.Head().(string)
+	b, _ := result.Tail().Head().(string)
+	if !re.MatchString(a) || !re.MatchString(b) {
+		t.Fatal(a, b)
+	}
+
+	ok := extract.MakeAtom("ok")
+	checkList(t, result.Tail().Tail(),
+		extract.TupleOf(ok, int64(4)),
+		extract.TupleOf(ok, int64(7)),
+		false,
+		extract.TupleOf(ok, "6ba7b810-9dad-11d1-80b4-00c04fd430c8"),
+		extract.TupleOf(ok, "6ba7b810-9dad-11d1-80b4-00c04fd430c8"),
+		extract.TupleOf(extract.MakeAtom("error"), extract.MakeAtom("invalid")),
+		true,
+		false,
+	)
+}
@@ -0,0 +1,464 @@
+package extract
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Program is Extract code that has been compiled to bytecode by
+// [Compile]. The bytecode is a flat list of instructions for a small
+// stack machine that evaluates calls to functions declared in Extract
+// code without the recursive calls to [Eval] and the intermediate Envs
+// of the tree-walking evaluator. Anything else, such as a call to
+// let, is evaluated by handing the expression to Eval, so a Program
+// always behaves exactly like the code that it was compiled from.
+//
+// The body of a function declared in Extract code is run in a frame
+// on the machine's own stack, so calls between such functions don't
+// recurse on the Go stack unless hooks or a tracer are installed. A
+// call in the arguments of a builtin function, such as add, is still
+// evaluated by the builtin.
+//
+// Evaluating a Program runs the compiled code like [Run], returning
+// the result of the last expression, or the first error.
+type Program struct {
+	code   []instr
+	consts []any
+}
+
+type opcode uint8
+
+const (
+	// opConst pushes consts[a], which evaluates to itself.
+	opConst opcode = iota
+
+	// opEval evaluates consts[a] with Eval, updating the Env, and
+	// pushes the result.
+	opEval
+
+	// opCallee finds the function called by the callSite consts[a].
+	// If it is a function declared in Extract code, execution
+	// continues with the instructions that evaluate the arguments.
+	// Otherwise, the call is made without them and execution
+	// continues at b with the result pushed.
+	opCallee
+
+	// opCall calls the function found by the matching opCallee with
+	// the a values on top of the stack. consts[b] is the callSite.
+	opCall
+
+	// opSave saves the Env so that it can be restored by opList.
+	opSave
+
+	// opList replaces the a values on top of the stack with a list of
+	// them and restores the Env saved by the matching opSave.
+	opList
+
+	// opDrop pops the result of an expression that isn't the last one
+	// in the Program, stopping with it if it's an error.
+	opDrop
+)
+
+var opcodeNames = [...]string{
+	opConst:  "const",
+	opEval:   "eval",
+	opCallee: "callee",
+	opCall:   "call",
+	opSave:   "save",
+	opList:   "list",
+	opDrop:   "drop",
+}
+
+func (op opcode) String() string {
+	return opcodeNames[op]
+}
+
+type instr struct {
+	op   opcode
+	a, b int32
+}
+
+// callSite is a call that has been compiled. If the call might be to
+// a function in strictKernel, lazy holds its arguments with every call
+// compiled to a separate Program. Those functions are passed lazy
+// instead of having the VM evaluate their arguments because they
+// evaluate their arguments in different ways, such as by stopping at
+// the first error, which would be observable if an argument had side
+// effects.
+type callSite struct {
+	call Call
+	lazy *List
+}
+
+func (site *callSite) String() string {
+	return site.call.String()
+}
+
+// strictKernel is the set of kernel functions that evaluate their
+// arguments without inspecting them first, so they can be passed
+// arguments that are compiled.
+var strictKernel = map[Ident]struct{}{
+	MakeIdent("add"):     {},
+	MakeIdent("sub"):     {},
+	MakeIdent("mul"):     {},
+	MakeIdent("div"):     {},
+	MakeIdent("rem"):     {},
+	MakeIdent("pow"):     {},
+	MakeIdent("eq"):      {},
+	MakeIdent("inspect"): {},
+}
+
+// kernelLen is the number of bindings in kernel. Bindings with a
+// sequence number no greater than it are kernel functions. kernelForms
+// is the set of kernel functions that aren't in strictKernel, such as
+// let and def, which are never called by the VM. They are set in init
+// because the VM referring to kernel directly would be an
+// initialization cycle, as some kernel functions evaluate code.
+var (
+	kernelLen   int
+	kernelForms = make(map[Ident]struct{})
+)
+
+func init() {
+	kernelLen = kernel.Len()
+	for b := range kernel.All() {
+		if _, ok := b.val.(EvalFunc); !ok {
+			continue
+		}
+		if _, ok := strictKernel[b.ident]; !ok {
+			kernelForms[b.ident] = struct{}{}
+		}
+	}
+}
+
+// Compile compiles exprs, such as a parsed script or the body of a
+// function, to bytecode.
+func Compile(exprs *List) *Program {
+	var c compiler
+	for expr := range exprs.All() {
+		if len(c.p.code) > 0 {
+			c.emit(opDrop, 0, 0)
+		}
+		c.expr(expr)
+	}
+	return &c.p
+}
+
+type compiler struct {
+	p Program
+}
+
+func (c *compiler) emit(op opcode, a, b int32) int {
+	c.p.code = append(c.p.code, instr{op: op, a: a, b: b})
+	return len(c.p.code) - 1
+}
+
+func (c *compiler) konst(v any) int32 {
+	c.p.consts = append(c.p.consts, v)
+	return int32(len(c.p.consts) - 1)
+}
+
+func (c *compiler) expr(expr any) {
+	switch expr := expr.(type) {
+	case Call:
+		if !compilableCall(expr) {
+			c.emit(opEval, c.konst(expr), 0)
+			return
+		}
+
+		site := callSite{call: expr}
+		if head, ok := expr.Head().(Ident); ok {
+			if _, ok := strictKernel[head]; ok {
+				site.lazy = lazyArgs(expr.Tail())
+			}
+		}
+
+		k := c.konst(&site)
+		callee := c.emit(opCallee, k, 0)
+		for arg := range expr.Tail().All() {
+			c.expr(arg)
+		}
+		c.emit(opCall, int32(expr.Len()-1), k)
+		c.p.code[callee].b = int32(len(c.p.code))
+
+	case ListExpr:
+		c.emit(opSave, 0, 0)
+		for elem := range expr.All() {
+			c.expr(elem)
+		}
+		c.emit(opList, int32(expr.Len()), 0)
+
+	case Evaluator:
+		c.emit(opEval, c.konst(expr), 0)
+
+	default:
+		c.emit(opConst, c.konst(expr), 0)
+	}
+}
+
+// lazyArgs compiles the calls in args to separate Programs.
+func lazyArgs(args *List) *List {
+	lazy := make([]any, 0, args.Len())
+	for arg := range args.All() {
+		switch arg.(type) {
+		case Call, ListExpr:
+			arg = Compile(ListOf(arg))
+		}
+		lazy = append(lazy, arg)
+	}
+	return callArgs(ListOf(lazy...))
+}
+
+// compilableCall returns true if the function that call calls might
+// be one that the VM can call, which depends on what its head is
+// bound to when it is evaluated.
+func compilableCall(call Call) bool {
+	switch head := call.Head().(type) {
+	case Ident:
+		_, ok := kernelForms[head]
+		return !ok
+	case Ref:
+		_, ok := head.In.(Atom)
+		return ok
+	default:
+		return false
+	}
+}
+
+// directCallee returns the function that head refers to in env if it
+// is one that can be called directly, without evaluating head and
+// without the position of the call in the Env, either a *Func or a
+// function in strictKernel, or nil otherwise.
+func (env *Env) directCallee(head any) any {
+	var v any
+	switch head := head.(type) {
+	case Ident:
+		b, ok := env.locals.Get(head)
+		if ok && !env.shadowed(b) {
+			if f, ok := b.val.(EvalFunc); ok {
+				if _, strict := strictKernel[head]; strict && b.seq <= kernelLen {
+					return f
+				}
+				return nil
+			}
+			v = b.val
+		} else if env.currentModule != nil {
+			v, _ = env.currentModule.Lookup(head)
+		}
+
+	case Ref:
+		if in, ok := head.In.(Atom); ok {
+			if m := env.GetModule(in); m != nil {
+				v, _ = m.Lookup(head.Name)
+			}
+		}
+	}
+
+	if f, ok := v.(*Func); ok {
+		return f
+	}
+	return nil
+}
+
+// vmCall is a call to a function declared in Extract code whose
+// arguments are being evaluated by the VM. env is the Env from before
+// the arguments were evaluated, which is restored after the call.
+type vmCall struct {
+	f   *Func
+	env *Env
+}
+
+// vmFrame is the state of a Program that is being run by the VM. When
+// a function declared in Extract code is called, the caller's frame is
+// saved and the function's body is run in a new one, so calls between
+// such functions don't recurse on the Go stack.
+type vmFrame struct {
+	p     *Program
+	pc    int
+	env   *Env
+	stack []any
+	calls []vmCall
+	saved []*Env
+
+	// call is the call whose body the frame is running, if any.
+	call activeCall
+}
+
+// vmEnter is a call to a function declared in Extract code whose
+// body is to be run in a new frame.
+type vmEnter struct {
+	env  *Env
+	v    *funcVariant
+	call activeCall
+}
+
+// useVM returns true if the bodies of functions called in env should
+// be run with the VM.
+func (env *Env) useVM() bool {
+	return env.bytecode && env.hooks == nil && env.tracer == nil
+}
+
+func (p *Program) Eval(env *Env, args *List) (*Env, any) {
+	env, r := p.run(env)
+	if args == nil {
+		return env, r
+	}
+	return Eval(env, r, args)
+}
+
+func (p *Program) run(env *Env) (*Env, any) {
+	var frames []*vmFrame
+	fr := &vmFrame{p: p, env: env}
+	for {
+		r, enter := fr.exec()
+		if enter != nil {
+			frames = append(frames, fr)
+			fr = &vmFrame{
+				p:    enter.v.program.get(enter.v.Body),
+				env:  enter.env,
+				call: enter.call,
+			}
+			continue
+		}
+		if len(frames) == 0 {
+			return fr.env, r
+		}
+
+		r = fr.call.finish(r)
+		fr = frames[len(frames)-1]
+		frames = frames[:len(frames)-1]
+		fr.stack = append(fr.stack, r)
+	}
+}
+
+// exec runs the frame's instructions until either the Program is done,
+// in which case it returns the result, or a function declared in
+// Extract code is called, in which case it returns the call so that
+// the function's body can be run in a new frame. The frame can then be
+// resumed with the result of the call pushed onto its stack.
+func (fr *vmFrame) exec() (any, *vmEnter) {
+	p, env := fr.p, fr.env
+	for ; fr.pc < len(p.code); fr.pc++ {
+		in := p.code[fr.pc]
+		switch in.op {
+		case opConst:
+			fr.stack = append(fr.stack, p.consts[in.a])
+
+		case opEval:
+			var r any
+			env, r = Eval(env, p.consts[in.a], nil)
+			fr.stack = append(fr.stack, r)
+
+		case opCallee:
+			site := p.consts[in.a].(*callSite)
+			if err := env.step(); err != nil {
+				fr.stack = append(fr.stack, err)
+				fr.pc = int(in.b) - 1
+				continue
+			}
+
+			var r any
+			switch f := env.directCallee(site.call.Head()).(type) {
+			case *Func:
+				fr.calls = append(fr.calls, vmCall{f: f, env: env})
+				continue
+			case EvalFunc:
+				if site.lazy == nil {
+					env, r = site.call.Eval(env, nil)
+					break
+				}
+				_, r = evalEvaluator(env, f, site.lazy)
+				r = traceCall(env.stack, site.call.Pos, r)
+			default:
+				env, r = site.call.Eval(env, nil)
+			}
+			fr.stack = append(fr.stack, r)
+			fr.pc = int(in.b) - 1
+
+		case opCall:
+			c := fr.calls[len(fr.calls)-1]
+			fr.calls = fr.calls[:len(fr.calls)-1]
+			args := ListOf(fr.stack[len(fr.stack)-int(in.a):]...)
+			fr.stack = fr.stack[:len(fr.stack)-int(in.a)]
+
+			site := p.consts[in.b].(*callSite)
+			env = c.env
+			fenv, v, call, r := c.f.enter(env, site.call.Pos, args)
+			if v != nil && fenv.useVM() {
+				fr.pc++
+				fr.env = env
+				return nil, &vmEnter{env: fenv, v: v, call: call}
+			}
+			if v != nil {
+				r = call.finish(v.run(fenv))
+			}
+			fr.stack = append(fr.stack, r)
+
+		case opSave:
+			fr.saved = append(fr.saved, env)
+
+		case opList:
+			list := ListOf(fr.stack[len(fr.stack)-int(in.a):]...)
+			fr.stack = append(fr.stack[:len(fr.stack)-int(in.a)], list)
+			env = fr.saved[len(fr.saved)-1]
+			fr.saved = fr.saved[:len(fr.saved)-1]
+
+		case opDrop:
+			r := fr.stack[len(fr.stack)-1]
+			fr.stack = fr.stack[:len(fr.stack)-1]
+			if err, ok := r.(error); ok {
+				fr.env = env
+				return err, nil
+			}
+		}
+	}
+
+	fr.env = env
+	if len(fr.stack) == 0 {
+		return nil, nil
+	}
+	return fr.stack[len(fr.stack)-1], nil
+}
+
+// String returns a listing of the Program's instructions.
+func (p *Program) String() string {
+	var sb strings.Builder
+	for pc, in := range p.code {
+		fmt.Fprintf(&sb, "%v: %v", pc, in.op)
+		switch in.op {
+		case opConst, opEval, opCallee:
+			fmt.Fprintf(&sb, " %v", Inspect(p.consts[in.a]))
+			if in.op == opCallee {
+				fmt.Fprintf(&sb, " else %v", in.b)
+			}
+		case opCall, opList:
+			fmt.Fprintf(&sb, " %v", in.a)
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// lazyProgram is the bytecode of a function variant's body, which is
+// compiled the first time that it's needed.
+type lazyProgram struct {
+	once sync.Once
+	p    *Program
+}
+
+func (lp *lazyProgram) get(body *List) *Program {
+	lp.once.Do(func() { lp.p = Compile(body) })
+	return lp.p
+}
+
+// WithBytecode makes functions declared in Extract code that are
+// called in the Env run their bodies with the bytecode VM instead of
+// the tree-walking evaluator. Each variant of a function is compiled
+// the first time that it is chosen. See [Program]. The VM is not used
+// while [Hooks] or [WithTrace] are observing the evaluation.
+func WithBytecode() Option {
+	return func(env *Env) {
+		env.bytecode = true
+	}
+}
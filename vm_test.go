@@ -0,0 +1,168 @@
+package extract_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"testing"
+
+	"deedles.dev/extract"
+	"deedles.dev/extract/parser"
+)
+
+const fibScript = `
+(defmodule Fib
+	(def (fib 0) 0)
+	(def (fib 1) 1)
+	(def (fib n) (add (fib (sub n 1)) (fib (sub n 2)))))
+(Fib.fib 20)`
+
+func TestBytecode(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{"Fib", fibScript},
+		{"Lets", `
+			(let f (func f ((x) (let y (mul x 2)) (list x y [x (add y 1)]))))
+			(f 3)`},
+		{"Shadowed", `
+			(let add (func add ((a b) (sub a b))))
+			(let g (func g ((x) (add x 1))))
+			(g 5)`},
+		{"ModuleShadow", `
+			(defmodule M
+				(def (mul a b) (add a b))
+				(def (f x) (mul x x)))
+			(M.f 4)`},
+		{"LazyArgs", `
+			(let f (func f ((x) (add :a (IO.println "not printed")))))
+			(f 1)`},
+		{"ListEnv", `
+			(let f (func f (() [(let x 1) x] (inspect x))))
+			(f)`},
+		{"Errors", `
+			(let f (func f ((x) (IO.println "a") (div x 0) (IO.println "b"))))
+			(f 1)`},
+		{"SpecialForms", `
+			(defmodule M
+				(def (count n) (loop (n 0)
+					((0 acc) acc)
+					((n acc) (recur (sub n 1) (add acc n)))))
+				(def (classify v) (match v
+					(0 :zero)
+					(_ (String.concat "n" (inspect v))))))
+			[(M.count 10) (M.classify 0) (M.classify 3)]`},
+	}
+
+	run := func(t *testing.T, src string, opts ...extract.Option) string {
+		script, err := parser.ParseString(t.Name(), src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out strings.Builder
+		env := extract.New(context.Background(), append(opts, extract.WithStdout(&out))...)
+		_, r := extract.Run(env, script.All())
+
+		var traced *extract.TracedError
+		if err, _ := r.(error); errors.As(err, &traced) {
+			fmt.Fprintf(&out, "%v: ", traced.Pos)
+		}
+		return out.String() + extract.Inspect(r)
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ex := run(t, test.src)
+			if got := run(t, test.src, extract.WithBytecode()); got != ex {
+				t.Fatalf("got %q, expected %q", got, ex)
+			}
+		})
+	}
+}
+
+func TestCompile(t *testing.T) {
+	script, err := parser.ParseString(t.Name(), `
+	(let f (func f ((l n) (list l n))))
+	(f [2 3] (add 2 1))`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const ex = `0: eval (let f (func f ((l n) (list l n))))
+1: drop
+2: callee (f [2 3] (add 2 1)) else 12
+3: save
+4: const 2
+5: const 3
+6: list 2
+7: callee (add 2 1) else 11
+8: const 2
+9: const 1
+10: call 2
+11: call 2
+`
+	p := extract.Compile(script)
+	if str := p.String(); str != ex {
+		t.Fatal(str)
+	}
+
+	_, r := extract.Eval(extract.New(context.Background()), p, nil)
+	if ex := extract.ListOf(extract.ListOf(int64(2), int64(3)), int64(3)); !extract.Equal(r, ex) {
+		t.Fatal(extract.Inspect(r))
+	}
+}
+
+func BenchmarkBytecode(b *testing.B) {
+	script, err := parser.ParseString(b.Name(), fibScript)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, bench := range []struct {
+		name string
+		opts []extract.Option
+	}{
+		{"TreeWalk", nil},
+		{"Bytecode", []extract.Option{extract.WithBytecode()}},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			for range b.N {
+				env := extract.New(context.Background(), bench.opts...)
+				extract.Run(env, script.All())
+			}
+		})
+	}
+}
+
+func TestBytecodeDepth(t *testing.T) {
+	script, err := parser.ParseString(t.Name(), `
+	(defmodule Deep
+		(def (inc n) (add n 1))
+		(def (count 0) 0)
+		(def (count n) (inc (count (sub n 1)))))
+	(Deep.count 100000)
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Calls between functions declared in Extract code are run on the
+	// VM's own stack, so they shouldn't need much of the Go stack.
+	defer debug.SetMaxStack(debug.SetMaxStack(16 << 20))
+
+	env := extract.New(context.Background(), extract.WithBytecode(), extract.WithMaxDepth(0))
+	_, r := extract.Run(env, script.All())
+	if r != int64(100000) {
+		t.Fatalf("%#v", r)
+	}
+
+	env = extract.New(context.Background(), extract.WithBytecode(), extract.WithMaxDepth(100))
+	_, r = extract.Run(env, script.All())
+	var overflow *extract.StackOverflowError
+	if err, ok := r.(error); !ok || !errors.As(err, &overflow) || overflow.Depth != 100 {
+		t.Fatalf("%#v", r)
+	}
+}
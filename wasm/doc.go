@@ -0,0 +1,21 @@
+// Package wasm exposes Extract to JavaScript when it is compiled with
+// GOOS=js and GOARCH=wasm, such as for an in-browser playground. It
+// is empty on every other platform.
+//
+// [Register] installs an object on the JavaScript global scope whose
+// evalString method runs Extract source code and returns a Promise of
+// the result, converted to a JavaScript value with [ValueOf]. The
+// program in cmd/extract-wasm does this and then waits forever, so
+// the simplest way to use Extract from a web page is to build it,
+//
+//	GOOS=js GOARCH=wasm go build -o extract.wasm ./cmd/extract-wasm
+//
+// and load it with the wasm_exec.js that ships with Go:
+//
+//	const go = new Go();
+//	const { instance } = await WebAssembly.instantiateStreaming(fetch("extract.wasm"), go.importObject);
+//	go.run(instance);
+//
+//	const r = await extract.evalString(`(add 1 2)`);
+//	console.log(r.value, r.inspect, r.output, r.error);
+package wasm
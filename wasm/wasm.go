@@ -0,0 +1,206 @@
+//go:build js && wasm
+
+package wasm
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"syscall/js"
+
+	"deedles.dev/extract"
+	_ "deedles.dev/extract/parser"
+)
+
+// Result is the outcome of evaluating a script with [EvalString].
+type Result struct {
+	// Value is the result of the last top-level expression, or nil
+	// if the script failed.
+	Value any
+
+	// Output is everything that the script wrote to standard output
+	// and standard error, interleaved in the order that it was
+	// written.
+	Output string
+
+	// Err is the error that parsing or running the script failed
+	// with, if any.
+	Err error
+}
+
+// EvalString runs src with [extract.EvalString] and the given options.
+// The script's standard output and standard error are captured in the
+// Result, as a browser has nowhere else to send them.
+func EvalString(ctx context.Context, src string, opts ...extract.Option) Result {
+	var out outputBuffer
+	opts = append([]extract.Option{
+		extract.WithStdout(&out),
+		extract.WithStderr(&out),
+		extract.WithoutFileSystem(),
+	}, opts...)
+	r, err := extract.EvalString(ctx, src, opts...)
+	return Result{Value: r, Output: out.String(), Err: err}
+}
+
+// JS converts the Result to a JavaScript object with the properties
+// value, the Value converted with [ValueOf], inspect, the Value
+// rendered with [extract.Inspect], output, and error, the message of
+// Err or null.
+func (r Result) JS() js.Value {
+	obj := js.Global().Get("Object").New()
+	obj.Set("output", r.Output)
+	if r.Err != nil {
+		obj.Set("value", js.Null())
+		obj.Set("inspect", "")
+		obj.Set("error", r.Err.Error())
+		return obj
+	}
+	obj.Set("value", ValueOf(r.Value))
+	obj.Set("inspect", extract.Inspect(r.Value))
+	obj.Set("error", js.Null())
+	return obj
+}
+
+// ValueOf converts an Extract value to a JavaScript value. nil becomes
+// null, and booleans, numbers, and strings become their JavaScript
+// equivalents. Integers outside of the range that a JavaScript number
+// can represent exactly become BigInts. Runes and atoms become
+// strings. *Lists and *Tuples become arrays and *Binaries become
+// Uint8Arrays. *Maps become plain objects if all of their keys are
+// strings or atoms and Maps otherwise, with their keys and values
+// converted recursively. Anything else, such as a function, becomes
+// the string returned by [extract.Inspect].
+func ValueOf(v any) js.Value {
+	switch v := v.(type) {
+	case nil:
+		return js.Null()
+	case bool:
+		return js.ValueOf(v)
+	case int64:
+		if v > maxSafeInteger || v < -maxSafeInteger {
+			return js.Global().Get("BigInt").Invoke(strconv.FormatInt(v, 10))
+		}
+		return js.ValueOf(v)
+	case float64:
+		return js.ValueOf(v)
+	case string:
+		return js.ValueOf(v)
+	case extract.Rune:
+		return js.ValueOf(string(v))
+	case extract.Atom:
+		return js.ValueOf(v.String())
+
+	case *extract.List:
+		arr := js.Global().Get("Array").New()
+		for v := range v.All() {
+			arr.Call("push", ValueOf(v))
+		}
+		return arr
+	case *extract.Tuple:
+		arr := js.Global().Get("Array").New()
+		for v := range v.All() {
+			arr.Call("push", ValueOf(v))
+		}
+		return arr
+	case *extract.Binary:
+		data := v.Bytes()
+		arr := js.Global().Get("Uint8Array").New(len(data))
+		js.CopyBytesToJS(arr, data)
+		return arr
+
+	case *extract.Map:
+		if !stringKeys(v) {
+			m := js.Global().Get("Map").New()
+			for k, v := range v.All() {
+				m.Call("set", ValueOf(k), ValueOf(v))
+			}
+			return m
+		}
+		obj := js.Global().Get("Object").New()
+		for k, v := range v.All() {
+			obj.Set(ValueOf(k).String(), ValueOf(v))
+		}
+		return obj
+
+	default:
+		return js.ValueOf(extract.Inspect(v))
+	}
+}
+
+// maxSafeInteger is JavaScript's Number.MAX_SAFE_INTEGER.
+const maxSafeInteger = 1<<53 - 1
+
+// stringKeys returns true if every key in m is a string or an atom.
+func stringKeys(m *extract.Map) bool {
+	for k := range m.All() {
+		switch k.(type) {
+		case string, extract.Atom:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Register sets the global JavaScript variable name to an object with
+// an evalString method. evalString takes a string of Extract source
+// code and returns a Promise that resolves to the [Result] of running
+// it with [EvalString] and opts, converted with [Result.JS]. The
+// Promise resolves even if the script fails, as its output is still of
+// interest. The script runs in its own goroutine, so it can wait for
+// timers and other JavaScript events without blocking the page.
+//
+// The returned function removes the variable and releases the
+// resources of the method.
+func Register(name string, opts ...extract.Option) (unregister func()) {
+	eval := js.FuncOf(func(this js.Value, args []js.Value) any {
+		src := ""
+		if len(args) > 0 {
+			src = args[0].String()
+		}
+		return newPromise(func() js.Value {
+			return EvalString(context.Background(), src, opts...).JS()
+		})
+	})
+
+	obj := js.Global().Get("Object").New()
+	obj.Set("evalString", eval)
+	js.Global().Set(name, obj)
+	return func() {
+		js.Global().Delete(name)
+		eval.Release()
+	}
+}
+
+// newPromise returns a Promise that resolves to the result of calling
+// fn in a new goroutine.
+func newPromise(fn func() js.Value) js.Value {
+	var executor js.Func
+	executor = js.FuncOf(func(this js.Value, args []js.Value) any {
+		resolve := args[0]
+		executor.Release()
+		go func() { resolve.Invoke(fn()) }()
+		return nil
+	})
+	return js.Global().Get("Promise").New(executor)
+}
+
+// outputBuffer collects a script's output, which may be written to
+// concurrently by processes that it spawns.
+type outputBuffer struct {
+	m   sync.Mutex
+	buf []byte
+}
+
+func (b *outputBuffer) Write(data []byte) (int, error) {
+	b.m.Lock()
+	defer b.m.Unlock()
+	b.buf = append(b.buf, data...)
+	return len(data), nil
+}
+
+func (b *outputBuffer) String() string {
+	b.m.Lock()
+	defer b.m.Unlock()
+	return string(b.buf)
+}
@@ -0,0 +1,88 @@
+//go:build js && wasm
+
+package wasm_test
+
+import (
+	"context"
+	"syscall/js"
+	"testing"
+
+	"deedles.dev/extract"
+	"deedles.dev/extract/wasm"
+)
+
+func TestEvalString(t *testing.T) {
+	r := wasm.EvalString(context.Background(), `(IO.println "hi") [1 2.5 "three" :four]`).JS()
+	if r.Get("error").Truthy() || r.Get("output").String() != "hi\n" {
+		t.Fatal(r.Get("error"), r.Get("output"))
+	}
+	if r.Get("inspect").String() != `[1 2.5 "three" :four]` {
+		t.Fatal(r.Get("inspect"))
+	}
+
+	json := js.Global().Get("JSON").Call("stringify", r.Get("value")).String()
+	if json != `[1,2.5,"three","four"]` {
+		t.Fatal(json)
+	}
+
+	r = wasm.EvalString(context.Background(), `(add 1 :a)`).JS()
+	if !r.Get("value").IsNull() || r.Get("error").IsNull() {
+		t.Fatal(r.Get("value"), r.Get("error"))
+	}
+
+	r = wasm.EvalString(context.Background(), `(add 1`).JS()
+	if r.Get("error").IsNull() {
+		t.Fatal(r.Get("value"))
+	}
+}
+
+func TestValueOf(t *testing.T) {
+	tests := []struct {
+		val  any
+		json string
+	}{
+		{
+			extract.MapOf(extract.MakeAtom("a"), int64(1), "b", extract.ListOf(true, nil)),
+			`{"a":1,"b":[true,null]}`,
+		},
+		{extract.BinaryOf([]byte{1, 2, 3}), `{"0":1,"1":2,"2":3}`},
+		{extract.Rune('x'), `"x"`},
+		{extract.MakeIdent("f"), `"f"`},
+	}
+	for _, test := range tests {
+		json := js.Global().Get("JSON").Call("stringify", wasm.ValueOf(test.val)).String()
+		if json != test.json {
+			t.Errorf("%v: %v, expected %v", extract.Inspect(test.val), json, test.json)
+		}
+	}
+
+	big := wasm.ValueOf(int64(1) << 60)
+	// syscall/js can't inspect BigInts directly.
+	describe := js.Global().Call("eval", "(v) => `${typeof v} ${v}`")
+	if str := describe.Invoke(big).String(); str != "bigint 1152921504606846976" {
+		t.Fatal(str)
+	}
+
+	m := wasm.ValueOf(extract.MapOf(int64(1), extract.MakeAtom("one")))
+	if !m.InstanceOf(js.Global().Get("Map")) || m.Call("get", 1).String() != "one" {
+		t.Fatal(m)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	unregister := wasm.Register("extractTest")
+	defer unregister()
+
+	done := make(chan js.Value)
+	then := js.FuncOf(func(this js.Value, args []js.Value) any {
+		done <- args[0]
+		return nil
+	})
+	defer then.Release()
+
+	js.Global().Get("extractTest").Call("evalString", `(mul 6 7)`).Call("then", then)
+	r := <-done
+	if r.Get("value").Int() != 42 || r.Get("inspect").String() != "42" {
+		t.Fatal(r.Get("value"))
+	}
+}